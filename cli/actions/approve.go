@@ -0,0 +1,87 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"text/tabwriter"
+	"time"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/server"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+// An Approve is an Action that approves, or rejects, the change awaiting
+// approval with ID. If ID is empty, it lists the changes awaiting approval
+// which match ResolveFilter instead.
+type Approve struct {
+	ResolveFilter *sous.ResolveFilter
+	HTTPClient    restful.HTTPClient
+	User          sous.User
+	ID            string
+	Reject        bool
+	LogSink       logging.LogSink
+	OutWriter     io.Writer
+}
+
+// Do implements Action on Approve.
+func (a *Approve) Do() error {
+	if a.ID == "" {
+		return a.list()
+	}
+
+	data := server.ApprovalData{}
+	up, err := a.HTTPClient.Retrieve("./approval", map[string]string{"id": a.ID}, &data, nil)
+	if err != nil {
+		return errors.Wrapf(err, "retrieving pending change %s", a.ID)
+	}
+	c := data.Change
+
+	if a.Reject {
+		if err := up.Delete(a.User.HTTPHeaders()); err != nil {
+			return errors.Wrapf(err, "rejecting pending change %s", a.ID)
+		}
+		fmt.Fprintf(a.OutWriter, "Rejected %s's change of %s to version %s.\n", c.User, c.DeploymentID, c.Post.Version)
+		return nil
+	}
+
+	rz, err := up.Update(&data, a.User.HTTPHeaders())
+	if err != nil {
+		return errors.Wrapf(err, "approving pending change %s", a.ID)
+	}
+	fmt.Fprintf(a.OutWriter, "Approved %s's change of %s from version %s to %s.\n",
+		c.User, c.DeploymentID, c.Prior.Version, c.Post.Version)
+	if location := rz.Location(); location != "" {
+		fmt.Fprintf(a.OutWriter, "Deployment queued: %s\n", location)
+	}
+	return nil
+}
+
+func (a *Approve) list() error {
+	data := server.ApprovalsData{}
+	if _, err := a.HTTPClient.Retrieve("./approvals", filterQueryMap(a.ResolveFilter), &data, nil); err != nil {
+		return errors.Wrapf(err, "retrieving pending changes for %v", a.ResolveFilter)
+	}
+
+	w := tabwriter.NewWriter(a.OutWriter, 2, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tDEPLOYMENT\tUSER\tFROM\tTO")
+	for _, c := range data.Changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", c.ID, c.Time.Format(time.RFC3339),
+			c.DeploymentID, c.User, c.Prior.Version, c.Post.Version)
+	}
+	return w.Flush()
+}
+
+// pendingApprovalID returns the ID of the pending change at location, the
+// Location of a response to a deploy, if the server held the deploy for
+// approval rather than queueing it.
+func pendingApprovalID(location string) (string, bool) {
+	u, err := url.Parse("http://" + location)
+	if err != nil || u.Path != "/approval" {
+		return "", false
+	}
+	return u.Query().Get("id"), true
+}
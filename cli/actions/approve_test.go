@@ -0,0 +1,110 @@
+package actions
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nyarly/spies"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/server"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/opentable/sous/util/restful/restfultest"
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// approvalRecorder records the updates and deletes made to a pending change.
+type approvalRecorder struct {
+	updates, deletes []map[string]string
+}
+
+func (a *approvalRecorder) Update(_ restful.Comparable, headers map[string]string) (restful.UpdateDeleter, error) {
+	a.updates = append(a.updates, headers)
+	return a, nil
+}
+
+func (a *approvalRecorder) Delete(headers map[string]string) error {
+	a.deletes = append(a.deletes, headers)
+	return nil
+}
+
+func (a *approvalRecorder) Location() string {
+	return "sous.example.com/deploy-queue-item?action=a1"
+}
+
+func TestApprove(t *testing.T) {
+	change := sous.PendingChange{
+		ID: "change-1",
+		DeploymentID: sous.DeploymentID{
+			ManifestID: sous.ManifestID{Source: sous.SourceLocation{Repo: "github.com/user/project"}},
+			Cluster:    "prod",
+		},
+		Prior: sous.DeploySpec{Version: semv.MustParse("1.0.0")},
+		Post:  sous.DeploySpec{Version: semv.MustParse("2.0.0")},
+		User:  sous.User{Name: "Requester", Email: "requester@example.com"},
+	}
+	approver := sous.User{Name: "Approver", Email: "approver@example.com"}
+
+	setup := func(id string, reject bool) (*Approve, *approvalRecorder, *spies.Spy, *bytes.Buffer) {
+		cl, control := restfultest.NewHTTPClientSpy()
+		rec := &approvalRecorder{}
+		control.MatchMethod("Retrieve", func(args mock.Arguments) bool { return args.String(0) == "./approvals" }, server.ApprovalsData{Changes: []sous.PendingChange{change}}, rec, nil)
+		control.MatchMethod("Retrieve", spies.AnyArgs, server.ApprovalData{Change: change}, rec, nil)
+		out := &bytes.Buffer{}
+		return &Approve{
+			ResolveFilter: &sous.ResolveFilter{Cluster: sous.NewResolveFieldMatcher("prod")},
+			HTTPClient:    cl,
+			User:          approver,
+			ID:            id,
+			Reject:        reject,
+			LogSink:       logging.SilentLogSet(),
+			OutWriter:     out,
+		}, rec, control, out
+	}
+
+	t.Run("list", func(t *testing.T) {
+		a, rec, spy, out := setup("", false)
+		require.NoError(t, a.Do())
+		calls := spy.CallsTo("Retrieve")
+		require.Len(t, calls, 1)
+		assert.Equal(t, map[string]string{"cluster": "prod"}, calls[0].PassedArgs().Get(1))
+		assert.Contains(t, out.String(), "change-1")
+		assert.Contains(t, out.String(), "requester@example.com")
+		assert.Empty(t, rec.updates)
+	})
+
+	t.Run("approve", func(t *testing.T) {
+		a, rec, spy, out := setup("change-1", false)
+		require.NoError(t, a.Do())
+		calls := spy.CallsTo("Retrieve")
+		require.Len(t, calls, 1)
+		assert.Equal(t, "./approval", calls[0].PassedArgs().String(0))
+		assert.Equal(t, map[string]string{"id": "change-1"}, calls[0].PassedArgs().Get(1))
+		require.Len(t, rec.updates, 1)
+		assert.Equal(t, approver.HTTPHeaders(), rec.updates[0])
+		assert.Contains(t, out.String(), "from version 1.0.0 to 2.0.0")
+		assert.Contains(t, out.String(), "deploy-queue-item")
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		a, rec, _, out := setup("change-1", true)
+		require.NoError(t, a.Do())
+		assert.Empty(t, rec.updates)
+		require.Len(t, rec.deletes, 1)
+		assert.Contains(t, out.String(), "Rejected")
+	})
+}
+
+func TestPendingApprovalID(t *testing.T) {
+	id, ok := pendingApprovalID("sous.example.com/approval?id=change-1")
+	assert.True(t, ok)
+	assert.Equal(t, "change-1", id)
+
+	_, ok = pendingApprovalID("sous.example.com/deploy-queue-item?action=a1")
+	assert.False(t, ok)
+	_, ok = pendingApprovalID("")
+	assert.False(t, ok)
+}
@@ -57,6 +57,24 @@ func (sd *Deploy) Do() error {
 		return errors.Wrap(err, "Failed to update deployment")
 	}
 
+	return sd.await(updateResponse, fmt.Sprintf("Desired version for %q already %q", sd.TargetDeploymentID, newVersion))
+}
+
+// await follows the change to TargetDeploymentID requested with
+// updateResponse: it reports a change held for approval, or waits for a
+// queued deployment to complete if WaitStable is set. unchanged is reported
+// if the server did not need to change anything.
+func (sd *Deploy) await(updateResponse restful.UpdateDeleter, unchanged string) error {
+	if id, pending := pendingApprovalID(updateResponse.Location()); pending {
+		messages.ReportLogFieldsMessageToConsole(
+			fmt.Sprintf("Deploy %q requires approval. Another user may approve it with:\n\tsous approve -cluster %s -id %s",
+				sd.TargetDeploymentID, sd.TargetDeploymentID.Cluster, id),
+			logging.WarningLevel,
+			sd.LogSink,
+		)
+		return nil
+	}
+
 	if !sd.WaitStable {
 		messages.ReportLogFieldsMessageToConsole(
 			fmt.Sprintf("Deploy %q requested of server. Exiting optimistically.", sd.TargetDeploymentID),
@@ -103,7 +121,7 @@ func (sd *Deploy) Do() error {
 		return result
 	}
 	messages.ReportLogFieldsMessageToConsole(
-		fmt.Sprintf("Desired version for %q already %q", sd.TargetDeploymentID, newVersion),
+		unchanged,
 		logging.DebugLevel,
 		sd.LogSink,
 	)
@@ -119,6 +137,7 @@ func (sd *Deploy) pollDeployQueue(location string, pollAtempts int, bar *mpb.Bar
 	start := time.Now()
 	response := dto.R11nResponse{}
 	location = "http://" + location
+	var lastStage sous.RolloutProgress
 
 	for i := 0; i < pollAtempts; i++ {
 		if bar != nil {
@@ -130,6 +149,15 @@ func (sd *Deploy) pollDeployQueue(location string, pollAtempts int, bar *mpb.Bar
 
 		queuePosition := response.QueuePosition
 
+		if response.Rollout != nil && *response.Rollout != lastStage {
+			lastStage = *response.Rollout
+			messages.ReportLogFieldsMessageToConsole(
+				fmt.Sprintf("\n\tRolling out %s", lastStage),
+				logging.InformationLevel,
+				sd.LogSink,
+			)
+		}
+
 		if response.Resolution != nil && response.Resolution.Error != nil {
 			return errors.Wrapf(response.Resolution.Error, "Failed to deploy, duration: %s\n", timeTrack(start))
 		}
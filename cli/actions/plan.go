@@ -0,0 +1,57 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+// A Plan is an Action that reports what the server would do to make the
+// running deployments matched by its ResolveFilter match the GDM.
+type Plan struct {
+	ResolveFilter *sous.ResolveFilter
+	HTTPClient    restful.HTTPClient
+	LogSink       logging.LogSink
+	OutWriter     io.Writer
+}
+
+// Do implements Action on Plan. It returns an error if any deployment could
+// not be planned.
+func (p *Plan) Do() error {
+	plan := sous.ResolvePlan{}
+	if _, err := p.HTTPClient.Retrieve("./plan", filterQueryMap(p.ResolveFilter), &plan, nil); err != nil {
+		return errors.Wrapf(err, "retrieving plan for %v", p.ResolveFilter)
+	}
+
+	for _, group := range []struct {
+		name  string
+		pairs []sous.PlannedPair
+	}{
+		{"Added", plan.Added},
+		{"Removed", plan.Removed},
+		{"Modified", plan.Modified},
+		{"Unchanged", plan.Unchanged},
+		{"Frozen", plan.Frozen},
+	} {
+		fmt.Fprintf(p.OutWriter, "%s (%d):\n", group.name, len(group.pairs))
+		for _, pair := range group.pairs {
+			fmt.Fprintf(p.OutWriter, "  %s\n", pair.DeploymentID)
+			for _, d := range pair.Diffs {
+				fmt.Fprintf(p.OutWriter, "    %s\n", d)
+			}
+		}
+	}
+
+	if len(plan.Errors) == 0 {
+		return nil
+	}
+	fmt.Fprintf(p.OutWriter, "Errors (%d):\n", len(plan.Errors))
+	for _, rez := range plan.Errors {
+		fmt.Fprintf(p.OutWriter, "  %s: %s\n", rez.DeploymentID, rez.Error)
+	}
+	return errors.Errorf("%d deployments could not be planned", len(plan.Errors))
+}
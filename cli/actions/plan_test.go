@@ -0,0 +1,50 @@
+package actions
+
+import (
+	"bytes"
+	"testing"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful/restfultest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan(t *testing.T) {
+	did := func(repo string) sous.DeploymentID {
+		return sous.DeploymentID{
+			ManifestID: sous.ManifestID{Source: sous.SourceLocation{Repo: repo}},
+			Cluster:    "left",
+		}
+	}
+	plan := sous.ResolvePlan{
+		Added:    []sous.PlannedPair{{DeploymentID: did("github.com/user/added")}},
+		Modified: []sous.PlannedPair{{DeploymentID: did("github.com/user/modified"), Diffs: sous.Differences{"version changed"}}},
+	}
+
+	cl, control := restfultest.NewHTTPClientSpy()
+	control.Any("Retrieve", plan, restfultest.DummyUpdater(), nil)
+	out := &bytes.Buffer{}
+	p := &Plan{
+		ResolveFilter: &sous.ResolveFilter{Cluster: sous.NewResolveFieldMatcher("left")},
+		HTTPClient:    cl,
+		LogSink:       logging.SilentLogSet(),
+		OutWriter:     out,
+	}
+
+	assert.NoError(t, p.Do())
+	if assert.Len(t, control.Calls(), 1) {
+		assert.Equal(t, "./plan", control.Calls()[0].PassedArgs().String(0))
+		assert.Equal(t, map[string]string{"cluster": "left"}, control.Calls()[0].PassedArgs().Get(1))
+	}
+	assert.Contains(t, out.String(), "Added (1):\n  left:github.com/user/added")
+	assert.Contains(t, out.String(), "Removed (0):")
+	assert.Contains(t, out.String(), "Frozen (0):")
+	assert.Contains(t, out.String(), "    version changed")
+
+	plan.Errors = []sous.DiffResolution{{DeploymentID: did("github.com/user/broken")}}
+	cl, control = restfultest.NewHTTPClientSpy()
+	control.Any("Retrieve", plan, restfultest.DummyUpdater(), nil)
+	p.HTTPClient = cl
+	assert.Error(t, p.Do())
+}
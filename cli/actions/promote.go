@@ -0,0 +1,43 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Promote is an Action which has the server promote the version deployed
+// to one cluster, with some of its environment variables and resources, to
+// another.
+type Promote struct {
+	// Deploy follows the promotion once the server has made it. Its
+	// TargetDeploymentID is the deployment promoted to.
+	*Deploy
+	// From is the cluster promoted from.
+	From string
+	// Env and Resources name the environment variables and resources copied
+	// along with the version.
+	Env, Resources []string
+}
+
+// Do implements Action on Promote.
+func (p *Promote) Do() error {
+	to := p.TargetDeploymentID
+	q := to.QueryMap()
+	delete(q, "cluster")
+	q["from"] = p.From
+	q["to"] = to.Cluster
+	if len(p.Env) != 0 {
+		q["env"] = strings.Join(p.Env, ",")
+	}
+	if len(p.Resources) != 0 {
+		q["resources"] = strings.Join(p.Resources, ",")
+	}
+
+	rz, err := p.HTTPClient.Create("./promotion", q, nil, p.User.HTTPHeaders())
+	if err != nil {
+		return errors.Wrapf(err, "promoting %s from %s", to, p.From)
+	}
+	return p.await(rz, fmt.Sprintf("%s already matches %s", to, p.From))
+}
@@ -0,0 +1,48 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/nyarly/spies"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful/restfultest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromote(t *testing.T) {
+	cl, control := restfultest.NewHTTPClientSpy()
+	up, upControl := restfultest.NewUpdateSpy()
+	upControl.MatchMethod("Location", spies.AnyArgs, "sous.example.com/approval?id=c1")
+	control.MatchMethod("Create", spies.AnyArgs, nil, up, nil)
+
+	user := sous.User{Name: "Someone", Email: "someone@example.com"}
+	p := &Promote{
+		Deploy: &Deploy{
+			HTTPClient: cl,
+			TargetDeploymentID: sous.DeploymentID{
+				ManifestID: sous.ManifestID{Source: sous.SourceLocation{Repo: "github.com/user/project", Dir: "api"}},
+				Cluster:    "prod",
+			},
+			LogSink: logging.SilentLogSet(),
+			User:    user,
+		},
+		From: "staging",
+		Env:  []string{"FEATURE", "OTHER"},
+	}
+	require.NoError(t, p.Do())
+
+	creates := control.CallsTo("Create")
+	require.Len(t, creates, 1)
+	assert.Equal(t, "./promotion", creates[0].PassedArgs().String(0))
+	assert.Equal(t, map[string]string{
+		"repo":   "github.com/user/project",
+		"offset": "api",
+		"flavor": "",
+		"from":   "staging",
+		"to":     "prod",
+		"env":    "FEATURE,OTHER",
+	}, creates[0].PassedArgs().Get(1))
+	assert.Equal(t, user.HTTPHeaders(), creates[0].PassedArgs().Get(3))
+}
@@ -0,0 +1,65 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// A QueryCapacity is an Action that reports the resources claimed in
+	// each cluster, and by each owner, against their quotas.
+	QueryCapacity struct {
+		Cluster, Owner string
+		HTTPClient     restful.HTTPClient
+		LogSink        logging.LogSink
+		OutWriter      io.Writer
+	}
+
+	// capacityData mirrors server.CapacityData.
+	capacityData struct {
+		Entries sous.CapacityReport
+	}
+)
+
+// Do implements Action on QueryCapacity.
+func (qc *QueryCapacity) Do() error {
+	query := map[string]string{}
+	if qc.Cluster != "" {
+		query["cluster"] = qc.Cluster
+	}
+	if qc.Owner != "" {
+		query["owner"] = qc.Owner
+	}
+	data := capacityData{}
+	if _, err := qc.HTTPClient.Retrieve("./capacity", query, &data, nil); err != nil {
+		return errors.Wrapf(err, "retrieving capacity")
+	}
+
+	w := tabwriter.NewWriter(qc.OutWriter, 2, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tOWNER\tINSTANCES\tCPUS\tCPU QUOTA\tCPU HEADROOM\tMEMORY\tMEMORY QUOTA\tMEMORY HEADROOM")
+	for _, e := range data.Entries {
+		owner := e.Owner
+		if owner == "" {
+			owner = "(all)"
+		}
+		h := e.Quota.Headroom(e.Used)
+		fmt.Fprintf(w, "%s\t%s\t%d\t%g\t%s\t%s\t%g\t%s\t%s\n", e.Cluster, owner, e.Used.Instances,
+			e.Used.Cpus, limited(e.Quota.Cpus, e.Quota.Cpus), limited(h.Cpus, e.Quota.Cpus),
+			e.Used.Memory, limited(e.Quota.Memory, e.Quota.Memory), limited(h.Memory, e.Quota.Memory))
+	}
+	return w.Flush()
+}
+
+// limited formats v, which derives from quota, or "-" if quota is unset.
+func limited(v, quota float64) string {
+	if quota == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%g", v)
+}
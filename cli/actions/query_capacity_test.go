@@ -0,0 +1,40 @@
+package actions
+
+import (
+	"bytes"
+	"testing"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful/restfultest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCapacity(t *testing.T) {
+	out := &bytes.Buffer{}
+	cl, control := restfultest.NewHTTPClientSpy()
+	qc := &QueryCapacity{
+		Cluster:    "ci",
+		HTTPClient: cl,
+		LogSink:    logging.SilentLogSet(),
+		OutWriter:  out,
+	}
+
+	control.Any(
+		"Retrieve",
+		capacityData{Entries: sous.CapacityReport{
+			{Cluster: "ci", Used: sous.Usage{Cpus: 3, Memory: 1024, Instances: 3}, Quota: sous.Quota{Cpus: 2}},
+			{Cluster: "ci", Owner: "team-a", Used: sous.Usage{Cpus: 1, Memory: 256, Instances: 1}, Quota: sous.Quota{Memory: 512}},
+		}}, restfultest.DummyUpdater(), nil,
+	)
+
+	assert.NoError(t, qc.Do())
+
+	if assert.Len(t, control.Calls(), 1) {
+		assert.Equal(t, "./capacity", control.Calls()[0].PassedArgs().String(0))
+		params := control.Calls()[0].PassedArgs().Get(1).(map[string]string)
+		assert.Equal(t, map[string]string{"cluster": "ci"}, params)
+	}
+	assert.Regexp(t, `ci\s+\(all\)\s+3\s+3\s+2\s+-1\s+1024\s+-\s+-`, out.String())
+	assert.Regexp(t, `ci\s+team-a\s+1\s+1\s+-\s+-\s+256\s+512\s+256`, out.String())
+}
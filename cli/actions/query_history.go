@@ -0,0 +1,63 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// A QueryHistory is an Action that lists the recorded changes to the
+	// manifests matched by its ResolveFilter.
+	QueryHistory struct {
+		ResolveFilter *sous.ResolveFilter
+		HTTPClient    restful.HTTPClient
+		LogSink       logging.LogSink
+		OutWriter     io.Writer
+	}
+
+	// historyData mirrors server.HistoryData.
+	historyData struct {
+		Changes []sous.ManifestChange
+	}
+)
+
+// Do implements Action on QueryHistory.
+func (qh *QueryHistory) Do() error {
+	data := historyData{}
+	if _, err := qh.HTTPClient.Retrieve("./history", filterQueryMap(qh.ResolveFilter), &data, nil); err != nil {
+		return errors.Wrapf(err, "retrieving history for %v", qh.ResolveFilter)
+	}
+
+	w := tabwriter.NewWriter(qh.OutWriter, 2, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tMANIFEST\tUSER\tTRACE ID\tCHANGES")
+	for _, c := range data.Changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Time.Format(time.RFC3339), c.ManifestID,
+			c.User, c.TraceID, strings.Join(c.Diffs, "; "))
+	}
+	return w.Flush()
+}
+
+// filterQueryMap returns the query parameters which select what rf matches
+// from the /history and /plan endpoints.
+func filterQueryMap(rf *sous.ResolveFilter) map[string]string {
+	query := map[string]string{}
+	for field, m := range map[string]sous.ResolveFieldMatcher{
+		"repo":    rf.Repo,
+		"offset":  rf.Offset,
+		"flavor":  rf.Flavor,
+		"cluster": rf.Cluster,
+	} {
+		if !m.All() {
+			query[field] = *m.Match
+		}
+	}
+	return query
+}
@@ -0,0 +1,53 @@
+package actions
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful/restfultest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryHistory(t *testing.T) {
+	out := &bytes.Buffer{}
+	cl, control := restfultest.NewHTTPClientSpy()
+	qh := &QueryHistory{
+		ResolveFilter: &sous.ResolveFilter{
+			Repo:    sous.NewResolveFieldMatcher("github.com/user/project"),
+			Offset:  sous.NewResolveFieldMatcher(""),
+			Cluster: sous.NewResolveFieldMatcher("left"),
+		},
+		HTTPClient: cl,
+		LogSink:    logging.SilentLogSet(),
+		OutWriter:  out,
+	}
+
+	control.Any(
+		"Retrieve",
+		historyData{Changes: []sous.ManifestChange{{
+			ManifestID: sous.ManifestID{Source: sous.SourceLocation{Repo: "github.com/user/project"}},
+			Time:       time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+			User:       sous.User{Name: "Judson", Email: "jlester@opentable.com"},
+			TraceID:    "trace-1",
+			Diffs:      []string{"manifest added"},
+		}}}, restfultest.DummyUpdater(), nil,
+	)
+
+	assert.NoError(t, qh.Do())
+
+	if assert.Len(t, control.Calls(), 1) {
+		assert.Equal(t, "./history", control.Calls()[0].PassedArgs().String(0))
+		params := control.Calls()[0].PassedArgs().Get(1).(map[string]string)
+		assert.Equal(t, map[string]string{
+			"repo":    "github.com/user/project",
+			"offset":  "",
+			"cluster": "left",
+		}, params)
+	}
+	assert.Contains(t, out.String(), "2018-01-02T03:04:05Z")
+	assert.Contains(t, out.String(), "trace-1")
+	assert.Contains(t, out.String(), "manifest added")
+}
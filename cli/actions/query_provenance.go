@@ -0,0 +1,47 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+// A QueryProvenance is an Action that prints the provenance recorded for the
+// artifact built from the source location and tag of its ResolveFilter.
+type QueryProvenance struct {
+	ResolveFilter *sous.ResolveFilter
+	HTTPClient    restful.HTTPClient
+	LogSink       logging.LogSink
+	OutWriter     io.Writer
+}
+
+// Do implements Action on QueryProvenance.
+func (qp *QueryProvenance) Do() error {
+	sl, ok := qp.ResolveFilter.SourceLocation()
+	if !ok {
+		return errors.New("you must provide the -repo and -offset flags, or run in a repository")
+	}
+	version, err := qp.ResolveFilter.TagVersion()
+	if err != nil {
+		return errors.Wrap(err, "you must provide the -tag flag, or run at a tagged revision")
+	}
+	sid := sous.SourceID{Location: sl, Version: version}
+
+	p := sous.Provenance{}
+	query := map[string]string{"repo": sl.Repo, "offset": sl.Dir, "version": version.String()}
+	if _, err := qp.HTTPClient.Retrieve("./artifact/provenance", query, &p, nil); err != nil {
+		return errors.Wrapf(err, "retrieving provenance of %s", sid)
+	}
+
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(qp.OutWriter, string(b))
+	return err
+}
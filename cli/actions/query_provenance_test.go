@@ -0,0 +1,50 @@
+package actions
+
+import (
+	"bytes"
+	"testing"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful/restfultest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryProvenance(t *testing.T) {
+	out := &bytes.Buffer{}
+	cl, control := restfultest.NewHTTPClientSpy()
+	qp := &QueryProvenance{
+		ResolveFilter: &sous.ResolveFilter{
+			Repo:   sous.NewResolveFieldMatcher("github.com/user/project"),
+			Offset: sous.NewResolveFieldMatcher(""),
+			Tag:    sous.NewResolveFieldMatcher("1.2.3"),
+		},
+		HTTPClient: cl,
+		LogSink:    logging.SilentLogSet(),
+		OutWriter:  out,
+	}
+
+	control.Any(
+		"Retrieve",
+		sous.Provenance{
+			Type:      sous.ProvenanceStatementType,
+			Predicate: sous.ProvenancePredicate{Builder: sous.ProvenanceBuilder{ID: "sous://judson@ci.example.com"}},
+		}, restfultest.DummyUpdater(), nil,
+	)
+
+	assert.NoError(t, qp.Do())
+
+	if assert.Len(t, control.Calls(), 1) {
+		assert.Equal(t, "./artifact/provenance", control.Calls()[0].PassedArgs().String(0))
+		params := control.Calls()[0].PassedArgs().Get(1).(map[string]string)
+		assert.Equal(t, map[string]string{
+			"repo":    "github.com/user/project",
+			"offset":  "",
+			"version": "1.2.3",
+		}, params)
+	}
+	assert.Contains(t, out.String(), `"id": "sous://judson@ci.example.com"`)
+
+	qp.ResolveFilter.Tag = sous.ResolveFieldMatcher{}
+	assert.Error(t, qp.Do())
+}
@@ -0,0 +1,84 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/server"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+// A RemoteBuild is an Action which has the server build SourceID, printing
+// the output of the build as it progresses until it is done.
+type RemoteBuild struct {
+	SourceID   sous.SourceID
+	HTTPClient restful.HTTPClient
+	User       sous.User
+	// PollAttempts is the number of times the build is checked on before
+	// giving up on it, PollInterval apart.
+	PollAttempts int
+	PollInterval time.Duration
+	LogSink      logging.LogSink
+	OutWriter    io.Writer
+}
+
+// Do implements Action on RemoteBuild.
+func (rb *RemoteBuild) Do() error {
+	sid := rb.SourceID
+	query := map[string]string{
+		"repo":    sid.Location.Repo,
+		"offset":  sid.Location.Dir,
+		"version": sid.Version.String(),
+	}
+	rz, err := rb.HTTPClient.Create("./build", query, nil, rb.User.HTTPHeaders())
+	if err != nil {
+		return errors.Wrapf(err, "requesting build of %s", sid)
+	}
+	location := rz.Location()
+	if location == "" {
+		return errors.Errorf("server did not say where to follow the build of %s", sid)
+	}
+	fmt.Fprintf(rb.OutWriter, "Build queued: %s\n", location)
+	return rb.follow(location)
+}
+
+// follow polls the build at location, printing each line of its log once.
+func (rb *RemoteBuild) follow(location string) error {
+	u, err := url.Parse("http://" + location)
+	if err != nil {
+		return errors.Wrapf(err, "parsing build location %q", location)
+	}
+	id := u.Query().Get("id")
+	u.RawQuery = ""
+
+	seen := 0
+	for i := 0; i < rb.PollAttempts; i++ {
+		data := server.BuildData{}
+		if _, err := rb.HTTPClient.Retrieve(u.String(), map[string]string{"id": id, "from": strconv.Itoa(seen)}, &data, nil); err != nil {
+			return errors.Wrapf(err, "checking on build %s", id)
+		}
+		b := data.Build
+		for _, line := range b.Log {
+			fmt.Fprintln(rb.OutWriter, line)
+		}
+		seen = b.LogOffset + len(b.Log)
+
+		switch b.State {
+		case sous.RemoteBuildSucceeded:
+			if b.Result != nil {
+				fmt.Fprintln(rb.OutWriter, b.Result.String())
+			}
+			return nil
+		case sous.RemoteBuildFailed:
+			return errors.Errorf("build of %s failed: %s", b.Source, b.Error)
+		}
+		time.Sleep(rb.PollInterval)
+	}
+	return errors.Errorf("build %s not done after %d attempts", id, rb.PollAttempts)
+}
@@ -0,0 +1,89 @@
+package actions
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nyarly/spies"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/server"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful/restfultest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteBuild(t *testing.T) {
+	sid := sous.MakeSourceID("github.com/user/project", "api", "1.2.3+abc123")
+	user := sous.User{Name: "Someone", Email: "someone@example.com"}
+
+	setup := func(final sous.RemoteBuild) (*RemoteBuild, *bytes.Buffer, *spies.Spy) {
+		cl, control := restfultest.NewHTTPClientSpy()
+		up, upControl := restfultest.NewUpdateSpy()
+		upControl.MatchMethod("Location", spies.AnyArgs, "sous.example.com/build?id=b1")
+		control.MatchMethod("Create", spies.AnyArgs, nil, up, nil)
+		fromStart := func(args mock.Arguments) bool {
+			return args.Get(1).(map[string]string)["from"] == "0"
+		}
+		control.MatchMethod("Retrieve", fromStart, server.BuildData{Build: sous.RemoteBuild{
+			ID:    "b1",
+			State: sous.RemoteBuildRunning,
+			Log:   []string{"Fetching", "step 1"},
+		}}, up, nil)
+		control.MatchMethod("Retrieve", spies.AnyArgs, server.BuildData{Build: final}, up, nil)
+		out := &bytes.Buffer{}
+		return &RemoteBuild{
+			SourceID:     sid,
+			HTTPClient:   cl,
+			User:         user,
+			PollAttempts: 5,
+			LogSink:      logging.SilentLogSet(),
+			OutWriter:    out,
+		}, out, control
+	}
+
+	t.Run("succeeded", func(t *testing.T) {
+		rb, out, cl := setup(sous.RemoteBuild{
+			ID:        "b1",
+			State:     sous.RemoteBuildSucceeded,
+			Log:       []string{"step 2"},
+			LogOffset: 2,
+		})
+		require.NoError(t, rb.Do())
+
+		creates := cl.CallsTo("Create")
+		require.Len(t, creates, 1)
+		assert.Equal(t, "./build", creates[0].PassedArgs().String(0))
+		assert.Equal(t, map[string]string{
+			"repo":    "github.com/user/project",
+			"offset":  "api",
+			"version": "1.2.3+abc123",
+		}, creates[0].PassedArgs().Get(1))
+		assert.Equal(t, user.HTTPHeaders(), creates[0].PassedArgs().Get(3))
+
+		retrieves := cl.CallsTo("Retrieve")
+		require.Len(t, retrieves, 2)
+		assert.Equal(t, "http://sous.example.com/build", retrieves[1].PassedArgs().String(0))
+		assert.Equal(t, map[string]string{"id": "b1", "from": "2"}, retrieves[1].PassedArgs().Get(1))
+		assert.Contains(t, out.String(), "Fetching\nstep 1\nstep 2\n")
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		rb, _, _ := setup(sous.RemoteBuild{
+			ID:     "b1",
+			Source: sid,
+			State:  sous.RemoteBuildFailed,
+			Error:  "no Dockerfile",
+		})
+		err := rb.Do()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no Dockerfile")
+	})
+
+	t.Run("never_done", func(t *testing.T) {
+		rb, _, cl := setup(sous.RemoteBuild{ID: "b1", State: sous.RemoteBuildQueued})
+		assert.Error(t, rb.Do())
+		assert.Len(t, cl.CallsTo("Retrieve"), 5)
+	})
+}
@@ -0,0 +1,46 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+// A Rollback is an Action that deploys the version of a deployment that
+// preceded its latest version change, as recorded in the GDM history.
+type Rollback struct {
+	// Deploy deploys the previous version; its ResolveFilter's tag is
+	// replaced with that version.
+	*Deploy
+	OutWriter io.Writer
+}
+
+// Do implements Action on Rollback.
+func (rb *Rollback) Do() error {
+	did := rb.TargetDeploymentID
+	data := historyData{}
+	if _, err := rb.HTTPClient.Retrieve("./history", did.QueryMap(), &data, nil); err != nil {
+		return errors.Wrapf(err, "retrieving history of %s", did)
+	}
+
+	change, version, ok := sous.PreviousVersion(data.Changes, did.Cluster)
+	if !ok {
+		return errors.Errorf("no previous version of %s is recorded", did)
+	}
+
+	fmt.Fprintf(rb.OutWriter, "Rolling back %s from %s to %s, undoing this change by %s at %s:\n",
+		did, change.Post.Deployments[did.Cluster].Version, version, change.User, change.Time.Format(time.RFC3339))
+	for _, d := range change.Diffs {
+		fmt.Fprintf(rb.OutWriter, "\t%s\n", d)
+	}
+
+	rf := *rb.ResolveFilter
+	if err := rf.SetTag(version.String()); err != nil {
+		return err
+	}
+	rb.ResolveFilter = &rf
+	return rb.Deploy.Do()
+}
@@ -0,0 +1,89 @@
+package actions
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nyarly/spies"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/server"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/opentable/sous/util/restful/restfultest"
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// updateRecorder records the bodies passed to Update.
+type updateRecorder struct {
+	restful.UpdateDeleter
+	bodies []restful.Comparable
+}
+
+func (u *updateRecorder) Update(body restful.Comparable, _ map[string]string) (restful.UpdateDeleter, error) {
+	u.bodies = append(u.bodies, body)
+	return u.UpdateDeleter, nil
+}
+
+func TestRollback(t *testing.T) {
+	did := sous.DeploymentID{
+		ManifestID: sous.ManifestID{Source: sous.SourceLocation{Repo: "github.com/user/project"}},
+		Cluster:    "left",
+	}
+	manifest := func(version string) *sous.Manifest {
+		return &sous.Manifest{
+			Source: did.ManifestID.Source,
+			Deployments: sous.DeploySpecs{
+				"left": sous.DeploySpec{Version: semv.MustParse(version)},
+			},
+		}
+	}
+
+	httpClient, ctrl := restfultest.NewHTTPClientSpy()
+	ctrl.MatchMethod("Retrieve", func(args mock.Arguments) bool { return args.String(0) == "./history" },
+		historyData{Changes: []sous.ManifestChange{
+			{ManifestID: did.ManifestID, Prior: manifest("1.0.0"), Post: manifest("2.0.0"), Diffs: []string{"upgrade"}},
+			{ManifestID: did.ManifestID, Prior: manifest("2.0.0"), Post: manifest("2.0.0")},
+			{ManifestID: did.ManifestID, Prior: manifest("2.0.0"), Post: manifest("3.0.0"), Diffs: []string{"left version"}},
+		}}, restfultest.DummyUpdater(), nil)
+	updater := &updateRecorder{UpdateDeleter: restfultest.DummyUpdater()}
+	ctrl.MatchMethod("Retrieve", spies.AnyArgs,
+		server.SingleDeploymentBody{Deployment: &sous.DeploySpec{Version: semv.MustParse("3.0.0")}}, updater, nil)
+
+	out := &bytes.Buffer{}
+	rb := &Rollback{
+		Deploy: &Deploy{
+			ResolveFilter:      &sous.ResolveFilter{},
+			HTTPClient:         httpClient,
+			TargetDeploymentID: did,
+			LogSink:            logging.SilentLogSet(),
+		},
+		OutWriter: out,
+	}
+	require.NoError(t, rb.Do())
+
+	assert.Contains(t, out.String(), "from 3.0.0 to 2.0.0")
+	assert.Contains(t, out.String(), "left version")
+
+	require.Len(t, updater.bodies, 1)
+	body := updater.bodies[0].(server.SingleDeploymentBody)
+	assert.Equal(t, "2.0.0", body.Deployment.Version.String())
+}
+
+func TestRollback_NoPreviousVersion(t *testing.T) {
+	httpClient, ctrl := restfultest.NewHTTPClientSpy()
+	ctrl.Any("Retrieve", historyData{}, restfultest.DummyUpdater(), nil)
+
+	rb := &Rollback{
+		Deploy: &Deploy{
+			ResolveFilter: &sous.ResolveFilter{},
+			HTTPClient:    httpClient,
+			LogSink:       logging.SilentLogSet(),
+		},
+		OutWriter: &bytes.Buffer{},
+	}
+	assert.Error(t, rb.Do())
+	assert.Len(t, ctrl.CallsTo("Retrieve"), 1)
+}
@@ -27,6 +27,11 @@ type Server struct {
 	*config.Config
 	ServerHandler http.Handler
 	*sous.AutoResolver
+	// Scaler, if set, autoscales deployments while the auto-resolver runs.
+	Scaler *sous.Scaler
+	// TagWatcher, if set, polls for newly tagged versions to deploy while
+	// the auto-resolver runs.
+	TagWatcher *sous.TagWatcher
 }
 
 // Do runs the server.
@@ -39,6 +44,12 @@ func (ss *Server) Do() error {
 
 	if ss.AutoResolver != nil {
 		ss.AutoResolver.Kickoff()
+		if ss.Scaler != nil {
+			ss.Scaler.Kickoff()
+		}
+		if ss.TagWatcher != nil {
+			ss.TagWatcher.Kickoff()
+		}
 	} else {
 		reportServerMessage("Auto-resolver DISABLED", ss.DeployFilterFlags, ss.ListenAddr, ss.Log)
 	}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"flag"
+	"os"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousApprove is the command description for `sous approve`.
+type SousApprove struct {
+	SousGraph *graph.SousGraph
+
+	DeployFilterFlags config.DeployFilterFlags `inject:"optional"`
+	id                string
+}
+
+func init() { TopLevelCommands["approve"] = &SousApprove{} }
+
+const sousApproveHelp = `approves a deploy to a cluster which requires approval
+
+usage: sous approve -cluster <cluster> [-id <id>] [(options)]
+
+Deploys which change the version in a cluster requiring approval are held by
+the server until a second user approves them. Without -id, sous approve lists
+the deploys awaiting approval in the named cluster. With -id, it approves that
+deploy, which the server then writes to the GDM and deploys. Nobody may
+approve their own deploy.
+`
+
+// Help returns the help string for this command.
+func (sa *SousApprove) Help() string { return sousApproveHelp }
+
+// AddFlags adds the flags for sous approve.
+func (sa *SousApprove) AddFlags(fs *flag.FlagSet) {
+	MustAddFlags(fs, &sa.DeployFilterFlags, MetadataFilterFlagsHelp)
+
+	fs.StringVar(&sa.id, "id", "", "the ID of the deploy to approve, as listed by sous approve")
+}
+
+// Execute fulfills the cmdr.Executor interface.
+func (sa *SousApprove) Execute(args []string) cmdr.Result {
+	approve, err := sa.SousGraph.GetApprove(sa.DeployFilterFlags, sa.id, false, os.Stdout)
+	if err != nil {
+		return cmdr.EnsureErrorResult(err)
+	}
+
+	if err := approve.Do(); err != nil {
+		return EnsureErrorResult(err)
+	}
+	return cmdr.Success()
+}
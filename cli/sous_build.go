@@ -2,10 +2,13 @@ package cli
 
 import (
 	"flag"
+	"os"
 
 	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/graph"
 	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/cmdr"
+	"github.com/pkg/errors"
 )
 
 type (
@@ -16,6 +19,9 @@ type (
 		config.PolicyFlags       `inject:"optional"`
 
 		*sous.BuildManager
+		SousGraph *graph.SousGraph
+
+		remote bool
 	}
 )
 
@@ -26,6 +32,10 @@ const sousBuildHelp = `build your project
 build builds the project in your current directory by default. If you pass it a
 path, it will instead build the project at that path.
 
+With -remote, the Sous server fetches the source from its source host and
+builds it instead, and the output of the build is shown as it progresses. The
+revision built must have been pushed.
+
 args: [path]
 `
 
@@ -34,6 +44,8 @@ func (sb *SousBuild) AddFlags(fs *flag.FlagSet) {
 	MustAddFlags(fs, &sb.DeployFilterFlags, SourceFlagsHelp)
 	fs.BoolVar(&sb.PolicyFlags.Strict, "strict", false, "require that the build be pristine")
 	fs.BoolVar(&sb.PolicyFlags.Dev, "dev", false, "run build with developer options")
+	fs.StringVar(&sb.PolicyFlags.Platforms, "platforms", "", "build images for these comma-separated platforms, e.g. linux/amd64,linux/arm64, and push a manifest list of them")
+	fs.BoolVar(&sb.remote, "remote", false, "have the server fetch and build the source, rather than building it here")
 	//fs.BoolVar(&sb.PolicyFlags.ForceClone, "force-clone", false, "force a shallow clone of the codebase before build")
 	// above is commented prior to impl.
 }
@@ -56,6 +68,10 @@ func (sb *SousBuild) Execute(args []string) cmdr.Result {
 		}
 	}
 
+	if sb.remote {
+		return sb.buildRemotely()
+	}
+
 	result, err := sb.BuildManager.Build()
 
 	if err != nil {
@@ -63,3 +79,38 @@ func (sb *SousBuild) Execute(args []string) cmdr.Result {
 	}
 	return cmdr.Success(result)
 }
+
+func (sb *SousBuild) buildRemotely() cmdr.Result {
+	sid, err := sb.remoteSourceID()
+	if err != nil {
+		return cmdr.UsageErrorf("%s", err)
+	}
+	build, err := sb.SousGraph.GetRemoteBuild(sid, os.Stdout)
+	if err != nil {
+		return cmdr.EnsureErrorResult(err)
+	}
+	if err := build.Do(); err != nil {
+		return cmdr.EnsureErrorResult(err)
+	}
+	return cmdr.Success()
+}
+
+// remoteSourceID returns the source ID a local build would have, except that
+// a revision or tag given by flags is used as given, rather than the revision
+// checked out here.
+func (sb *SousBuild) remoteSourceID() (sous.SourceID, error) {
+	bc := sb.BuildManager.BuildConfig
+	sid := bc.NewContext().Version()
+	switch {
+	case sb.DeployFilterFlags.Revision != "":
+		sid.Version.Meta = sb.DeployFilterFlags.Revision
+	case sb.DeployFilterFlags.Tag != "":
+		sid.Version.Meta = ""
+	case bc.Context.Source.RevisionUnpushed:
+		return sid, errors.Errorf("revision %s has not been pushed, so the server cannot build it", sid.RevID())
+	}
+	if sid.Location.Repo == "" {
+		return sid, errors.New("no repository to build: use -repo")
+	}
+	return sid, nil
+}
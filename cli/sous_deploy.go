@@ -14,6 +14,7 @@ type SousDeploy struct {
 
 	DeployFilterFlags config.DeployFilterFlags `inject:"optional"`
 	waitStable        bool
+	emergency         bool
 	force             bool
 	dryrunOption      string
 }
@@ -39,6 +40,8 @@ func (sd *SousDeploy) AddFlags(fs *flag.FlagSet) {
 		"force deploy no matter if GDM already is at the correct version")
 	fs.BoolVar(&sd.waitStable, "wait-stable", true,
 		"wait for the deploy to complete before returning (otherwise, use --wait-stable=false)")
+	fs.BoolVar(&sd.emergency, "emergency", false,
+		"override any freeze on the cluster; the override is recorded")
 	fs.StringVar(&sd.dryrunOption, "dry-run", "none",
 		"prevent rectify from actually changing things - "+
 			"values are none,scheduler,registry,both")
@@ -46,7 +49,7 @@ func (sd *SousDeploy) AddFlags(fs *flag.FlagSet) {
 
 // Execute fulfills the cmdr.Executor interface.
 func (sd *SousDeploy) Execute(args []string) cmdr.Result {
-	deploy, err := sd.SousGraph.GetDeploy(sd.DeployFilterFlags, sd.dryrunOption, sd.force, sd.waitStable)
+	deploy, err := sd.SousGraph.GetDeploy(sd.DeployFilterFlags, sd.dryrunOption, sd.force, sd.waitStable, sd.emergency)
 	if err != nil {
 		return cmdr.EnsureErrorResult(err)
 	}
@@ -16,6 +16,7 @@ type SousNewDeploy struct {
 
 	DeployFilterFlags config.DeployFilterFlags `inject:"optional"`
 	waitStable        bool
+	emergency         bool
 	force             bool
 	dryrunOption      string
 }
@@ -44,6 +45,8 @@ func (sd *SousNewDeploy) AddFlags(fs *flag.FlagSet) {
 		"force deploy no matter if GDM already is at the correct version")
 	fs.BoolVar(&sd.waitStable, "wait-stable", true,
 		"wait for the deploy to complete before returning (otherwise, use --wait-stable=false)")
+	fs.BoolVar(&sd.emergency, "emergency", false,
+		"override any freeze on the cluster; the override is recorded")
 	fs.StringVar(&sd.dryrunOption, "dry-run", "none",
 		"prevent rectify from actually changing things - "+
 			"values are none,scheduler,registry,both")
@@ -51,7 +54,7 @@ func (sd *SousNewDeploy) AddFlags(fs *flag.FlagSet) {
 
 // Execute creates the new deployment.
 func (sd *SousNewDeploy) Execute(args []string) cmdr.Result {
-	deploy, err := sd.SousGraph.GetDeploy(sd.DeployFilterFlags, sd.dryrunOption, sd.force, sd.waitStable)
+	deploy, err := sd.SousGraph.GetDeploy(sd.DeployFilterFlags, sd.dryrunOption, sd.force, sd.waitStable, sd.emergency)
 	if err != nil {
 		return cmdr.EnsureErrorResult(err)
 	}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"flag"
+	"os"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousPlan is the command description for `sous plan`.
+type SousPlan struct {
+	SousGraph *graph.SousGraph
+
+	DeployFilterFlags config.DeployFilterFlags `inject:"optional"`
+}
+
+func init() { TopLevelCommands["plan"] = &SousPlan{} }
+
+const sousPlanHelp = `show what the server would change to match the GDM
+
+usage: sous plan [-all | (options)]
+
+sous plan asks the server to compare the GDM with the running deployments, as
+it does before rectifying them, and lists the deployments it would add, remove
+and modify, with their differences, without changing anything. It fails if any
+deployment could not be planned, for instance because it has no artifact.
+`
+
+// Help returns the help string for this command.
+func (sp *SousPlan) Help() string { return sousPlanHelp }
+
+// AddFlags adds the flags for sous plan.
+func (sp *SousPlan) AddFlags(fs *flag.FlagSet) {
+	MustAddFlags(fs, &sp.DeployFilterFlags, RectifyFilterFlagsHelp)
+}
+
+// Execute fulfills the cmdr.Executor interface.
+func (sp *SousPlan) Execute(args []string) cmdr.Result {
+	plan, err := sp.SousGraph.GetPlan(sp.DeployFilterFlags, os.Stdout)
+	if err != nil {
+		return cmdr.EnsureErrorResult(err)
+	}
+
+	if err := plan.Do(); err != nil {
+		return EnsureErrorResult(err)
+	}
+	return cmdr.Success()
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousPromote is the command description for `sous promote`.
+type SousPromote struct {
+	SousGraph *graph.SousGraph
+
+	DeployFilterFlags config.DeployFilterFlags `inject:"optional"`
+	from, to          string
+	env, resources    string
+	waitStable        bool
+	emergency         bool
+}
+
+func init() { TopLevelCommands["promote"] = &SousPromote{} }
+
+const sousPromoteHelp = `promotes the version deployed to one cluster to another
+
+usage: sous promote -from <cluster> -to <cluster> [(options)]
+
+sous promote deploys the version of this application deployed to the -from
+cluster to the -to cluster, along with the values of any environment
+variables named by -env and resources named by -resources. The server
+refuses changes of version, promotions included, which break the promotion
+rules in defs.yaml, which may require versions to come from a particular
+cluster, to have been deployed there for a while, and to be running there.
+`
+
+// Help returns the help string for this command.
+func (sp *SousPromote) Help() string { return sousPromoteHelp }
+
+// AddFlags adds the flags for sous promote.
+func (sp *SousPromote) AddFlags(fs *flag.FlagSet) {
+	MustAddFlags(fs, &sp.DeployFilterFlags, ManifestFilterFlagsHelp)
+
+	fs.StringVar(&sp.from, "from", "", "the cluster to promote from")
+	fs.StringVar(&sp.to, "to", "", "the cluster to promote to")
+	fs.StringVar(&sp.env, "env", "",
+		"comma separated names of environment variables to copy along with the version")
+	fs.StringVar(&sp.resources, "resources", "",
+		"comma separated names of resources, e.g. cpus,memory, to copy along with the version")
+	fs.BoolVar(&sp.waitStable, "wait-stable", true,
+		"wait for the deploy to complete before returning (otherwise, use --wait-stable=false)")
+	fs.BoolVar(&sp.emergency, "emergency", false,
+		"override any freeze on the cluster promoted to; the override is recorded")
+}
+
+// Execute fulfills the cmdr.Executor interface.
+func (sp *SousPromote) Execute(args []string) cmdr.Result {
+	if sp.from == "" || sp.to == "" {
+		return cmdr.UsageErrorf("both -from and -to must be given")
+	}
+	promote, err := sp.SousGraph.GetPromote(sp.DeployFilterFlags, sp.from, sp.to,
+		sous.SplitPromotedNames(sp.env), sous.SplitPromotedNames(sp.resources), sp.waitStable, sp.emergency)
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+
+	if err := promote.Do(); err != nil {
+		return EnsureErrorResult(err)
+	}
+	return cmdr.Success("Done.")
+}
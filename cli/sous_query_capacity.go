@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"flag"
+	"os"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousQueryCapacity is the description of the `sous query capacity` command.
+type SousQueryCapacity struct {
+	config.DeployFilterFlags `inject:"optional"`
+	SousGraph                *graph.SousGraph
+	flags                    struct {
+		owner string
+	}
+}
+
+func init() { QuerySubcommands["capacity"] = &SousQueryCapacity{} }
+
+const sousQueryCapacityHelp = `The resources claimed in each cluster, against their quotas.
+
+For each cluster, and each owner with deployments or a quota in it, lists the
+instances, CPUs and memory (in MB) claimed by the deployments in the GDM, the
+quotas for them and the headroom left under those quotas. A "-" marks an
+unlimited resource. Specify -cluster or -owner to narrow the report.`
+
+// Help implements Command on SousQueryCapacity.
+func (*SousQueryCapacity) Help() string { return sousQueryCapacityHelp }
+
+// AddFlags implements AddFlagger on SousQueryCapacity.
+func (sqc *SousQueryCapacity) AddFlags(fs *flag.FlagSet) {
+	MustAddFlags(fs, &sqc.DeployFilterFlags, ClusterFilterFlagsHelp)
+	fs.StringVar(&sqc.flags.owner, "owner", "", "report only on the deployments of this owner")
+}
+
+// Execute implements Executor on SousQueryCapacity.
+func (sqc *SousQueryCapacity) Execute(args []string) cmdr.Result {
+	qc, err := sqc.SousGraph.GetQueryCapacity(sqc.DeployFilterFlags, sqc.flags.owner, os.Stdout)
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+
+	if err := qc.Do(); err != nil {
+		return EnsureErrorResult(err)
+	}
+
+	return cmdr.Success()
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"flag"
+	"os"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousQueryHistory is the description of the `sous query history` command.
+type SousQueryHistory struct {
+	config.DeployFilterFlags `inject:"optional"`
+	SousGraph                *graph.SousGraph
+}
+
+func init() { QuerySubcommands["history"] = &SousQueryHistory{} }
+
+const sousQueryHistoryHelp = `The recorded changes to a manifest in the GDM.
+
+Lists who changed the manifest, when, and what they changed, oldest first.
+Specify -cluster to see only changes to that cluster's deployment.`
+
+// Help implements Command on SousQueryHistory.
+func (*SousQueryHistory) Help() string { return sousQueryHistoryHelp }
+
+// AddFlags implements AddFlagger on SousQueryHistory.
+func (sqh *SousQueryHistory) AddFlags(fs *flag.FlagSet) {
+	MustAddFlags(fs, &sqh.DeployFilterFlags, MetadataFilterFlagsHelp)
+}
+
+// Execute implements Executor on SousQueryHistory.
+func (sqh *SousQueryHistory) Execute(args []string) cmdr.Result {
+	qh, err := sqh.SousGraph.GetQueryHistory(sqh.DeployFilterFlags, os.Stdout)
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+
+	if err := qh.Do(); err != nil {
+		return EnsureErrorResult(err)
+	}
+
+	return cmdr.Success()
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"flag"
+	"os"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousQueryProvenance is the description of the `sous query provenance`
+// command.
+type SousQueryProvenance struct {
+	config.DeployFilterFlags `inject:"optional"`
+	SousGraph                *graph.SousGraph
+}
+
+func init() { QuerySubcommands["provenance"] = &SousQueryProvenance{} }
+
+const sousQueryProvenanceHelp = `The provenance recorded for a built artifact.
+
+Prints the in-toto/SLSA provenance statement pushed alongside the image built
+for -tag: who built it and where, from which commit, with which parameters,
+and when. Run it in the project's repository, or specify -repo and -offset.`
+
+// Help implements Command on SousQueryProvenance.
+func (*SousQueryProvenance) Help() string { return sousQueryProvenanceHelp }
+
+// AddFlags implements AddFlagger on SousQueryProvenance.
+func (sqp *SousQueryProvenance) AddFlags(fs *flag.FlagSet) {
+	MustAddFlags(fs, &sqp.DeployFilterFlags, SourceFlagsHelp)
+}
+
+// Execute implements Executor on SousQueryProvenance.
+func (sqp *SousQueryProvenance) Execute(args []string) cmdr.Result {
+	qp, err := sqp.SousGraph.GetQueryProvenance(sqp.DeployFilterFlags, os.Stdout)
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+
+	if err := qp.Do(); err != nil {
+		return EnsureErrorResult(err)
+	}
+
+	return cmdr.Success()
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"flag"
+	"os"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousReject is the command description for `sous reject`.
+type SousReject struct {
+	SousGraph *graph.SousGraph
+
+	DeployFilterFlags config.DeployFilterFlags `inject:"optional"`
+	id                string
+}
+
+func init() { TopLevelCommands["reject"] = &SousReject{} }
+
+const sousRejectHelp = `rejects a deploy awaiting approval
+
+usage: sous reject -cluster <cluster> [-id <id>] [(options)]
+
+Without -id, sous reject lists the deploys awaiting approval in the named
+cluster, as sous approve does. With -id, it discards that deploy, leaving the
+GDM unchanged.
+`
+
+// Help returns the help string for this command.
+func (sr *SousReject) Help() string { return sousRejectHelp }
+
+// AddFlags adds the flags for sous reject.
+func (sr *SousReject) AddFlags(fs *flag.FlagSet) {
+	MustAddFlags(fs, &sr.DeployFilterFlags, MetadataFilterFlagsHelp)
+
+	fs.StringVar(&sr.id, "id", "", "the ID of the deploy to reject, as listed by sous reject")
+}
+
+// Execute fulfills the cmdr.Executor interface.
+func (sr *SousReject) Execute(args []string) cmdr.Result {
+	reject, err := sr.SousGraph.GetApprove(sr.DeployFilterFlags, sr.id, true, os.Stdout)
+	if err != nil {
+		return cmdr.EnsureErrorResult(err)
+	}
+
+	if err := reject.Do(); err != nil {
+		return EnsureErrorResult(err)
+	}
+	return cmdr.Success()
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"flag"
+	"os"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousRollback is the command description for `sous rollback`.
+type SousRollback struct {
+	SousGraph *graph.SousGraph
+
+	DeployFilterFlags config.DeployFilterFlags `inject:"optional"`
+	waitStable        bool
+	emergency         bool
+	dryrunOption      string
+}
+
+func init() { TopLevelCommands["rollback"] = &SousRollback{} }
+
+const sousRollbackHelp = `deploys the previous version into a particular cluster
+
+usage: sous rollback -cluster <cluster> [(options)]
+
+sous rollback finds the version which was deployed to the named cluster before
+its latest version change, as recorded in the GDM history, shows the change it
+is undoing, and deploys that version. Rolling back again undoes the change
+before that, and so on.
+`
+
+// Help returns the help string for this command.
+func (sr *SousRollback) Help() string { return sousRollbackHelp }
+
+// AddFlags adds the flags for sous rollback.
+func (sr *SousRollback) AddFlags(fs *flag.FlagSet) {
+	MustAddFlags(fs, &sr.DeployFilterFlags, MetadataFilterFlagsHelp)
+
+	fs.BoolVar(&sr.waitStable, "wait-stable", true,
+		"wait for the deploy to complete before returning (otherwise, use --wait-stable=false)")
+	fs.BoolVar(&sr.emergency, "emergency", false,
+		"override any freeze on the cluster; the override is recorded")
+	fs.StringVar(&sr.dryrunOption, "dry-run", "none",
+		"prevent rectify from actually changing things - "+
+			"values are none,scheduler,registry,both")
+}
+
+// Execute fulfills the cmdr.Executor interface.
+func (sr *SousRollback) Execute(args []string) cmdr.Result {
+	rollback, err := sr.SousGraph.GetRollback(sr.DeployFilterFlags, sr.dryrunOption, sr.waitStable, sr.emergency, os.Stdout)
+	if err != nil {
+		return cmdr.EnsureErrorResult(err)
+	}
+
+	if err := rollback.Do(); err != nil {
+		return EnsureErrorResult(err)
+	}
+	return cmdr.Success("Done.")
+}
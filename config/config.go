@@ -7,8 +7,17 @@ import (
 	"os/user"
 	"path"
 
+	"github.com/opentable/sous/ext/autoscale"
+	"github.com/opentable/sous/ext/bitbucket"
 	"github.com/opentable/sous/ext/docker"
+	"github.com/opentable/sous/ext/gitlab"
+	"github.com/opentable/sous/ext/kubernetes"
+	"github.com/opentable/sous/ext/nomad"
+	"github.com/opentable/sous/ext/secrets"
 	"github.com/opentable/sous/ext/storage"
+	"github.com/opentable/sous/ext/tagwatch"
+	"github.com/opentable/sous/ext/vulnscan"
+	"github.com/opentable/sous/ext/webhook"
 	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/firsterr"
 	"github.com/opentable/sous/util/logging"
@@ -22,6 +31,12 @@ type (
 		// StateLocation is either a file containing a pre-compiled state, or
 		// a directory containing the state as a tree.
 		StateLocation string `env:"SOUS_STATE_LOCATION"`
+		// HistoryLocation is a file in which every change made to the state
+		// at StateLocation is recorded. It must be outside StateLocation.
+		HistoryLocation string `env:"SOUS_HISTORY_LOCATION"`
+		// PendingLocation is a file holding the changes to clusters which
+		// require approval that are waiting to be approved.
+		PendingLocation string `env:"SOUS_PENDING_LOCATION"`
 		// Server is the location of a Sous Server which this sous instance
 		// considers the master. If this is not set, this node is considered
 		// to be a master. This value must be in URL format.
@@ -36,8 +51,35 @@ type (
 		// BuildStateDir is a directory where information about builds
 		// performed by this user on this machine are stored.
 		BuildStateDir string `env:"SOUS_BUILD_STATE_DIR"`
+		// SigningKey is the path of a PEM-encoded ECDSA private key, with
+		// which builds sign the images they register.
+		SigningKey string `env:"SOUS_SIGNING_KEY"`
 		// Docker is the Docker configuration.
 		Docker docker.Config
+		// Kubernetes is the configuration for deploying to clusters of kind
+		// "kubernetes".
+		Kubernetes kubernetes.Config
+		// Nomad is the configuration for deploying to clusters of kind "nomad".
+		Nomad nomad.Config
+		// GitLab lists the GitLab servers Sous recognises source locations on.
+		GitLab gitlab.Config
+		// Bitbucket lists the Bitbucket Server instances Sous recognises
+		// source locations on.
+		Bitbucket bitbucket.Config
+		// Scanner selects how built images are scanned for vulnerabilities.
+		Scanner vulnscan.Config
+		// Secrets selects where the secret references in deployments' Env
+		// are resolved when they are deployed.
+		Secrets secrets.Config
+		// Webhooks are notified of the outcome of each rectification made by
+		// the server.
+		Webhooks webhook.Config
+		// Autoscale selects where the server reads the metrics autoscaled
+		// deployments are scaled by.
+		Autoscale autoscale.Config
+		// TagWatch selects the deployments the server deploys newly tagged
+		// versions to.
+		TagWatch tagwatch.Config
 		// Logging is the logging configuration.
 		Logging logging.Config
 		// User identifies the user of this client.
@@ -82,7 +124,11 @@ func (c Config) Validate() error {
 // DefaultConfig returns the default configuration.
 func DefaultConfig() Config {
 	return Config{
-		Docker: docker.DefaultConfig(),
+		Docker:                        docker.DefaultConfig(),
+		Kubernetes:                    kubernetes.DefaultConfig(),
+		Nomad:                         nomad.DefaultConfig(),
+		GitLab:                        gitlab.DefaultConfig(),
+		Webhooks:                      webhook.DefaultConfig(),
 		MaxHTTPConcurrencySingularity: 10,
 		PollIntervalForClient:         600,
 	}
@@ -93,15 +139,51 @@ func (c *Config) Equal(other *Config) bool {
 	if c.StateLocation != other.StateLocation {
 		return false
 	}
+	if c.HistoryLocation != other.HistoryLocation {
+		return false
+	}
+	if c.PendingLocation != other.PendingLocation {
+		return false
+	}
 	if c.Server != other.Server {
 		return false
 	}
 	if c.BuildStateDir != other.BuildStateDir {
 		return false
 	}
+	if c.SigningKey != other.SigningKey {
+		return false
+	}
 	if c.Docker != other.Docker {
 		return false
 	}
+	if c.Kubernetes != other.Kubernetes {
+		return false
+	}
+	if c.Nomad != other.Nomad {
+		return false
+	}
+	if c.Scanner != other.Scanner {
+		return false
+	}
+	if c.Secrets != other.Secrets {
+		return false
+	}
+	if !c.Webhooks.Equal(other.Webhooks) {
+		return false
+	}
+	if c.GitLab != other.GitLab {
+		return false
+	}
+	if c.Bitbucket != other.Bitbucket {
+		return false
+	}
+	if c.Autoscale != other.Autoscale {
+		return false
+	}
+	if !c.TagWatch.Equal(other.TagWatch) {
+		return false
+	}
 	if !c.Logging.Equal(other.Logging) {
 		return false
 	}
@@ -128,11 +210,62 @@ func (c *Config) FillDefaults() error {
 		func(e *error) {
 			*e = EnsureDirExists(c.StateLocation)
 		},
+		func(e *error) {
+			if c.HistoryLocation == "" {
+				c.HistoryLocation, *e = c.defaultHistoryLocation()
+			}
+		},
+		func(e *error) {
+			if c.PendingLocation == "" {
+				c.PendingLocation, *e = c.defaultPendingLocation()
+			}
+		},
+		func(e *error) {
+			if c.BuildStateDir == "" {
+				c.BuildStateDir, *e = c.defaultBuildStateDir()
+			}
+		},
 	)
 }
 
 // defaultStateLocation returns the default state location.
 func (*Config) defaultStateLocation() (string, error) {
+	dataRoot, err := dataRoot()
+	if err != nil {
+		return "", err
+	}
+	stateLocation := path.Join(dataRoot, "sous", "state")
+	return stateLocation, nil
+}
+
+// defaultHistoryLocation returns the default history location.
+func (*Config) defaultHistoryLocation() (string, error) {
+	dataRoot, err := dataRoot()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(dataRoot, "sous", "history"), nil
+}
+
+// defaultPendingLocation returns the default pending changes location.
+func (*Config) defaultPendingLocation() (string, error) {
+	dataRoot, err := dataRoot()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(dataRoot, "sous", "pending"), nil
+}
+
+// defaultBuildStateDir returns the default build state directory.
+func (*Config) defaultBuildStateDir() (string, error) {
+	dataRoot, err := dataRoot()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(dataRoot, "sous", "build"), nil
+}
+
+func dataRoot() (string, error) {
 	dataRoot := os.Getenv("XDG_DATA_HOME")
 	if dataRoot == "" {
 		u, err := user.Current()
@@ -141,8 +274,7 @@ func (*Config) defaultStateLocation() (string, error) {
 		}
 		dataRoot = path.Join(u.HomeDir, ".local", "share")
 	}
-	stateLocation := path.Join(dataRoot, "sous", "state")
-	return stateLocation, nil
+	return dataRoot, nil
 }
 
 // EnsureDirExists creates the named directory if it does not exist.
@@ -9,7 +9,13 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/opentable/sous/ext/autoscale"
+	"github.com/opentable/sous/ext/bitbucket"
 	"github.com/opentable/sous/ext/docker"
+	"github.com/opentable/sous/ext/gitlab"
+	"github.com/opentable/sous/ext/secrets"
+	"github.com/opentable/sous/ext/vulnscan"
+	"github.com/opentable/sous/ext/webhook"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -80,15 +86,24 @@ func TestConfig_Validate(t *testing.T) {
 
 func TestConfig_Equals(t *testing.T) {
 	expected := &Config{
-		StateLocation: "statelocation",
-		Server:        "server",
-		SiblingURLs:   map[string]string{"x": "sibling", "y": "urls"},
-		BuildStateDir: "buildstatedir",
+		StateLocation:   "statelocation",
+		HistoryLocation: "historylocation",
+		PendingLocation: "pendinglocation",
+		Server:          "server",
+		SiblingURLs:     map[string]string{"x": "sibling", "y": "urls"},
+		BuildStateDir:   "buildstatedir",
+		SigningKey:      "signingkey",
 		Docker: docker.Config{
 			RegistryHost:       "registryhost",
 			DatabaseDriver:     "databasedriver",
 			DatabaseConnection: "databaseconnection",
+			Daemonless:         true,
 		},
+		GitLab:    gitlab.Config{Hosts: "gitlab.example.com"},
+		Bitbucket: bitbucket.Config{Hosts: "bitbucket.example.com"},
+		Scanner:   vulnscan.Config{Scanner: "trivy"},
+		Secrets:   secrets.Config{VaultAddr: "https://vault.example.com"},
+		Autoscale: autoscale.Config{Source: "prometheus"},
 	}
 	var actual *Config
 
@@ -107,12 +122,36 @@ func TestConfig_Equals(t *testing.T) {
 	actual.StateLocation = "statelocation"
 	checkNotEqual()
 
+	actual.HistoryLocation = "historylocation"
+	checkNotEqual()
+
+	actual.PendingLocation = "pendinglocation"
+	checkNotEqual()
+
 	actual.Server = "server"
 	checkNotEqual()
 
 	actual.BuildStateDir = "buildstatedir"
 	checkNotEqual()
 
+	actual.SigningKey = "signingkey"
+	checkNotEqual()
+
+	actual.GitLab.Hosts = "gitlab.example.com"
+	checkNotEqual()
+
+	actual.Bitbucket.Hosts = "bitbucket.example.com"
+	checkNotEqual()
+
+	actual.Scanner.Scanner = "trivy"
+	checkNotEqual()
+
+	actual.Secrets.VaultAddr = "https://vault.example.com"
+	checkNotEqual()
+
+	actual.Autoscale.Source = "prometheus"
+	checkNotEqual()
+
 	actual.Docker = expected.Docker
 	checkNotEqual()
 
@@ -127,6 +166,21 @@ func TestConfig_Equals(t *testing.T) {
 	// Confirming that the two Docker structs are separate memory
 	actual.Docker.DatabaseConnection = ""
 	checkNotEqual()
+	actual.Docker = expected.Docker
+
+	actual.Webhooks.Hooks = []webhook.Hook{{URL: "http://hooks.example.com"}}
+	checkNotEqual()
+	expected.Webhooks.Hooks = []webhook.Hook{{URL: "http://hooks.example.com"}}
+	if !expected.Equal(actual) {
+		t.Errorf("expected.Equal(actual) was false:\n%v\n%v", expected, actual)
+	}
+
+	actual.TagWatch.Manifests = []string{"github.com/user/repo"}
+	checkNotEqual()
+	expected.TagWatch.Manifests = []string{"github.com/user/repo"}
+	if !expected.Equal(actual) {
+		t.Errorf("expected.Equal(actual) was false:\n%v\n%v", expected, actual)
+	}
 }
 
 func TestEnsureDirExists(t *testing.T) {
@@ -8,4 +8,6 @@ type R11nResponse struct {
 	// Pointer here is just to allow nil which is a clearer indication of
 	// "nothing to see here" than a JSON-marshalled zero value would be.
 	Resolution *sous.DiffResolution
+	// Rollout is the stage reached by a progressive rollout, if any.
+	Rollout *sous.RolloutProgress
 }
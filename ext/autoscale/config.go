@@ -0,0 +1,15 @@
+package autoscale
+
+// Config selects where the server reads the metrics which autoscaled
+// deployments are scaled by.
+type Config struct {
+	// Source is "graphite", "prometheus" or "stub". Autoscaling is disabled
+	// if it is empty.
+	Source string `env:"SOUS_AUTOSCALE_SOURCE"`
+	// URL is the base URL of the Graphite or Prometheus server, or, for
+	// "stub", the path of a JSON file mapping each query to its value.
+	URL string `env:"SOUS_AUTOSCALE_URL"`
+	// IntervalSeconds is the time between checks of the metrics. The
+	// default is 60.
+	IntervalSeconds int `env:"SOUS_AUTOSCALE_INTERVAL_SECONDS"`
+}
@@ -0,0 +1,180 @@
+// Package autoscale reads the metrics autoscaled deployments are scaled by,
+// from the Graphite render API, the Prometheus query API or a local file.
+package autoscale
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+type (
+	// GraphiteSource reads metrics from the Graphite render API. The value
+	// of a query is the sum of the latest value of each series it matches.
+	GraphiteSource struct {
+		URL    string
+		Client *http.Client
+	}
+
+	// PrometheusSource reads metrics from the Prometheus query API. The
+	// value of a query is its scalar result, or the sum of its vector result.
+	PrometheusSource struct {
+		URL    string
+		Client *http.Client
+	}
+
+	// StubSource reads metrics from a JSON file mapping each query to its
+	// value. The file is read for each query, so it may be edited to
+	// exercise autoscaling.
+	StubSource struct {
+		Path string
+	}
+
+	graphiteSeries struct {
+		Target     string
+		Datapoints [][2]*float64
+	}
+
+	prometheusResponse struct {
+		Status string
+		Error  string
+		Data   struct {
+			ResultType string
+			Result     json.RawMessage
+		}
+	}
+)
+
+// New returns the MetricSource cfg selects, or nil if it selects none.
+func New(cfg Config) (sous.MetricSource, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	base := strings.TrimSuffix(cfg.URL, "/")
+	switch cfg.Source {
+	default:
+		return nil, errors.Errorf("unknown autoscale metric source %q", cfg.Source)
+	case "":
+		return nil, nil
+	case "graphite":
+		return &GraphiteSource{URL: base, Client: client}, nil
+	case "prometheus":
+		return &PrometheusSource{URL: base, Client: client}, nil
+	case "stub":
+		return &StubSource{Path: cfg.URL}, nil
+	}
+}
+
+// Interval returns the time between checks of the metrics cfg selects.
+func (cfg Config) Interval() time.Duration {
+	if cfg.IntervalSeconds <= 0 {
+		return 60 * time.Second
+	}
+	return time.Duration(cfg.IntervalSeconds) * time.Second
+}
+
+// Metric implements sous.MetricSource on GraphiteSource.
+func (s *GraphiteSource) Metric(query string) (float64, error) {
+	q := url.Values{"target": {query}, "from": {"-5min"}, "format": {"json"}}
+	series := []graphiteSeries{}
+	if err := getJSON(s.Client, s.URL+"/render?"+q.Encode(), &series); err != nil {
+		return 0, err
+	}
+	if len(series) == 0 {
+		return 0, errors.Errorf("graphite has no series for %q", query)
+	}
+	total := 0.0
+	for _, ser := range series {
+		for i := len(ser.Datapoints) - 1; i >= 0; i-- {
+			if v := ser.Datapoints[i][0]; v != nil {
+				total += *v
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+// Metric implements sous.MetricSource on PrometheusSource.
+func (s *PrometheusSource) Metric(query string) (float64, error) {
+	q := url.Values{"query": {query}}
+	resp := prometheusResponse{}
+	if err := getJSON(s.Client, s.URL+"/api/v1/query?"+q.Encode(), &resp); err != nil {
+		return 0, err
+	}
+	if resp.Status != "success" {
+		return 0, errors.Errorf("prometheus query %q failed: %s", query, resp.Error)
+	}
+
+	switch resp.Data.ResultType {
+	default:
+		return 0, errors.Errorf("prometheus query %q returned a %s, not a scalar or vector", query, resp.Data.ResultType)
+	case "scalar":
+		sample := [2]interface{}{}
+		if err := json.Unmarshal(resp.Data.Result, &sample); err != nil {
+			return 0, err
+		}
+		return prometheusValue(sample)
+	case "vector":
+		samples := []struct{ Value [2]interface{} }{}
+		if err := json.Unmarshal(resp.Data.Result, &samples); err != nil {
+			return 0, err
+		}
+		if len(samples) == 0 {
+			return 0, errors.Errorf("prometheus query %q returned no samples", query)
+		}
+		total := 0.0
+		for _, sample := range samples {
+			v, err := prometheusValue(sample.Value)
+			if err != nil {
+				return 0, err
+			}
+			total += v
+		}
+		return total, nil
+	}
+}
+
+// prometheusValue returns the value of a sample, which Prometheus gives as
+// [time, "value"].
+func prometheusValue(sample [2]interface{}) (float64, error) {
+	s, ok := sample[1].(string)
+	if !ok {
+		return 0, errors.Errorf("prometheus sample value %v is not a string", sample[1])
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// Metric implements sous.MetricSource on StubSource.
+func (s *StubSource) Metric(query string) (float64, error) {
+	b, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return 0, err
+	}
+	values := map[string]float64{}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return 0, errors.Wrapf(err, "reading %s", s.Path)
+	}
+	v, ok := values[query]
+	if !ok {
+		return 0, errors.Errorf("%s has no value for %q", s.Path, query)
+	}
+	return v, nil
+}
+
+func getJSON(client *http.Client, u string, into interface{}) error {
+	resp, err := client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(into), "GET %s", u)
+}
@@ -0,0 +1,109 @@
+package autoscale
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	ms, err := New(Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, ms)
+
+	_, err = New(Config{Source: "datadog"})
+	assert.Error(t, err)
+
+	ms, err = New(Config{Source: "graphite", URL: "http://graphite.example.com/"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://graphite.example.com", ms.(*GraphiteSource).URL)
+
+	assert.Equal(t, 60, int(Config{}.Interval().Seconds()))
+	assert.Equal(t, 15, int(Config{IntervalSeconds: 15}.Interval().Seconds()))
+}
+
+func TestGraphiteSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/render", r.URL.Path)
+		assert.Equal(t, "json", r.URL.Query().Get("format"))
+		if r.URL.Query().Get("target") != "sumSeries(app.*.requests)" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[
+			{"target": "a", "datapoints": [[10, 1500000000], [12, 1500000060], [null, 1500000120]]},
+			{"target": "b", "datapoints": [[5, 1500000000], [7.5, 1500000060]]}
+		]`))
+	}))
+	defer srv.Close()
+
+	ms, err := New(Config{Source: "graphite", URL: srv.URL})
+	require.NoError(t, err)
+
+	v, err := ms.Metric("sumSeries(app.*.requests)")
+	require.NoError(t, err)
+	assert.Equal(t, 19.5, v)
+
+	_, err = ms.Metric("nothing")
+	assert.Error(t, err)
+}
+
+func TestPrometheusSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		switch r.URL.Query().Get("query") {
+		case "vector":
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"instance":"a"},"value":[1500000000.1,"3"]},
+				{"metric":{"instance":"b"},"value":[1500000000.1,"4.5"]}]}}`))
+		case "scalar":
+			w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1500000000.1,"42"]}}`))
+		case "matrix":
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","error":"parse error"}`))
+		}
+	}))
+	defer srv.Close()
+
+	ms, err := New(Config{Source: "prometheus", URL: srv.URL})
+	require.NoError(t, err)
+
+	v, err := ms.Metric("vector")
+	require.NoError(t, err)
+	assert.Equal(t, 7.5, v)
+
+	v, err = ms.Metric("scalar")
+	require.NoError(t, err)
+	assert.Equal(t, 42.0, v)
+
+	_, err = ms.Metric("matrix")
+	assert.Error(t, err)
+	_, err = ms.Metric("bad(")
+	assert.Error(t, err)
+}
+
+func TestStubSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-autoscale")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metrics.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"requests": 250}`), 0600))
+
+	ms, err := New(Config{Source: "stub", URL: path})
+	require.NoError(t, err)
+
+	v, err := ms.Metric("requests")
+	require.NoError(t, err)
+	assert.Equal(t, 250.0, v)
+
+	_, err = ms.Metric("errors")
+	assert.Error(t, err)
+}
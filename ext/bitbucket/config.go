@@ -0,0 +1,21 @@
+package bitbucket
+
+import "strings"
+
+// Config lists the Bitbucket Server instances whose source locations Sous
+// recognises.
+type Config struct {
+	// Hosts is a comma separated list of the host names of Bitbucket Server
+	// instances.
+	Hosts string `env:"SOUS_BITBUCKET_HOSTS"`
+}
+
+func (c Config) hosts() []string {
+	hosts := []string{}
+	for _, h := range strings.Split(c.Hosts, ",") {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,117 @@
+package bitbucket
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/opentable/sous/ext/git"
+	"github.com/opentable/sous/lib"
+)
+
+// SourceHost is a Bitbucket Server source code host.
+// It satisfies sous.SourceHost.
+//
+// Repositories are identified by their HTTP clone paths, e.g.
+// bitbucket.example.com/scm/proj/repo, and source locations may also be
+// given as repository browse URLs or SSH clone URLs. Project keys are not case
+// sensitive, and are lower cased. An offset directory may follow a comma, or,
+// in a browse URL, "/browse/".
+type SourceHost struct {
+	// Hosts are the host names of the Bitbucket Server instances this
+	// SourceHost handles.
+	Hosts []string
+	// Git is the client source is fetched with. If nil, git in the path is
+	// used.
+	Git *git.Client
+	// RepoURL returns the URL repo is fetched from. If nil, it is fetched
+	// over HTTPS.
+	RepoURL func(repo string) string
+	// WorkDir is where source is fetched to, each in a new temporary
+	// directory. If empty, the default directory for temporary files is used.
+	WorkDir string
+}
+
+// NewSourceHost returns a SourceHost for the instances listed in c.
+func NewSourceHost(c Config) SourceHost {
+	return SourceHost{Hosts: c.hosts()}
+}
+
+func (h SourceHost) handles(host string) bool {
+	for _, hh := range h.Hosts {
+		if host == hh {
+			return true
+		}
+	}
+	return false
+}
+
+// CanParseSourceLocation returns true if s is on one of h's Hosts.
+func (h SourceHost) CanParseSourceLocation(s string) bool {
+	host, _ := git.HostAndPath(strings.SplitN(s, ",", 2)[0])
+	return h.handles(host)
+}
+
+// ParseSourceLocation parses a Bitbucket Server source location.
+func (h SourceHost) ParseSourceLocation(s string) (sous.SourceLocation, error) {
+	repodir := strings.SplitN(s, ",", 2)
+	host, p := git.HostAndPath(repodir[0])
+	if !h.handles(host) {
+		return sous.SourceLocation{}, fmt.Errorf("%q is not on a Bitbucket host (%s)", s, strings.Join(h.Hosts, ", "))
+	}
+
+	var project, repo, dir string
+	var rest []string
+	parts := strings.Split(p, "/")
+	switch {
+	case len(parts) >= 4 && parts[0] == "projects" && parts[2] == "repos":
+		project, repo, rest = parts[1], parts[3], parts[4:]
+	case len(parts) >= 4 && parts[0] == "users" && parts[2] == "repos":
+		project, repo, rest = "~"+parts[1], parts[3], parts[4:]
+	case len(parts) == 3 && parts[0] == "scm":
+		project, repo = parts[1], parts[2]
+	case len(parts) == 2 && parts[0] != "scm":
+		// The path of an SSH clone URL.
+		project, repo = parts[0], parts[1]
+	}
+	if project == "" || project == "~" || repo == "" {
+		return sous.SourceLocation{}, fmt.Errorf("%q does not identify a Bitbucket repository", s)
+	}
+	if len(rest) > 0 {
+		if rest[0] != "browse" {
+			return sous.SourceLocation{}, fmt.Errorf("%q does not identify a directory in a Bitbucket repository", s)
+		}
+		dir = path.Join(rest[1:]...)
+	}
+	if len(repodir) == 2 {
+		if dir != "" {
+			return sous.SourceLocation{}, fmt.Errorf("%q has two offset directories", s)
+		}
+		dir = repodir[1]
+	}
+	return sous.SourceLocation{
+		Repo: path.Join(host, "scm", strings.ToLower(project), repo),
+		Dir:  dir,
+	}, nil
+}
+
+// Owns returns true if sl.Repo is on one of h's Hosts.
+func (h SourceHost) Owns(sl sous.SourceLocation) bool {
+	host, _ := git.HostAndPath(sl.Repo)
+	return h.handles(host)
+}
+
+// GetSource returns a sous.Source for the provided id, fetched into a new
+// directory. It returns an error if Owns(id.Location) returns false, or if
+// fetching the source fails.
+func (h SourceHost) GetSource(id sous.SourceID) (sous.Source, error) {
+	if !h.Owns(id.Location) {
+		return sous.Source{}, fmt.Errorf("the bitbucket source host cannot get source for %q",
+			id.Location)
+	}
+	repoURL := "https://" + id.Location.Repo + ".git"
+	if h.RepoURL != nil {
+		repoURL = h.RepoURL(id.Location.Repo)
+	}
+	return git.FetchSource(h.Git, id, repoURL, h.WorkDir)
+}
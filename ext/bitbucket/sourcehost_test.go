@@ -0,0 +1,106 @@
+package bitbucket
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opentable/sous/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceHost_ParseSourceLocation(t *testing.T) {
+	h := NewSourceHost(Config{Hosts: "bitbucket.example.com"})
+
+	repo := "bitbucket.example.com/scm/proj/repo"
+	good := map[string]sous.SourceLocation{
+		"bitbucket.example.com/scm/proj/repo":                                        {Repo: repo},
+		"bitbucket.example.com/scm/proj/repo,svc/api":                                {Repo: repo, Dir: "svc/api"},
+		"https://bitbucket.example.com/scm/proj/repo.git":                            {Repo: repo},
+		"ssh://git@bitbucket.example.com:7999/proj/repo.git":                         {Repo: repo},
+		"https://bitbucket.example.com/projects/PROJ/repos/repo/browse":              {Repo: repo},
+		"https://bitbucket.example.com/projects/PROJ/repos/repo/browse/svc/api?at=x": {Repo: repo, Dir: "svc/api"},
+		"https://bitbucket.example.com/users/Someone/repos/repo/browse":              {Repo: "bitbucket.example.com/scm/~someone/repo"},
+	}
+	for in, expected := range good {
+		assert.True(t, h.CanParseSourceLocation(in), in)
+		actual, err := h.ParseSourceLocation(in)
+		if assert.NoError(t, err, in) {
+			assert.Equal(t, expected, actual, in)
+			assert.True(t, h.Owns(actual), in)
+		}
+	}
+
+	bad := []string{
+		"bitbucket.example.com/repo",
+		"bitbucket.example.com/scm/proj",
+		"bitbucket.example.com/projects/PROJ/repos/repo/commits",
+		"bitbucket.example.com/projects/PROJ/repos/repo/browse/dir,dir",
+	}
+	for _, in := range bad {
+		_, err := h.ParseSourceLocation(in)
+		assert.Error(t, err, in)
+	}
+
+	assert.False(t, h.CanParseSourceLocation("github.com/user/repo"))
+	assert.False(t, h.Owns(sous.SourceLocation{Repo: "github.com/user/repo"}))
+	assert.False(t, NewSourceHost(Config{}).CanParseSourceLocation(repo))
+}
+
+// bareRepo makes a bare repository in dir with one commit, tagged 1.2.3,
+// and returns its path and the revision of that commit.
+func bareRepo(t *testing.T, dir string) (string, string) {
+	work := filepath.Join(dir, "work")
+	require.NoError(t, os.MkdirAll(filepath.Join(work, "svc"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(work, "svc", "Dockerfile"), []byte("FROM scratch\n"), 0644))
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	git(work, "init", "-q")
+	git(work, "add", ".")
+	git(work, "commit", "-q", "-m", "initial")
+	git(work, "tag", "1.2.3")
+	rev := git(work, "rev-parse", "HEAD")
+	git(dir, "clone", "-q", "--bare", work, "bare.git")
+	return filepath.Join(dir, "bare.git"), rev
+}
+
+func TestSourceHost_GetSource(t *testing.T) {
+	work, err := ioutil.TempDir("", "bitbucket-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(work)
+	bare, rev := bareRepo(t, work)
+
+	h := SourceHost{
+		Hosts:   []string{"bitbucket.example.com"},
+		RepoURL: func(string) string { return "file://" + bare },
+		WorkDir: work,
+	}
+
+	for _, version := range []string{"1.2.3+" + rev, "1.2.3"} {
+		id := sous.MakeSourceID("bitbucket.example.com/scm/proj/repo", "svc", version)
+		src, err := h.GetSource(id)
+		require.NoError(t, err, version)
+		assert.Equal(t, id, src.ID)
+		assert.Equal(t, rev, src.Context.Revision)
+		assert.Equal(t, filepath.Join(src.LocalRootDir, "svc"), src.LocalOffsetDir)
+		assert.Contains(t, src.Context.Files, "svc/Dockerfile")
+		_, err = os.Stat(filepath.Join(src.LocalOffsetDir, "Dockerfile"))
+		assert.NoError(t, err)
+	}
+
+	_, err = h.GetSource(sous.MakeSourceID("bitbucket.example.com/scm/proj/repo", "svc", "2.0.0"))
+	assert.Error(t, err)
+	_, err = h.GetSource(sous.MakeSourceID("bitbucket.example.com/scm/proj/repo", "nope", "1.2.3"))
+	assert.Error(t, err)
+	_, err = h.GetSource(sous.MakeSourceID("github.com/user/repo", "", "1.2.3"))
+	assert.Error(t, err)
+}
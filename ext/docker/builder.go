@@ -2,12 +2,17 @@ package docker
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/nyarly/inlinefiles/templatestore"
 	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/docker_registry"
 	"github.com/opentable/sous/util/logging"
 	"github.com/opentable/sous/util/logging/messages"
 	"github.com/opentable/sous/util/shell"
@@ -22,6 +27,12 @@ type (
 		DockerRegistryHost        string
 		SourceShell, ScratchShell shell.Shell
 		Pack                      sous.Buildpack
+		// RegistryClient pushes the images which OCIBuildpack exports as OCI
+		// image layouts, rather than leaving in a Docker daemon.
+		RegistryClient docker_registry.Client
+		// Signer, if not nil, signs each image registered, over its digest
+		// and SourceID.
+		Signer *sous.ArtifactSigner
 	}
 	// BuildTarget represents a single target within a Build.
 	BuildTarget interface {
@@ -73,7 +84,14 @@ func (b *Builder) Register(br *sous.BuildResult) error {
 			return err
 		}
 
-		err = b.recordName(prod)
+		qs, err := b.sign(prod)
+		if err != nil {
+			return err
+		}
+
+		b.pushProvenance(prod)
+
+		err = b.recordName(prod, qs...)
 		if err != nil {
 			return err
 		}
@@ -93,14 +111,53 @@ func (b *Builder) applyMetadata(bp *sous.BuildProduct) error {
 	bp.VersionName = b.VersionTag(bp.Source, bp.Kind)
 	bp.RevisionName = b.RevisionTag(bp.Source, bp.Kind, time.Now())
 
+	if dir, ok := OCILayoutDir(bp.ID); ok {
+		l, err := docker_registry.OpenImageLayout(dir)
+		if err == nil {
+			err = l.AddLabels(imageLabels(bp))
+		}
+		if err != nil {
+			// It will never be pushed.
+			os.RemoveAll(dir)
+		}
+		return err
+	}
+
+	if len(bp.Platforms) > 0 {
+		for _, p := range platforms(bp) {
+			c := b.SourceShell.Cmd("docker", "build", "--platform", p, "-t", platformTag(bp.VersionName, p), "-")
+			c.SetStdin(b.metadataDockerfile(bp, bp.Platforms[p]))
+			if err := c.Succeed(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	c := b.SourceShell.Cmd("docker", "build", "-t", bp.VersionName, "-t", bp.RevisionName, "-")
-	bf := b.metadataDockerfile(bp)
+	bf := b.metadataDockerfile(bp, bp.ID)
 	c.SetStdin(bf)
 
 	return c.Succeed()
 }
 
-func (b *Builder) metadataDockerfile(bp *sous.BuildProduct) io.Reader {
+// platforms returns the platforms bp was built for, in order.
+func platforms(bp *sous.BuildProduct) []string {
+	ps := make([]string, 0, len(bp.Platforms))
+	for p := range bp.Platforms {
+		ps = append(ps, p)
+	}
+	sort.Strings(ps)
+	return ps
+}
+
+// platformTag returns the name of the image for platform, among those listed
+// by the manifest list named name.
+func platformTag(name, platform string) string {
+	return name + "-" + strings.Replace(platform, "/", "-", -1)
+}
+
+func (b *Builder) metadataDockerfile(bp *sous.BuildProduct, imageID string) io.Reader {
 	bf := bytes.Buffer{}
 	sv := bp.Source
 	md, err := templatestore.LoadText(templateVFS, "metadata", "metadataDockerfile.tmpl")
@@ -113,15 +170,29 @@ func (b *Builder) metadataDockerfile(bp *sous.BuildProduct) io.Reader {
 		Labels     map[string]string
 		Advisories []string
 	}{
-		bp.ID,
+		imageID,
 		Labels(sv),
 		bp.Advisories,
 	})
 	return &bf
 }
 
-// pushToRegistry sends the built image to the registry
+// pushToRegistry sends the built image to the registry. An OCI image layout
+// is removed afterwards, whether or not the push succeeded, so cached
+// daemonless builds are built again rather than reused.
 func (b *Builder) pushToRegistry(bp *sous.BuildProduct) error {
+	if dir, ok := OCILayoutDir(bp.ID); ok {
+		defer os.RemoveAll(dir)
+		if b.RegistryClient == nil {
+			return fmt.Errorf("no registry client to push %s", bp.VersionName)
+		}
+		return b.RegistryClient.PushImageLayout(dir, bp.VersionName, bp.RevisionName)
+	}
+
+	if len(bp.Platforms) > 0 {
+		return b.pushManifestList(bp)
+	}
+
 	verr := b.SourceShell.Run("docker", "push", bp.VersionName)
 	rerr := b.SourceShell.Run("docker", "push", bp.RevisionName)
 
@@ -131,15 +202,115 @@ func (b *Builder) pushToRegistry(bp *sous.BuildProduct) error {
 	return verr
 }
 
+// pushManifestList pushes bp's image for each platform, then a manifest list
+// of them under both its version and revision names.
+func (b *Builder) pushManifestList(bp *sous.BuildProduct) error {
+	tags := []interface{}{}
+	for _, p := range platforms(bp) {
+		tag := platformTag(bp.VersionName, p)
+		if err := b.SourceShell.Run("docker", "push", tag); err != nil {
+			return err
+		}
+		tags = append(tags, tag)
+	}
+	for _, name := range []string{bp.VersionName, bp.RevisionName} {
+		create := append([]interface{}{"manifest", "create", "--amend", name}, tags...)
+		if err := b.SourceShell.Run("docker", create...); err != nil {
+			return err
+		}
+		if err := b.SourceShell.Run("docker", "manifest", "push", "--purge", name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canonicalName returns the digest-qualified name, including the registry
+// host, of bp's pushed image.
+func (b *Builder) canonicalName(bp *sous.BuildProduct) (string, error) {
+	if b.RegistryClient == nil {
+		return "", fmt.Errorf("no registry client to find the digest of %s", bp.VersionName)
+	}
+	md, err := b.RegistryClient.GetImageMetadata(bp.VersionName, "")
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(md.CanonicalName, "@") {
+		return "", fmt.Errorf("no digest for %s in %q", bp.VersionName, md.CanonicalName)
+	}
+	// The registry client names images without their registry host.
+	return b.DockerRegistryHost + "/" + md.CanonicalName, nil
+}
+
+// sign returns the signature Quality for the pushed image, if b has a Signer.
+// The signature is attached to the image in the registry too, which is where
+// servers harvesting the image find it.
+func (b *Builder) sign(bp *sous.BuildProduct) ([]sous.Quality, error) {
+	if b.Signer == nil {
+		return nil, nil
+	}
+	canonical, err := b.canonicalName(bp)
+	if err != nil {
+		return nil, err
+	}
+	q, err := b.Signer.Sign(canonical[strings.LastIndex(canonical, "@")+1:], bp.Source)
+	if err != nil {
+		return nil, err
+	}
+	name, err := docker_registry.AttachmentName(canonical, "signature")
+	if err != nil {
+		return nil, err
+	}
+	if err := b.RegistryClient.PushAttachment(name, sous.SignatureMediaType, []byte(q.Name)); err != nil {
+		return nil, fmt.Errorf("attaching signature to %s: %v", canonical, err)
+	}
+	b.SourceShell.ConsoleEcho(fmt.Sprintf("[signed %s]", canonical))
+	return []sous.Quality{q}, nil
+}
+
+// pushProvenance pushes the provenance of bp, if any, as an attachment to
+// its image. Registries which cannot store it should not stop the image being
+// deployed, so failure is only a warning.
+func (b *Builder) pushProvenance(bp *sous.BuildProduct) {
+	if bp.Provenance == nil {
+		return
+	}
+	err := func() error {
+		canonical, err := b.canonicalName(bp)
+		if err != nil {
+			return err
+		}
+		name, err := docker_registry.AttachmentName(canonical, "provenance")
+		if err != nil {
+			return err
+		}
+		bp.Provenance.SetSubject(bp.VersionName, canonical[strings.LastIndex(canonical, "@")+1:])
+		content, err := json.Marshal(bp.Provenance)
+		if err != nil {
+			return err
+		}
+		if err := b.RegistryClient.PushAttachment(name, sous.ProvenanceMediaType, content); err != nil {
+			return err
+		}
+		b.SourceShell.ConsoleEcho(fmt.Sprintf("[attached provenance to %s]", canonical))
+		return nil
+	}()
+	if err != nil {
+		messages.ReportLogFieldsMessageToConsole(fmt.Sprintf("Could not push provenance of %s: %v", bp.VersionName, err), logging.WarningLevel, logging.Log)
+	}
+}
+
 // recordName inserts metadata about the newly built image into our local name cache
-func (b *Builder) recordName(bp *sous.BuildProduct) error {
+func (b *Builder) recordName(bp *sous.BuildProduct, qs ...sous.Quality) error {
 	sv := bp.Source
 	in := bp.VersionName
 	b.SourceShell.ConsoleEcho(fmt.Sprintf("[recording \"%s\" as the docker name for \"%s\"]", in, sv.String()))
-	var qs []sous.Quality
 	for _, adv := range bp.Advisories {
 		qs = append(qs, sous.Quality{Name: adv, Kind: "advisory"})
 	}
+	for _, p := range platforms(bp) {
+		qs = append(qs, sous.Quality{Name: p, Kind: sous.PlatformQuality})
+	}
 	return b.ImageMapper.Insert(sv, in, "", qs)
 }
 
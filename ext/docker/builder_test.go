@@ -1,11 +1,20 @@
 package docker
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
 	"io/ioutil"
 	"testing"
 	"time"
 
+	"github.com/nyarly/spies"
 	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/docker_registry"
+	"github.com/opentable/sous/util/logging"
 	"github.com/opentable/sous/util/shell"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,7 +30,7 @@ func TestMetadataDockerfile(t *testing.T) {
 		Advisories: []string{`something is horribly wrong`},
 		Source:     sous.MakeSourceID("github.com/opentable/test", "sub", "2.3.7+abcd"),
 	}
-	mddf, err := ioutil.ReadAll(b.metadataDockerfile(&bp))
+	mddf, err := ioutil.ReadAll(b.metadataDockerfile(&bp, bp.ID))
 
 	assert.NoError(err)
 	assert.Equal(
@@ -78,3 +87,171 @@ func TestBuilderApplyMetadata(t *testing.T) {
 
 	assert.Len(t, srcCtl.CmdsLike("docker", "push"), 4)
 }
+
+func TestBuilderRegister_Signed(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	signer, err := sous.NewArtifactSigner(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+
+	srcSh, _ := shell.NewTestShell()
+	scratchSh, _ := shell.NewTestShell()
+	nc := sous.NewInserterSpy()
+	b, err := NewBuilder(nc, "docker.example.com", srcSh, scratchSh)
+	require.NoError(t, err)
+	rc := docker_registry.NewDummyClient()
+	rc.MatchMethod("GetImageMetadata", spies.AnyArgs, docker_registry.Metadata{
+		Registry:      "docker.example.com",
+		CanonicalName: "test@sha256:cabba9e",
+	}, nil)
+	rc.MatchMethod("PushAttachment", spies.AnyArgs, nil)
+	b.RegistryClient = rc
+	b.Signer = signer
+
+	bp := &sous.BuildProduct{
+		Source:      sous.MakeSourceID("github.com/opentable/test", "", "2.3.7+abcd"),
+		Advisories:  []string{"dirty workspace"},
+		VersionName: "docker.example.com/test:2.3.7",
+	}
+	require.NoError(t, b.Register(&sous.BuildResult{Products: []*sous.BuildProduct{bp}}))
+
+	inserts := nc.CallsTo("Insert")
+	require.Len(t, inserts, 1)
+	qs := inserts[0].PassedArgs().Get(3).([]sous.Quality)
+	require.Len(t, qs, 2)
+	art := &sous.BuildArtifact{Name: bp.VersionName, Qualities: qs}
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	digest, err := art.VerifySignature(bp.Source, []string{string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))})
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:cabba9e", digest)
+
+	pushes := rc.CallsTo("PushAttachment")
+	require.Len(t, pushes, 1)
+	assert.Equal(t, "docker.example.com/test:sha256-cabba9e.signature", pushes[0].PassedArgs().String(0))
+	assert.Equal(t, sous.SignatureMediaType, pushes[0].PassedArgs().String(1))
+}
+
+// TestBuilderRegister_SignedHarvested checks that a server, which learns of
+// images by harvesting its registry rather than from the Builder, finds their
+// signatures.
+func TestBuilderRegister_SignedHarvested(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	signer, err := sous.NewArtifactSigner(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	host := "docker.example.com"
+	sid := sous.MakeSourceID("github.com/opentable/test", "", "2.3.7+abcd")
+	digest := "sha256:012345678901234567890123456789ab012345678901234567890123456789ab"
+	rc := docker_registry.NewDummyClient()
+	rc.MatchMethod("GetImageMetadata", spies.AnyArgs, docker_registry.Metadata{
+		Registry:      host,
+		Labels:        Labels(sid),
+		Etag:          digest,
+		CanonicalName: "opentable/test@" + digest,
+		AllNames:      []string{"opentable/test@" + digest, "opentable/test:2.3.7"},
+	}, nil)
+	rc.MatchMethod("PushAttachment", spies.AnyArgs, nil)
+	rc.FeedTags([]string{"2.3.7"})
+
+	srcSh, _ := shell.NewTestShell()
+	scratchSh, _ := shell.NewTestShell()
+	b, err := NewBuilder(sous.NewInserterSpy(), host, srcSh, scratchSh)
+	require.NoError(t, err)
+	b.RegistryClient = rc
+	b.Signer = signer
+	bp := &sous.BuildProduct{Source: sid, VersionName: host + "/opentable/test:2.3.7"}
+	require.NoError(t, b.Register(&sous.BuildResult{Products: []*sous.BuildProduct{bp}}))
+
+	pushes := rc.CallsTo("PushAttachment")
+	require.Len(t, pushes, 1)
+	rc.MatchMethod("GetAttachment", spies.AnyArgs, pushes[0].PassedArgs().Get(2), nil)
+
+	nc, err := NewNameCache(host, rc, logging.SilentLogSet(), inMemoryDB("signed_harvest"))
+	require.NoError(t, err)
+	art, err := nc.GetArtifact(sid)
+	require.NoError(t, err)
+	signed, err := art.VerifySignature(sid, []string{string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))})
+	require.NoError(t, err)
+	assert.Equal(t, digest, signed)
+}
+
+func TestBuilderRegister_Provenance(t *testing.T) {
+	srcSh, _ := shell.NewTestShell()
+	scratchSh, _ := shell.NewTestShell()
+	nc := sous.NewInserterSpy()
+	b, err := NewBuilder(nc, "docker.example.com", srcSh, scratchSh)
+	require.NoError(t, err)
+	rc := docker_registry.NewDummyClient()
+	rc.MatchMethod("GetImageMetadata", spies.AnyArgs, docker_registry.Metadata{
+		Registry:      "docker.example.com",
+		CanonicalName: "test@sha256:cabba9e",
+	}, nil)
+	rc.MatchMethod("PushAttachment", spies.AnyArgs, nil)
+	b.RegistryClient = rc
+
+	bp := &sous.BuildProduct{
+		Source:      sous.MakeSourceID("github.com/opentable/test", "", "2.3.7+abcd"),
+		VersionName: "docker.example.com/test:2.3.7",
+		Provenance:  &sous.Provenance{Type: sous.ProvenanceStatementType},
+	}
+	require.NoError(t, b.Register(&sous.BuildResult{Products: []*sous.BuildProduct{bp}}))
+
+	pushes := rc.CallsTo("PushAttachment")
+	require.Len(t, pushes, 1)
+	assert.Equal(t, "docker.example.com/test:sha256-cabba9e.provenance", pushes[0].PassedArgs().String(0))
+	assert.Equal(t, sous.ProvenanceMediaType, pushes[0].PassedArgs().String(1))
+	assert.Contains(t, string(pushes[0].PassedArgs().Get(2).([]byte)), `"sha256":"cabba9e"`)
+	assert.Len(t, nc.CallsTo("Insert"), 1)
+
+	// A registry which cannot store the provenance does not stop the image
+	// being registered.
+	rc = docker_registry.NewDummyClient()
+	rc.MatchMethod("GetImageMetadata", spies.AnyArgs, docker_registry.Metadata{
+		Registry:      "docker.example.com",
+		CanonicalName: "test@sha256:cabba9e",
+	}, nil)
+	rc.MatchMethod("PushAttachment", spies.AnyArgs, fmt.Errorf("unsupported media type"))
+	b.RegistryClient = rc
+	require.NoError(t, b.Register(&sous.BuildResult{Products: []*sous.BuildProduct{bp}}))
+	assert.Len(t, nc.CallsTo("Insert"), 2)
+}
+
+func TestBuilder_Platforms(t *testing.T) {
+	srcSh, srcCtl := shell.NewTestShell()
+	scratchSh, _ := shell.NewTestShell()
+	nc := sous.NewInserterSpy()
+	b, err := NewBuilder(nc, "docker.example.com", srcSh, scratchSh)
+	require.NoError(t, err)
+
+	bp := &sous.BuildProduct{
+		ID:        "amd64-id",
+		Source:    sous.MakeSourceID("github.com/opentable/test", "", "2.3.7+abcd"),
+		Platforms: map[string]string{"linux/arm64": "arm64-id", "linux/amd64": "amd64-id"},
+	}
+	br := &sous.BuildResult{Products: []*sous.BuildProduct{bp}}
+	require.NoError(t, b.ApplyMetadata(br))
+	assert.Len(t, srcCtl.CmdsLike("docker", "build", "--platform", "linux/amd64", "-t", "docker.example.com/test:2.3.7-linux-amd64"), 1)
+	assert.Len(t, srcCtl.CmdsLike("docker", "build", "--platform", "linux/arm64", "-t", "docker.example.com/test:2.3.7-linux-arm64"), 1)
+
+	require.NoError(t, b.Register(br))
+	assert.Len(t, srcCtl.CmdsLike("docker", "push"), 2)
+	assert.Len(t, srcCtl.CmdsLike("docker", "manifest", "create", "--amend", bp.VersionName,
+		"docker.example.com/test:2.3.7-linux-amd64", "docker.example.com/test:2.3.7-linux-arm64"), 1)
+	assert.Len(t, srcCtl.CmdsLike("docker", "manifest", "create", "--amend", bp.RevisionName), 1)
+	assert.Len(t, srcCtl.CmdsLike("docker", "manifest", "push", "--purge"), 2)
+
+	inserts := nc.CallsTo("Insert")
+	require.Len(t, inserts, 1)
+	assert.Equal(t, []sous.Quality{
+		{Name: "linux/amd64", Kind: sous.PlatformQuality},
+		{Name: "linux/arm64", Kind: sous.PlatformQuality},
+	}, inserts[0].PassedArgs().Get(3).([]sous.Quality))
+}
@@ -8,6 +8,14 @@ type Config struct {
 	// DatabaseConnection is the database connection string for local
 	// persistence.
 	DatabaseConnection string `env:"SOUS_DOCKER_DB_CONN"`
+	// Daemonless selects the OCIBuildpack, which builds images with buildah
+	// rather than a Docker daemon.
+	Daemonless bool `env:"SOUS_DOCKER_DAEMONLESS"`
+	// Platforms is a comma-separated list of the platforms (e.g.
+	// "linux/amd64,linux/arm64") which builds produce images for, unless
+	// `sous build -platforms` overrides it. If it is empty, builds produce an
+	// image for the build host's platform.
+	Platforms string `env:"SOUS_DOCKER_PLATFORMS"`
 }
 
 // DefaultConfig builds a default configuration, which can be then overridden by
@@ -77,6 +77,16 @@ func (d *DockerfileBuildpack) Detect(c *sous.BuildContext) (*sous.DetectResult,
 
 // Build implements Buildpack.Build
 func (d *DockerfileBuildpack) Build(c *sous.BuildContext) (*sous.BuildResult, error) {
+	return d.build(c, "")
+}
+
+// BuildPlatform implements PlatformBuildpack.BuildPlatform.
+func (d *DockerfileBuildpack) BuildPlatform(c *sous.BuildContext, platform string) (*sous.BuildResult, error) {
+	return d.build(c, platform)
+}
+
+// build builds for platform, or the build host's platform if it is empty.
+func (d *DockerfileBuildpack) build(c *sous.BuildContext, platform string) (*sous.BuildResult, error) {
 	dr := d.detected
 	start := time.Now()
 	offset := c.Source.OffsetDir
@@ -85,17 +95,10 @@ func (d *DockerfileBuildpack) Build(c *sous.BuildContext) (*sous.BuildResult, er
 	}
 
 	cmd := []interface{}{"build", "--pull"}
-
-	r := dr.Data.(detectData)
-	if r.HasAppVersionArg {
-		v := c.Version().Version
-		v.Meta = ""
-		cmd = append(cmd, "--build-arg", fmt.Sprintf("%s=%s", AppVersionBuildArg, v))
-	}
-	if r.HasAppRevisionArg {
-		cmd = append(cmd, "--build-arg", fmt.Sprintf("%s=%s", AppRevisionBuildArg, c.Version().RevID()))
+	if platform != "" {
+		cmd = append(cmd, "--platform", platform)
 	}
-
+	cmd = append(cmd, buildArgs(c, dr.Data.(detectData))...)
 	cmd = append(cmd, offset)
 
 	output, err := c.Sh.Stdout("docker", cmd...)
@@ -113,3 +116,18 @@ func (d *DockerfileBuildpack) Build(c *sous.BuildContext) (*sous.BuildResult, er
 		Products: []*sous.BuildProduct{{ID: match[1]}},
 	}, nil
 }
+
+// buildArgs returns the --build-arg flags for the build arguments the
+// Dockerfile was detected to accept.
+func buildArgs(c *sous.BuildContext, r detectData) []interface{} {
+	args := []interface{}{}
+	if r.HasAppVersionArg {
+		v := c.Version().Version
+		v.Meta = ""
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", AppVersionBuildArg, v))
+	}
+	if r.HasAppRevisionArg {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", AppRevisionBuildArg, c.Version().RevID()))
+	}
+	return args
+}
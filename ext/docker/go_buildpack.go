@@ -0,0 +1,179 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"text/template"
+	"time"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/logging/messages"
+)
+
+const (
+	// DefaultGoBuildImage is the image Go binaries are compiled in, if
+	// go.mod names no Go version. Otherwise, the golang image for that
+	// version is used.
+	DefaultGoBuildImage = "golang"
+	// DefaultGoRuntimeImage is the image a GoBuildpack adds the compiled
+	// binary to.
+	DefaultGoRuntimeImage = "gcr.io/distroless/static"
+)
+
+// GoBuildpack builds Go modules which have no Dockerfile. It compiles a
+// static binary in a builder container, and copies it into a minimal runtime
+// image.
+type GoBuildpack struct {
+	// BuildImage, if set, is the image the binary is compiled in, overriding
+	// the one chosen from go.mod.
+	BuildImage string
+	// RuntimeImage is the image the binary is added to.
+	RuntimeImage string
+
+	detected *sous.DetectResult
+}
+
+// goDetectData is passed from GoBuildpack.Detect to GoBuildpack.Build.
+type goDetectData struct {
+	// Module is the module path declared in go.mod.
+	Module string
+	// GoVersion is the Go version declared in go.mod, if any.
+	GoVersion string
+	// Main is the path of the main package, relative to the offset.
+	Main string
+}
+
+var (
+	goModulePattern  = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+	goVersionPattern = regexp.MustCompile(`(?m)^go\s+(\d+(?:\.\d+){1,2})\s*$`)
+)
+
+var goDockerfile = template.Must(template.New("Dockerfile").Parse(`FROM {{.BuildImage}} AS build
+WORKDIR /src
+COPY go.mod go.sum* ./
+RUN go mod download
+COPY . .
+RUN CGO_ENABLED=0 go build -trimpath -ldflags="-s -w" -o /out/app {{.Main}}
+
+FROM {{.RuntimeImage}}
+COPY --from=build /out/app /app
+ENV APP_VERSION={{.Version}} APP_REVISION={{.Revision}}
+ENTRYPOINT ["/app"]
+`))
+
+// NewGoBuildpack creates a Go buildpack.
+func NewGoBuildpack() *GoBuildpack {
+	return &GoBuildpack{RuntimeImage: DefaultGoRuntimeImage}
+}
+
+// Detect detects if c has a go.mod, and a main package at its root or at
+// cmd/<name>, where name is the last element of the module path.
+func (b *GoBuildpack) Detect(c *sous.BuildContext) (*sous.DetectResult, error) {
+	modPath := filepath.Join(c.Source.OffsetDir, "go.mod")
+	if !c.Sh.Exists(modPath) {
+		return nil, fmt.Errorf("%s does not exist", modPath)
+	}
+	sh := c.Sh.Clone()
+	sh.LongRunning(false)
+	mod, err := sh.Stdout("cat", modPath)
+	if err != nil {
+		return nil, err
+	}
+	data := goDetectData{}
+	if m := goModulePattern.FindStringSubmatch(mod); m != nil {
+		data.Module = m[1]
+	}
+	if m := goVersionPattern.FindStringSubmatch(mod); m != nil {
+		data.GoVersion = m[1]
+	}
+
+	cmdMain := path.Join("cmd", path.Base(data.Module))
+	switch {
+	default:
+		return nil, fmt.Errorf("no main package in %s: neither main.go nor %s/main.go exists", c.Source.OffsetDir, cmdMain)
+	case c.Sh.Exists(filepath.Join(c.Source.OffsetDir, "main.go")):
+		data.Main = "."
+	case data.Module != "" && c.Sh.Exists(filepath.Join(c.Source.OffsetDir, cmdMain, "main.go")):
+		data.Main = "./" + cmdMain
+	}
+
+	messages.ReportLogFieldsMessage("Detected a Go module", logging.DebugLevel, logging.Log, modPath, data.Module, data.GoVersion, data.Main)
+	result := &sous.DetectResult{Compatible: true, Data: data}
+	b.detected = result
+	return result, nil
+}
+
+// Build implements Buildpack.Build.
+func (b *GoBuildpack) Build(c *sous.BuildContext) (*sous.BuildResult, error) {
+	return b.build(c, "")
+}
+
+// BuildPlatform implements PlatformBuildpack.BuildPlatform.
+func (b *GoBuildpack) BuildPlatform(c *sous.BuildContext, platform string) (*sous.BuildResult, error) {
+	return b.build(c, platform)
+}
+
+func (b *GoBuildpack) build(c *sous.BuildContext, platform string) (*sous.BuildResult, error) {
+	start := time.Now()
+	offset := c.Source.OffsetDir
+	if offset == "" {
+		offset = "."
+	}
+
+	df, err := b.dockerfile(c)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{"build", "--pull"}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	args = append(args, "-f", "-", offset)
+	cmd := c.Sh.Cmd("docker", args...)
+	cmd.SetStdin(df)
+	output, err := cmd.Stdout()
+	if err != nil {
+		return nil, err
+	}
+
+	match := successfulBuildRE.FindStringSubmatch(output)
+	if match == nil {
+		return nil, fmt.Errorf("Couldn't find container id in:\n%s", output)
+	}
+
+	return &sous.BuildResult{
+		Elapsed:  time.Since(start),
+		Products: []*sous.BuildProduct{{ID: match[1]}},
+	}, nil
+}
+
+// dockerfile returns the multi-stage Dockerfile which builds c.
+func (b *GoBuildpack) dockerfile(c *sous.BuildContext) (*bytes.Buffer, error) {
+	data := b.detected.Data.(goDetectData)
+	buildImage := b.BuildImage
+	if buildImage == "" {
+		buildImage = DefaultGoBuildImage
+		if data.GoVersion != "" {
+			buildImage += ":" + data.GoVersion
+		}
+	}
+	v := c.Version().Version
+	v.Meta = ""
+
+	df := &bytes.Buffer{}
+	err := goDockerfile.Execute(df, struct {
+		BuildImage, RuntimeImage, Main, Version, Revision string
+	}{
+		BuildImage:   buildImage,
+		RuntimeImage: b.RuntimeImage,
+		Main:         data.Main,
+		Version:      v.String(),
+		Revision:     c.Version().RevID(),
+	})
+	return df, err
+}
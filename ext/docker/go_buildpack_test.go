@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoBuildpack_Detect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-go-buildpack")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0777))
+		require.NoError(t, ioutil.WriteFile(p, []byte(content), 0666))
+	}
+	sh, err := shell.DefaultInDir(dir)
+	require.NoError(t, err)
+	detect := func() (goDetectData, error) {
+		dr, err := NewGoBuildpack().Detect(&sous.BuildContext{Sh: sh})
+		if err != nil {
+			return goDetectData{}, err
+		}
+		return dr.Data.(goDetectData), nil
+	}
+
+	_, err = detect()
+	assert.Error(t, err, "no go.mod")
+
+	write("go.mod", "module github.com/opentable/widget\n\ngo 1.21\n\nrequire github.com/pkg/errors v0.9.1\n")
+	_, err = detect()
+	assert.Error(t, err, "no main package")
+
+	write("cmd/widget/main.go", "package main")
+	data, err := detect()
+	require.NoError(t, err)
+	assert.Equal(t, goDetectData{Module: "github.com/opentable/widget", GoVersion: "1.21", Main: "./cmd/widget"}, data)
+
+	write("main.go", "package main")
+	data, err = detect()
+	require.NoError(t, err)
+	assert.Equal(t, ".", data.Main)
+}
+
+func TestGoBuildpack_Build(t *testing.T) {
+	sh, ctl := shell.NewTestShell()
+	_, cctl := ctl.CmdFor("docker", "build")
+	cctl.ResultSuccess("Step 1/10 : FROM golang:1.21 AS build\nSuccessfully built cabba9e\n", "")
+
+	bp := NewGoBuildpack()
+	bp.detected = &sous.DetectResult{Compatible: true, Data: goDetectData{Module: "github.com/opentable/widget", GoVersion: "1.21", Main: "./cmd/widget"}}
+	ctx := &sous.BuildContext{
+		Sh: sh,
+		Source: sous.SourceContext{
+			RemoteURL:  "github.com/opentable/widget",
+			NearestTag: sous.Tag{Name: "1.2.3"},
+			Revision:   "abcd",
+		},
+	}
+
+	br, err := bp.BuildPlatform(ctx, "linux/arm64")
+	require.NoError(t, err)
+	require.Len(t, br.Products, 1)
+	assert.Equal(t, "cabba9e", br.Products[0].ID)
+	assert.Len(t, ctl.CmdsLike("docker", "build", "--pull", "--platform", "linux/arm64", "-f", "-", "."), 1)
+
+	stdins := cctl.CallsTo("SetStdin")
+	require.Len(t, stdins, 1)
+	df, err := ioutil.ReadAll(stdins[0].PassedArgs().Get(0).(io.Reader))
+	require.NoError(t, err)
+	assert.Contains(t, string(df), "FROM golang:1.21 AS build\n")
+	assert.Contains(t, string(df), "-o /out/app ./cmd/widget\n")
+	assert.Contains(t, string(df), "FROM "+DefaultGoRuntimeImage+"\n")
+	assert.Contains(t, string(df), "ENV APP_VERSION=1.2.3 APP_REVISION=abcd\n")
+}
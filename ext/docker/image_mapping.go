@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"regexp"
@@ -167,6 +168,41 @@ func (nc *NameCache) GetArtifact(sid sous.SourceID) (*sous.BuildArtifact, error)
 	return NewBuildArtifact(name, qls), nil
 }
 
+// Provenance implements sous.ProvenanceReader. It fetches the provenance
+// attached to the image built from sid from the registry.
+func (nc *NameCache) Provenance(sid sous.SourceID) (*sous.Provenance, error) {
+	name, _, err := nc.getImageName(sid)
+	if _, ok := errors.Cause(err).(NoImageNameFound); ok {
+		return nil, sous.NoProvenanceError{SourceID: sid}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(name, "@") {
+		md, err := nc.RegistryClient.GetImageMetadata(name, "")
+		if err != nil {
+			return nil, err
+		}
+		name = nc.DockerRegistryHost + "/" + md.CanonicalName
+	}
+	an, err := docker_registry.AttachmentName(name, "provenance")
+	if err != nil {
+		return nil, err
+	}
+	content, err := nc.RegistryClient.GetAttachment(an)
+	if err == docker_registry.ErrNoAttachment {
+		return nil, sous.NoProvenanceError{SourceID: sid}
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := &sous.Provenance{}
+	if err := json.Unmarshal(content, p); err != nil {
+		return nil, errors.Wrapf(err, "parsing provenance of %s", name)
+	}
+	return p, nil
+}
+
 func meansBodyUnchanged(err error) bool {
 	_, ok := err.(NotModifiedErr)
 	return ok || err == distribution.ErrManifestNotModified
@@ -220,6 +256,9 @@ func (nc *NameCache) GetSourceID(a *sous.BuildArtifact) (sous.SourceID, error) {
 	}
 
 	qualities := qualitiesFromLabels(md.Labels)
+	for _, p := range md.Platforms {
+		qualities = append(qualities, sous.Quality{Name: p, Kind: sous.PlatformQuality})
+	}
 
 	fullCanon := nc.DockerRegistryHost + "/" + md.CanonicalName
 	mirrored := false
@@ -232,6 +271,8 @@ func (nc *NameCache) GetSourceID(a *sous.BuildArtifact) (sous.SourceID, error) {
 		}
 	}
 
+	qualities = append(qualities, nc.signatureQualities(fullCanon)...)
+
 	messages.ReportLogFieldsMessage("Recording with etag as cannical for", logging.ExtraDebug1Level, nc.Log, fullCanon, md.Etag, newSID)
 	err = nc.dbInsert(newSID, fullCanon, md.Etag, qualities)
 	if err != nil {
@@ -296,6 +337,25 @@ func (nc *NameCache) getImageNameAfterHarvest(sid sous.SourceID) (string, strpai
 	return "", nil, err
 }
 
+// signatureQualities returns the signature attached to the image with the
+// canonical name canon, if any, as a Quality. Failing to read it is only a
+// warning: the image is still usable where signatures are not required.
+func (nc *NameCache) signatureQualities(canon string) []sous.Quality {
+	an, err := docker_registry.AttachmentName(canon, "signature")
+	if err != nil {
+		return nil
+	}
+	content, err := nc.RegistryClient.GetAttachment(an)
+	if err == docker_registry.ErrNoAttachment || (err == nil && len(content) == 0) {
+		return nil
+	}
+	if err != nil {
+		messages.ReportLogFieldsMessage("Could not read image signature", logging.WarningLevel, nc.Log, an, err)
+		return nil
+	}
+	return []sous.Quality{{Name: strings.TrimSpace(string(content)), Kind: sous.SignatureQuality}}
+}
+
 func qualitiesFromLabels(lm map[string]string) []sous.Quality {
 	advs, ok := lm[`com.opentable.sous.advisories`]
 	if !ok {
@@ -16,6 +16,7 @@ import (
 	"github.com/opentable/sous/util/logging"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -474,3 +475,37 @@ func TestUnion(t *testing.T) {
 	assert.Contains(all, "c")
 	assert.Contains(all, "d")
 }
+
+func TestNameCache_Provenance(t *testing.T) {
+	dc := docker_registry.NewDummyClient()
+	nc, err := NewNameCache("docker.repo.io", dc, logging.SilentLogSet(), inMemoryDB("provenance"))
+	require.NoError(t, err)
+
+	sid := sous.MustNewSourceID("https://github.com/opentable/wackadoo", "", "1.2.3")
+	require.NoError(t, nc.Insert(sid, "docker.repo.io/ot/wackadoo@sha256:012345678901234567890123456789ab012345678901234567890123456789ab", "", nil))
+	dc.MatchMethod("GetAttachment", func(args mock.Arguments) bool {
+		return args.String(0) == "docker.repo.io/ot/wackadoo:sha256-012345678901234567890123456789ab012345678901234567890123456789ab.provenance"
+	}, []byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`), nil)
+	dc.MatchMethod("GetAttachment", spies.AnyArgs, nil, docker_registry.ErrNoAttachment)
+
+	p, err := nc.Provenance(sid)
+	require.NoError(t, err)
+	assert.Equal(t, sous.ProvenanceStatementType, p.Type)
+
+	unattested := sous.MustNewSourceID("https://github.com/opentable/wackadoo", "", "1.2.4")
+	require.NoError(t, nc.Insert(unattested, "docker.repo.io/ot/wackadoo@sha256:ba987654321098765432109876543210ba987654321098765432109876543210", "", nil))
+	_, err = nc.Provenance(unattested)
+	assert.Equal(t, sous.NoProvenanceError{SourceID: unattested}, err)
+
+	// Images recorded by tag are looked up by the digest the registry
+	// reports, which it names without the registry host.
+	tagged := sous.MustNewSourceID("https://github.com/opentable/wackadoo", "", "1.2.5")
+	require.NoError(t, nc.Insert(tagged, "docker.repo.io/ot/wackadoo:1.2.5", "", nil))
+	dc.MatchMethod("GetImageMetadata", spies.AnyArgs, docker_registry.Metadata{
+		Registry:      "docker.repo.io",
+		CanonicalName: "ot/wackadoo@sha256:012345678901234567890123456789ab012345678901234567890123456789ab",
+	}, nil)
+	p, err = nc.Provenance(tagged)
+	require.NoError(t, err)
+	assert.Equal(t, sous.ProvenanceStatementType, p.Type)
+}
@@ -0,0 +1,104 @@
+package docker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/opentable/sous/lib"
+)
+
+// ociLayoutPrefix marks a BuildProduct ID as the path of an OCI image layout
+// directory, rather than the ID of an image held by a Docker daemon.
+const ociLayoutPrefix = "oci:"
+
+// OCIBuildpack builds projects using their own Dockerfile, like
+// DockerfileBuildpack, but without a Docker daemon. It drives buildah to
+// build the image and export it as an OCI image layout, which Builder then
+// labels and pushes to the registry itself. Builder removes the layout once
+// it has pushed it.
+type OCIBuildpack struct {
+	DockerfileBuildpack
+}
+
+// NewOCIBuildpack creates an OCI buildpack.
+func NewOCIBuildpack() *OCIBuildpack {
+	return &OCIBuildpack{}
+}
+
+// Detect implements Buildpack.Detect: OCIBuildpack needs a Dockerfile, like
+// DockerfileBuildpack, and buildah.
+func (b *OCIBuildpack) Detect(c *sous.BuildContext) (*sous.DetectResult, error) {
+	dr, err := b.DockerfileBuildpack.Detect(c)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath("buildah"); err != nil {
+		return nil, fmt.Errorf("buildah is needed to build without a Docker daemon: %v", err)
+	}
+	return dr, nil
+}
+
+// Build implements Buildpack.Build.
+func (b *OCIBuildpack) Build(c *sous.BuildContext) (*sous.BuildResult, error) {
+	start := time.Now()
+	offset := c.Source.OffsetDir
+	if offset == "" {
+		offset = "."
+	}
+
+	cmd := []interface{}{"bud", "--pull", "--quiet", "--format", "oci"}
+	cmd = append(cmd, buildArgs(c, b.detected.Data.(detectData))...)
+	cmd = append(cmd, offset)
+
+	output, err := c.Sh.Stdout("buildah", cmd...)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Fields(output)
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("Couldn't find image id in:\n%s", output)
+	}
+	imageID := lines[len(lines)-1]
+
+	dir, err := ioutil.TempDir("", "sous-oci-build")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Sh.Run("buildah", "push", imageID, ociLayoutPrefix+dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return &sous.BuildResult{
+		Elapsed:  time.Since(start),
+		Products: []*sous.BuildProduct{{ID: ociLayoutPrefix + dir}},
+	}, nil
+}
+
+// BuildPlatform implements PlatformBuildpack.BuildPlatform. OCIBuildpack
+// only builds for the build host's platform.
+func (b *OCIBuildpack) BuildPlatform(c *sous.BuildContext, platform string) (*sous.BuildResult, error) {
+	return nil, fmt.Errorf("daemonless builds cannot build for %s", platform)
+}
+
+// OCILayoutDir returns the OCI image layout directory a BuildProduct ID
+// refers to, or false if it refers to an image held by a Docker daemon.
+func OCILayoutDir(id string) (string, bool) {
+	if !strings.HasPrefix(id, ociLayoutPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(id, ociLayoutPrefix), true
+}
+
+// imageLabels returns the labels Builder applies to the image built for bp.
+func imageLabels(bp *sous.BuildProduct) map[string]string {
+	labels := Labels(bp.Source)
+	if len(bp.Advisories) > 0 {
+		labels[DockerAdvisoriesLabel] = strings.Join(bp.Advisories, ",")
+	}
+	return labels
+}
@@ -0,0 +1,156 @@
+package docker
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/distribution/digest"
+	"github.com/nyarly/spies"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/docker_registry"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOCIBuildpack_Build(t *testing.T) {
+	sh, ctl := shell.NewTestShell()
+	_, cctl := ctl.CmdFor("buildah", "bud")
+	cctl.ResultSuccess("cabba9edeadbeef\n", "")
+
+	bp := NewOCIBuildpack()
+	bp.detected = &sous.DetectResult{Compatible: true, Data: detectData{HasAppVersionArg: true}}
+	ctx := &sous.BuildContext{
+		Sh: sh,
+		Source: sous.SourceContext{
+			RemoteURL:  "github.com/opentable/test",
+			NearestTag: sous.Tag{Name: "1.2.3"},
+			Revision:   "abcd",
+		},
+	}
+
+	br, err := bp.Build(ctx)
+	require.NoError(t, err)
+	require.Len(t, br.Products, 1)
+	dir, ok := OCILayoutDir(br.Products[0].ID)
+	require.True(t, ok)
+	defer os.RemoveAll(dir)
+
+	buds := ctl.CmdsLike("buildah", "bud")
+	require.Len(t, buds, 1)
+	assert.Contains(t, buds[0].PassedArgs().Get(1), "APP_VERSION=1.2.3")
+	pushes := ctl.CmdsLike("buildah", "push")
+	require.Len(t, pushes, 1)
+	assert.Equal(t, []interface{}{"push", "cabba9edeadbeef", "oci:" + dir}, pushes[0].PassedArgs().Get(1))
+	assert.Len(t, ctl.CmdsLike("docker"), 0)
+}
+
+func TestOCIBuildpack_Build_pushFails(t *testing.T) {
+	sh, ctl := shell.NewTestShell()
+	_, cctl := ctl.CmdFor("buildah", "bud")
+	cctl.ResultSuccess("cabba9edeadbeef\n", "")
+	_, pctl := ctl.CmdFor("buildah", "push")
+	pctl.ResultFailure("", "no space left on device")
+
+	bp := NewOCIBuildpack()
+	bp.detected = &sous.DetectResult{Compatible: true, Data: detectData{}}
+	_, err := bp.Build(&sous.BuildContext{Sh: sh})
+	require.Error(t, err)
+
+	pushes := ctl.CmdsLike("buildah", "push")
+	require.Len(t, pushes, 1)
+	dir, ok := OCILayoutDir(pushes[0].PassedArgs().Get(1).([]interface{})[2].(string))
+	require.True(t, ok)
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err), "layout left at %s", dir)
+}
+
+func TestOCIBuildpack_Detect_noBuildah(t *testing.T) {
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", "")
+
+	sh, ctl := shell.NewTestShell()
+	ctl.MatchMethod("Exists", spies.AnyArgs, true)
+	_, err := NewOCIBuildpack().Detect(&sous.BuildContext{Sh: sh})
+	assert.Error(t, err)
+
+	_, err = NewDaemonlessStrategySelector(logging.SilentLogSet(), nil).SelectBuildpack(&sous.BuildContext{Sh: sh})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "buildah")
+	}
+}
+
+// writeOCILayout writes an unlabelled single-layer OCI image layout to a new
+// temporary directory.
+func writeOCILayout(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "sous-oci-test")
+	require.NoError(t, err)
+	write := func(mediaType string, v interface{}) docker_registry.Descriptor {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		d := docker_registry.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(b), Size: int64(len(b))}
+		path := filepath.Join(dir, "blobs", "sha256", d.Digest.Hex())
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, b, 0644))
+		return d
+	}
+	config := write(docker_registry.OCIConfigMediaType, map[string]interface{}{"config": map[string]interface{}{}})
+	layer := write("application/vnd.oci.image.layer.v1.tar", "layer")
+	manifest := write(docker_registry.OCIManifestMediaType, map[string]interface{}{
+		"schemaVersion": 2, "config": config, "layers": []docker_registry.Descriptor{layer},
+	})
+	b, err := json.Marshal(map[string]interface{}{"schemaVersion": 2, "manifests": []docker_registry.Descriptor{manifest}})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "index.json"), b, 0644))
+	return dir
+}
+
+func TestBuilder_OCILayout(t *testing.T) {
+	dir := writeOCILayout(t)
+	defer os.RemoveAll(dir)
+
+	srcSh, srcCtl := shell.NewTestShell()
+	scratchSh, _ := shell.NewTestShell()
+	nc := sous.NewInserterSpy()
+	b, err := NewBuilder(nc, "docker.example.com", srcSh, scratchSh)
+	require.NoError(t, err)
+	rc := docker_registry.NewDummyClient()
+	rc.MatchMethod("PushImageLayout", spies.AnyArgs, nil)
+	b.RegistryClient = rc
+
+	br := &sous.BuildResult{
+		Products: []*sous.BuildProduct{{
+			ID:         ociLayoutPrefix + dir,
+			Source:     sous.MakeSourceID("github.com/opentable/test", "sub", "2.3.7+abcd"),
+			Advisories: []string{"dirty workspace", "ephemeral tag"},
+		}},
+	}
+	require.NoError(t, b.ApplyMetadata(br))
+
+	l, err := docker_registry.OpenImageLayout(dir)
+	require.NoError(t, err)
+	labels, err := l.Labels()
+	require.NoError(t, err)
+	sid, err := SourceIDFromLabels(labels)
+	require.NoError(t, err)
+	assert.Equal(t, br.Products[0].Source.Location, sid.Location)
+	assert.Equal(t, "2.3.7", sid.Version.Format("M.m.p"))
+	assert.Equal(t, "abcd", sid.RevID())
+	assert.Equal(t, "dirty workspace,ephemeral tag", labels[DockerAdvisoriesLabel])
+
+	require.NoError(t, b.Register(br))
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err), "layout left at %s", dir)
+
+	pushes := rc.CallsTo("PushImageLayout")
+	require.Len(t, pushes, 1)
+	assert.Equal(t, dir, pushes[0].PassedArgs().String(0))
+	assert.Equal(t, []string{br.Products[0].VersionName, br.Products[0].RevisionName}, pushes[0].PassedArgs().Get(1))
+	assert.Len(t, nc.CallsTo("Insert"), 1)
+	assert.Len(t, srcCtl.CmdsLike("docker"), 0)
+}
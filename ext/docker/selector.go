@@ -11,18 +11,41 @@ import (
 )
 
 type selector struct {
-	regClient docker_registry.Client
-	log       logging.LogSink
+	regClient  docker_registry.Client
+	log        logging.LogSink
+	cache      *sous.BuildCache
+	daemonless bool
 }
 
-// NewBuildStrategySelector constructs a sous.Selector that uses docker build images as its strategies
-func NewBuildStrategySelector(ls logging.LogSink, rc docker_registry.Client) sous.Selector {
-	return &selector{regClient: rc, log: ls}
+// NewBuildStrategySelector constructs a sous.Selector that uses docker build images as its strategies.
+// Split container builds reuse builder images recorded in cache, which may be nil.
+func NewBuildStrategySelector(ls logging.LogSink, rc docker_registry.Client, cache *sous.BuildCache) sous.Selector {
+	return &selector{regClient: rc, log: ls, cache: cache}
+}
+
+// NewDaemonlessStrategySelector constructs a sous.Selector that only selects
+// strategies which need no Docker daemon.
+func NewDaemonlessStrategySelector(ls logging.LogSink, rc docker_registry.Client) sous.Selector {
+	return &selector{regClient: rc, log: ls, daemonless: true}
 }
 
 // SelectBuildpack tries to select a buildpack for this BuildContext.
 func (s *selector) SelectBuildpack(ctx *sous.BuildContext) (sous.Buildpack, error) {
+	if s.daemonless {
+		ocibp := NewOCIBuildpack()
+		dr, err := ocibp.Detect(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("daemonless build: %v", err)
+		}
+		if !dr.Compatible {
+			return nil, errors.New("no Dockerfile present")
+		}
+		reportStrategyChoice("daemonless OCI", s.log)
+		return ocibp, nil
+	}
+
 	sbp := NewSplitBuildpack(s.regClient)
+	sbp.Cache = s.cache
 	dr, err := sbp.Detect(ctx)
 	if err == nil && dr.Compatible {
 		reportStrategyChoice("split container", s.log)
@@ -35,7 +58,14 @@ func (s *selector) SelectBuildpack(ctx *sous.BuildContext) (sous.Buildpack, erro
 		reportStrategyChoice("simple dockerfile", s.log)
 		return dfbp, nil
 	}
-	return nil, errors.New("no Dockerfile present")
+
+	gobp := NewGoBuildpack()
+	dr, err = gobp.Detect(ctx)
+	if err == nil && dr.Compatible {
+		reportStrategyChoice("Go module", s.log)
+		return gobp, nil
+	}
+	return nil, errors.New("no Dockerfile or Go module present")
 }
 
 type strategyChoiceMessage struct {
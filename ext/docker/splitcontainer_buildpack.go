@@ -17,6 +17,10 @@ import (
 type (
 	// A SplitBuildpack implements the pattern of using a build container and producing a separate deploy container
 	SplitBuildpack struct {
+		// Cache, if not nil, records builder images, so that a build whose
+		// context differs from an earlier one only in its runspec reuses the
+		// earlier builder image.
+		Cache    *sous.BuildCache
 		registry docker_registry.Client
 		detected *sous.DetectResult
 	}
@@ -94,7 +98,7 @@ func (sbp *SplitBuildpack) Detect(ctx *sous.BuildContext) (*sous.DetectResult, e
 // Build implements Buildpack on SplitBuildpack
 func (sbp *SplitBuildpack) Build(ctx *sous.BuildContext) (*sous.BuildResult, error) {
 	drez := sbp.detected
-	script := splitBuilder{context: ctx, detected: drez, subBuilders: []*runnableBuilder{}}
+	script := splitBuilder{context: ctx, detected: drez, cache: sbp.Cache, subBuilders: []*runnableBuilder{}}
 
 	/*
 			docker build <args> <offset> #-> Successfully build (image id)
@@ -16,6 +16,9 @@ import (
 type splitBuilder struct {
 	context          *sous.BuildContext
 	detected         *sous.DetectResult
+	cache            *sous.BuildCache
+	reusedBuildImage bool
+	runspecSource    string
 	start            time.Time
 	VersionConfig    string
 	RevisionConfig   string
@@ -68,6 +71,14 @@ func (sb *splitBuilder) buildBuild() error {
 	// XXX I really think this should be "-f", path.Join(offset, "Dockerfile") -jdl
 	cmd = append(cmd, offset)
 
+	key, err := sb.buildImageCacheKey()
+	if err != nil {
+		return err
+	}
+	if sb.reuseBuildImage(key) {
+		return nil
+	}
+
 	output, err := sb.context.Sh.Stdout("docker", cmd...)
 	if err != nil {
 		return err
@@ -79,7 +90,64 @@ func (sb *splitBuilder) buildBuild() error {
 	}
 	sb.buildImageID = match[1]
 
-	return nil
+	return sb.cache.Store(sous.BuildCacheEntry{
+		Key:      key,
+		Source:   sb.context.Version(),
+		Products: []*sous.BuildProduct{{ID: sb.buildImageID, Kind: "builder"}},
+		Time:     time.Now(),
+	})
+}
+
+// contextDir returns the directory docker builds the build image from.
+func (sb *splitBuilder) contextDir() string {
+	return filepath.Join(sb.context.Source.RootDir, sb.context.Source.OffsetDir)
+}
+
+// buildImageCacheKey returns the key of the build image in the cache, which
+// ignores the runspec if it is copied into the build image from the build
+// context: changing only the runspec need not rebuild the build image.
+func (sb *splitBuilder) buildImageCacheKey() (string, error) {
+	if sb.cache == nil {
+		return "", nil
+	}
+	sb.runspecSource = runspecSource(sb.contextDir(), sb.detected.Data.(detectData).RunImageSpecPath)
+	hash, err := sous.HashBuildContext(sb.contextDir(), sb.runspecSource)
+	if err != nil {
+		return "", err
+	}
+	return sous.BuildCacheKey(sb.context.Version(), "builder", hash), nil
+}
+
+// reuseBuildImage uses the build image cached under key, if there is one and
+// docker still has it.
+func (sb *splitBuilder) reuseBuildImage(key string) bool {
+	entry, ok := sb.cache.Lookup(key)
+	if !ok || len(entry.Products) != 1 {
+		return false
+	}
+	id := entry.Products[0].ID
+	if err := sb.context.Sh.Run("docker", "image", "inspect", id); err != nil {
+		return false
+	}
+	sb.buildImageID = id
+	sb.reusedBuildImage = true
+	sb.context.Sh.ConsoleEcho(fmt.Sprintf("[reusing build image %s]", id))
+	return true
+}
+
+// runspecSource returns the path, relative to dir, of the file in dir which
+// is most likely the source of the runspec at runspecPath in the build image:
+// the one whose path is the longest suffix of runspecPath. It returns "" if
+// no file in dir matches.
+func runspecSource(dir, runspecPath string) string {
+	parts := strings.Split(strings.Trim(filepath.ToSlash(runspecPath), "/"), "/")
+	for i := range parts {
+		rel := filepath.Join(parts[i:]...)
+		if info, err := os.Stat(filepath.Join(dir, rel)); err == nil && info.Mode().IsRegular() {
+			return rel
+		}
+	}
+	return ""
 }
 
 func (sb *splitBuilder) setupTempdir() error {
@@ -105,9 +173,21 @@ func (sb *splitBuilder) createBuildContainer() error {
 func (sb *splitBuilder) extractRunSpec() error {
 	runspecPath := sb.detected.Data.(detectData).RunImageSpecPath
 	destPath := filepath.Join(sb.tempDir, "runspec.json")
-	_, err := sb.context.Sh.Stdout("docker", "cp", fmt.Sprintf("%s:%s", sb.buildContainerID, runspecPath), destPath)
-	if err != nil {
-		return err
+	if sb.reusedBuildImage && sb.runspecSource != "" {
+		// The reused build image may hold an older runspec.
+		runspecPath = filepath.Join(sb.contextDir(), sb.runspecSource)
+		spec, err := ioutil.ReadFile(runspecPath)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(destPath, spec, 0644); err != nil {
+			return err
+		}
+	} else {
+		_, err := sb.context.Sh.Stdout("docker", "cp", fmt.Sprintf("%s:%s", sb.buildContainerID, runspecPath), destPath)
+		if err != nil {
+			return err
+		}
 	}
 
 	specF, err := os.Open(destPath)
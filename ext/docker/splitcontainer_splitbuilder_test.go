@@ -1,12 +1,15 @@
 package docker
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/shell"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSplitBuilder_BuildBuild(t *testing.T) {
@@ -30,6 +33,66 @@ func TestSplitBuilder_BuildBuild(t *testing.T) {
 	assert.Equal(t, builder.buildImageID, "cabba9edeadbeef")
 }
 
+func TestSplitBuilder_BuildBuild_ReusesBuildImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-split-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "conf"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, "Dockerfile"), []byte("FROM builder"), 0644))
+	writeRunspec := func(images string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(src, "conf", "runspec.json"),
+			[]byte(`{"images": [`+images+`]}`), 0644))
+	}
+	cache := sous.NewBuildCache(filepath.Join(dir, "cache"))
+	newBuilder := func() (*splitBuilder, *shell.TestShellController) {
+		sh, ctl := shell.NewTestShell()
+		return &splitBuilder{
+			context: &sous.BuildContext{
+				Sh: sh,
+				Source: sous.SourceContext{
+					RootDir:    src,
+					RemoteURL:  "github.com/opentable/example",
+					NearestTag: sous.Tag{Name: "1.2.3"},
+				},
+			},
+			detected: &sous.DetectResult{
+				Data: detectData{RunImageSpecPath: "/srv/app/conf/runspec.json"},
+			},
+			cache:            cache,
+			tempDir:          dir,
+			buildContainerID: "container",
+		}, ctl
+	}
+
+	writeRunspec("{}")
+	first, ctl := newBuilder()
+	_, cctl := ctl.CmdFor("docker", "build")
+	cctl.ResultSuccess("Successfully built cabba9edeadbeef", "")
+	require.NoError(t, first.buildBuild())
+	assert.Equal(t, "conf/runspec.json", first.runspecSource)
+	assert.False(t, first.reusedBuildImage)
+
+	writeRunspec("{}, {}")
+	second, ctl := newBuilder()
+	require.NoError(t, second.buildBuild())
+	assert.Len(t, ctl.CmdsLike("docker", "build"), 0)
+	assert.True(t, second.reusedBuildImage)
+	assert.Equal(t, "cabba9edeadbeef", second.buildImageID)
+
+	require.NoError(t, second.extractRunSpec())
+	assert.Len(t, ctl.CmdsLike("docker", "cp"), 0)
+	assert.Len(t, second.RunSpec.Images, 2, "the runspec should come from the build context")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, "Dockerfile"), []byte("FROM other"), 0644))
+	third, ctl := newBuilder()
+	_, cctl = ctl.CmdFor("docker", "build")
+	cctl.ResultSuccess("Successfully built deadbeefcabba9e", "")
+	require.NoError(t, third.buildBuild())
+	assert.Len(t, ctl.CmdsLike("docker", "build"), 1)
+	assert.Equal(t, "deadbeefcabba9e", third.buildImageID)
+}
+
 func TestSplitBuilder_SetupTempdir(t *testing.T) {
 	builder := splitBuilder{}
 	assert.NoError(t, builder.setupTempdir())
@@ -0,0 +1,137 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/shell"
+	"github.com/pkg/errors"
+)
+
+// FetchSource checks out id from repoURL as CheckoutSource does, into a new
+// temporary directory in workDir, or in the default directory for temporary
+// files if workDir is empty. If c is nil, git in the path is used. It is up to
+// the caller to remove the directory when it is done with the source.
+func FetchSource(c *Client, id sous.SourceID, repoURL, workDir string) (sous.Source, error) {
+	if c == nil {
+		sh, err := shell.Default()
+		if err != nil {
+			return sous.Source{}, err
+		}
+		if c, err = NewClient(sh); err != nil {
+			return sous.Source{}, err
+		}
+	}
+	dir, err := ioutil.TempDir(workDir, "sous-source")
+	if err != nil {
+		return sous.Source{}, err
+	}
+	src, err := c.CheckoutSource(id, repoURL, dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return sous.Source{}, err
+	}
+	return src, nil
+}
+
+// CheckoutSource makes a shallow clone of the repository at repoURL in dir,
+// checked out at the revision of id, and returns the sous.Source for it. The
+// tag for id's version, with or without a leading "v", is fetched if it
+// exists. If id has a revision the tag is not at, that revision is fetched
+// directly, which the remote must allow.
+func (c *Client) CheckoutSource(id sous.SourceID, repoURL, dir string) (sous.Source, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return sous.Source{}, err
+	}
+	sh, ok := c.Sh.Clone().(*shell.Sh)
+	if !ok {
+		return sous.Source{}, errors.Errorf("cannot clone shell %T", c.Sh)
+	}
+	if err := sh.CD(dir); err != nil {
+		return sous.Source{}, err
+	}
+	gc := &Client{Sh: sh, Bin: c.Bin, Version: c.Version}
+
+	if _, err := gc.stdout("init", "-q"); err != nil {
+		return sous.Source{}, err
+	}
+	if _, err := gc.stdout("remote", "add", "origin", repoURL); err != nil {
+		return sous.Source{}, err
+	}
+
+	tag, tagRev, err := gc.fetchShallow(id)
+	if err != nil {
+		return sous.Source{}, errors.Wrapf(err, "fetching %s from %s", id, repoURL)
+	}
+	revision, err := gc.Revision()
+	if err != nil {
+		return sous.Source{}, err
+	}
+
+	offsetDir := filepath.Join(dir, id.Location.Dir)
+	if fi, err := os.Stat(offsetDir); err != nil || !fi.IsDir() {
+		return sous.Source{}, errors.Errorf("%s has no directory %q at revision %s", repoURL, id.Location.Dir, revision)
+	}
+	files, err := gc.ListFiles()
+	if err != nil {
+		return sous.Source{}, err
+	}
+
+	ctx := sous.SourceContext{
+		RootDir:          dir,
+		OffsetDir:        id.Location.Dir,
+		Revision:         revision,
+		Files:            files,
+		PrimaryRemoteURL: id.Location.Repo,
+		RemoteURLs:       []string{id.Location.Repo},
+	}
+	if tag != "" {
+		t := sous.Tag{Name: tag, Revision: tagRev}
+		ctx.Tags = []sous.Tag{t}
+		ctx.NearestTag, ctx.NearestTagName, ctx.NearestTagRevision = t, tag, tagRev
+	}
+	return sous.Source{
+		ID:             id,
+		Context:        ctx,
+		LocalRootDir:   dir,
+		LocalOffsetDir: offsetDir,
+	}, nil
+}
+
+// fetchShallow fetches the commit for id from origin, to a depth of one, and
+// checks it out. It returns the name of the tag for id's version and the
+// revision it is at, if the tag exists.
+func (c *Client) fetchShallow(id sous.SourceID) (tag, tagRev string, err error) {
+	var errs []string
+	for _, t := range []string{id.Tag(), "v" + id.Tag()} {
+		ref := "refs/tags/" + t
+		if _, err := c.stdout("fetch", "-q", "--depth", "1", "origin", ref+":"+ref); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if tagRev, err = c.RevisionAt(ref + "^{commit}"); err != nil {
+			return "", "", err
+		}
+		tag = t
+		break
+	}
+
+	rev := id.RevID()
+	switch {
+	case tag != "" && (rev == "" || strings.HasPrefix(tagRev, rev)):
+		_, err = c.stdout("checkout", "-q", tagRev)
+		return tag, tagRev, err
+	case rev == "":
+		return "", "", errors.New(strings.Join(errs, "; "))
+	}
+	if _, err := c.stdout("fetch", "-q", "--depth", "1", "origin", rev); err != nil {
+		return "", "", errors.Wrapf(err, "fetching revision %s", rev)
+	}
+	if _, err := c.stdout("checkout", "-q", "FETCH_HEAD"); err != nil {
+		return "", "", err
+	}
+	return tag, tagRev, nil
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opentable/sous/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitRepo creates a repository with two commits, the first tagged 1.2.3, and
+// returns its directory and the revisions of the commits.
+func gitRepo(t *testing.T) (dir, tagged, head string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "sous-git-origin")
+	require.NoError(t, err)
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=true",
+			"GIT_AUTHOR_NAME=Sous", "GIT_AUTHOR_EMAIL=sous@opentable.com",
+			"GIT_COMMITTER_NAME=Sous", "GIT_COMMITTER_EMAIL=sous@opentable.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "-q")
+	run("config", "uploadpack.allowReachableSHA1InWant", "true")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\n"), 0644))
+	run("add", ".")
+	run("commit", "-q", "-m", "first")
+	run("tag", "1.2.3")
+	tagged = run("rev-parse", "HEAD")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte("sous\n"), 0644))
+	run("add", ".")
+	run("commit", "-q", "-m", "second")
+	head = run("rev-parse", "HEAD")
+	return dir, tagged, head
+}
+
+func TestFetchSource(t *testing.T) {
+	origin, tagged, head := gitRepo(t)
+	defer os.RemoveAll(origin)
+
+	for name, tc := range map[string]struct {
+		version, revision string
+	}{
+		"tag":      {"1.2.3", ""},
+		"tag_rev":  {"1.2.3+" + tagged, tagged},
+		"revision": {"1.2.3+" + head, head},
+	} {
+		t.Run(name, func(t *testing.T) {
+			id := sous.MakeSourceID("gitlab.example.com/team/project", "", tc.version)
+			src, err := FetchSource(nil, id, "file://"+origin, "")
+			require.NoError(t, err)
+			defer os.RemoveAll(src.Context.RootDir)
+
+			ctx := src.Context
+			assert.Equal(t, "gitlab.example.com/team/project", ctx.PrimaryRemoteURL)
+			assert.Equal(t, []string{"gitlab.example.com/team/project"}, ctx.RemoteURLs)
+			want := tc.revision
+			if want == "" {
+				want = tagged
+			}
+			assert.Equal(t, want, ctx.Revision)
+			assert.Equal(t, "1.2.3", ctx.NearestTagName)
+			assert.Equal(t, tagged, ctx.NearestTagRevision)
+		})
+	}
+}
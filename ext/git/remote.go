@@ -73,3 +73,33 @@ func CanonicalRepoURL(repoURL string) (string, error) {
 	c := path.Join(host, strings.TrimSuffix(dir, ".git"))
 	return c, nil
 }
+
+// HostAndPath splits a repository URL, in any of the forms CanonicalRepoURL
+// accepts or with no scheme at all, into its lower case host name, without
+// credentials or port, and its path, without surrounding slashes, query or
+// ".git" suffix.
+func HostAndPath(repoURL string) (host, repoPath string) {
+	s := repoURL
+	if i := strings.IndexAny(s, "?#"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	hostPart, repoPath := s, ""
+	if i := strings.Index(s, "/"); i >= 0 {
+		hostPart, repoPath = s[:i], s[i+1:]
+	}
+	if i := strings.LastIndex(hostPart, "@"); i >= 0 {
+		hostPart = hostPart[i+1:]
+	}
+	if i := strings.Index(hostPart, ":"); i >= 0 {
+		// Either a port, or the start of the path of an scp-like URL.
+		if port := hostPart[i+1:]; strings.Trim(port, "0123456789") != "" {
+			repoPath = path.Join(port, repoPath)
+		}
+		hostPart = hostPart[:i]
+	}
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+	return strings.ToLower(hostPart), repoPath
+}
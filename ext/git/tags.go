@@ -0,0 +1,72 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/shell"
+	"github.com/pkg/errors"
+)
+
+// A TagFetcher lists the tags of remote repositories. It fetches the tags of
+// each into a bare repository of its own in WorkDir, so that later fetches
+// only transfer new tags.
+type TagFetcher struct {
+	// Git is the client tags are fetched with. If nil, git in the path is
+	// used.
+	Git *Client
+	// WorkDir holds the repositories tags are fetched into. If empty, the
+	// default directory for temporary files is used.
+	WorkDir string
+	// RepoURL returns the URL repo is fetched from. If nil, it is fetched
+	// over HTTPS.
+	RepoURL func(repo string) string
+}
+
+// ListTags implements sous.TagLister on TagFetcher. Tags deleted from the
+// remote are no longer listed.
+func (f *TagFetcher) ListTags(repo string) ([]sous.Tag, error) {
+	workDir := f.WorkDir
+	if workDir == "" {
+		workDir = filepath.Join(os.TempDir(), "sous-tags")
+	}
+	dir := filepath.Join(workDir, filepath.FromSlash(repo))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	gc, err := f.client(dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); os.IsNotExist(err) {
+		if _, err := gc.stdout("init", "-q", "--bare"); err != nil {
+			return nil, err
+		}
+	}
+
+	repoURL := "https://" + repo + ".git"
+	if f.RepoURL != nil {
+		repoURL = f.RepoURL(repo)
+	}
+	if _, err := gc.stdout("fetch", "-q", "--prune", "--force", repoURL, "+refs/tags/*:refs/tags/*"); err != nil {
+		return nil, errors.Wrapf(err, "fetching tags from %s", repoURL)
+	}
+	return gc.ListTags()
+}
+
+// client returns a client for the repository in dir.
+func (f *TagFetcher) client(dir string) (*Client, error) {
+	if f.Git == nil {
+		sh, err := shell.DefaultInDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		return NewClient(sh)
+	}
+	gc := f.Git.CloneClient()
+	if err := gc.Sh.CD(dir); err != nil {
+		return nil, err
+	}
+	return gc, nil
+}
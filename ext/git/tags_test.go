@@ -0,0 +1,60 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagFetcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-tags-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	require.NoError(t, os.MkdirAll(remote, 0755))
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = remote
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+	}
+	commit := func(msg string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(remote, "file"), []byte(msg), 0644))
+		git("add", "file")
+		git("commit", "-q", "-m", msg)
+	}
+	git("init", "-q")
+	commit("first")
+
+	f := &TagFetcher{
+		WorkDir: filepath.Join(dir, "work"),
+		RepoURL: func(repo string) string { return remote },
+	}
+	names := func() []string {
+		tags, err := f.ListTags("example.com/user/repo")
+		require.NoError(t, err)
+		var ns []string
+		for _, tag := range tags {
+			ns = append(ns, tag.Name)
+		}
+		sort.Strings(ns)
+		return ns
+	}
+
+	assert.Empty(t, names())
+
+	git("tag", "v1.0.0")
+	commit("second")
+	git("tag", "-a", "-m", "release", "v1.1.0")
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, names())
+
+	git("tag", "-d", "v1.0.0")
+	assert.Equal(t, []string{"v1.1.0"}, names())
+}
@@ -0,0 +1,30 @@
+package gitlab
+
+import "strings"
+
+// Config lists the GitLab servers whose source locations Sous recognises.
+type Config struct {
+	// Hosts is a comma separated list of the host names of GitLab servers.
+	Hosts string `env:"SOUS_GITLAB_HOSTS"`
+}
+
+// DefaultHosts is the default value of Config.Hosts.
+const DefaultHosts = "gitlab.com"
+
+// DefaultConfig builds a default configuration, which can be then overridden by
+// client code.
+func DefaultConfig() Config {
+	return Config{
+		Hosts: DefaultHosts,
+	}
+}
+
+func (c Config) hosts() []string {
+	hosts := []string{}
+	for _, h := range strings.Split(c.Hosts, ",") {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,111 @@
+package gitlab
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/opentable/sous/ext/git"
+	"github.com/opentable/sous/lib"
+)
+
+// SourceHost is a GitLab source code host.
+// It satisfies sous.SourceHost.
+//
+// Source locations may be given as project URLs, clone URLs, or
+// host/group/project, with an offset directory either after a comma or, in
+// a URL copied from the GitLab UI, after "/-/tree/<ref>/". Since projects
+// may be nested in any number of subgroups, the offset cannot otherwise be
+// told apart from the project path.
+type SourceHost struct {
+	// Hosts are the host names of the GitLab servers this SourceHost handles.
+	Hosts []string
+	// Git is the client source is fetched with. If nil, git in the path is
+	// used.
+	Git *git.Client
+	// RepoURL returns the URL repo is fetched from. If nil, it is fetched
+	// over HTTPS.
+	RepoURL func(repo string) string
+	// WorkDir is where source is fetched to, each in a new temporary
+	// directory. If empty, the default directory for temporary files is used.
+	WorkDir string
+}
+
+// NewSourceHost returns a SourceHost for the servers listed in c.
+func NewSourceHost(c Config) SourceHost {
+	return SourceHost{Hosts: c.hosts()}
+}
+
+func (h SourceHost) handles(host string) bool {
+	for _, hh := range h.Hosts {
+		if host == hh {
+			return true
+		}
+	}
+	return false
+}
+
+// CanParseSourceLocation returns true if s is on one of h's Hosts.
+func (h SourceHost) CanParseSourceLocation(s string) bool {
+	host, _ := git.HostAndPath(strings.SplitN(s, ",", 2)[0])
+	return h.handles(host)
+}
+
+// ParseSourceLocation parses a GitLab source location.
+func (h SourceHost) ParseSourceLocation(s string) (sous.SourceLocation, error) {
+	repodir := strings.SplitN(s, ",", 2)
+	host, project := git.HostAndPath(repodir[0])
+	if !h.handles(host) {
+		return sous.SourceLocation{}, fmt.Errorf("%q is not on a GitLab host (%s)", s, strings.Join(h.Hosts, ", "))
+	}
+
+	var dir string
+	if i := strings.Index(project, "/-/"); i >= 0 {
+		rest := strings.Split(project[i+3:], "/")
+		if len(rest) < 2 || (rest[0] != "tree" && rest[0] != "blob") {
+			return sous.SourceLocation{}, fmt.Errorf("%q does not identify a directory in a GitLab project", s)
+		}
+		project, dir = project[:i], path.Join(rest[2:]...)
+	}
+	if len(repodir) == 2 {
+		if dir != "" {
+			return sous.SourceLocation{}, fmt.Errorf("%q has two offset directories", s)
+		}
+		dir = repodir[1]
+	}
+
+	parts := strings.Split(project, "/")
+	if len(parts) < 2 {
+		return sous.SourceLocation{}, fmt.Errorf("%q does not identify a GitLab project", s)
+	}
+	for _, p := range parts {
+		if p == "" {
+			return sous.SourceLocation{}, fmt.Errorf("%q does not identify a GitLab project", s)
+		}
+	}
+	return sous.SourceLocation{
+		Repo: path.Join(host, project),
+		Dir:  dir,
+	}, nil
+}
+
+// Owns returns true if sl.Repo is on one of h's Hosts.
+func (h SourceHost) Owns(sl sous.SourceLocation) bool {
+	host, _ := git.HostAndPath(sl.Repo)
+	return h.handles(host)
+}
+
+// GetSource returns a sous.Source for the provided id, fetched into a new
+// directory. It returns an error if Owns(id.Location) returns false, or if
+// fetching the source fails.
+func (h SourceHost) GetSource(id sous.SourceID) (sous.Source, error) {
+	if !h.Owns(id.Location) {
+		return sous.Source{}, fmt.Errorf("the gitlab source host cannot get source for %q",
+			id.Location)
+	}
+	repoURL := "https://" + id.Location.Repo + ".git"
+	if h.RepoURL != nil {
+		repoURL = h.RepoURL(id.Location.Repo)
+	}
+	return git.FetchSource(h.Git, id, repoURL, h.WorkDir)
+}
@@ -0,0 +1,103 @@
+package gitlab
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opentable/sous/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceHost_ParseSourceLocation(t *testing.T) {
+	h := NewSourceHost(Config{Hosts: "gitlab.com, git.example.com"})
+
+	good := map[string]sous.SourceLocation{
+		"gitlab.com/group/project":                                {Repo: "gitlab.com/group/project"},
+		"gitlab.com/group/project,svc/api":                        {Repo: "gitlab.com/group/project", Dir: "svc/api"},
+		"https://gitlab.com/group/sub/project.git":                {Repo: "gitlab.com/group/sub/project"},
+		"git@git.example.com:group/project.git":                   {Repo: "git.example.com/group/project"},
+		"https://gitlab.com/group/project/-/tree/master/svc/api":  {Repo: "gitlab.com/group/project", Dir: "svc/api"},
+		"https://gitlab.com/group/project/-/tree/v1.0.0?ref_type": {Repo: "gitlab.com/group/project"},
+	}
+	for in, expected := range good {
+		assert.True(t, h.CanParseSourceLocation(in), in)
+		actual, err := h.ParseSourceLocation(in)
+		if assert.NoError(t, err, in) {
+			assert.Equal(t, expected, actual, in)
+			assert.True(t, h.Owns(actual), in)
+		}
+	}
+
+	bad := []string{
+		"gitlab.com/project",
+		"gitlab.com//project",
+		"gitlab.com/group/project/-/issues/1",
+		"gitlab.com/group/project/-/tree/master/dir,dir",
+	}
+	for _, in := range bad {
+		_, err := h.ParseSourceLocation(in)
+		assert.Error(t, err, in)
+	}
+
+	assert.False(t, h.CanParseSourceLocation("github.com/user/repo"))
+	assert.False(t, h.Owns(sous.SourceLocation{Repo: "github.com/user/repo"}))
+}
+
+// bareRepo makes a bare repository in dir with one commit, tagged 1.2.3,
+// and returns its path and the revision of that commit.
+func bareRepo(t *testing.T, dir string) (string, string) {
+	work := filepath.Join(dir, "work")
+	require.NoError(t, os.MkdirAll(filepath.Join(work, "svc"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(work, "svc", "Dockerfile"), []byte("FROM scratch\n"), 0644))
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	git(work, "init", "-q")
+	git(work, "add", ".")
+	git(work, "commit", "-q", "-m", "initial")
+	git(work, "tag", "1.2.3")
+	rev := git(work, "rev-parse", "HEAD")
+	git(dir, "clone", "-q", "--bare", work, "bare.git")
+	return filepath.Join(dir, "bare.git"), rev
+}
+
+func TestSourceHost_GetSource(t *testing.T) {
+	work, err := ioutil.TempDir("", "gitlab-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(work)
+	bare, rev := bareRepo(t, work)
+
+	h := SourceHost{
+		Hosts:   []string{"gitlab.com"},
+		RepoURL: func(string) string { return "file://" + bare },
+		WorkDir: work,
+	}
+
+	for _, version := range []string{"1.2.3+" + rev, "1.2.3"} {
+		id := sous.MakeSourceID("gitlab.com/group/project", "svc", version)
+		src, err := h.GetSource(id)
+		require.NoError(t, err, version)
+		assert.Equal(t, id, src.ID)
+		assert.Equal(t, rev, src.Context.Revision)
+		assert.Equal(t, filepath.Join(src.LocalRootDir, "svc"), src.LocalOffsetDir)
+		assert.Contains(t, src.Context.Files, "svc/Dockerfile")
+		_, err = os.Stat(filepath.Join(src.LocalOffsetDir, "Dockerfile"))
+		assert.NoError(t, err)
+	}
+
+	_, err = h.GetSource(sous.MakeSourceID("gitlab.com/group/project", "svc", "2.0.0"))
+	assert.Error(t, err)
+	_, err = h.GetSource(sous.MakeSourceID("gitlab.com/group/project", "nope", "1.2.3"))
+	assert.Error(t, err)
+	_, err = h.GetSource(sous.MakeSourceID("github.com/user/repo", "", "1.2.3"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,163 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// kubeClient abstracts the raw interactions with a Kubernetes API server.
+	kubeClient interface {
+		List(r resource, selector string, into interface{}) error
+		Get(r resource, name string, into interface{}) error
+		Create(r resource, obj interface{}) error
+		Update(r resource, name string, obj interface{}) error
+		Delete(r resource, name string) error
+	}
+
+	// resource identifies a kind of Kubernetes object by its API path.
+	resource struct {
+		apiPrefix, plural string
+	}
+
+	// httpClient is a kubeClient which talks to an API server over HTTP.
+	httpClient struct {
+		baseURL, namespace, token string
+		http                      *http.Client
+	}
+
+	// apiError is returned when the API server responds with a non-2xx status.
+	apiError struct {
+		StatusCode int
+		Method     string
+		URL        string
+		Status     apiStatus
+	}
+)
+
+var (
+	deploymentsResource = resource{apiPrefix: "/apis/apps/v1", plural: "deployments"}
+	cronJobsResource    = resource{apiPrefix: "/apis/batch/v1", plural: "cronjobs"}
+	jobsResource        = resource{apiPrefix: "/apis/batch/v1", plural: "jobs"}
+)
+
+func (e *apiError) Error() string {
+	msg := e.Status.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, msg)
+}
+
+// isNotFound reports whether err is a 404 from the API server.
+func isNotFound(err error) bool {
+	ae, is := errors.Cause(err).(*apiError)
+	return is && ae.StatusCode == http.StatusNotFound
+}
+
+func newHTTPClient(baseURL, namespace, token string) *httpClient {
+	return &httpClient{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		namespace: namespace,
+		token:     token,
+		http:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *httpClient) path(r resource, name string) string {
+	p := fmt.Sprintf("%s%s/namespaces/%s/%s", c.baseURL, r.apiPrefix, url.PathEscape(c.namespace), r.plural)
+	if name != "" {
+		p += "/" + url.PathEscape(name)
+	}
+	return p
+}
+
+// List implements kubeClient on httpClient.
+func (c *httpClient) List(r resource, selector string, into interface{}) error {
+	u := c.path(r, "")
+	if selector != "" {
+		u += "?" + url.Values{"labelSelector": {selector}}.Encode()
+	}
+	return c.do("GET", u, nil, into)
+}
+
+// Get implements kubeClient on httpClient.
+func (c *httpClient) Get(r resource, name string, into interface{}) error {
+	return c.do("GET", c.path(r, name), nil, into)
+}
+
+// Create implements kubeClient on httpClient.
+func (c *httpClient) Create(r resource, obj interface{}) error {
+	return c.do("POST", c.path(r, ""), obj, nil)
+}
+
+// Update implements kubeClient on httpClient.
+func (c *httpClient) Update(r resource, name string, obj interface{}) error {
+	return c.do("PUT", c.path(r, name), obj, nil)
+}
+
+// Delete implements kubeClient on httpClient. Dependent objects (e.g. the Pods
+// of a Job) are removed in the background.
+func (c *httpClient) Delete(r resource, name string) error {
+	body := map[string]interface{}{
+		"kind":              "DeleteOptions",
+		"apiVersion":        "v1",
+		"propagationPolicy": "Background",
+	}
+	return c.do("DELETE", c.path(r, name), body, nil)
+}
+
+func (c *httpClient) do(method, u string, body, into interface{}) error {
+	var reqBody *bytes.Buffer
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrapf(err, "encoding %s %s", method, u)
+		}
+		reqBody = bytes.NewBuffer(b)
+	} else {
+		reqBody = &bytes.Buffer{}
+	}
+
+	req, err := http.NewRequest(method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "reading response to %s %s", method, u)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		ae := &apiError{StatusCode: resp.StatusCode, Method: method, URL: u}
+		json.Unmarshal(respBody, &ae.Status) // best effort: the body may not be a Status
+		return ae
+	}
+
+	if into == nil {
+		return nil
+	}
+	return errors.Wrapf(json.Unmarshal(respBody, into), "decoding response to %s %s", method, u)
+}
@@ -0,0 +1,21 @@
+package kubernetes
+
+// Config describes how Sous connects to Kubernetes clusters. The API server
+// URL for each cluster is its Cluster.BaseURL.
+type Config struct {
+	// Namespace is the namespace Sous manages objects in.
+	Namespace string `env:"SOUS_KUBERNETES_NAMESPACE"`
+	// Token is a bearer token presented to the API server.
+	Token string `env:"SOUS_KUBERNETES_TOKEN"`
+}
+
+// DefaultNamespace is the namespace used when Config.Namespace is empty.
+const DefaultNamespace = "default"
+
+// DefaultConfig builds a default configuration, which can be then overridden by
+// client code.
+func DefaultConfig() Config {
+	return Config{
+		Namespace: DefaultNamespace,
+	}
+}
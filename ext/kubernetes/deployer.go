@@ -0,0 +1,264 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/logging/messages"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+type (
+	deployer struct {
+		namespace, token string
+		clientFac        func(baseURL string) kubeClient
+		secrets          sous.SecretResolver
+		log              logging.LogSink
+	}
+
+	// DeployerOption is an option for configuring Kubernetes deployers.
+	DeployerOption func(*deployer)
+)
+
+// NewDeployer creates a new Kubernetes-based sous.Deployer.
+func NewDeployer(ls logging.LogSink, options ...DeployerOption) sous.Deployer {
+	d := &deployer{namespace: DefaultNamespace, log: ls}
+	for _, opt := range options {
+		opt(d)
+	}
+	return d
+}
+
+// OptConfig configures a deployer from a Config.
+func OptConfig(c Config) DeployerOption {
+	return func(d *deployer) {
+		if c.Namespace != "" {
+			d.namespace = c.Namespace
+		}
+		d.token = c.Token
+	}
+}
+
+// OptSecretResolver sets the SecretResolver which resolves secret references
+// in the Env of each deployment.
+func OptSecretResolver(r sous.SecretResolver) DeployerOption {
+	return func(d *deployer) { d.secrets = r }
+}
+
+func (d *deployer) client(baseURL string) kubeClient {
+	if d.clientFac != nil {
+		return d.clientFac(baseURL)
+	}
+	return newHTTPClient(baseURL, d.namespace, d.token)
+}
+
+// RunningDeployments implements sous.Deployer on deployer. It lists every
+// Sous-managed Deployment, CronJob and Job in each cluster.
+func (d *deployer) RunningDeployments(reg sous.Registry, clusters sous.Clusters) (sous.DeployStates, error) {
+	states := sous.NewDeployStates()
+	seen := map[string]struct{}{}
+	for _, name := range clusters.Names() {
+		baseURL := clusters[name].BaseURL
+		if _, ok := seen[baseURL]; ok {
+			continue
+		}
+		seen[baseURL] = struct{}{}
+
+		messages.ReportLogFieldsMessage("Listing Kubernetes deployments", logging.ExtraDebug1Level, d.log, baseURL)
+		if err := d.collect(states, d.client(baseURL), baseURL, clusters); err != nil {
+			return states, errors.Wrapf(err, "listing %s", baseURL)
+		}
+	}
+	return states, nil
+}
+
+func (d *deployer) collect(states sous.DeployStates, cl kubeClient, baseURL string, clusters sous.Clusters) error {
+	selector := ManagedLabel + "=true"
+
+	deps := kubeDeploymentList{}
+	if err := cl.List(deploymentsResource, selector, &deps); err != nil {
+		return err
+	}
+	for _, obj := range deps.Items {
+		ds, err := deploymentState(obj, baseURL, clusters)
+		d.add(states, ds, err)
+	}
+
+	crons := kubeCronJobList{}
+	if err := cl.List(cronJobsResource, selector, &crons); err != nil {
+		return err
+	}
+	for _, obj := range crons.Items {
+		ds, err := cronJobState(obj, baseURL, clusters)
+		d.add(states, ds, err)
+	}
+
+	jobs := kubeJobList{}
+	if err := cl.List(jobsResource, selector, &jobs); err != nil {
+		return err
+	}
+	for _, obj := range jobs.Items {
+		ds, err := jobState(obj, baseURL, clusters)
+		d.add(states, ds, err)
+	}
+	return nil
+}
+
+func (d *deployer) add(states sous.DeployStates, ds *sous.DeployState, err error) {
+	if err != nil {
+		if _, ignorable := errors.Cause(err).(notThisClusterError); !ignorable {
+			logging.ReportError(d.log, errors.Wrapf(err, "malformed Kubernetes object"))
+		}
+		return
+	}
+	messages.ReportLogFieldsMessage("Adding deployment", logging.DebugLevel, d.log, ds)
+	states.Add(ds)
+}
+
+// Status implements sous.Deployer on deployer.
+func (d *deployer) Status(reg sous.Registry, clusters sous.Clusters, pair *sous.DeployablePair) (*sous.DeployState, error) {
+	clusterName := pair.Post.Deployment.ClusterName
+	cluster, has := clusters[clusterName]
+	if !has {
+		return nil, errors.Errorf("No cluster found for %q. Known are: %q.", clusterName, clusters.Names())
+	}
+	if pair.UUID == uuid.Nil {
+		pair.UUID = uuid.NewV4()
+	}
+
+	name, err := MakeObjectName(pair.Post.ID())
+	if err != nil {
+		return nil, err
+	}
+	r, err := resourceForKind(pair.Post.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	ds, err := d.get(d.client(cluster.BaseURL), r, name, cluster.BaseURL, clusters)
+	return ds, errors.Wrapf(err, "getting %s %s", r.plural, name)
+}
+
+func (d *deployer) get(cl kubeClient, r resource, name, baseURL string, clusters sous.Clusters) (*sous.DeployState, error) {
+	switch r {
+	default:
+		return nil, errors.Errorf("unknown resource %v", r)
+	case deploymentsResource:
+		obj := kubeDeployment{}
+		if err := cl.Get(r, name, &obj); err != nil {
+			return nil, err
+		}
+		return deploymentState(obj, baseURL, clusters)
+	case cronJobsResource:
+		obj := kubeCronJob{}
+		if err := cl.Get(r, name, &obj); err != nil {
+			return nil, err
+		}
+		return cronJobState(obj, baseURL, clusters)
+	case jobsResource:
+		obj := kubeJob{}
+		if err := cl.Get(r, name, &obj); err != nil {
+			return nil, err
+		}
+		return jobState(obj, baseURL, clusters)
+	}
+}
+
+// Rectify invokes actions to ensure that the real world matches pair.Post,
+// given that it currently matches pair.Prior.
+func (d *deployer) Rectify(pair *sous.DeployablePair) sous.DiffResolution {
+	if pair.UUID == uuid.Nil {
+		pair.UUID = uuid.NewV4()
+	}
+	result := sous.DiffResolution{DeploymentID: pair.ID()}
+
+	switch k := pair.Kind(); k {
+	default:
+		panic(fmt.Sprintf("unrecognised kind %q", k))
+	case sous.SameKind:
+		result = pair.SameResolution()
+		if pair.Post.Status == sous.DeployStatusFailed {
+			result.Error = sous.WrapResolveError(&sous.FailedStatusError{})
+		}
+	case sous.AddedKind:
+		if err := d.create(pair); err != nil {
+			result.Desc = "not created"
+			result.Error = sous.WrapResolveError(&sous.CreateError{Deployment: pair.Post.Deployment.Clone(), Err: err})
+		} else {
+			result.Desc = sous.CreateDiff
+		}
+	case sous.RemovedKind:
+		// As with Singularity, Sous does not delete deployments whose manifests
+		// have been removed; the owners should either remove them by hand or
+		// restore the manifest.
+		messages.ReportLogFieldsMessage("Rectify not deleting Kubernetes object", logging.WarningLevel, d.log, pair.ID())
+		result.Desc = sous.DeleteDiff
+	case sous.ModifiedKind:
+		if err := d.modify(pair); err != nil {
+			result.Desc = "not updated"
+			result.Error = sous.WrapResolveError(&sous.ChangeError{
+				Deployments: &sous.DeploymentPair{Prior: pair.Prior.Deployment.Clone(), Post: pair.Post.Deployment.Clone()},
+				Err:         err,
+			})
+		} else if pair.Prior.Status == sous.DeployStatusFailed || pair.Post.Status == sous.DeployStatusFailed {
+			result.Desc = sous.ModifyDiff
+			result.Error = sous.WrapResolveError(&sous.FailedStatusError{})
+		} else {
+			result.Desc = sous.ModifyDiff
+		}
+	}
+	messages.ReportLogFieldsMessage("Result of Kubernetes rectification", logging.InformationLevel, d.log, pair.ID(), result)
+	return result
+}
+
+func (d *deployer) create(pair *sous.DeployablePair) error {
+	r, obj, err := buildObject(*pair.Post, d.namespace, d.secrets)
+	if err != nil {
+		return err
+	}
+	return d.client(pair.Post.Deployment.Cluster.BaseURL).Create(r, obj)
+}
+
+func (d *deployer) modify(pair *sous.DeployablePair) error {
+	r, obj, err := buildObject(*pair.Post, d.namespace, d.secrets)
+	if err != nil {
+		return err
+	}
+	cl := d.client(pair.Post.Deployment.Cluster.BaseURL)
+
+	data, ok := pair.ExecutorData.(*kubeTaskData)
+	if !ok {
+		return errors.Errorf("Modification record %#v doesn't contain Kubernetes compatible data: was %T", pair.ID(), pair.ExecutorData)
+	}
+
+	// Changing between kinds of object, and changing a Job (whose pod
+	// template is immutable), both require replacing the running object.
+	if data.resource != r || r == jobsResource {
+		if err := cl.Delete(data.resource, data.name); err != nil && !isNotFound(err) {
+			return err
+		}
+		return cl.Create(r, obj)
+	}
+
+	current := struct {
+		Metadata objectMeta `json:"metadata"`
+	}{}
+	if err := cl.Get(r, data.name, &current); err != nil {
+		return err
+	}
+	setResourceVersion(obj, current.Metadata.ResourceVersion)
+	return cl.Update(r, data.name, obj)
+}
+
+func setResourceVersion(obj interface{}, rv string) {
+	switch o := obj.(type) {
+	case *kubeDeployment:
+		o.Metadata.ResourceVersion = rv
+	case *kubeCronJob:
+		o.Metadata.ResourceVersion = rv
+	case *kubeJob:
+		o.Metadata.ResourceVersion = rv
+	}
+}
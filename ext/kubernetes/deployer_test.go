@@ -0,0 +1,328 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPIServer is a minimal in-memory stand-in for the Kubernetes API. It
+// stores objects by path and supports exactly the operations httpClient uses.
+type fakeAPIServer struct {
+	sync.Mutex
+	objects map[string]map[string]json.RawMessage // collection path -> name -> object
+	server  *httptest.Server
+}
+
+func newFakeAPIServer() *fakeAPIServer {
+	f := &fakeAPIServer{objects: map[string]map[string]json.RawMessage{}}
+	f.server = httptest.NewServer(f)
+	return f
+}
+
+func (f *fakeAPIServer) Close() { f.server.Close() }
+
+func (f *fakeAPIServer) URL() string { return f.server.URL }
+
+func (f *fakeAPIServer) split(p string) (collection, name string) {
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	// apis/<group>/<version>/namespaces/<ns>/<plural>[/<name>]
+	if len(parts) == 7 {
+		return "/" + strings.Join(parts[:6], "/"), parts[6]
+	}
+	return "/" + strings.Join(parts, "/"), ""
+}
+
+func (f *fakeAPIServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	collection, name := f.split(req.URL.Path)
+	objs := f.objects[collection]
+	if objs == nil {
+		objs = map[string]json.RawMessage{}
+		f.objects[collection] = objs
+	}
+	body, _ := ioutil.ReadAll(req.Body)
+
+	switch {
+	default:
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+	case req.Method == "GET" && name == "":
+		items := []json.RawMessage{}
+		for _, o := range objs {
+			items = append(items, o)
+		}
+		json.NewEncoder(rw).Encode(map[string]interface{}{"items": items})
+	case req.Method == "GET":
+		o, ok := objs[name]
+		if !ok {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		rw.Write(o)
+	case req.Method == "POST":
+		meta := struct {
+			Metadata objectMeta `json:"metadata"`
+		}{}
+		json.Unmarshal(body, &meta)
+		if _, exists := objs[meta.Metadata.Name]; exists {
+			rw.WriteHeader(http.StatusConflict)
+			return
+		}
+		objs[meta.Metadata.Name] = body
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write(body)
+	case req.Method == "PUT":
+		if _, ok := objs[name]; !ok {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		objs[name] = body
+		rw.Write(body)
+	case req.Method == "DELETE":
+		if _, ok := objs[name]; !ok {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(objs, name)
+		rw.Write([]byte(`{}`))
+	}
+}
+
+// count returns the number of stored objects of resource r.
+func (f *fakeAPIServer) count(r resource) int {
+	f.Lock()
+	defer f.Unlock()
+	return len(f.objects[r.apiPrefix+"/namespaces/"+DefaultNamespace+"/"+r.plural])
+}
+
+func testDeployable(baseURL string) *sous.Deployable {
+	cluster := &sous.Cluster{Name: "kube-cluster", Kind: "kubernetes", BaseURL: baseURL}
+	sid := sous.MustNewSourceID("github.com/opentable/example", "api", "1.2.3")
+	return &sous.Deployable{
+		Status: sous.DeployStatusActive,
+		Deployment: &sous.Deployment{
+			ClusterName: "kube-cluster",
+			Cluster:     cluster,
+			SourceID:    sid,
+			Flavor:      "vanilla",
+			Kind:        sous.ManifestKindService,
+			Owners:      sous.OwnerSet{"someone@example.com": struct{}{}},
+			DeployConfig: sous.DeployConfig{
+				NumInstances: 3,
+				Resources:    sous.Resources{"cpus": "0.5", "memory": "256", "ports": "2"},
+				Env:          sous.Env{"GREETING": "hello"},
+				Metadata:     sous.Metadata{"team": "example"},
+				Volumes:      sous.Volumes{{Host: "/srv/data", Container: "/data", Mode: sous.ReadOnly}},
+				Startup: sous.Startup{
+					ConnectDelay:         5,
+					Timeout:              120,
+					CheckReadyURIPath:    "/health",
+					CheckReadyInterval:   10,
+					CheckReadyRetries:    3,
+					CheckReadyURITimeout: 2,
+				},
+			},
+		},
+		BuildArtifact: &sous.BuildArtifact{Name: "docker.example.com/example/api:1.2.3", Type: "docker"},
+	}
+}
+
+func testClusters(d *sous.Deployable) sous.Clusters {
+	return sous.Clusters{d.ClusterName: d.Cluster}
+}
+
+func TestMakeObjectName(t *testing.T) {
+	did := testDeployable("http://kube").ID()
+	name, err := MakeObjectName(did)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(name, "example-api-vanilla-kube-cluster-"), name)
+	assert.True(t, len(name) <= maxNameLen+9, name)
+
+	did.ManifestID.Source.Dir = strings.Repeat("very/deep/", 20)
+	long, err := MakeObjectName(did)
+	require.NoError(t, err)
+	assert.True(t, len(long) <= maxNameLen+9, long)
+	assert.NotEqual(t, name, long)
+}
+
+func TestBuildObject_RoundTrip(t *testing.T) {
+	for _, kind := range []sous.ManifestKind{sous.ManifestKindService, sous.ManifestKindScheduled, sous.ManifestKindOnce} {
+		d := testDeployable("http://kube")
+		d.Kind = kind
+		if kind == sous.ManifestKindScheduled {
+			d.Schedule = "*/5 * * * *"
+		}
+
+		r, obj, err := buildObject(*d, DefaultNamespace, nil)
+		require.NoError(t, err, "%s", kind)
+
+		// Go through JSON, as the objects would when stored by the API server.
+		b, err := json.Marshal(obj)
+		require.NoError(t, err)
+
+		var ds *sous.DeployState
+		switch r {
+		case deploymentsResource:
+			o := kubeDeployment{}
+			require.NoError(t, json.Unmarshal(b, &o))
+			ds, err = deploymentState(o, "http://kube", testClusters(d))
+		case cronJobsResource:
+			o := kubeCronJob{}
+			require.NoError(t, json.Unmarshal(b, &o))
+			ds, err = cronJobState(o, "http://kube", testClusters(d))
+		case jobsResource:
+			o := kubeJob{}
+			require.NoError(t, json.Unmarshal(b, &o))
+			ds, err = jobState(o, "http://kube", testClusters(d))
+		}
+		require.NoError(t, err, "%s", kind)
+
+		different, diffs := d.Deployment.Diff(&ds.Deployment)
+		assert.False(t, different, "%s: %v", kind, diffs)
+	}
+}
+
+type testSecretResolver map[string]string
+
+func (r testSecretResolver) ResolveSecret(ref sous.SecretRef) (string, error) {
+	v, ok := r[ref.String()]
+	if !ok {
+		return "", errors.Errorf("no secret %s", ref)
+	}
+	return v, nil
+}
+
+func TestBuildObject_Secrets(t *testing.T) {
+	d := testDeployable("http://kube")
+	d.Env = sous.Env{"DB_PASSWORD": "secret://db/app#password", "PLAIN": "value"}
+
+	_, _, err := buildObject(*d, DefaultNamespace, nil)
+	assert.Error(t, err, "a reference with no resolver")
+
+	_, obj, err := buildObject(*d, DefaultNamespace, testSecretResolver{"secret://db/app#password": "hunter2"})
+	require.NoError(t, err)
+	dep := obj.(*kubeDeployment)
+	env := map[string]string{}
+	for _, e := range dep.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	assert.Equal(t, "hunter2", env["DB_PASSWORD"])
+	assert.Equal(t, "value", env["PLAIN"])
+	assert.Equal(t, "secret://db/app#password", d.Env["DB_PASSWORD"], "the deployment keeps the reference")
+
+	ds, err := deploymentState(*dep, "http://kube", testClusters(d))
+	require.NoError(t, err)
+	assert.Equal(t, d.Env, ds.Env)
+}
+
+func TestBuildObject_OnDemandUnsupported(t *testing.T) {
+	d := testDeployable("http://kube")
+	d.Kind = sous.ManifestKindOnDemand
+	_, _, err := buildObject(*d, DefaultNamespace, nil)
+	assert.Error(t, err)
+}
+
+func TestDeploymentStatus(t *testing.T) {
+	obj := kubeDeployment{Metadata: objectMeta{Generation: 2}}
+	obj.Status.ObservedGeneration = 1
+	st, _ := deploymentStatus(obj, 2)
+	assert.Equal(t, sous.DeployStatusPending, st)
+
+	obj.Status = kubeDeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+	st, _ = deploymentStatus(obj, 2)
+	assert.Equal(t, sous.DeployStatusActive, st)
+
+	obj.Status.Conditions = []condition{{Type: "Progressing", Status: "False", Reason: "ProgressDeadlineExceeded", Message: "too slow"}}
+	st, msg := deploymentStatus(obj, 2)
+	assert.Equal(t, sous.DeployStatusFailed, st)
+	assert.Contains(t, msg, "too slow")
+}
+
+func TestDeployer_Lifecycle(t *testing.T) {
+	api := newFakeAPIServer()
+	defer api.Close()
+
+	dep := NewDeployer(logging.SilentLogSet())
+	post := testDeployable(api.URL())
+	clusters := testClusters(post)
+
+	pair := &sous.DeployablePair{Post: post}
+	pair.SetID(post.ID())
+	res := dep.Rectify(pair)
+	require.Nil(t, res.Error)
+	assert.Equal(t, sous.CreateDiff, res.Desc)
+	assert.Equal(t, 1, api.count(deploymentsResource))
+
+	states, err := dep.RunningDeployments(nil, clusters)
+	require.NoError(t, err)
+	require.Len(t, states.Snapshot(), 1)
+	prior, has := states.Get(post.ID())
+	require.True(t, has)
+	assert.Equal(t, 3, prior.NumInstances)
+
+	ds, err := dep.Status(nil, clusters, pair)
+	require.NoError(t, err)
+	assert.Equal(t, post.SourceID, ds.SourceID)
+
+	// Scale up in place.
+	updated := testDeployable(api.URL())
+	updated.NumInstances = 5
+	pair = &sous.DeployablePair{
+		Prior:        &sous.Deployable{Status: sous.DeployStatusActive, Deployment: &prior.Deployment},
+		Post:         updated,
+		ExecutorData: prior.ExecutorData,
+	}
+	pair.SetID(post.ID())
+	res = dep.Rectify(pair)
+	require.Nil(t, res.Error)
+	assert.Equal(t, sous.ModifyDiff, res.Desc)
+
+	states, err = dep.RunningDeployments(nil, clusters)
+	require.NoError(t, err)
+	now, _ := states.Get(post.ID())
+	assert.Equal(t, 5, now.NumInstances)
+
+	// Changing kind replaces the object.
+	scheduled := testDeployable(api.URL())
+	scheduled.Kind = sous.ManifestKindScheduled
+	scheduled.Schedule = "0 * * * *"
+	pair = &sous.DeployablePair{
+		Prior:        &sous.Deployable{Status: sous.DeployStatusActive, Deployment: &now.Deployment},
+		Post:         scheduled,
+		ExecutorData: now.ExecutorData,
+	}
+	pair.SetID(post.ID())
+	res = dep.Rectify(pair)
+	require.Nil(t, res.Error)
+	assert.Equal(t, 0, api.count(deploymentsResource))
+	assert.Equal(t, 1, api.count(cronJobsResource))
+}
+
+func TestDeployer_RunningDeployments_OtherClusters(t *testing.T) {
+	api := newFakeAPIServer()
+	defer api.Close()
+
+	dep := NewDeployer(logging.SilentLogSet())
+	post := testDeployable(api.URL())
+	pair := &sous.DeployablePair{Post: post}
+	pair.SetID(post.ID())
+	require.Nil(t, dep.Rectify(pair).Error)
+
+	// A second Sous cluster sharing the same API server only sees its own.
+	other := sous.Clusters{"other": &sous.Cluster{Name: "other", Kind: "kubernetes", BaseURL: api.URL()}}
+	states, err := dep.RunningDeployments(nil, other)
+	require.NoError(t, err)
+	assert.Len(t, states.Snapshot(), 0)
+}
@@ -0,0 +1,208 @@
+package kubernetes
+
+// The types in this file are the subset of the Kubernetes API objects that
+// Sous reads and writes. Fields that Sous does not manage are omitted; since
+// updates are made by replacing whole objects, anything set out-of-band on
+// those fields will be overwritten on the next rectification.
+
+type (
+	objectMeta struct {
+		Name              string            `json:"name"`
+		Namespace         string            `json:"namespace,omitempty"`
+		Labels            map[string]string `json:"labels,omitempty"`
+		Annotations       map[string]string `json:"annotations,omitempty"`
+		ResourceVersion   string            `json:"resourceVersion,omitempty"`
+		Generation        int64             `json:"generation,omitempty"`
+		CreationTimestamp string            `json:"creationTimestamp,omitempty"`
+	}
+
+	listMeta struct {
+		ResourceVersion string `json:"resourceVersion,omitempty"`
+	}
+
+	labelSelector struct {
+		MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	}
+
+	condition struct {
+		Type    string `json:"type"`
+		Status  string `json:"status"`
+		Reason  string `json:"reason,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+
+	// kubeDeployment is an apps/v1 Deployment.
+	kubeDeployment struct {
+		APIVersion string               `json:"apiVersion"`
+		Kind       string               `json:"kind"`
+		Metadata   objectMeta           `json:"metadata"`
+		Spec       deploymentSpec       `json:"spec"`
+		Status     kubeDeploymentStatus `json:"status,omitempty"`
+	}
+
+	kubeDeploymentList struct {
+		Metadata listMeta         `json:"metadata"`
+		Items    []kubeDeployment `json:"items"`
+	}
+
+	deploymentSpec struct {
+		Replicas                *int32          `json:"replicas,omitempty"`
+		Selector                labelSelector   `json:"selector"`
+		Template                podTemplateSpec `json:"template"`
+		ProgressDeadlineSeconds *int32          `json:"progressDeadlineSeconds,omitempty"`
+	}
+
+	kubeDeploymentStatus struct {
+		ObservedGeneration  int64       `json:"observedGeneration,omitempty"`
+		Replicas            int32       `json:"replicas,omitempty"`
+		UpdatedReplicas     int32       `json:"updatedReplicas,omitempty"`
+		ReadyReplicas       int32       `json:"readyReplicas,omitempty"`
+		AvailableReplicas   int32       `json:"availableReplicas,omitempty"`
+		UnavailableReplicas int32       `json:"unavailableReplicas,omitempty"`
+		Conditions          []condition `json:"conditions,omitempty"`
+	}
+
+	// kubeCronJob is a batch/v1 CronJob.
+	kubeCronJob struct {
+		APIVersion string            `json:"apiVersion"`
+		Kind       string            `json:"kind"`
+		Metadata   objectMeta        `json:"metadata"`
+		Spec       cronJobSpec       `json:"spec"`
+		Status     kubeCronJobStatus `json:"status,omitempty"`
+	}
+
+	kubeCronJobList struct {
+		Metadata listMeta      `json:"metadata"`
+		Items    []kubeCronJob `json:"items"`
+	}
+
+	cronJobSpec struct {
+		Schedule          string          `json:"schedule"`
+		ConcurrencyPolicy string          `json:"concurrencyPolicy,omitempty"`
+		JobTemplate       jobTemplateSpec `json:"jobTemplate"`
+	}
+
+	jobTemplateSpec struct {
+		Metadata objectMeta `json:"metadata,omitempty"`
+		Spec     jobSpec    `json:"spec"`
+	}
+
+	kubeCronJobStatus struct {
+		LastScheduleTime string `json:"lastScheduleTime,omitempty"`
+	}
+
+	// kubeJob is a batch/v1 Job.
+	kubeJob struct {
+		APIVersion string        `json:"apiVersion"`
+		Kind       string        `json:"kind"`
+		Metadata   objectMeta    `json:"metadata"`
+		Spec       jobSpec       `json:"spec"`
+		Status     kubeJobStatus `json:"status,omitempty"`
+	}
+
+	kubeJobList struct {
+		Metadata listMeta  `json:"metadata"`
+		Items    []kubeJob `json:"items"`
+	}
+
+	jobSpec struct {
+		Parallelism  *int32          `json:"parallelism,omitempty"`
+		Completions  *int32          `json:"completions,omitempty"`
+		BackoffLimit *int32          `json:"backoffLimit,omitempty"`
+		Template     podTemplateSpec `json:"template"`
+	}
+
+	kubeJobStatus struct {
+		Active     int32       `json:"active,omitempty"`
+		Succeeded  int32       `json:"succeeded,omitempty"`
+		Failed     int32       `json:"failed,omitempty"`
+		Conditions []condition `json:"conditions,omitempty"`
+	}
+
+	podTemplateSpec struct {
+		Metadata objectMeta `json:"metadata,omitempty"`
+		Spec     podSpec    `json:"spec"`
+	}
+
+	podSpec struct {
+		Containers    []container `json:"containers"`
+		Volumes       []volume    `json:"volumes,omitempty"`
+		RestartPolicy string      `json:"restartPolicy,omitempty"`
+	}
+
+	container struct {
+		Name           string               `json:"name"`
+		Image          string               `json:"image"`
+		Env            []envVar             `json:"env,omitempty"`
+		Ports          []containerPort      `json:"ports,omitempty"`
+		Resources      resourceRequirements `json:"resources,omitempty"`
+		ReadinessProbe *probe               `json:"readinessProbe,omitempty"`
+		VolumeMounts   []volumeMount        `json:"volumeMounts,omitempty"`
+	}
+
+	envVar struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	containerPort struct {
+		Name          string `json:"name,omitempty"`
+		ContainerPort int32  `json:"containerPort"`
+		Protocol      string `json:"protocol,omitempty"`
+	}
+
+	resourceRequirements struct {
+		Limits   map[string]string `json:"limits,omitempty"`
+		Requests map[string]string `json:"requests,omitempty"`
+	}
+
+	probe struct {
+		HTTPGet             *httpGetAction `json:"httpGet,omitempty"`
+		InitialDelaySeconds int32          `json:"initialDelaySeconds,omitempty"`
+		TimeoutSeconds      int32          `json:"timeoutSeconds,omitempty"`
+		PeriodSeconds       int32          `json:"periodSeconds,omitempty"`
+		FailureThreshold    int32          `json:"failureThreshold,omitempty"`
+	}
+
+	httpGetAction struct {
+		Path   string `json:"path,omitempty"`
+		Port   string `json:"port"`
+		Scheme string `json:"scheme,omitempty"`
+	}
+
+	volume struct {
+		Name     string                `json:"name"`
+		HostPath *hostPathVolumeSource `json:"hostPath,omitempty"`
+	}
+
+	hostPathVolumeSource struct {
+		Path string `json:"path"`
+	}
+
+	volumeMount struct {
+		Name      string `json:"name"`
+		MountPath string `json:"mountPath"`
+		ReadOnly  bool   `json:"readOnly,omitempty"`
+	}
+
+	// apiStatus is the body of an error response from the Kubernetes API.
+	apiStatus struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Reason  string `json:"reason"`
+		Code    int    `json:"code"`
+	}
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func findCondition(cs []condition, kind string) (condition, bool) {
+	for _, c := range cs {
+		if c.Type == kind {
+			return c, true
+		}
+	}
+	return condition{}, false
+}
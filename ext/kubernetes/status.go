@@ -0,0 +1,80 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"github.com/opentable/sous/lib"
+)
+
+// deploymentState reconstructs a DeployState from a Kubernetes Deployment.
+func deploymentState(obj kubeDeployment, baseURL string, clusters sous.Clusters) (*sous.DeployState, error) {
+	var replicas int32
+	if obj.Spec.Replicas != nil {
+		replicas = *obj.Spec.Replicas
+	}
+	ds, err := deployStateFromObject(obj.Metadata, obj.Spec.Template, replicas, deploymentsResource, baseURL, clusters)
+	if err != nil {
+		return nil, err
+	}
+	ds.Status, ds.ExecutorMessage = deploymentStatus(obj, replicas)
+	return ds, nil
+}
+
+// deploymentStatus determines the sous.DeployStatus of a rollout: Active
+// once every replica has been updated and is available, Failed if the
+// rollout exceeded its progress deadline, and Pending otherwise.
+func deploymentStatus(obj kubeDeployment, replicas int32) (sous.DeployStatus, string) {
+	st := obj.Status
+	if c, ok := findCondition(st.Conditions, "Progressing"); ok &&
+		c.Status == "False" && c.Reason == "ProgressDeadlineExceeded" {
+		return sous.DeployStatusFailed, fmt.Sprintf("Deploy failure: %q", c.Message)
+	}
+	if st.ObservedGeneration < obj.Metadata.Generation {
+		return sous.DeployStatusPending, ""
+	}
+	if st.UpdatedReplicas >= replicas && st.AvailableReplicas >= replicas && st.Replicas == st.UpdatedReplicas {
+		return sous.DeployStatusActive, ""
+	}
+	return sous.DeployStatusPending, ""
+}
+
+// cronJobState reconstructs a DeployState from a Kubernetes CronJob. A
+// CronJob is active as soon as it is accepted by the API server; the success
+// of individual runs is not a property of the deployment.
+func cronJobState(obj kubeCronJob, baseURL string, clusters sous.Clusters) (*sous.DeployState, error) {
+	var parallelism int32
+	if obj.Spec.JobTemplate.Spec.Parallelism != nil {
+		parallelism = *obj.Spec.JobTemplate.Spec.Parallelism
+	}
+	ds, err := deployStateFromObject(obj.Metadata, obj.Spec.JobTemplate.Spec.Template, parallelism, cronJobsResource, baseURL, clusters)
+	if err != nil {
+		return nil, err
+	}
+	ds.Schedule = obj.Spec.Schedule
+	ds.Status = sous.DeployStatusActive
+	return ds, nil
+}
+
+// jobState reconstructs a DeployState from a Kubernetes Job.
+func jobState(obj kubeJob, baseURL string, clusters sous.Clusters) (*sous.DeployState, error) {
+	var parallelism int32
+	if obj.Spec.Parallelism != nil {
+		parallelism = *obj.Spec.Parallelism
+	}
+	ds, err := deployStateFromObject(obj.Metadata, obj.Spec.Template, parallelism, jobsResource, baseURL, clusters)
+	if err != nil {
+		return nil, err
+	}
+	ds.Status, ds.ExecutorMessage = jobStatus(obj)
+	return ds, nil
+}
+
+func jobStatus(obj kubeJob) (sous.DeployStatus, string) {
+	if c, ok := findCondition(obj.Status.Conditions, "Failed"); ok && c.Status == "True" {
+		return sous.DeployStatusFailed, fmt.Sprintf("Deploy failure: %q", c.Message)
+	}
+	if c, ok := findCondition(obj.Status.Conditions, "Complete"); ok && c.Status == "True" {
+		return sous.DeployStatusActive, ""
+	}
+	return sous.DeployStatusPending, ""
+}
@@ -0,0 +1,496 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ManagedLabel marks the Kubernetes objects which Sous controls. Only
+	// objects carrying this label are considered by RunningDeployments.
+	ManagedLabel = "com.opentable.sous.managed"
+	// KindAnnotation records the sous.ManifestKind of a deployment, which
+	// cannot always be recovered from the Kubernetes object kind.
+	KindAnnotation = "com.opentable.sous.kind"
+	// OwnersAnnotation records the owners of a deployment as a JSON list.
+	OwnersAnnotation = "com.opentable.sous.owners"
+	// MetadataAnnotation records the deployment Metadata as a JSON object.
+	MetadataAnnotation = "com.opentable.sous.metadata"
+	// StartupAnnotation records the Startup fields with no direct Kubernetes
+	// equivalent as a JSON object.
+	StartupAnnotation = "com.opentable.sous.startup"
+
+	appLabel      = "app"
+	containerName = "app"
+
+	// basePort is the first container port assigned to a deployment. Ports
+	// are numbered consecutively from here and exposed to the application as
+	// PORT0, PORT1 etc., as Singularity does.
+	basePort = 8080
+
+	// CronJob names are limited to 52 characters, which is the tightest limit
+	// of the object kinds Sous uses.
+	maxNameLen = 52
+)
+
+var illegalNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+type (
+	// kubeTaskData is the ExecutorData for deployments running in Kubernetes.
+	kubeTaskData struct {
+		name     string
+		resource resource
+	}
+
+	// startupExtras holds the Startup fields that are not represented by the
+	// readiness probe.
+	startupExtras struct {
+		Timeout                   int   `json:",omitempty"`
+		ConnectInterval           int   `json:",omitempty"`
+		CheckReadyFailureStatuses []int `json:",omitempty"`
+	}
+
+	// notThisClusterError is returned when a Sous-managed object belongs to a
+	// cluster this server is not responsible for.
+	notThisClusterError struct {
+		foundClusterName string
+	}
+)
+
+func (ntc notThisClusterError) Error() string {
+	return fmt.Sprintf("%s does not belong to this Sous server", ntc.foundClusterName)
+}
+
+// MakeObjectName creates a Kubernetes object name from a sous.DeploymentID.
+// The name is a valid DNS-1123 label, and includes a digest of the full ID so
+// that truncation does not cause collisions.
+func MakeObjectName(did sous.DeploymentID) (string, error) {
+	sn, err := did.ManifestID.Source.ShortName()
+	if err != nil {
+		return "", err
+	}
+	parts := []string{sn}
+	for _, p := range []string{did.ManifestID.Source.Dir, did.ManifestID.Flavor, did.Cluster} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	base := illegalNameChars.ReplaceAllString(strings.ToLower(strings.Join(parts, "-")), "-")
+	digest := fmt.Sprintf("%x", did.Digest())[:8]
+
+	if len(base) > maxNameLen-len(digest)-1 {
+		base = base[:maxNameLen-len(digest)-1]
+	}
+	base = strings.Trim(base, "-")
+	return base + "-" + digest, nil
+}
+
+// resourceForKind returns the kind of Kubernetes object used to run a
+// deployment of kind k.
+func resourceForKind(k sous.ManifestKind) (resource, error) {
+	switch k {
+	default:
+		return resource{}, errors.Errorf("Manifest kind %q is not supported by the Kubernetes deployer", k)
+	case sous.ManifestKindService, sous.ManifestKindWorker:
+		return deploymentsResource, nil
+	case sous.ManifestKindScheduled, sous.ScheduledJob:
+		return cronJobsResource, nil
+	case sous.ManifestKindOnce:
+		return jobsResource, nil
+	}
+}
+
+// buildObject builds the Kubernetes object which runs d, along with the
+// resource it should be written to. The secret references in the Env of d are
+// resolved by secrets into the container env, and recorded in an annotation,
+// from which deployStateFromObject restores them.
+func buildObject(d sous.Deployable, namespace string, secrets sous.SecretResolver) (resource, interface{}, error) {
+	if d.BuildArtifact == nil {
+		return resource{}, nil, &sous.MissingImageNameError{Cause: fmt.Errorf("Missing BuildArtifact on Deployable")}
+	}
+	name, err := MakeObjectName(d.ID())
+	if err != nil {
+		return resource{}, nil, err
+	}
+	r, err := resourceForKind(d.Kind)
+	if err != nil {
+		return r, nil, err
+	}
+	env, refs, err := d.Deployment.Env.ResolveSecrets(secrets)
+	if err != nil {
+		return r, nil, err
+	}
+	meta, err := buildObjectMeta(d.Deployment, name, namespace, refs)
+	if err != nil {
+		return r, nil, err
+	}
+	dep := d.Deployment
+	replicas := int32(dep.NumInstances)
+
+	switch r {
+	default:
+		return r, nil, errors.Errorf("unknown resource %v", r)
+	case deploymentsResource:
+		obj := kubeDeployment{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Metadata:   meta,
+			Spec: deploymentSpec{
+				Replicas: &replicas,
+				Selector: labelSelector{MatchLabels: map[string]string{appLabel: name}},
+				Template: buildPodTemplate(d, env, name, "Always"),
+			},
+		}
+		if !dep.Startup.SkipCheck && dep.Startup.Timeout > 0 {
+			obj.Spec.ProgressDeadlineSeconds = int32Ptr(int32(dep.Startup.Timeout))
+		}
+		return r, &obj, nil
+	case cronJobsResource:
+		return r, &kubeCronJob{
+			APIVersion: "batch/v1",
+			Kind:       "CronJob",
+			Metadata:   meta,
+			Spec: cronJobSpec{
+				Schedule:          dep.Schedule,
+				ConcurrencyPolicy: "Forbid",
+				JobTemplate: jobTemplateSpec{
+					Spec: jobSpec{
+						Parallelism: &replicas,
+						Template:    buildPodTemplate(d, env, name, "OnFailure"),
+					},
+				},
+			},
+		}, nil
+	case jobsResource:
+		return r, &kubeJob{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+			Metadata:   meta,
+			Spec: jobSpec{
+				Parallelism: &replicas,
+				Completions: &replicas,
+				Template:    buildPodTemplate(d, env, name, "OnFailure"),
+			},
+		}, nil
+	}
+}
+
+func buildObjectMeta(dep *sous.Deployment, name, namespace string, secretRefs map[string]string) (objectMeta, error) {
+	owners, err := json.Marshal(dep.Owners.Slice())
+	if err != nil {
+		return objectMeta{}, err
+	}
+	annotations := map[string]string{
+		sous.ClusterNameLabel: dep.ClusterName,
+		sous.FlavorLabel:      dep.Flavor,
+		sous.RepoLabel:        dep.SourceID.Location.Repo,
+		sous.PathLabel:        dep.SourceID.Location.Dir,
+		sous.VersionLabel:     dep.SourceID.Version.String(),
+		KindAnnotation:        string(dep.Kind),
+		OwnersAnnotation:      string(owners),
+	}
+	if len(dep.Metadata) > 0 {
+		md, err := json.Marshal(dep.Metadata)
+		if err != nil {
+			return objectMeta{}, err
+		}
+		annotations[MetadataAnnotation] = string(md)
+	}
+	if len(secretRefs) > 0 {
+		refs, err := json.Marshal(secretRefs)
+		if err != nil {
+			return objectMeta{}, err
+		}
+		annotations[sous.SecretRefsLabel] = string(refs)
+	}
+	if !dep.Startup.SkipCheck {
+		extras, err := json.Marshal(startupExtras{
+			Timeout:                   dep.Startup.Timeout,
+			ConnectInterval:           dep.Startup.ConnectInterval,
+			CheckReadyFailureStatuses: dep.Startup.CheckReadyFailureStatuses,
+		})
+		if err != nil {
+			return objectMeta{}, err
+		}
+		annotations[StartupAnnotation] = string(extras)
+	}
+
+	return objectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Labels:      map[string]string{ManagedLabel: "true", appLabel: name},
+		Annotations: annotations,
+	}, nil
+}
+
+func portName(i int) string {
+	return fmt.Sprintf("port%d", i)
+}
+
+func portEnvName(i int) string {
+	return fmt.Sprintf("PORT%d", i)
+}
+
+func buildPodTemplate(d sous.Deployable, env sous.Env, name, restartPolicy string) podTemplateSpec {
+	dep := d.Deployment
+	res := dep.DeployConfig.Resources
+
+	c := container{
+		Name:  containerName,
+		Image: d.BuildArtifact.Name,
+		Resources: resourceRequirements{
+			Requests: map[string]string{
+				"cpu":    formatCPU(res.Cpus()),
+				"memory": formatMemory(res.Memory()),
+			},
+			Limits: map[string]string{
+				"memory": formatMemory(res.Memory()),
+			},
+		},
+	}
+
+	names := make([]string, 0, len(env))
+	for n := range env {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		c.Env = append(c.Env, envVar{Name: n, Value: env[n]})
+	}
+
+	for i := 0; i < int(res.Ports()); i++ {
+		port := int32(basePort + i)
+		c.Ports = append(c.Ports, containerPort{Name: portName(i), ContainerPort: port, Protocol: "TCP"})
+		c.Env = append(c.Env, envVar{Name: portEnvName(i), Value: strconv.Itoa(int(port))})
+	}
+
+	if s := dep.Startup; !s.SkipCheck {
+		c.ReadinessProbe = &probe{
+			HTTPGet: &httpGetAction{
+				Path:   s.CheckReadyURIPath,
+				Port:   portName(s.CheckReadyPortIndex),
+				Scheme: strings.ToUpper(s.CheckReadyProtocol),
+			},
+			InitialDelaySeconds: int32(s.ConnectDelay),
+			TimeoutSeconds:      int32(s.CheckReadyURITimeout),
+			PeriodSeconds:       int32(s.CheckReadyInterval),
+			FailureThreshold:    int32(s.CheckReadyRetries),
+		}
+	}
+
+	spec := podSpec{RestartPolicy: restartPolicy}
+	for i, v := range dep.DeployConfig.Volumes {
+		if v == nil {
+			continue
+		}
+		vn := fmt.Sprintf("vol%d", i)
+		spec.Volumes = append(spec.Volumes, volume{Name: vn, HostPath: &hostPathVolumeSource{Path: v.Host}})
+		c.VolumeMounts = append(c.VolumeMounts, volumeMount{Name: vn, MountPath: v.Container, ReadOnly: v.Mode == sous.ReadOnly})
+	}
+	spec.Containers = []container{c}
+
+	return podTemplateSpec{
+		Metadata: objectMeta{Labels: map[string]string{appLabel: name}},
+		Spec:     spec,
+	}
+}
+
+// formatCPU renders a number of CPUs as Kubernetes millicores.
+func formatCPU(cpus float64) string {
+	return fmt.Sprintf("%dm", int64(math.Round(cpus*1000)))
+}
+
+// formatMemory renders a number of megabytes as a Kubernetes quantity.
+func formatMemory(mb float64) string {
+	return fmt.Sprintf("%dMi", int64(math.Round(mb)))
+}
+
+// parseCPU parses a Kubernetes CPU quantity into a number of CPUs.
+func parseCPU(q string) (float64, error) {
+	if strings.HasSuffix(q, "m") {
+		m, err := strconv.ParseFloat(strings.TrimSuffix(q, "m"), 64)
+		return m / 1000, err
+	}
+	return strconv.ParseFloat(q, 64)
+}
+
+var memorySuffixes = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+	{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+}
+
+// parseMemory parses a Kubernetes memory quantity into megabytes (MiB, to
+// match the Singularity interpretation of MemoryMb).
+func parseMemory(q string) (float64, error) {
+	for _, s := range memorySuffixes {
+		if strings.HasSuffix(q, s.suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(q, s.suffix), 64)
+			return n * s.bytes / (1 << 20), err
+		}
+	}
+	n, err := strconv.ParseFloat(q, 64)
+	return n / (1 << 20), err
+}
+
+// deployStateFromObject rebuilds the Sous view of a deployment from the
+// metadata and pod template of a Kubernetes object.
+func deployStateFromObject(meta objectMeta, pod podTemplateSpec, instances int32, r resource, baseURL string, clusters sous.Clusters) (*sous.DeployState, error) {
+	ann := meta.Annotations
+	clusterName, ok := ann[sous.ClusterNameLabel]
+	if !ok {
+		return nil, errors.Errorf("%s has no %s annotation", meta.Name, sous.ClusterNameLabel)
+	}
+	cluster, ok := clusters[clusterName]
+	if !ok {
+		return nil, notThisClusterError{foundClusterName: clusterName}
+	}
+
+	sid, err := sous.NewSourceID(ann[sous.RepoLabel], ann[sous.PathLabel], ann[sous.VersionLabel])
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s source ID", meta.Name)
+	}
+
+	ds := &sous.DeployState{
+		Deployment: sous.Deployment{
+			ClusterName: clusterName,
+			Cluster:     cluster,
+			SourceID:    sid,
+			Flavor:      ann[sous.FlavorLabel],
+			Kind:        sous.ManifestKind(ann[KindAnnotation]),
+			Owners:      sous.OwnerSet{},
+		},
+		ExecutorData: &kubeTaskData{name: meta.Name, resource: r},
+		SchedulerURL: fmt.Sprintf("%s%s/namespaces/%s/%s/%s", strings.TrimRight(baseURL, "/"), r.apiPrefix, meta.Namespace, r.plural, meta.Name),
+	}
+	ds.NumInstances = int(instances)
+
+	if o, ok := ann[OwnersAnnotation]; ok {
+		var owners []string
+		if err := json.Unmarshal([]byte(o), &owners); err != nil {
+			return nil, errors.Wrapf(err, "%s owners", meta.Name)
+		}
+		for _, owner := range owners {
+			ds.Owners.Add(owner)
+		}
+	}
+	if md, ok := ann[MetadataAnnotation]; ok {
+		if err := json.Unmarshal([]byte(md), &ds.Metadata); err != nil {
+			return nil, errors.Wrapf(err, "%s metadata", meta.Name)
+		}
+	}
+
+	if len(pod.Spec.Containers) != 1 {
+		return nil, errors.Errorf("%s has %d containers, expected 1", meta.Name, len(pod.Spec.Containers))
+	}
+	c := pod.Spec.Containers[0]
+
+	if err := unpackResources(ds, c); err != nil {
+		return nil, errors.Wrapf(err, "%s resources", meta.Name)
+	}
+	unpackEnv(ds, c)
+	if rj, ok := ann[sous.SecretRefsLabel]; ok {
+		refs := map[string]string{}
+		if err := json.Unmarshal([]byte(rj), &refs); err != nil {
+			return nil, errors.Wrapf(err, "%s secret references", meta.Name)
+		}
+		ds.Env.RestoreSecretRefs(refs)
+	}
+	unpackVolumes(ds, pod.Spec, c)
+	if err := unpackStartup(ds, ann, c); err != nil {
+		return nil, errors.Wrapf(err, "%s startup", meta.Name)
+	}
+	return ds, nil
+}
+
+func unpackResources(ds *sous.DeployState, c container) error {
+	cpus, err := parseCPU(c.Resources.Requests["cpu"])
+	if err != nil {
+		return err
+	}
+	mem, err := parseMemory(c.Resources.Requests["memory"])
+	if err != nil {
+		return err
+	}
+	ds.Resources = sous.Resources{
+		"cpus":   fmt.Sprintf("%f", cpus),
+		"memory": fmt.Sprintf("%f", mem),
+		"ports":  fmt.Sprintf("%d", len(c.Ports)),
+	}
+	return nil
+}
+
+func unpackEnv(ds *sous.DeployState, c container) {
+	injected := map[string]bool{}
+	for i := range c.Ports {
+		injected[portEnvName(i)] = true
+	}
+	ds.Env = sous.Env{}
+	for _, e := range c.Env {
+		if injected[e.Name] {
+			continue
+		}
+		ds.Env[e.Name] = e.Value
+	}
+}
+
+func unpackVolumes(ds *sous.DeployState, spec podSpec, c container) {
+	hostPaths := map[string]string{}
+	for _, v := range spec.Volumes {
+		if v.HostPath != nil {
+			hostPaths[v.Name] = v.HostPath.Path
+		}
+	}
+	for _, m := range c.VolumeMounts {
+		host, ok := hostPaths[m.Name]
+		if !ok {
+			continue
+		}
+		mode := sous.ReadWrite
+		if m.ReadOnly {
+			mode = sous.ReadOnly
+		}
+		ds.DeployConfig.Volumes = append(ds.DeployConfig.Volumes, &sous.Volume{Host: host, Container: m.MountPath, Mode: mode})
+	}
+}
+
+func unpackStartup(ds *sous.DeployState, ann map[string]string, c container) error {
+	p := c.ReadinessProbe
+	if p == nil || p.HTTPGet == nil {
+		ds.Startup.SkipCheck = true
+		return nil
+	}
+	s := &ds.Startup
+	s.ConnectDelay = int(p.InitialDelaySeconds)
+	s.CheckReadyProtocol = p.HTTPGet.Scheme
+	s.CheckReadyURIPath = p.HTTPGet.Path
+	s.CheckReadyURITimeout = int(p.TimeoutSeconds)
+	s.CheckReadyInterval = int(p.PeriodSeconds)
+	s.CheckReadyRetries = int(p.FailureThreshold)
+	for i, port := range c.Ports {
+		if port.Name == p.HTTPGet.Port {
+			s.CheckReadyPortIndex = i
+		}
+	}
+
+	if extras, ok := ann[StartupAnnotation]; ok {
+		se := startupExtras{}
+		if err := json.Unmarshal([]byte(extras), &se); err != nil {
+			return err
+		}
+		s.Timeout = se.Timeout
+		s.ConnectInterval = se.ConnectInterval
+		s.CheckReadyFailureStatuses = se.CheckReadyFailureStatuses
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package nomad
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// nomadClient abstracts the raw interactions with the Nomad job API.
+	nomadClient interface {
+		ListJobs() ([]jobListStub, error)
+		GetJob(id string) (*job, error)
+		Register(j *job) error
+		Deregister(id string) error
+		Summary(id string) (*jobSummary, error)
+		LatestDeployment(id string) (*deployment, error)
+	}
+
+	// httpClient is a nomadClient which talks to a Nomad agent over HTTP.
+	httpClient struct {
+		baseURL, region, namespace, token string
+		http                              *http.Client
+	}
+
+	// apiError is returned when Nomad responds with a non-2xx status.
+	apiError struct {
+		StatusCode int
+		Method     string
+		URL        string
+		Body       string
+	}
+)
+
+func (e *apiError) Error() string {
+	msg := strings.TrimSpace(e.Body)
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, msg)
+}
+
+// isNotFound reports whether err is a 404 from Nomad.
+func isNotFound(err error) bool {
+	ae, is := errors.Cause(err).(*apiError)
+	return is && ae.StatusCode == http.StatusNotFound
+}
+
+func newHTTPClient(baseURL, region, namespace, token string) *httpClient {
+	return &httpClient{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		region:    region,
+		namespace: namespace,
+		token:     token,
+		http:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *httpClient) url(path string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	if c.region != "" {
+		query.Set("region", c.region)
+	}
+	if c.namespace != "" {
+		query.Set("namespace", c.namespace)
+	}
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// ListJobs implements nomadClient on httpClient.
+func (c *httpClient) ListJobs() ([]jobListStub, error) {
+	stubs := []jobListStub{}
+	if err := c.do("GET", c.url("/v1/jobs", nil), nil, &stubs); err != nil {
+		return nil, err
+	}
+	return stubs, nil
+}
+
+// GetJob implements nomadClient on httpClient.
+func (c *httpClient) GetJob(id string) (*job, error) {
+	j := &job{}
+	return j, c.do("GET", c.url("/v1/job/"+url.PathEscape(id), nil), nil, j)
+}
+
+// Register implements nomadClient on httpClient. Registering a job with an
+// existing ID updates it.
+func (c *httpClient) Register(j *job) error {
+	return c.do("POST", c.url("/v1/jobs", nil), registerRequest{Job: j}, nil)
+}
+
+// Deregister implements nomadClient on httpClient. The job is purged, so
+// that its ID may be reused by a job of a different type.
+func (c *httpClient) Deregister(id string) error {
+	return c.do("DELETE", c.url("/v1/job/"+url.PathEscape(id), url.Values{"purge": {"true"}}), nil, nil)
+}
+
+// Summary implements nomadClient on httpClient.
+func (c *httpClient) Summary(id string) (*jobSummary, error) {
+	s := &jobSummary{}
+	return s, c.do("GET", c.url("/v1/job/"+url.PathEscape(id)+"/summary", nil), nil, s)
+}
+
+// LatestDeployment implements nomadClient on httpClient. It returns nil if
+// the job has never had a deployment (e.g. because it is a batch job).
+func (c *httpClient) LatestDeployment(id string) (*deployment, error) {
+	var d *deployment
+	if err := c.do("GET", c.url("/v1/job/"+url.PathEscape(id)+"/deployment", nil), nil, &d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (c *httpClient) do(method, u string, body, into interface{}) error {
+	reqBody := &bytes.Buffer{}
+	if body != nil {
+		if err := json.NewEncoder(reqBody).Encode(body); err != nil {
+			return errors.Wrapf(err, "encoding %s %s", method, u)
+		}
+	}
+
+	req, err := http.NewRequest(method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("X-Nomad-Token", c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "reading response to %s %s", method, u)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &apiError{StatusCode: resp.StatusCode, Method: method, URL: u, Body: string(respBody)}
+	}
+
+	if into == nil {
+		return nil
+	}
+	return errors.Wrapf(json.Unmarshal(respBody, into), "decoding response to %s %s", method, u)
+}
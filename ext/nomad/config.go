@@ -0,0 +1,39 @@
+package nomad
+
+import "strings"
+
+// Config describes how Sous connects to Nomad clusters. The API address for
+// each cluster is its Cluster.BaseURL.
+type Config struct {
+	// Region is the Nomad region jobs are registered in. If empty, the
+	// region of the agent at Cluster.BaseURL is used.
+	Region string `env:"SOUS_NOMAD_REGION"`
+	// Namespace is the Nomad namespace Sous manages jobs in.
+	Namespace string `env:"SOUS_NOMAD_NAMESPACE"`
+	// Datacenters is a comma separated list of datacenters jobs may be
+	// placed in.
+	Datacenters string `env:"SOUS_NOMAD_DATACENTERS"`
+	// Token is an ACL token presented to the Nomad API.
+	Token string `env:"SOUS_NOMAD_TOKEN"`
+}
+
+// DefaultDatacenters is used when Config.Datacenters is empty.
+const DefaultDatacenters = "dc1"
+
+// DefaultConfig builds a default configuration, which can be then overridden by
+// client code.
+func DefaultConfig() Config {
+	return Config{
+		Datacenters: DefaultDatacenters,
+	}
+}
+
+func (c Config) datacenters() []string {
+	dcs := []string{}
+	for _, dc := range strings.Split(c.Datacenters, ",") {
+		if dc = strings.TrimSpace(dc); dc != "" {
+			dcs = append(dcs, dc)
+		}
+	}
+	return dcs
+}
@@ -0,0 +1,214 @@
+package nomad
+
+import (
+	"fmt"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/logging/messages"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+type (
+	deployer struct {
+		config    Config
+		clientFac func(baseURL string) nomadClient
+		secrets   sous.SecretResolver
+		log       logging.LogSink
+	}
+
+	// DeployerOption is an option for configuring Nomad deployers.
+	DeployerOption func(*deployer)
+)
+
+// NewDeployer creates a new Nomad-based sous.Deployer.
+func NewDeployer(ls logging.LogSink, options ...DeployerOption) sous.Deployer {
+	d := &deployer{config: DefaultConfig(), log: ls}
+	for _, opt := range options {
+		opt(d)
+	}
+	return d
+}
+
+// OptConfig configures a deployer from a Config.
+func OptConfig(c Config) DeployerOption {
+	return func(d *deployer) {
+		if c.Datacenters == "" {
+			c.Datacenters = DefaultDatacenters
+		}
+		d.config = c
+	}
+}
+
+// OptSecretResolver sets the SecretResolver which resolves secret references
+// in the Env of each deployment.
+func OptSecretResolver(r sous.SecretResolver) DeployerOption {
+	return func(d *deployer) { d.secrets = r }
+}
+
+func (d *deployer) client(baseURL string) nomadClient {
+	if d.clientFac != nil {
+		return d.clientFac(baseURL)
+	}
+	return newHTTPClient(baseURL, d.config.Region, d.config.Namespace, d.config.Token)
+}
+
+// RunningDeployments implements sous.Deployer on deployer. It reads every
+// Sous-managed job in each cluster.
+func (d *deployer) RunningDeployments(reg sous.Registry, clusters sous.Clusters) (sous.DeployStates, error) {
+	states := sous.NewDeployStates()
+	seen := map[string]struct{}{}
+	for _, name := range clusters.Names() {
+		baseURL := clusters[name].BaseURL
+		if _, ok := seen[baseURL]; ok {
+			continue
+		}
+		seen[baseURL] = struct{}{}
+
+		messages.ReportLogFieldsMessage("Listing Nomad jobs", logging.ExtraDebug1Level, d.log, baseURL)
+		if err := d.collect(states, d.client(baseURL), baseURL, clusters); err != nil {
+			return states, errors.Wrapf(err, "listing %s", baseURL)
+		}
+	}
+	return states, nil
+}
+
+func (d *deployer) collect(states sous.DeployStates, cl nomadClient, baseURL string, clusters sous.Clusters) error {
+	stubs, err := cl.ListJobs()
+	if err != nil {
+		return err
+	}
+	for _, stub := range stubs {
+		if stub.Stop {
+			continue
+		}
+		// The job list does not include Meta, so every job must be fetched
+		// to find out whether Sous manages it.
+		j, err := cl.GetJob(stub.ID)
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if j.Meta[ManagedMeta] != "true" {
+			continue
+		}
+		d.add(states, cl, j, baseURL, clusters)
+	}
+	return nil
+}
+
+func (d *deployer) add(states sous.DeployStates, cl nomadClient, j *job, baseURL string, clusters sous.Clusters) {
+	ds, err := jobState(cl, j, baseURL, clusters)
+	if err != nil {
+		if _, ignorable := errors.Cause(err).(notThisClusterError); !ignorable {
+			logging.ReportError(d.log, errors.Wrapf(err, "malformed Nomad job"))
+		}
+		return
+	}
+	messages.ReportLogFieldsMessage("Adding deployment", logging.DebugLevel, d.log, ds)
+	states.Add(ds)
+}
+
+// Status implements sous.Deployer on deployer.
+func (d *deployer) Status(reg sous.Registry, clusters sous.Clusters, pair *sous.DeployablePair) (*sous.DeployState, error) {
+	clusterName := pair.Post.Deployment.ClusterName
+	cluster, has := clusters[clusterName]
+	if !has {
+		return nil, errors.Errorf("No cluster found for %q. Known are: %q.", clusterName, clusters.Names())
+	}
+	if pair.UUID == uuid.Nil {
+		pair.UUID = uuid.NewV4()
+	}
+
+	id, err := MakeJobID(pair.Post.ID())
+	if err != nil {
+		return nil, err
+	}
+	cl := d.client(cluster.BaseURL)
+	j, err := cl.GetJob(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting job %s", id)
+	}
+	ds, err := jobState(cl, j, cluster.BaseURL, clusters)
+	return ds, errors.Wrapf(err, "getting job %s", id)
+}
+
+// Rectify invokes actions to ensure that the real world matches pair.Post,
+// given that it currently matches pair.Prior.
+func (d *deployer) Rectify(pair *sous.DeployablePair) sous.DiffResolution {
+	if pair.UUID == uuid.Nil {
+		pair.UUID = uuid.NewV4()
+	}
+	result := sous.DiffResolution{DeploymentID: pair.ID()}
+
+	switch k := pair.Kind(); k {
+	default:
+		panic(fmt.Sprintf("unrecognised kind %q", k))
+	case sous.SameKind:
+		result = pair.SameResolution()
+		if pair.Post.Status == sous.DeployStatusFailed {
+			result.Error = sous.WrapResolveError(&sous.FailedStatusError{})
+		}
+	case sous.AddedKind:
+		if err := d.create(pair); err != nil {
+			result.Desc = "not created"
+			result.Error = sous.WrapResolveError(&sous.CreateError{Deployment: pair.Post.Deployment.Clone(), Err: err})
+		} else {
+			result.Desc = sous.CreateDiff
+		}
+	case sous.RemovedKind:
+		// As with Singularity, Sous does not stop jobs whose manifests have
+		// been removed; the owners should either stop them by hand or restore
+		// the manifest.
+		messages.ReportLogFieldsMessage("Rectify not deleting Nomad job", logging.WarningLevel, d.log, pair.ID())
+		result.Desc = sous.DeleteDiff
+	case sous.ModifiedKind:
+		if err := d.modify(pair); err != nil {
+			result.Desc = "not updated"
+			result.Error = sous.WrapResolveError(&sous.ChangeError{
+				Deployments: &sous.DeploymentPair{Prior: pair.Prior.Deployment.Clone(), Post: pair.Post.Deployment.Clone()},
+				Err:         err,
+			})
+		} else if pair.Prior.Status == sous.DeployStatusFailed || pair.Post.Status == sous.DeployStatusFailed {
+			result.Desc = sous.ModifyDiff
+			result.Error = sous.WrapResolveError(&sous.FailedStatusError{})
+		} else {
+			result.Desc = sous.ModifyDiff
+		}
+	}
+	messages.ReportLogFieldsMessage("Result of Nomad rectification", logging.InformationLevel, d.log, pair.ID(), result)
+	return result
+}
+
+func (d *deployer) create(pair *sous.DeployablePair) error {
+	j, err := buildJob(*pair.Post, d.config, d.secrets)
+	if err != nil {
+		return err
+	}
+	return d.client(pair.Post.Deployment.Cluster.BaseURL).Register(j)
+}
+
+func (d *deployer) modify(pair *sous.DeployablePair) error {
+	j, err := buildJob(*pair.Post, d.config, d.secrets)
+	if err != nil {
+		return err
+	}
+	cl := d.client(pair.Post.Deployment.Cluster.BaseURL)
+
+	data, ok := pair.ExecutorData.(*nomadTaskData)
+	if !ok {
+		return errors.Errorf("Modification record %#v doesn't contain Nomad compatible data: was %T", pair.ID(), pair.ExecutorData)
+	}
+
+	// Nomad refuses to change the type of an existing job, so it must be
+	// purged first.
+	if data.jobType != j.Type {
+		if err := cl.Deregister(data.jobID); err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+	return cl.Register(j)
+}
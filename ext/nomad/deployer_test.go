@@ -0,0 +1,356 @@
+package nomad
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNomad is a minimal in-memory stand-in for the Nomad job API. Every
+// registered service job gets a successful deployment, and every batch job
+// completes immediately.
+type fakeNomad struct {
+	sync.Mutex
+	jobs   map[string]*job
+	server *httptest.Server
+}
+
+func newFakeNomad() *fakeNomad {
+	f := &fakeNomad{jobs: map[string]*job{}}
+	f.server = httptest.NewServer(f)
+	return f
+}
+
+func (f *fakeNomad) Close() { f.server.Close() }
+
+func (f *fakeNomad) URL() string { return f.server.URL }
+
+func (f *fakeNomad) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	enc := json.NewEncoder(rw)
+	path := req.URL.Path
+
+	if path == "/v1/jobs" {
+		switch req.Method {
+		case "GET":
+			stubs := []jobListStub{}
+			for _, j := range f.jobs {
+				stubs = append(stubs, jobListStub{ID: j.ID, Name: j.Name, Type: j.Type, Status: j.Status})
+			}
+			enc.Encode(stubs)
+		case "POST":
+			reg := registerRequest{}
+			if err := json.NewDecoder(req.Body).Decode(&reg); err != nil {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if old, ok := f.jobs[reg.Job.ID]; ok {
+				if old.Type != reg.Job.Type {
+					rw.WriteHeader(http.StatusBadRequest)
+					rw.Write([]byte("cannot update job type"))
+					return
+				}
+				reg.Job.Version = old.Version + 1
+			}
+			reg.Job.Status = "running"
+			f.jobs[reg.Job.ID] = reg.Job
+			enc.Encode(map[string]interface{}{"EvalID": "eval"})
+		}
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "/v1/job/"), "/")
+	j, ok := f.jobs[parts[0]]
+	if !ok {
+		rw.WriteHeader(http.StatusNotFound)
+		rw.Write([]byte("job not found"))
+		return
+	}
+	switch {
+	case len(parts) == 1 && req.Method == "GET":
+		enc.Encode(j)
+	case len(parts) == 1 && req.Method == "DELETE":
+		delete(f.jobs, j.ID)
+		enc.Encode(map[string]interface{}{"EvalID": "eval"})
+	case parts[1] == "summary":
+		count := *j.TaskGroups[0].Count
+		enc.Encode(jobSummary{JobID: j.ID, Summary: map[string]taskGroupSummary{groupName: {Complete: count}}})
+	case parts[1] == "deployment":
+		if j.Update == nil {
+			rw.Write([]byte("null"))
+			return
+		}
+		enc.Encode(deployment{ID: "dep", JobID: j.ID, JobVersion: j.Version, Status: "successful"})
+	}
+}
+
+func (f *fakeNomad) job(id string) *job {
+	f.Lock()
+	defer f.Unlock()
+	return f.jobs[id]
+}
+
+func testDeployable(baseURL string) *sous.Deployable {
+	cluster := &sous.Cluster{Name: "nomad-cluster", Kind: "nomad", BaseURL: baseURL}
+	return &sous.Deployable{
+		Status: sous.DeployStatusActive,
+		Deployment: &sous.Deployment{
+			ClusterName: "nomad-cluster",
+			Cluster:     cluster,
+			SourceID:    sous.MustNewSourceID("github.com/opentable/example", "api", "1.2.3"),
+			Flavor:      "vanilla",
+			Kind:        sous.ManifestKindService,
+			Owners:      sous.OwnerSet{"someone@example.com": struct{}{}},
+			DeployConfig: sous.DeployConfig{
+				NumInstances: 3,
+				Resources:    sous.Resources{"cpus": "0.5", "memory": "256", "ports": "2"},
+				Env:          sous.Env{"GREETING": "hello"},
+				Metadata:     sous.Metadata{"team": "example"},
+				Volumes:      sous.Volumes{{Host: "/srv/data", Container: "/data", Mode: sous.ReadOnly}},
+				Startup: sous.Startup{
+					ConnectDelay:         5,
+					ConnectInterval:      1,
+					Timeout:              120,
+					CheckReadyProtocol:   "HTTP",
+					CheckReadyURIPath:    "/health",
+					CheckReadyPortIndex:  1,
+					CheckReadyInterval:   10,
+					CheckReadyRetries:    3,
+					CheckReadyURITimeout: 2,
+				},
+			},
+		},
+		BuildArtifact: &sous.BuildArtifact{Name: "docker.example.com/example/api:1.2.3", Type: "docker"},
+	}
+}
+
+func TestMakeJobID(t *testing.T) {
+	did := testDeployable("http://nomad").ID()
+	id, err := MakeJobID(did)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(id, "example-api-vanilla-nomad-cluster-"), id)
+
+	did.ManifestID.Source.Dir = strings.Repeat("very/deep/", 20)
+	long, err := MakeJobID(did)
+	require.NoError(t, err)
+	assert.True(t, len(long) <= maxIDLen, long)
+	assert.NotEqual(t, id, long)
+}
+
+func TestBuildJob_RoundTrip(t *testing.T) {
+	for _, kind := range []sous.ManifestKind{sous.ManifestKindService, sous.ManifestKindScheduled, sous.ManifestKindOnce} {
+		d := testDeployable("http://nomad")
+		d.Kind = kind
+		if kind == sous.ManifestKindScheduled {
+			d.Schedule = "*/5 * * * *"
+		}
+
+		j, err := buildJob(*d, DefaultConfig(), nil)
+		require.NoError(t, err, "%s", kind)
+
+		// Go through JSON, as the job would when stored by Nomad.
+		b, err := json.Marshal(j)
+		require.NoError(t, err)
+		stored := &job{}
+		require.NoError(t, json.Unmarshal(b, stored))
+
+		ds, err := deployStateFromJob(stored, "http://nomad", sous.Clusters{d.ClusterName: d.Cluster})
+		require.NoError(t, err, "%s", kind)
+
+		different, diffs := d.Deployment.Diff(&ds.Deployment)
+		assert.False(t, different, "%s: %v", kind, diffs)
+	}
+}
+
+type testSecretResolver map[string]string
+
+func (r testSecretResolver) ResolveSecret(ref sous.SecretRef) (string, error) {
+	v, ok := r[ref.String()]
+	if !ok {
+		return "", errors.Errorf("no secret %s", ref)
+	}
+	return v, nil
+}
+
+func TestBuildJob_Secrets(t *testing.T) {
+	d := testDeployable("http://nomad")
+	d.Env = sous.Env{"DB_PASSWORD": "secret://db/app#password", "PLAIN": "value"}
+
+	_, err := buildJob(*d, DefaultConfig(), nil)
+	assert.Error(t, err, "a reference with no resolver")
+
+	j, err := buildJob(*d, DefaultConfig(), testSecretResolver{"secret://db/app#password": "hunter2"})
+	require.NoError(t, err)
+	env := j.TaskGroups[0].Tasks[0].Env
+	assert.Equal(t, "hunter2", env["DB_PASSWORD"])
+	assert.Equal(t, "value", env["PLAIN"])
+	assert.Equal(t, "secret://db/app#password", d.Env["DB_PASSWORD"], "the deployment keeps the reference")
+
+	b, err := json.Marshal(j)
+	require.NoError(t, err)
+	stored := &job{}
+	require.NoError(t, json.Unmarshal(b, stored))
+	ds, err := deployStateFromJob(stored, "http://nomad", sous.Clusters{d.ClusterName: d.Cluster})
+	require.NoError(t, err)
+	assert.Equal(t, d.Env, ds.Env)
+}
+
+func TestHTTPClient_Decodes(t *testing.T) {
+	f := newFakeNomad()
+	defer f.Close()
+	c := newHTTPClient(f.URL(), "", "", "")
+
+	j, err := buildJob(*testDeployable(f.URL()), DefaultConfig(), nil)
+	require.NoError(t, err)
+	require.NoError(t, c.Register(j))
+
+	stubs, err := c.ListJobs()
+	require.NoError(t, err)
+	require.Len(t, stubs, 1)
+	assert.Equal(t, j.ID, stubs[0].ID)
+
+	d, err := c.LatestDeployment(j.ID)
+	require.NoError(t, err)
+	require.NotNil(t, d)
+	assert.Equal(t, j.ID, d.JobID)
+
+	_, err = c.LatestDeployment("no-such-job")
+	assert.Error(t, err)
+}
+
+func TestBuildJob_Types(t *testing.T) {
+	d := testDeployable("http://nomad")
+	j, err := buildJob(*d, DefaultConfig(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, serviceJob, j.Type)
+	assert.Nil(t, j.Periodic)
+	assert.Equal(t, []string{"dc1"}, j.Datacenters)
+	assert.Equal(t, "${NOMAD_PORT_port1}", j.TaskGroups[0].Tasks[0].Env["PORT1"])
+
+	d.Kind = sous.ManifestKindScheduled
+	d.Schedule = "0 * * * *"
+	j, err = buildJob(*d, DefaultConfig(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, batchJob, j.Type)
+	require.NotNil(t, j.Periodic)
+	assert.Equal(t, "0 * * * *", j.Periodic.Spec)
+
+	d.Kind = sous.ManifestKindOnDemand
+	_, err = buildJob(*d, DefaultConfig(), nil)
+	assert.Error(t, err)
+}
+
+func TestBatchStatus(t *testing.T) {
+	st, _ := batchStatus(2, &jobSummary{Summary: map[string]taskGroupSummary{groupName: {Running: 2}}})
+	assert.Equal(t, sous.DeployStatusPending, st)
+	st, _ = batchStatus(2, &jobSummary{Summary: map[string]taskGroupSummary{groupName: {Complete: 2}}})
+	assert.Equal(t, sous.DeployStatusActive, st)
+	st, msg := batchStatus(2, &jobSummary{Summary: map[string]taskGroupSummary{groupName: {Complete: 1, Failed: 1}}})
+	assert.Equal(t, sous.DeployStatusFailed, st)
+	assert.Contains(t, msg, "1 of 2")
+}
+
+func TestServiceStatus(t *testing.T) {
+	j := &job{Version: 2, Update: &updateStrategy{}}
+	st, _ := serviceStatus(j, &deployment{JobVersion: 1, Status: "successful"})
+	assert.Equal(t, sous.DeployStatusPending, st)
+	st, _ = serviceStatus(j, &deployment{JobVersion: 2, Status: "successful"})
+	assert.Equal(t, sous.DeployStatusActive, st)
+	st, msg := serviceStatus(j, &deployment{JobVersion: 2, Status: "failed", StatusDescription: "Failed due to progress deadline"})
+	assert.Equal(t, sous.DeployStatusFailed, st)
+	assert.Contains(t, msg, "progress deadline")
+}
+
+func TestDeployer_Lifecycle(t *testing.T) {
+	srv := newFakeNomad()
+	defer srv.Close()
+
+	dep := NewDeployer(logging.SilentLogSet())
+	post := testDeployable(srv.URL())
+	clusters := sous.Clusters{post.ClusterName: post.Cluster}
+	id, err := MakeJobID(post.ID())
+	require.NoError(t, err)
+
+	pair := &sous.DeployablePair{Post: post}
+	pair.SetID(post.ID())
+	res := dep.Rectify(pair)
+	require.Nil(t, res.Error)
+	assert.Equal(t, sous.CreateDiff, res.Desc)
+	require.NotNil(t, srv.job(id))
+
+	states, err := dep.RunningDeployments(nil, clusters)
+	require.NoError(t, err)
+	require.Len(t, states.Snapshot(), 1)
+	prior, has := states.Get(post.ID())
+	require.True(t, has)
+	assert.Equal(t, sous.DeployStatusActive, prior.Status)
+
+	ds, err := dep.Status(nil, clusters, pair)
+	require.NoError(t, err)
+	assert.Equal(t, post.SourceID, ds.SourceID)
+
+	// Scale up in place.
+	updated := testDeployable(srv.URL())
+	updated.NumInstances = 5
+	pair = &sous.DeployablePair{
+		Prior:        &sous.Deployable{Status: sous.DeployStatusActive, Deployment: &prior.Deployment},
+		Post:         updated,
+		ExecutorData: prior.ExecutorData,
+	}
+	pair.SetID(post.ID())
+	res = dep.Rectify(pair)
+	require.Nil(t, res.Error)
+	assert.Equal(t, sous.ModifyDiff, res.Desc)
+	assert.Equal(t, 5, *srv.job(id).TaskGroups[0].Count)
+
+	states, err = dep.RunningDeployments(nil, clusters)
+	require.NoError(t, err)
+	now, _ := states.Get(post.ID())
+
+	// Changing type requires the job to be purged and registered again.
+	scheduled := testDeployable(srv.URL())
+	scheduled.Kind = sous.ManifestKindScheduled
+	scheduled.Schedule = "0 * * * *"
+	pair = &sous.DeployablePair{
+		Prior:        &sous.Deployable{Status: sous.DeployStatusActive, Deployment: &now.Deployment},
+		Post:         scheduled,
+		ExecutorData: now.ExecutorData,
+	}
+	pair.SetID(post.ID())
+	res = dep.Rectify(pair)
+	require.Nil(t, res.Error)
+	assert.Equal(t, batchJob, srv.job(id).Type)
+}
+
+func TestDeployer_RunningDeployments_Unmanaged(t *testing.T) {
+	srv := newFakeNomad()
+	defer srv.Close()
+	srv.jobs["someone-elses"] = &job{ID: "someone-elses", Type: serviceJob}
+
+	dep := NewDeployer(logging.SilentLogSet())
+	post := testDeployable(srv.URL())
+	pair := &sous.DeployablePair{Post: post}
+	pair.SetID(post.ID())
+	require.Nil(t, dep.Rectify(pair).Error)
+
+	states, err := dep.RunningDeployments(nil, sous.Clusters{post.ClusterName: post.Cluster})
+	require.NoError(t, err)
+	assert.Len(t, states.Snapshot(), 1)
+
+	// A second Sous cluster sharing the same Nomad only sees its own jobs.
+	other := sous.Clusters{"other": &sous.Cluster{Name: "other", Kind: "nomad", BaseURL: srv.URL()}}
+	states, err = dep.RunningDeployments(nil, other)
+	require.NoError(t, err)
+	assert.Len(t, states.Snapshot(), 0)
+}
@@ -0,0 +1,123 @@
+package nomad
+
+// The types in this file are the subset of the Nomad HTTP API objects that
+// Sous reads and writes. Nomad's JSON uses Go-style field names, so no tags
+// are needed except to omit empty optional values.
+
+type (
+	job struct {
+		ID          string
+		Name        string
+		Type        string
+		Region      string            `json:",omitempty"`
+		Namespace   string            `json:",omitempty"`
+		Datacenters []string          `json:",omitempty"`
+		Meta        map[string]string `json:",omitempty"`
+		Periodic    *periodicConfig   `json:",omitempty"`
+		TaskGroups  []*taskGroup
+		Update      *updateStrategy `json:",omitempty"`
+
+		// The following are set by Nomad.
+		Status  string `json:",omitempty"`
+		Stop    bool   `json:",omitempty"`
+		Version uint64 `json:",omitempty"`
+	}
+
+	jobListStub struct {
+		ID     string
+		Name   string
+		Type   string
+		Status string
+		Stop   bool
+	}
+
+	registerRequest struct {
+		Job *job
+	}
+
+	periodicConfig struct {
+		Enabled         bool
+		SpecType        string
+		Spec            string
+		ProhibitOverlap bool
+	}
+
+	updateStrategy struct {
+		HealthCheck     string `json:",omitempty"`
+		MinHealthyTime  int64  `json:",omitempty"`
+		HealthyDeadline int64  `json:",omitempty"`
+	}
+
+	taskGroup struct {
+		Name     string
+		Count    *int
+		Networks []*networkResource `json:",omitempty"`
+		Tasks    []*task
+	}
+
+	networkResource struct {
+		DynamicPorts []port `json:",omitempty"`
+	}
+
+	port struct {
+		Label string
+		Value int `json:",omitempty"`
+	}
+
+	task struct {
+		Name      string
+		Driver    string
+		Config    map[string]interface{}
+		Env       map[string]string `json:",omitempty"`
+		Resources *resources
+		Services  []*service `json:",omitempty"`
+	}
+
+	resources struct {
+		CPU      *int
+		MemoryMB *int
+	}
+
+	service struct {
+		Name      string
+		PortLabel string          `json:",omitempty"`
+		Checks    []*serviceCheck `json:",omitempty"`
+	}
+
+	serviceCheck struct {
+		Name         string
+		Type         string
+		Protocol     string        `json:",omitempty"`
+		Path         string        `json:",omitempty"`
+		PortLabel    string        `json:",omitempty"`
+		Interval     int64         `json:",omitempty"` // nanoseconds
+		Timeout      int64         `json:",omitempty"` // nanoseconds
+		CheckRestart *checkRestart `json:",omitempty"`
+	}
+
+	checkRestart struct {
+		Limit int   `json:",omitempty"`
+		Grace int64 `json:",omitempty"` // nanoseconds
+	}
+
+	jobSummary struct {
+		JobID   string
+		Summary map[string]taskGroupSummary
+	}
+
+	taskGroupSummary struct {
+		Queued, Complete, Failed, Running, Starting, Lost int
+	}
+
+	deployment struct {
+		ID                string
+		JobID             string
+		JobVersion        uint64
+		Status            string
+		StatusDescription string
+	}
+)
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,71 @@
+package nomad
+
+import (
+	"fmt"
+
+	"github.com/opentable/sous/lib"
+)
+
+// jobState reconstructs a DeployState from a Nomad job, consulting its latest
+// deployment or its summary to determine the DeployStatus.
+func jobState(cl nomadClient, j *job, baseURL string, clusters sous.Clusters) (*sous.DeployState, error) {
+	ds, err := deployStateFromJob(j, baseURL, clusters)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case j.Type == serviceJob:
+		d, err := cl.LatestDeployment(j.ID)
+		if err != nil {
+			return nil, err
+		}
+		ds.Status, ds.ExecutorMessage = serviceStatus(j, d)
+	case j.Periodic != nil:
+		// A periodic job is active as soon as Nomad accepts it; the success
+		// of individual runs is not a property of the deployment.
+		ds.Status = sous.DeployStatusActive
+	default:
+		s, err := cl.Summary(j.ID)
+		if err != nil {
+			return nil, err
+		}
+		ds.Status, ds.ExecutorMessage = batchStatus(ds.NumInstances, s)
+	}
+	return ds, nil
+}
+
+// serviceStatus determines the DeployStatus of a service job from its latest
+// deployment, which Nomad creates for each new version of the job.
+func serviceStatus(j *job, d *deployment) (sous.DeployStatus, string) {
+	if d == nil || d.JobVersion != j.Version {
+		// Jobs with no update strategy never get a deployment.
+		if j.Update == nil && j.Status == "running" {
+			return sous.DeployStatusActive, ""
+		}
+		return sous.DeployStatusPending, ""
+	}
+	switch d.Status {
+	default:
+		return sous.DeployStatusPending, ""
+	case "successful":
+		return sous.DeployStatusActive, ""
+	case "failed", "cancelled":
+		return sous.DeployStatusFailed, fmt.Sprintf("Deploy failure: %q", d.StatusDescription)
+	}
+}
+
+// batchStatus determines the DeployStatus of a one-off batch job: Active once
+// every instance has completed, Failed if any instance failed and none are
+// still running, and Pending otherwise.
+func batchStatus(count int, s *jobSummary) (sous.DeployStatus, string) {
+	tg := s.Summary[groupName]
+	switch {
+	default:
+		return sous.DeployStatusPending, ""
+	case tg.Complete >= count:
+		return sous.DeployStatusActive, ""
+	case tg.Failed > 0 && tg.Queued+tg.Starting+tg.Running == 0:
+		return sous.DeployStatusFailed, fmt.Sprintf("Deploy failure: %d of %d allocations failed", tg.Failed, count)
+	}
+}
@@ -0,0 +1,444 @@
+package nomad
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ManagedMeta marks the Nomad jobs which Sous controls. Only jobs carrying
+	// this meta key are considered by RunningDeployments.
+	ManagedMeta = "com.opentable.sous.managed"
+	// KindMeta records the sous.ManifestKind of a deployment, which cannot
+	// always be recovered from the Nomad job type.
+	KindMeta = "com.opentable.sous.kind"
+	// OwnersMeta records the owners of a deployment as a JSON list.
+	OwnersMeta = "com.opentable.sous.owners"
+	// MetadataMeta records the deployment Metadata as a JSON object.
+	MetadataMeta = "com.opentable.sous.metadata"
+	// StartupMeta records the deployment's Startup as a JSON object. Fields
+	// which are also expressed by the service check are read back from the
+	// check, so that changes made to it outside Sous are noticed.
+	StartupMeta = "com.opentable.sous.startup"
+
+	serviceJob = "service"
+	batchJob   = "batch"
+
+	groupName = "app"
+	taskName  = "app"
+
+	// mhzPerCPU converts between Sous CPU shares and Nomad's CPU resource,
+	// which is measured in MHz.
+	mhzPerCPU = 1000
+
+	maxIDLen = 100
+)
+
+var illegalIDChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+type (
+	// nomadTaskData is the ExecutorData for deployments running in Nomad.
+	nomadTaskData struct {
+		jobID, jobType string
+	}
+
+	// notThisClusterError is returned when a Sous-managed job belongs to a
+	// cluster this server is not responsible for.
+	notThisClusterError struct {
+		foundClusterName string
+	}
+)
+
+func (ntc notThisClusterError) Error() string {
+	return fmt.Sprintf("%s does not belong to this Sous server", ntc.foundClusterName)
+}
+
+// MakeJobID creates a Nomad job ID from a sous.DeploymentID. It includes a
+// digest of the full ID so that truncation does not cause collisions.
+func MakeJobID(did sous.DeploymentID) (string, error) {
+	sn, err := did.ManifestID.Source.ShortName()
+	if err != nil {
+		return "", err
+	}
+	parts := []string{sn}
+	for _, p := range []string{did.ManifestID.Source.Dir, did.ManifestID.Flavor, did.Cluster} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	base := illegalIDChars.ReplaceAllString(strings.ToLower(strings.Join(parts, "-")), "-")
+	digest := fmt.Sprintf("%x", did.Digest())[:8]
+
+	if len(base) > maxIDLen-len(digest)-1 {
+		base = base[:maxIDLen-len(digest)-1]
+	}
+	base = strings.Trim(base, "-")
+	return base + "-" + digest, nil
+}
+
+// jobTypeForKind returns the Nomad job type used to run a deployment of kind
+// k, and whether it should be periodic.
+func jobTypeForKind(k sous.ManifestKind) (jobType string, periodic bool, err error) {
+	switch k {
+	default:
+		return "", false, errors.Errorf("Manifest kind %q is not supported by the Nomad deployer", k)
+	case sous.ManifestKindService, sous.ManifestKindWorker:
+		return serviceJob, false, nil
+	case sous.ManifestKindScheduled, sous.ScheduledJob:
+		return batchJob, true, nil
+	case sous.ManifestKindOnce:
+		return batchJob, false, nil
+	}
+}
+
+// buildJob builds the Nomad job which runs d. The secret references in the
+// Env of d are resolved by secrets into the task env, and recorded in the job
+// meta, from which deployStateFromJob restores them.
+func buildJob(d sous.Deployable, c Config, secrets sous.SecretResolver) (*job, error) {
+	if d.BuildArtifact == nil {
+		return nil, &sous.MissingImageNameError{Cause: fmt.Errorf("Missing BuildArtifact on Deployable")}
+	}
+	id, err := MakeJobID(d.ID())
+	if err != nil {
+		return nil, err
+	}
+	jobType, periodic, err := jobTypeForKind(d.Kind)
+	if err != nil {
+		return nil, err
+	}
+	env, refs, err := d.Deployment.Env.ResolveSecrets(secrets)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := buildMeta(d.Deployment, refs)
+	if err != nil {
+		return nil, err
+	}
+	dep := d.Deployment
+
+	j := &job{
+		ID:          id,
+		Name:        id,
+		Type:        jobType,
+		Region:      c.Region,
+		Namespace:   c.Namespace,
+		Datacenters: c.datacenters(),
+		Meta:        meta,
+		TaskGroups:  []*taskGroup{buildTaskGroup(d, env, jobType)},
+	}
+	if periodic {
+		j.Periodic = &periodicConfig{
+			Enabled:         true,
+			SpecType:        "cron",
+			Spec:            dep.Schedule,
+			ProhibitOverlap: true,
+		}
+	}
+	if jobType == serviceJob && !dep.Startup.SkipCheck {
+		j.Update = &updateStrategy{HealthCheck: "checks"}
+		if dep.Startup.Timeout > 0 {
+			j.Update.HealthyDeadline = seconds(dep.Startup.Timeout)
+		}
+	}
+	return j, nil
+}
+
+func buildMeta(dep *sous.Deployment, secretRefs map[string]string) (map[string]string, error) {
+	owners, err := json.Marshal(dep.Owners.Slice())
+	if err != nil {
+		return nil, err
+	}
+	startup, err := json.Marshal(dep.Startup)
+	if err != nil {
+		return nil, err
+	}
+	meta := map[string]string{
+		ManagedMeta:           "true",
+		sous.ClusterNameLabel: dep.ClusterName,
+		sous.FlavorLabel:      dep.Flavor,
+		sous.RepoLabel:        dep.SourceID.Location.Repo,
+		sous.PathLabel:        dep.SourceID.Location.Dir,
+		sous.VersionLabel:     dep.SourceID.Version.String(),
+		KindMeta:              string(dep.Kind),
+		OwnersMeta:            string(owners),
+		StartupMeta:           string(startup),
+	}
+	if len(dep.Metadata) > 0 {
+		md, err := json.Marshal(dep.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		meta[MetadataMeta] = string(md)
+	}
+	if len(secretRefs) > 0 {
+		refs, err := json.Marshal(secretRefs)
+		if err != nil {
+			return nil, err
+		}
+		meta[sous.SecretRefsLabel] = string(refs)
+	}
+	return meta, nil
+}
+
+func portLabel(i int) string {
+	return fmt.Sprintf("port%d", i)
+}
+
+func portEnvName(i int) string {
+	return fmt.Sprintf("PORT%d", i)
+}
+
+func seconds(s int) int64 {
+	return int64(time.Duration(s) * time.Second)
+}
+
+func fromNanos(ns int64) int {
+	return int(time.Duration(ns) / time.Second)
+}
+
+func buildTaskGroup(d sous.Deployable, env sous.Env, jobType string) *taskGroup {
+	dep := d.Deployment
+	res := dep.DeployConfig.Resources
+
+	t := &task{
+		Name:   taskName,
+		Driver: "docker",
+		Config: map[string]interface{}{"image": d.BuildArtifact.Name},
+		Env:    map[string]string{},
+		Resources: &resources{
+			CPU:      intPtr(int(math.Round(res.Cpus() * mhzPerCPU))),
+			MemoryMB: intPtr(int(math.Round(res.Memory()))),
+		},
+	}
+	for n, v := range env {
+		t.Env[n] = v
+	}
+
+	g := &taskGroup{
+		Name:  groupName,
+		Count: intPtr(dep.NumInstances),
+		Tasks: []*task{t},
+	}
+
+	if n := int(res.Ports()); n > 0 {
+		network := &networkResource{}
+		labels := []string{}
+		for i := 0; i < n; i++ {
+			network.DynamicPorts = append(network.DynamicPorts, port{Label: portLabel(i)})
+			labels = append(labels, portLabel(i))
+			t.Env[portEnvName(i)] = fmt.Sprintf("${NOMAD_PORT_%s}", portLabel(i))
+		}
+		g.Networks = []*networkResource{network}
+		t.Config["ports"] = labels
+	}
+
+	vols := []string{}
+	for _, v := range dep.DeployConfig.Volumes {
+		if v == nil {
+			continue
+		}
+		vols = append(vols, fmt.Sprintf("%s:%s:%s", v.Host, v.Container, strings.ToLower(string(v.Mode))))
+	}
+	if len(vols) > 0 {
+		t.Config["volumes"] = vols
+	}
+
+	if s := dep.Startup; jobType == serviceJob && !s.SkipCheck && res.Ports() > 0 {
+		protocol := strings.ToLower(s.CheckReadyProtocol)
+		t.Services = []*service{{
+			Name:      groupName,
+			PortLabel: portLabel(s.CheckReadyPortIndex),
+			Checks: []*serviceCheck{{
+				Name:      "ready",
+				Type:      "http",
+				Protocol:  protocol,
+				Path:      s.CheckReadyURIPath,
+				PortLabel: portLabel(s.CheckReadyPortIndex),
+				Interval:  seconds(s.CheckReadyInterval),
+				Timeout:   seconds(s.CheckReadyURITimeout),
+				CheckRestart: &checkRestart{
+					Limit: s.CheckReadyRetries,
+					Grace: seconds(s.ConnectDelay),
+				},
+			}},
+		}}
+	}
+	return g
+}
+
+// deployStateFromJob reconstructs a DeployState from a Nomad job. Its Status
+// is left for the caller to determine.
+func deployStateFromJob(j *job, baseURL string, clusters sous.Clusters) (*sous.DeployState, error) {
+	meta := j.Meta
+	clusterName, ok := meta[sous.ClusterNameLabel]
+	if !ok {
+		return nil, errors.Errorf("%s has no %s meta", j.ID, sous.ClusterNameLabel)
+	}
+	cluster, ok := clusters[clusterName]
+	if !ok {
+		return nil, notThisClusterError{foundClusterName: clusterName}
+	}
+
+	sid, err := sous.NewSourceID(meta[sous.RepoLabel], meta[sous.PathLabel], meta[sous.VersionLabel])
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s source ID", j.ID)
+	}
+
+	ds := &sous.DeployState{
+		Deployment: sous.Deployment{
+			ClusterName: clusterName,
+			Cluster:     cluster,
+			SourceID:    sid,
+			Flavor:      meta[sous.FlavorLabel],
+			Kind:        sous.ManifestKind(meta[KindMeta]),
+			Owners:      sous.OwnerSet{},
+		},
+		ExecutorData: &nomadTaskData{jobID: j.ID, jobType: j.Type},
+		SchedulerURL: fmt.Sprintf("%s/v1/job/%s", strings.TrimRight(baseURL, "/"), j.ID),
+	}
+	if j.Periodic != nil {
+		ds.Schedule = j.Periodic.Spec
+	}
+
+	if o, ok := meta[OwnersMeta]; ok {
+		var owners []string
+		if err := json.Unmarshal([]byte(o), &owners); err != nil {
+			return nil, errors.Wrapf(err, "%s owners", j.ID)
+		}
+		for _, owner := range owners {
+			ds.Owners.Add(owner)
+		}
+	}
+	if md, ok := meta[MetadataMeta]; ok {
+		if err := json.Unmarshal([]byte(md), &ds.Metadata); err != nil {
+			return nil, errors.Wrapf(err, "%s metadata", j.ID)
+		}
+	}
+
+	if len(j.TaskGroups) != 1 || len(j.TaskGroups[0].Tasks) != 1 {
+		return nil, errors.Errorf("%s does not have exactly one task group with one task", j.ID)
+	}
+	g := j.TaskGroups[0]
+	t := g.Tasks[0]
+	if g.Count != nil {
+		ds.NumInstances = *g.Count
+	}
+
+	ports := 0
+	for _, n := range g.Networks {
+		ports += len(n.DynamicPorts)
+	}
+	unpackResources(ds, t, ports)
+	unpackEnv(ds, t, ports)
+	if rj, ok := meta[sous.SecretRefsLabel]; ok {
+		refs := map[string]string{}
+		if err := json.Unmarshal([]byte(rj), &refs); err != nil {
+			return nil, errors.Wrapf(err, "%s secret references", j.ID)
+		}
+		ds.Env.RestoreSecretRefs(refs)
+	}
+	if err := unpackVolumes(ds, t); err != nil {
+		return nil, errors.Wrapf(err, "%s volumes", j.ID)
+	}
+	if err := unpackStartup(ds, j, t); err != nil {
+		return nil, errors.Wrapf(err, "%s startup", j.ID)
+	}
+	return ds, nil
+}
+
+func unpackResources(ds *sous.DeployState, t *task, ports int) {
+	var cpu, mem int
+	if t.Resources != nil {
+		if t.Resources.CPU != nil {
+			cpu = *t.Resources.CPU
+		}
+		if t.Resources.MemoryMB != nil {
+			mem = *t.Resources.MemoryMB
+		}
+	}
+	ds.Resources = sous.Resources{
+		"cpus":   fmt.Sprintf("%f", float64(cpu)/mhzPerCPU),
+		"memory": fmt.Sprintf("%d", mem),
+		"ports":  fmt.Sprintf("%d", ports),
+	}
+}
+
+func unpackEnv(ds *sous.DeployState, t *task, ports int) {
+	injected := map[string]bool{}
+	for i := 0; i < ports; i++ {
+		injected[portEnvName(i)] = true
+	}
+	ds.Env = sous.Env{}
+	for n, v := range t.Env {
+		if injected[n] {
+			continue
+		}
+		ds.Env[n] = v
+	}
+}
+
+func unpackVolumes(ds *sous.DeployState, t *task) error {
+	raw, ok := t.Config["volumes"]
+	if !ok {
+		return nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return errors.Errorf("volumes is a %T, not a list", raw)
+	}
+	for _, item := range list {
+		spec, ok := item.(string)
+		if !ok {
+			return errors.Errorf("volume %v is a %T, not a string", item, item)
+		}
+		parts := strings.Split(spec, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return errors.Errorf("cannot parse volume %q", spec)
+		}
+		mode := sous.ReadWrite
+		if len(parts) == 3 && strings.ToUpper(parts[2]) == string(sous.ReadOnly) {
+			mode = sous.ReadOnly
+		}
+		ds.DeployConfig.Volumes = append(ds.DeployConfig.Volumes, &sous.Volume{Host: parts[0], Container: parts[1], Mode: mode})
+	}
+	return nil
+}
+
+func unpackStartup(ds *sous.DeployState, j *job, t *task) error {
+	if s, ok := j.Meta[StartupMeta]; ok {
+		if err := json.Unmarshal([]byte(s), &ds.Startup); err != nil {
+			return err
+		}
+	}
+	if j.Update != nil && j.Update.HealthyDeadline > 0 {
+		ds.Startup.Timeout = fromNanos(j.Update.HealthyDeadline)
+	}
+	if len(t.Services) == 0 || len(t.Services[0].Checks) == 0 {
+		return nil
+	}
+
+	c := t.Services[0].Checks[0]
+	s := &ds.Startup
+	if !strings.EqualFold(s.CheckReadyProtocol, c.Protocol) {
+		s.CheckReadyProtocol = strings.ToUpper(c.Protocol)
+	}
+	s.CheckReadyURIPath = c.Path
+	s.CheckReadyInterval = fromNanos(c.Interval)
+	s.CheckReadyURITimeout = fromNanos(c.Timeout)
+	if c.CheckRestart != nil {
+		s.CheckReadyRetries = c.CheckRestart.Limit
+		s.ConnectDelay = fromNanos(c.CheckRestart.Grace)
+	}
+	if idx, err := strconv.Atoi(strings.TrimPrefix(c.PortLabel, "port")); err == nil {
+		s.CheckReadyPortIndex = idx
+	}
+	return nil
+}
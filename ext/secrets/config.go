@@ -0,0 +1,16 @@
+package secrets
+
+// Config selects where the secret references in deployments' Env are
+// resolved.
+type Config struct {
+	// Dir is a directory holding a file for each secret path. A reference
+	// without a key resolves to the whole file; one with a key, to that
+	// field of the JSON object in the file.
+	Dir string `env:"SOUS_SECRETS_DIR"`
+	// VaultAddr is the URL of a Vault server, whose KV secrets engine (of
+	// either version) holds the secrets. It takes precedence over Dir.
+	VaultAddr string `env:"SOUS_VAULT_ADDR"`
+	// VaultTokenFile is the path of a file holding the Vault token. If it is
+	// empty, the token is taken from the VAULT_TOKEN environment variable.
+	VaultTokenFile string `env:"SOUS_VAULT_TOKEN_FILE"`
+}
@@ -0,0 +1,112 @@
+// Package secrets resolves the secret references in deployments' Env, from
+// files in a directory or from Vault.
+package secrets
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+type (
+	// FileResolver resolves secrets from the files in a directory: the
+	// reference secret://db/app#password names the "password" field of the
+	// JSON object in the file db/app.
+	FileResolver struct {
+		Dir string
+	}
+
+	// VaultResolver resolves secrets from a Vault server: the reference
+	// secret://secret/data/app#password names the "password" field of the
+	// secret read from the path secret/data/app.
+	VaultResolver struct {
+		Addr, Token string
+		Client      *http.Client
+	}
+)
+
+// New returns the SecretResolver cfg selects, or nil if it selects none.
+func New(cfg Config) (sous.SecretResolver, error) {
+	if cfg.VaultAddr != "" {
+		token := os.Getenv("VAULT_TOKEN")
+		if cfg.VaultTokenFile != "" {
+			b, err := ioutil.ReadFile(cfg.VaultTokenFile)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading Vault token")
+			}
+			token = strings.TrimSpace(string(b))
+		}
+		return &VaultResolver{Addr: strings.TrimSuffix(cfg.VaultAddr, "/"), Token: token, Client: http.DefaultClient}, nil
+	}
+	if cfg.Dir != "" {
+		return &FileResolver{Dir: cfg.Dir}, nil
+	}
+	return nil, nil
+}
+
+// ResolveSecret implements sous.SecretResolver on FileResolver.
+func (r *FileResolver) ResolveSecret(ref sous.SecretRef) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(r.Dir, filepath.FromSlash(ref.Path)))
+	if err != nil {
+		return "", err
+	}
+	if ref.Key == "" {
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return "", errors.Wrapf(err, "secret %s is not a JSON object", ref.Path)
+	}
+	return field(ref, fields)
+}
+
+// ResolveSecret implements sous.SecretResolver on VaultResolver.
+func (r *VaultResolver) ResolveSecret(ref sous.SecretRef) (string, error) {
+	if ref.Key == "" {
+		return "", errors.Errorf("%s must name a key: Vault secrets have several", ref)
+	}
+	req, err := http.NewRequest("GET", r.Addr+"/v1/"+ref.Path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", r.Token)
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("reading %s from Vault: %s", ref.Path, resp.Status)
+	}
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", errors.Wrapf(err, "parsing %s from Vault", ref.Path)
+	}
+	// Version 2 of the KV engine nests the fields, alongside metadata.
+	if nested, ok := secret.Data["data"].(map[string]interface{}); ok {
+		if _, ok := secret.Data["metadata"]; ok {
+			secret.Data = nested
+		}
+	}
+	return field(ref, secret.Data)
+}
+
+func field(ref sous.SecretRef, fields map[string]interface{}) (string, error) {
+	v, ok := fields[ref.Key]
+	if !ok {
+		return "", errors.Errorf("secret %s has no key %q", ref.Path, ref.Key)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", errors.Errorf("secret %s key %q is not a string", ref.Path, ref.Key)
+	}
+	return s, nil
+}
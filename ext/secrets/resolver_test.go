@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opentable/sous/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileResolver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "db"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "db", "app"), []byte(`{"password":"hunter2","port":5432}`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte("s3cr3t\n"), 0600))
+
+	r, err := New(Config{Dir: dir})
+	require.NoError(t, err)
+
+	v, err := r.ResolveSecret(sous.SecretRef{Path: "db/app", Key: "password"})
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", v)
+
+	v, err = r.ResolveSecret(sous.SecretRef{Path: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", v)
+
+	_, err = r.ResolveSecret(sous.SecretRef{Path: "db/app", Key: "port"})
+	assert.Error(t, err)
+	_, err = r.ResolveSecret(sous.SecretRef{Path: "db/app", Key: "user"})
+	assert.Error(t, err)
+	_, err = r.ResolveSecret(sous.SecretRef{Path: "db/other", Key: "password"})
+	assert.Error(t, err)
+}
+
+func TestVaultResolver(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		case "/v1/secret/data/app":
+			w.Write([]byte(`{"data":{"data":{"password":"hunter2"},"metadata":{"version":3}}}`))
+		case "/v1/kv/app":
+			w.Write([]byte(`{"data":{"password":"swordfish"}}`))
+		}
+	}))
+	defer vault.Close()
+
+	tokenFile, err := ioutil.TempFile("", "vault-token")
+	require.NoError(t, err)
+	defer os.Remove(tokenFile.Name())
+	tokenFile.WriteString("root\n")
+	tokenFile.Close()
+
+	r, err := New(Config{VaultAddr: vault.URL + "/", VaultTokenFile: tokenFile.Name()})
+	require.NoError(t, err)
+
+	v, err := r.ResolveSecret(sous.SecretRef{Path: "secret/data/app", Key: "password"})
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", v)
+
+	v, err = r.ResolveSecret(sous.SecretRef{Path: "kv/app", Key: "password"})
+	require.NoError(t, err)
+	assert.Equal(t, "swordfish", v)
+
+	_, err = r.ResolveSecret(sous.SecretRef{Path: "kv/other", Key: "password"})
+	assert.Error(t, err)
+	_, err = r.ResolveSecret(sous.SecretRef{Path: "kv/app"})
+	assert.Error(t, err)
+
+	r.(*VaultResolver).Token = "wrong"
+	_, err = r.ResolveSecret(sous.SecretRef{Path: "kv/app", Key: "password"})
+	assert.Error(t, err)
+
+	r, err = New(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, r)
+}
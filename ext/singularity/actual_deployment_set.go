@@ -214,10 +214,15 @@ func (sc *deployer) getSingularityRequestParents(client singClient) (dtos.Singul
 	return singRequests, errors.Wrap(err, "getting request")
 }
 
+// convertSingularityRequestParentsToSingReqs skips the requests running
+// canaries, which belong to the deployment of another request.
 func convertSingularityRequestParentsToSingReqs(url string, client singClient, srp dtos.SingularityRequestParentList) []SingReq {
 	reqs := make([]SingReq, 0, len(srp))
 
 	for _, sr := range srp {
+		if isCanaryRequestID(reqID(sr)) {
+			continue
+		}
 		reqs = append(reqs, SingReq{url, client, sr})
 	}
 	return reqs
@@ -0,0 +1,71 @@
+package singularity
+
+import (
+	"strings"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// canarySuffix ends the ID of the request which runs the canary of a
+// deployment, beside the deployment's own request.
+const canarySuffix = "-canary"
+
+// canaryRequestID returns the ID of the request running the canary of d.
+func canaryRequestID(d *sous.Deployable) (string, error) {
+	reqID, err := computeRequestID(d)
+	if err != nil {
+		return "", err
+	}
+	if len(reqID) > maxRequestIDLen-len(canarySuffix) {
+		reqID = reqID[:maxRequestIDLen-len(canarySuffix)]
+	}
+	return reqID + canarySuffix, nil
+}
+
+// isCanaryRequestID returns true if reqID names a canary request, which is
+// not itself a deployment.
+func isCanaryRequestID(reqID string) bool {
+	return strings.HasSuffix(reqID, canarySuffix)
+}
+
+// DeployCanary implements sous.CanaryDeployer on deployer. The canary runs as
+// a request of its own, so the deployment's request keeps all its instances.
+func (r *deployer) DeployCanary(pair *sous.DeployablePair) (err error) {
+	reportDeployerMessage("Deploying canary", pair, nil, nil, nil, logging.InformationLevel, r.log)
+	defer rectifyRecover(pair, "DeployCanary", &err)
+	reqID, err := canaryRequestID(pair.Post)
+	if err != nil {
+		return err
+	}
+	if pair.UUID == uuid.Nil {
+		pair.UUID = uuid.NewV4()
+	}
+	if err := r.Client.PostRequest(*pair.Post, reqID); err != nil {
+		return err
+	}
+	return r.Client.Deploy(*pair.Post, reqID, computeDeployIDFromUUID(pair.Post, pair.UUID))
+}
+
+// CanaryStatus implements sous.CanaryDeployer on deployer.
+func (r *deployer) CanaryStatus(reg sous.Registry, clusters sous.Clusters, pair *sous.DeployablePair) (*sous.DeployState, error) {
+	reqID, err := canaryRequestID(pair.Post)
+	if err != nil {
+		return nil, err
+	}
+	return r.requestStatus(reg, clusters, pair, reqID)
+}
+
+// RemoveCanary implements sous.CanaryDeployer on deployer.
+func (r *deployer) RemoveCanary(d *sous.Deployable) error {
+	reqID, err := canaryRequestID(d)
+	if err != nil {
+		return err
+	}
+	if d.Cluster == nil {
+		return errors.Errorf("no cluster for canary %s", reqID)
+	}
+	return r.Client.DeleteRequest(d.Cluster.BaseURL, reqID, "removing canary")
+}
@@ -0,0 +1,73 @@
+package singularity
+
+import (
+	"testing"
+
+	"github.com/opentable/go-singularity/dtos"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func canaryDeployable(numInstances int) *sous.Deployable {
+	return &sous.Deployable{
+		BuildArtifact: &sous.BuildArtifact{Name: "build-artifact", Type: "docker"},
+		Deployment: &sous.Deployment{
+			SourceID:     sous.MustNewSourceID("github.com/user/repo", "", "2.0.0"),
+			DeployConfig: sous.DeployConfig{NumInstances: numInstances},
+			ClusterName:  "some-cluster",
+			Cluster:      &sous.Cluster{BaseURL: "http://sing.example.com"},
+			Kind:         sous.ManifestKindService,
+		},
+	}
+}
+
+func TestCanaryRequestID(t *testing.T) {
+	d := canaryDeployable(1)
+	reqID, err := computeRequestID(d)
+	require.NoError(t, err)
+	canaryID, err := canaryRequestID(d)
+	require.NoError(t, err)
+	assert.Equal(t, reqID+"-canary", canaryID)
+	assert.True(t, isCanaryRequestID(canaryID))
+	assert.False(t, isCanaryRequestID(reqID))
+
+	d.Flavor = "wellwehavetohaveaflavorforthisservicebecausethereseighteeninstancesofitwithweirdquirkstotheirconfig"
+	canaryID, err = canaryRequestID(d)
+	require.NoError(t, err)
+	assert.Len(t, canaryID, maxRequestIDLen)
+	assert.True(t, isCanaryRequestID(canaryID))
+}
+
+func TestDeployCanary(t *testing.T) {
+	drc := sous.NewDummyRectificationClient()
+	dep := NewDeployer(drc, logging.SilentLogSet()).(*deployer)
+
+	post := canaryDeployable(2)
+	pair := &sous.DeployablePair{Prior: canaryDeployable(10), Post: post}
+	require.NoError(t, dep.DeployCanary(pair))
+
+	// Only the canary's own request is posted; the prior's is left alone.
+	require.Len(t, drc.Created, 1)
+	assert.Equal(t, 2, drc.Created[0].NumInstances)
+	assert.Len(t, drc.Deployed, 1)
+
+	require.NoError(t, dep.RemoveCanary(post))
+	canaryID, err := canaryRequestID(post)
+	require.NoError(t, err)
+	require.Len(t, drc.Deleted, 1)
+	assert.Equal(t, canaryID, drc.Deleted[0].Reqid)
+	assert.Equal(t, "http://sing.example.com", drc.Deleted[0].Cluster)
+}
+
+func TestConvertSingularityRequestParentsToSingReqs_SkipsCanaries(t *testing.T) {
+	srp := dtos.SingularityRequestParentList{
+		{Request: &dtos.SingularityRequest{Id: "repo---some_cluster-1234"}},
+		{Request: &dtos.SingularityRequest{Id: "repo---some_cluster-1234-canary"}},
+	}
+	reqs := convertSingularityRequestParentsToSingReqs("http://sing.example.com", nil, srp)
+	if assert.Len(t, reqs, 1) {
+		assert.Equal(t, "repo---some_cluster-1234", reqs[0].ReqParent.Request.Id)
+	}
+}
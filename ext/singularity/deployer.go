@@ -64,6 +64,12 @@ type (
 		DeleteRequest(cluster, reqID, message string) error
 	}
 
+	// scalingClient is a rectificationClient which can also change the
+	// number of instances of a request.
+	scalingClient interface {
+		Scale(cluster, reqID string, instanceCount int, message string) error
+	}
+
 	// DTOMap is shorthand for map[string]interface{}
 	dtoMap map[string]interface{}
 )
@@ -164,6 +170,23 @@ func (r *deployer) Rectify(pair *sous.DeployablePair) sous.DiffResolution {
 	}
 }
 
+// ScaleDeployment implements sous.InstanceScaler on deployer, scaling the
+// Singularity request for d.
+func (r *deployer) ScaleDeployment(d *sous.Deployment, instances int, message string) error {
+	sc, ok := r.Client.(scalingClient)
+	if !ok {
+		return errors.Errorf("%T cannot scale deployments", r.Client)
+	}
+	if d.Cluster == nil {
+		return errors.Errorf("deployment %s has no cluster", d.ID())
+	}
+	reqID, err := MakeRequestID(d.ID())
+	if err != nil {
+		return err
+	}
+	return sc.Scale(d.Cluster.BaseURL, reqID, instances, message)
+}
+
 func (r *deployer) SetSingularityFactory(fn func(string) singClient) {
 	r.singFac = fn
 }
@@ -393,11 +393,20 @@ func (db *deploymentBuilder) restoreFromMetadata() error {
 }
 
 func (db *deploymentBuilder) unpackDeployConfig() error {
-	db.Target.Env = db.deploy.Env
-	messages.ReportLogFieldsMessage("UnpackDeployConfig", logging.ExtraDebug1Level, db.log, db.reqID, db.deploy.Env)
-	if db.Target.Env == nil {
-		db.Target.Env = make(map[string]string)
+	db.Target.Env = make(map[string]string, len(db.deploy.Env))
+	for k, v := range db.deploy.Env {
+		db.Target.Env[k] = v
+	}
+	// Secrets were resolved when deployed: the references are what was
+	// intended, and the values are not to be logged.
+	if rj, ok := db.deploy.Metadata[sous.SecretRefsLabel]; ok {
+		refs := map[string]string{}
+		if err := json.Unmarshal([]byte(rj), &refs); err != nil {
+			return malformedResponse{fmt.Sprintf("Deploy Metadata %s is not valid JSON: %v", sous.SecretRefsLabel, err)}
+		}
+		db.Target.Env.RestoreSecretRefs(refs)
 	}
+	messages.ReportLogFieldsMessage("UnpackDeployConfig", logging.ExtraDebug1Level, db.log, db.reqID, db.Target.Env)
 
 	singRez := db.deploy.Resources
 	if singRez == nil {
@@ -157,7 +157,9 @@ func TestBuildDeployment(t *testing.T) {
 			Metadata: map[string]string{
 				"com.opentable.sous.clustername": "left",
 				"com.opentable.sous.flavor":      "vanilla",
+				"com.opentable.sous.secret_refs": `{"DB_PASSWORD":"secret://db/app#password"}`,
 			},
+			Env: map[string]string{"DB_PASSWORD": "hunter2", "PLAIN": "value"},
 
 			Healthcheck: &dtos.HealthcheckOptions{
 				Uri: "/health-report",
@@ -212,6 +214,8 @@ func TestBuildDeployment(t *testing.T) {
 	assert.Equal(t, actual.Startup.CheckReadyURITimeout, 350)
 
 	assert.Equal(t, actual.Startup.Timeout, 700)
+
+	assert.Equal(t, sous.Env{"DB_PASSWORD": "secret://db/app#password", "PLAIN": "value"}, actual.Env)
 }
 
 func TestBuildDeployment_failed_deploy(t *testing.T) {
@@ -1,6 +1,7 @@
 package singularity
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -39,19 +40,33 @@ type (
 		singClients map[string]*singularity.Client
 		sync.RWMutex
 		labeller sous.ImageLabeller
+		secrets  sous.SecretResolver
 	}
 
+	// RectiAgentOption is an option for configuring a RectiAgent.
+	RectiAgentOption func(*RectiAgent)
+
 	singularityTaskData struct {
 		requestID string
 	}
 )
 
 // NewRectiAgent returns a set-up RectiAgent
-func NewRectiAgent(l sous.ImageLabeller) *RectiAgent {
-	return &RectiAgent{
+func NewRectiAgent(l sous.ImageLabeller, opts ...RectiAgentOption) *RectiAgent {
+	ra := &RectiAgent{
 		singClients: make(map[string]*singularity.Client),
 		labeller:    l,
 	}
+	for _, opt := range opts {
+		opt(ra)
+	}
+	return ra
+}
+
+// OptSecretResolver sets the SecretResolver which resolves secret references
+// in the Env of each deployment.
+func OptSecretResolver(r sous.SecretResolver) RectiAgentOption {
+	return func(ra *RectiAgent) { ra.secrets = r }
 }
 
 // mapResources produces a dtoMap appropriate for building a Singularity
@@ -82,13 +97,39 @@ func (ra *RectiAgent) Deploy(d sous.Deployable, reqID, depID string) error {
 	}
 
 	messages.ReportLogFieldsMessage("Sending Deploy req to singularity Client", logging.DebugLevel, Log, depReq)
-	_, err = ra.singularityClient(clusterURI).Deploy(depReq)
+	resolved, err := ra.resolveSecrets(depReq)
+	if err != nil {
+		return err
+	}
+	_, err = ra.singularityClient(clusterURI).Deploy(resolved)
 	if err != nil {
 		messages.ReportLogFieldsMessage("Singularity client returned following error", logging.WarningLevel, Log, depReq, reqID, err)
 	}
 	return err
 }
 
+// resolveSecrets returns a copy of depReq in which the secret references in
+// the Env are replaced by their values. depReq itself, which may be logged,
+// keeps the references, and records them in its metadata, from which they are
+// restored when the deploy is read back.
+func (ra *RectiAgent) resolveSecrets(depReq *dtos.SingularityDeployRequest) (*dtos.SingularityDeployRequest, error) {
+	env, refs, err := sous.Env(depReq.Deploy.Env).ResolveSecrets(ra.secrets)
+	if err != nil || len(refs) == 0 {
+		return depReq, err
+	}
+	rj, err := json.Marshal(refs)
+	if err != nil {
+		return nil, err
+	}
+	depReq.Deploy.Metadata[sous.SecretRefsLabel] = string(rj)
+
+	dep := *depReq.Deploy
+	dep.Env = env
+	resolved := *depReq
+	resolved.Deploy = &dep
+	return &resolved, nil
+}
+
 func buildDeployRequest(d sous.Deployable, reqID, depID string, metadata map[string]string) (*dtos.SingularityDeployRequest, error) {
 	var depReq swaggering.Fielder
 	dockerImage := d.BuildArtifact.Name
@@ -283,7 +324,7 @@ func (ra *RectiAgent) Scale(cluster, reqID string, instanceCount int, message st
 		"ActionId": "SOUS_RECTIFY_" + StripDeployID(uuid.NewV4().String()), // not positive this is appropriate
 		// omitting DurationMillis - bears discussion
 		"Instances":        int32(instanceCount),
-		"Message":          "Sous" + message,
+		"Message":          "Sous: " + message,
 		"SkipHealthchecks": false,
 	})
 
@@ -177,3 +177,33 @@ func TestContainerStartupOptions(t *testing.T) {
 	}
 
 }
+
+type testSecretResolver map[string]string
+
+func (r testSecretResolver) ResolveSecret(ref sous.SecretRef) (string, error) {
+	return r[ref.String()], nil
+}
+
+func TestRectiAgent_resolveSecrets(t *testing.T) {
+	d := sous.Deployable{
+		Deployment:    &sous.Deployment{},
+		BuildArtifact: &sous.BuildArtifact{},
+	}
+	d.Env = sous.Env{"DB_PASSWORD": "secret://db/app#password", "PLAIN": "value"}
+	dr, err := buildDeployRequest(d, "fake-request-id", "fake-deploy-id", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewRectiAgent(nil).resolveSecrets(dr)
+	assert.Error(t, err, "references need a resolver")
+
+	ra := NewRectiAgent(nil, OptSecretResolver(testSecretResolver{"secret://db/app#password": "hunter2"}))
+	resolved, err := ra.resolveSecrets(dr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "hunter2", "PLAIN": "value"}, resolved.Deploy.Env)
+	assert.Equal(t, "secret://db/app#password", dr.Deploy.Env["DB_PASSWORD"], "the logged request keeps the reference")
+	assert.JSONEq(t, `{"DB_PASSWORD":"secret://db/app#password"}`, resolved.Deploy.Metadata[sous.SecretRefsLabel])
+}
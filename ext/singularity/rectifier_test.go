@@ -172,6 +172,22 @@ func TestModifyScale(t *testing.T) {
 	}
 }
 
+func TestScaleDeployment(t *testing.T) {
+	client := sous.NewDummyRectificationClient()
+	deployer := NewDeployer(client, logging.SilentLogSet()).(sous.InstanceScaler)
+
+	d := baseDeployablePair().Post.Deployment
+	require.NoError(t, deployer.ScaleDeployment(d, 6, "autoscaling"))
+
+	reqID, err := MakeRequestID(d.ID())
+	require.NoError(t, err)
+	if assert.Len(t, client.Scaled, 1) {
+		assert.Equal(t, "cluster", client.Scaled[0].Cluster)
+		assert.Equal(t, reqID, client.Scaled[0].Reqid)
+		assert.Equal(t, 6, client.Scaled[0].Instances)
+	}
+}
+
 func TestModifyImage(t *testing.T) {
 	assert := assert.New(t)
 
@@ -10,12 +10,17 @@ import (
 
 // Status implements sous.Deployer on deployer.
 func (r *deployer) Status(reg sous.Registry, clusters sous.Clusters, pair *sous.DeployablePair) (*sous.DeployState, error) {
-	var url string
-
 	reqID, err := r.getRequestID(pair.Post)
 	if err != nil {
 		return nil, err
 	}
+	return r.requestStatus(reg, clusters, pair, reqID)
+}
+
+// requestStatus returns the state of pair.Post as deployed by the request
+// reqID.
+func (r *deployer) requestStatus(reg sous.Registry, clusters sous.Clusters, pair *sous.DeployablePair, reqID string) (*sous.DeployState, error) {
+	var url string
 
 	clusterName := pair.Post.Deployment.ClusterName
 	if cluster, has := clusters[clusterName]; has {
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+// appendHistory records changes in the HistoryFile, one JSON object per line.
+func (dsm *DiskStateManager) appendHistory(changes []sous.ManifestChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	dsm.historyLock.Lock()
+	defer dsm.historyLock.Unlock()
+
+	f, err := os.OpenFile(dsm.HistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "opening history")
+	}
+	enc := json.NewEncoder(f)
+	for _, c := range changes {
+		if err := enc.Encode(c); err != nil {
+			f.Close()
+			return errors.Wrapf(err, "writing history")
+		}
+	}
+	return f.Close()
+}
+
+// ReadHistory implements sous.HistoryReader on DiskStateManager.
+func (dsm *DiskStateManager) ReadHistory(filter *sous.ResolveFilter) ([]sous.ManifestChange, error) {
+	changes := []sous.ManifestChange{}
+	if dsm.HistoryFile == "" {
+		return changes, nil
+	}
+	dsm.historyLock.Lock()
+	defer dsm.historyLock.Unlock()
+
+	f, err := os.Open(dsm.HistoryFile)
+	if os.IsNotExist(err) {
+		return changes, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening history")
+	}
+	defer f.Close()
+
+	lines := bufio.NewScanner(f)
+	lines.Buffer(nil, 16*1024*1024)
+	for lines.Scan() {
+		c := sous.ManifestChange{}
+		if err := json.Unmarshal(lines.Bytes(), &c); err != nil {
+			return nil, errors.Wrapf(err, "reading history")
+		}
+		if filter.FilterManifestChange(c) {
+			changes = append(changes, c)
+		}
+	}
+	return changes, errors.Wrapf(lines.Err(), "reading history")
+}
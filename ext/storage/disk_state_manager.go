@@ -15,7 +15,10 @@ package storage
 import (
 	"fmt"
 	"io"
+	"os"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/opentable/hy"
 	"github.com/opentable/sous/lib"
@@ -30,6 +33,10 @@ type (
 	DiskStateManager struct {
 		BaseDir string
 		Codec   *hy.Codec
+		// HistoryFile is a file outside BaseDir to which every change to a
+		// manifest is appended. If it is empty, no history is kept.
+		HistoryFile string
+		historyLock sync.Mutex
 	}
 )
 
@@ -102,11 +109,38 @@ func (dsm *DiskStateManager) ReadState() (*sous.State, error) {
 
 // WriteState records the entire intended state of the world to a dir.
 func (dsm *DiskStateManager) WriteState(s *sous.State, u sous.User) error {
+	changes, err := dsm.writeState(s, u)
+	if err != nil {
+		return err
+	}
+	return dsm.appendHistory(changes)
+}
+
+// writeState writes s to disk, and returns the changes it made for the
+// history, without recording them: the GitStateManager only records them
+// once they have been pushed.
+func (dsm *DiskStateManager) writeState(s *sous.State, u sous.User) ([]sous.ManifestChange, error) {
 	if e := repairState(s); e != nil {
-		return e
+		return nil, e
+	}
+	var prior *sous.State
+	if dsm.HistoryFile != "" {
+		var err error
+		if prior, err = dsm.ReadState(); err != nil {
+			if !os.IsNotExist(errors.Cause(err)) {
+				return nil, errors.Wrapf(err, "reading prior state for history")
+			}
+			prior = sous.NewState()
+		}
 	}
 	reportDebugDiskStateManagerMessage("Writing state to disk", nil, nil, logging.Log)
-	return dsm.Codec.Write(dsm.BaseDir, s)
+	if err := dsm.Codec.Write(dsm.BaseDir, s); err != nil {
+		return nil, err
+	}
+	if prior == nil {
+		return nil, nil
+	}
+	return sous.ManifestChanges(prior, s, u, time.Now()), nil
 }
 
 type diskStateManagerMessage struct {
@@ -1,14 +1,18 @@
 package storage
 
 import (
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/yaml"
 	"github.com/pkg/errors"
 	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWriteState(t *testing.T) {
@@ -241,3 +245,46 @@ func exampleState() *sous.State {
 	}
 	return s
 }
+
+func TestDiskStateManager_History(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dsm := NewDiskStateManager(filepath.Join(dir, "state"))
+	dsm.HistoryFile = filepath.Join(dir, "history")
+
+	s := exampleState()
+	require.NoError(t, dsm.WriteState(s, testUser))
+
+	m, ok := s.Manifests.Get(sous.ManifestID{Source: sous.SourceLocation{Repo: "github.com/user/project"}})
+	require.True(t, ok)
+	spec := m.Deployments["cluster-1"]
+	spec.NumInstances = 7
+	m.Deployments["cluster-1"] = spec
+	user := testUser
+	user.TraceID = "trace-1"
+	require.NoError(t, dsm.WriteState(s, user))
+
+	all, err := dsm.ReadHistory(&sous.ResolveFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, []string{"manifest added"}, all[0].Diffs)
+	assert.Nil(t, all[0].Prior)
+
+	last := all[2]
+	assert.Equal(t, m.ID(), last.ManifestID)
+	assert.Equal(t, testUser, last.User)
+	assert.Equal(t, sous.TraceID("trace-1"), last.TraceID)
+	assert.Len(t, last.Diffs, 1)
+	assert.Contains(t, last.Diffs[0], "cluster-1")
+	assert.Equal(t, 7, last.Post.Deployments["cluster-1"].NumInstances)
+
+	byRepo, err := dsm.ReadHistory(&sous.ResolveFilter{Repo: sous.NewResolveFieldMatcher("github.com/user/project")})
+	require.NoError(t, err)
+	assert.Len(t, byRepo, 2)
+
+	byCluster, err := dsm.ReadHistory(&sous.ResolveFilter{Cluster: sous.NewResolveFieldMatcher("no-such-cluster")})
+	require.NoError(t, err)
+	assert.Len(t, byCluster, 0)
+}
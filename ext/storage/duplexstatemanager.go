@@ -48,3 +48,15 @@ func (dup *DuplexStateManager) WriteState(state *sous.State, user sous.User) err
 	reportWriting(dup.log, start, state, err)
 	return err
 }
+
+// ReadHistory implements sous.HistoryReader on DuplexStateManager, reading
+// from the primary StateManager if it keeps history, and otherwise from the
+// secondary.
+func (dup *DuplexStateManager) ReadHistory(filter *sous.ResolveFilter) ([]sous.ManifestChange, error) {
+	for _, sm := range []sous.StateManager{dup.primary, dup.secondary} {
+		if hr, ok := sm.(sous.HistoryReader); ok {
+			return hr.ReadHistory(filter)
+		}
+	}
+	return nil, errors.Errorf("neither %T nor %T keeps history", dup.primary, dup.secondary)
+}
@@ -153,7 +153,8 @@ func (gsm *GitStateManager) assertOneChange() error {
 }
 
 // WriteState writes sous state to disk, then attempts to push it to Remote.
-// If the push fails, the state is reset and an error is returned.
+// If the push fails, the state is reset and an error is returned. The changes
+// are only recorded in the history once they have been pushed.
 func (gsm *GitStateManager) WriteState(s *sous.State, u sous.User) error {
 	gsm.Lock()
 	defer gsm.Unlock()
@@ -172,7 +173,8 @@ func (gsm *GitStateManager) WriteState(s *sous.State, u sous.User) error {
 	}
 	defer gsm.git("tag", "-d", tn)
 
-	if err := gsm.DiskStateManager.WriteState(s, u); err != nil {
+	changes, err := gsm.DiskStateManager.writeState(s, u)
+	if err != nil {
 		return err
 	}
 	if err := gsm.git(`add`, `.`); err != nil {
@@ -218,7 +220,7 @@ func (gsm *GitStateManager) WriteState(s *sous.State, u sous.User) error {
 		err = gsm.git("push", "-u", "origin", "master")
 		if err == nil {
 			// Success.
-			return nil
+			return gsm.appendHistory(changes)
 		}
 		messages.ReportLogFieldsMessage("git push failed; trying again with # attempts left", logging.DebugLevel, logging.Log, remainingAttempts, err)
 		gsm.reset(tn)
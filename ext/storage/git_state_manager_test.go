@@ -327,3 +327,28 @@ func TestGitReadState_empty(t *testing.T) {
 		t.Errorf("got len %d; want %d", d.Len(), 0)
 	}
 }
+
+func TestGitStateManager_History(t *testing.T) {
+	require := require.New(t)
+	gsm, _ := setupManagers(t)
+	gsm.HistoryFile = filepath.Join("testdata", "target-history")
+	defer os.Remove(gsm.HistoryFile)
+
+	s, err := gsm.ReadState()
+	require.NoError(err)
+	s.Manifests.Add(&sous.Manifest{Source: sous.SourceLocation{Repo: "github.com/opentable/brandnew"}})
+	require.NoError(gsm.WriteState(s, testUser))
+	changes, err := gsm.ReadHistory(&sous.ResolveFilter{})
+	require.NoError(err)
+	require.Len(changes, 1)
+
+	// A change which cannot be pushed is not recorded.
+	runScript(t, `git remote set-url origin ../no-such-origin`, `testdata/target`)
+	s, err = gsm.ReadState()
+	require.NoError(err)
+	s.Manifests.Add(&sous.Manifest{Source: sous.SourceLocation{Repo: "github.com/opentable/newhotness"}})
+	require.Error(gsm.WriteState(s, testUser))
+	changes, err = gsm.ReadHistory(&sous.ResolveFilter{})
+	require.NoError(err)
+	require.Len(changes, 1)
+}
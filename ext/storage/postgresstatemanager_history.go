@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/sqlgen"
+	"github.com/pkg/errors"
+)
+
+// ReadHistory implements sous.HistoryReader on PostgresStateManager.
+func (m PostgresStateManager) ReadHistory(filter *sous.ResolveFilter) ([]sous.ManifestChange, error) {
+	context := context.TODO()
+	tx, err := m.db.BeginTx(context, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening transaction")
+	}
+	defer func(tx *sql.Tx) {
+		// ignoring error - since if the Tx is committed, we would expect an error on rollback
+		tx.Rollback()
+	}(tx)
+
+	where, args := historyWhere(filter)
+	changes := []sous.ManifestChange{}
+	if err := loadTableWhere(context, m.log, tx, "manifest_history",
+		`select
+			"repo", "dir", "flavor", "changed_at",
+			"user_name", "user_email", "user_emergency", "trace_id",
+			"prior", "post", "diffs"
+		from manifest_history
+		`+where+`
+		order by change_id`, args,
+		func(rows *sql.Rows) error {
+			c := sous.ManifestChange{}
+			var prior, post sql.NullString
+			if err := rows.Scan(
+				&c.ManifestID.Source.Repo, &c.ManifestID.Source.Dir, &c.ManifestID.Flavor, &c.Time,
+				&c.User.Name, &c.User.Email, &c.User.Emergency, &c.TraceID,
+				&prior, &post, pq.Array(&c.Diffs),
+			); err != nil {
+				return errors.Wrapf(err, "loadHistory")
+			}
+			var err error
+			if c.Prior, err = unmarshalManifest(prior); err != nil {
+				return errors.Wrapf(err, "loadHistory prior")
+			}
+			if c.Post, err = unmarshalManifest(post); err != nil {
+				return errors.Wrapf(err, "loadHistory post")
+			}
+			if filter.FilterManifestChange(c) {
+				changes = append(changes, c)
+			}
+			return nil
+		}); err != nil {
+		return nil, err
+	}
+
+	return changes, tx.Commit()
+}
+
+// historyWhere returns a where clause, and its parameters, selecting the
+// changes to the manifests filter matches. Matching clusters needs the
+// manifests themselves, so that is left to FilterManifestChange.
+func historyWhere(filter *sous.ResolveFilter) (string, []interface{}) {
+	clauses := []string{}
+	args := []interface{}{}
+	for _, f := range []struct {
+		column  string
+		matcher sous.ResolveFieldMatcher
+	}{
+		{`"repo"`, filter.Repo},
+		{`"dir"`, filter.Offset},
+		{`"flavor"`, filter.Flavor},
+	} {
+		if f.matcher.All() {
+			continue
+		}
+		args = append(args, *f.matcher.Match)
+		clauses = append(clauses, fmt.Sprintf("%s = $%d", f.column, len(args)))
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return "where " + strings.Join(clauses, " and "), args
+}
+
+func storeHistory(ctx context.Context, log logging.LogSink, tx *sql.Tx, changes []sous.ManifestChange) error {
+	fields := sqlgen.NewFieldset()
+	for _, c := range changes {
+		prior, err := marshalManifest(c.Prior)
+		if err != nil {
+			return err
+		}
+		post, err := marshalManifest(c.Post)
+		if err != nil {
+			return err
+		}
+		fields.Row(func(r sqlgen.RowDef) {
+			r.FD("?", "repo", c.ManifestID.Source.Repo)
+			r.FD("?", "dir", c.ManifestID.Source.Dir)
+			r.FD("?", "flavor", c.ManifestID.Flavor)
+			r.FD("?", "changed_at", c.Time)
+			r.FD("?", "user_name", c.User.Name)
+			r.FD("?", "user_email", c.User.Email)
+			r.FD("?", "user_emergency", c.User.Emergency)
+			r.FD("?", "trace_id", string(c.TraceID))
+			r.FD("?", "prior", prior)
+			r.FD("?", "post", post)
+			r.FD("?", "diffs", pq.Array(c.Diffs))
+		})
+	}
+	if !fields.Potent() {
+		return nil
+	}
+	start := time.Now()
+
+	sql := fields.InsertSQL("manifest_history", "")
+	_, err := tx.ExecContext(ctx, sql, fields.InsertValues()...)
+	reportSQLMessage(log, start, "manifest_history", write, sql, fields.RowCount(), err)
+
+	return err
+}
+
+func marshalManifest(m *sous.Manifest) (sql.NullString, error) {
+	if m == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(m)
+	return sql.NullString{String: string(b), Valid: true}, err
+}
+
+func unmarshalManifest(s sql.NullString) (*sous.Manifest, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	m := &sous.Manifest{}
+	return m, json.Unmarshal([]byte(s.String), m)
+}
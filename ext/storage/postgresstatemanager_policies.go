@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/sqlgen"
+	"github.com/pkg/errors"
+)
+
+// The policies table holds one JSON document for each kind of policy in the
+// Defs, keyed by these names.
+const (
+	freezesPolicy     = "freezes"
+	ownerQuotasPolicy = "owner_quotas"
+	promotionsPolicy  = "promotions"
+	clustersPolicy    = "clusters"
+)
+
+// policies returns pointers to the policies in defs, keyed by their names in
+// the policies table.
+func policies(defs *sous.Defs) map[string]interface{} {
+	clusters := clusterPolicies(defs.Clusters)
+	return map[string]interface{}{
+		freezesPolicy:     &defs.Freezes,
+		ownerQuotasPolicy: &defs.OwnerQuotas,
+		promotionsPolicy:  &defs.Promotions,
+		clustersPolicy:    &clusters,
+	}
+}
+
+// clusterPolicies marshals the policies of a set of clusters, which the
+// clusters table has no columns for. Unmarshalling sets the policies of the
+// clusters already in the set, and ignores the rest.
+type clusterPolicies sous.Clusters
+
+type clusterPolicy struct {
+	RequireApproval bool       `json:",omitempty"`
+	SigningKeys     []string   `json:",omitempty"`
+	Platforms       []string   `json:",omitempty"`
+	Quota           sous.Quota `json:",omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler on clusterPolicies.
+func (cs *clusterPolicies) MarshalJSON() ([]byte, error) {
+	ps := map[string]clusterPolicy{}
+	for name, c := range *cs {
+		ps[name] = clusterPolicy{
+			RequireApproval: c.RequireApproval,
+			SigningKeys:     c.SigningKeys,
+			Platforms:       c.Platforms,
+			Quota:           c.Quota,
+		}
+	}
+	return json.Marshal(ps)
+}
+
+// UnmarshalJSON implements json.Unmarshaler on clusterPolicies.
+func (cs *clusterPolicies) UnmarshalJSON(b []byte) error {
+	ps := map[string]clusterPolicy{}
+	if err := json.Unmarshal(b, &ps); err != nil {
+		return err
+	}
+	for name, p := range ps {
+		c, has := (*cs)[name]
+		if !has {
+			continue
+		}
+		c.RequireApproval = p.RequireApproval
+		c.SigningKeys = p.SigningKeys
+		c.Platforms = p.Platforms
+		c.Quota = p.Quota
+	}
+	return nil
+}
+
+// loadPolicies must follow loadClusters, since some policies belong to
+// clusters.
+func loadPolicies(context context.Context, log logging.LogSink, tx *sql.Tx, state *sous.State) error {
+	ps := policies(&state.Defs)
+	return loadTable(context, log, tx, "policies",
+		`select "name", "doc" from policies;`,
+		func(rows *sql.Rows) error {
+			var name, doc string
+			if err := rows.Scan(&name, &doc); err != nil {
+				return errors.Wrapf(err, "loadPolicies")
+			}
+			into, known := ps[name]
+			if !known {
+				return nil
+			}
+			return errors.Wrapf(json.Unmarshal([]byte(doc), into), "loadPolicies %s", name)
+		})
+}
+
+// storePolicies writes every policy on each write, so that removing one
+// from the state removes it here too.
+func storePolicies(ctx context.Context, log logging.LogSink, state *sous.State, tx *sql.Tx) error {
+	fields := sqlgen.NewFieldset()
+	for name, policy := range policies(&state.Defs) {
+		doc, err := json.Marshal(policy)
+		if err != nil {
+			return errors.Wrapf(err, "storePolicies %s", name)
+		}
+		fields.Row(func(r sqlgen.RowDef) {
+			r.CF("?", "name", name)
+			r.FD("?", "doc", string(doc))
+		})
+	}
+	start := time.Now()
+
+	sql := fields.InsertSQL("policies", `on conflict {{.Candidates}} do update set doc = excluded.doc`)
+	_, err := tx.ExecContext(ctx, sql, fields.InsertValues()...)
+	reportSQLMessage(log, start, "policies", write, sql, fields.RowCount(), err)
+
+	return err
+}
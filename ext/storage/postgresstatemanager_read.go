@@ -58,6 +58,9 @@ func loadState(ctx context.Context, log logging.LogSink, tx *sql.Tx) (*sous.Stat
 	if err := loadClusters(ctx, log, tx, state); err != nil {
 		return nil, err
 	}
+	if err := loadPolicies(ctx, log, tx, state); err != nil {
+		return nil, err
+	}
 	if err := loadManifests(ctx, log, tx, state); err != nil {
 		return nil, err
 	}
@@ -287,9 +290,15 @@ func loadManifests(context context.Context, log logging.LogSink, tx *sql.Tx, sta
 }
 
 func loadTable(ctx context.Context, log logging.LogSink, tx *sql.Tx, mainTable string, sql string, pack func(*sql.Rows) error) error {
+	return loadTableWhere(ctx, log, tx, mainTable, sql, nil, pack)
+}
+
+// loadTableWhere is loadTable for queries with parameters, e.g. in a where
+// clause.
+func loadTableWhere(ctx context.Context, log logging.LogSink, tx *sql.Tx, mainTable string, sql string, args []interface{}, pack func(*sql.Rows) error) error {
 	rowcount := 0
 	start := time.Now()
-	rows, err := tx.QueryContext(ctx, sql)
+	rows, err := tx.QueryContext(ctx, sql, args...)
 	if err != nil {
 		reportSQLMessage(log, start, mainTable, read, sql, rowcount, err)
 		return errors.Wrapf(err, "loadTable %q", sql)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	sous "github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/logging"
@@ -64,7 +65,7 @@ func TestPostgresStateManagerWriteState_success(t *testing.T) {
 	}
 	suite.Equal(int64(4), suite.pluckSQL("select count(*) from deployments"))
 
-	assert.Len(t, suite.logs.CallsTo("Fields"), 13)
+	assert.Len(t, suite.logs.CallsTo("Fields"), 16)
 	message := suite.logs.CallsTo("Fields")[0].PassedArgs().Get(0).([]logging.EachFielder)
 	// XXX This message deserves its own test
 	logging.AssertMessageFieldlist(t, message, append(
@@ -126,3 +127,131 @@ func (suite *PostgresStateManagerSuite) pluckSQL(sql string) interface{} {
 
 	return v
 }
+
+func TestPostgresStateManager_History(t *testing.T) {
+	suite := SetupTest(t)
+
+	s := exampleState()
+	suite.require.NoError(suite.manager.WriteState(s, testUser))
+	// Writing the same state again records nothing.
+	suite.require.NoError(suite.manager.WriteState(s, testUser))
+	suite.Equal(int64(2), suite.pluckSQL("select count(*) from manifest_history"))
+
+	m, ok := s.Manifests.Get(sous.ManifestID{Source: sous.SourceLocation{Repo: "github.com/user/project"}})
+	suite.require.True(ok)
+	spec := m.Deployments["cluster-1"]
+	spec.NumInstances = 7
+	m.Deployments["cluster-1"] = spec
+	user := testUser
+	user.TraceID = "trace-1"
+	suite.require.NoError(suite.manager.WriteState(s, user))
+
+	changes, err := suite.manager.ReadHistory(&sous.ResolveFilter{Repo: sous.NewResolveFieldMatcher("github.com/user/project")})
+	suite.require.NoError(err)
+	suite.require.Len(changes, 2)
+	suite.Nil(changes[0].Prior)
+	last := changes[1]
+	suite.Equal(testUser, last.User)
+	suite.Equal(sous.TraceID("trace-1"), last.TraceID)
+	suite.Equal(7, last.Post.Deployments["cluster-1"].NumInstances)
+}
+
+func TestPostgresStateManager_HistoryEmergency(t *testing.T) {
+	suite := SetupTest(t)
+
+	s := exampleState()
+	user := testUser
+	user.Emergency = true
+	suite.require.NoError(suite.manager.WriteState(s, user))
+
+	changes, err := suite.manager.ReadHistory(&sous.ResolveFilter{})
+	suite.require.NoError(err)
+	suite.require.NotEmpty(changes)
+	suite.True(changes[0].User.Emergency)
+}
+
+// roundTrip writes s and reads it back.
+func (suite *PostgresStateManagerSuite) roundTrip(s *sous.State) *sous.State {
+	suite.t.Helper()
+	suite.require.NoError(suite.manager.WriteState(s, testUser))
+	ns, err := suite.manager.ReadState()
+	suite.require.NoError(err)
+	return ns
+}
+
+func TestPostgresStateManager_Freezes(t *testing.T) {
+	suite := SetupTest(t)
+
+	s := exampleState()
+	start := time.Date(2018, 12, 20, 0, 0, 0, 0, time.UTC)
+	s.Defs.Freezes = sous.Freezes{{Clusters: []string{"cluster-*"}, Start: start, End: start.Add(24 * time.Hour), Reason: "holidays"}}
+	suite.Equal(s.Defs.Freezes, suite.roundTrip(s).Defs.Freezes)
+
+	// Removing freezes from the state removes them from the database.
+	s.Defs.Freezes = nil
+	suite.Empty(suite.roundTrip(s).Defs.Freezes)
+}
+
+func TestPostgresStateManager_RequireApproval(t *testing.T) {
+	suite := SetupTest(t)
+
+	s := exampleState()
+	s.Defs.Clusters["cluster-1"].RequireApproval = true
+	ns := suite.roundTrip(s)
+	suite.True(ns.Defs.Clusters["cluster-1"].RequireApproval)
+	suite.False(ns.Defs.Clusters["other-cluster"].RequireApproval)
+}
+
+func TestPostgresStateManager_SigningKeys(t *testing.T) {
+	suite := SetupTest(t)
+
+	s := exampleState()
+	s.Defs.Clusters["cluster-1"].SigningKeys = []string{"-----BEGIN PUBLIC KEY-----"}
+	ns := suite.roundTrip(s)
+	suite.Equal([]string{"-----BEGIN PUBLIC KEY-----"}, ns.Defs.Clusters["cluster-1"].SigningKeys)
+	suite.Empty(ns.Defs.Clusters["other-cluster"].SigningKeys)
+}
+
+func TestPostgresStateManager_Platforms(t *testing.T) {
+	suite := SetupTest(t)
+
+	s := exampleState()
+	s.Defs.Clusters["cluster-1"].Platforms = []string{"linux/amd64", "linux/arm64"}
+	ns := suite.roundTrip(s)
+	suite.Equal([]string{"linux/amd64", "linux/arm64"}, ns.Defs.Clusters["cluster-1"].Platforms)
+	suite.Empty(ns.Defs.Clusters["other-cluster"].Platforms)
+}
+
+func TestPostgresStateManager_Quotas(t *testing.T) {
+	suite := SetupTest(t)
+
+	s := exampleState()
+	s.Defs.OwnerQuotas = sous.OwnerQuotas{{Owner: "Sam", Clusters: []string{"cluster-*"}, Quota: sous.Quota{Cpus: 2}}}
+	s.Defs.Clusters["cluster-1"].Quota = sous.Quota{Memory: 4096}
+	ns := suite.roundTrip(s)
+	suite.Equal(s.Defs.OwnerQuotas, ns.Defs.OwnerQuotas)
+	suite.Equal(sous.Quota{Memory: 4096}, ns.Defs.Clusters["cluster-1"].Quota)
+	suite.Equal(sous.Quota{}, ns.Defs.Clusters["other-cluster"].Quota)
+}
+
+func TestPostgresStateManager_Promotions(t *testing.T) {
+	suite := SetupTest(t)
+
+	s := exampleState()
+	s.Defs.Promotions = sous.Promotions{{To: []string{"cluster-1"}, From: "other-cluster", MinTime: time.Hour, RequireActive: true}}
+	suite.Equal(s.Defs.Promotions, suite.roundTrip(s).Defs.Promotions)
+}
+
+func TestHistoryWhere(t *testing.T) {
+	where, args := historyWhere(&sous.ResolveFilter{})
+	assert.Equal(t, "", where)
+	assert.Empty(t, args)
+
+	where, args = historyWhere(&sous.ResolveFilter{
+		Repo:    sous.NewResolveFieldMatcher("github.com/user/project"),
+		Flavor:  sous.NewResolveFieldMatcher("vanilla"),
+		Cluster: sous.NewResolveFieldMatcher("cluster-1"),
+	})
+	assert.Equal(t, `where "repo" = $1 and "flavor" = $2`, where)
+	assert.Equal(t, []interface{}{"github.com/user/project", "vanilla"}, args)
+}
@@ -26,11 +26,16 @@ func (m PostgresStateManager) WriteState(state *sous.State, user sous.User) erro
 		tx.Rollback()
 	}(tx)
 
-	if err := storeManifests(context, m.log, state, tx); err != nil {
+	if err := storeManifests(context, m.log, state, user, tx); err != nil {
 		reportWriting(m.log, start, state, errors.Wrapf(err, "storing state"))
 		return err
 	}
 
+	if err := storePolicies(context, m.log, state, tx); err != nil {
+		reportWriting(m.log, start, state, errors.Wrapf(err, "storing policies"))
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		reportWriting(m.log, start, state, errors.Wrapf(err, "committing transaction"))
 		return err
@@ -39,7 +44,7 @@ func (m PostgresStateManager) WriteState(state *sous.State, user sous.User) erro
 	return nil
 }
 
-func storeManifests(ctx context.Context, log logging.LogSink, state *sous.State, tx *sql.Tx) error {
+func storeManifests(ctx context.Context, log logging.LogSink, state *sous.State, user sous.User, tx *sql.Tx) error {
 	newDeps, err := state.Deployments()
 	if err != nil {
 		return err
@@ -71,6 +76,23 @@ func storeManifests(ctx context.Context, log logging.LogSink, state *sous.State,
 		}
 	}
 
+	// Only manifests with deployments stored here are recorded, since
+	// fields which are not stored would otherwise appear to change on every
+	// write.
+	changed := map[sous.ManifestID]struct{}{}
+	for _, dep := range alldeps.Snapshot() {
+		changed[dep.ManifestID()] = struct{}{}
+	}
+	history := []sous.ManifestChange{}
+	for _, c := range sous.ManifestChanges(currentState, state, user, time.Now()) {
+		if _, ok := changed[c.ManifestID]; ok {
+			history = append(history, c)
+		}
+	}
+	if err := storeHistory(ctx, log, tx, history); err != nil {
+		return err
+	}
+
 	/* XXX consider logging this
 	currentDeps.Len(),
 	newDeps.Len(),
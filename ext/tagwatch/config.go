@@ -0,0 +1,69 @@
+package tagwatch
+
+import (
+	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+// Config selects the deployments which the server deploys newly tagged
+// versions to.
+type Config struct {
+	// Clusters are the clusters tagged versions are deployed to.
+	Clusters []string
+	// Manifests are the IDs of the manifests, e.g.
+	// "github.com/user/repo,dir~flavor", whose deployments to Clusters are
+	// opted in to being deployed when their repository is tagged.
+	Manifests []string
+	// PollSeconds is the time between polls of the manifests' repositories
+	// for new tags. If it is zero, they are not polled, and only webhooks
+	// are acted on.
+	PollSeconds int `env:"SOUS_TAG_WATCH_POLL_SECONDS"`
+	// WebhookSecret is the secret GitHub signs webhooks with, or the token
+	// GitLab sends with them. Webhooks are refused while it is empty.
+	WebhookSecret string `env:"SOUS_TAG_WATCH_WEBHOOK_SECRET"`
+	// WorkDir is where the tags of the repositories polled are fetched to.
+	// If empty, the default directory for temporary files is used.
+	WorkDir string `env:"SOUS_TAG_WATCH_WORK_DIR"`
+}
+
+// Enabled returns true if c opts any deployment in to being deployed when
+// it is tagged.
+func (c Config) Enabled() bool {
+	return len(c.Clusters) != 0 && len(c.Manifests) != 0
+}
+
+// ManifestIDs parses c's Manifests.
+func (c Config) ManifestIDs() ([]sous.ManifestID, error) {
+	mids := make([]sous.ManifestID, len(c.Manifests))
+	for i, m := range c.Manifests {
+		mid, err := sous.ParseManifestID(m)
+		if err != nil {
+			return nil, errors.Wrapf(err, "tag watch manifest %q", m)
+		}
+		if mid.Source.Repo == "" {
+			return nil, errors.Errorf("tag watch manifest %q has no repository", m)
+		}
+		mids[i] = mid
+	}
+	return mids, nil
+}
+
+// Equal returns true if c and other are the same configuration.
+func (c Config) Equal(other Config) bool {
+	if c.PollSeconds != other.PollSeconds || c.WebhookSecret != other.WebhookSecret || c.WorkDir != other.WorkDir {
+		return false
+	}
+	return equalStrings(c.Clusters, other.Clusters) && equalStrings(c.Manifests, other.Manifests)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
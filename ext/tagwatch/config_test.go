@@ -0,0 +1,27 @@
+package tagwatch
+
+import (
+	"testing"
+
+	"github.com/opentable/sous/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ManifestIDs(t *testing.T) {
+	c := Config{Manifests: []string{"github.com/user/repo", "github.com/user/other,api~canary"}}
+	assert.False(t, c.Enabled(), "no clusters")
+	c.Clusters = []string{"cluster1"}
+	assert.True(t, c.Enabled())
+
+	mids, err := c.ManifestIDs()
+	require.NoError(t, err)
+	assert.Equal(t, []sous.ManifestID{
+		{Source: sous.SourceLocation{Repo: "github.com/user/repo"}},
+		{Source: sous.SourceLocation{Repo: "github.com/user/other", Dir: "api"}, Flavor: "canary"},
+	}, mids)
+
+	c.Manifests = append(c.Manifests, "")
+	_, err = c.ManifestIDs()
+	assert.Error(t, err)
+}
@@ -0,0 +1,13 @@
+package vulnscan
+
+// Config selects how built images are scanned for vulnerabilities.
+type Config struct {
+	// Scanner is the command line scanner run on each built image: either
+	// "trivy" or "grype". If it is empty, images are not scanned unless
+	// Report is set.
+	Scanner string `env:"SOUS_SCANNER"`
+	// Report is the path of a JSON vulnerability report, in the format of
+	// either trivy or grype, which is read in place of running Scanner. It
+	// suits pipelines which scan images in a separate step.
+	Report string `env:"SOUS_SCAN_REPORT"`
+}
@@ -0,0 +1,57 @@
+package vulnscan
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+// report holds the parts of either a trivy or a grype JSON report which
+// describe vulnerabilities.
+type report struct {
+	// Results are trivy's findings, grouped by target.
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID string
+			PkgName         string
+			Severity        string
+		}
+	}
+	// Matches are grype's findings.
+	Matches []struct {
+		Vulnerability struct {
+			ID       string `json:"id"`
+			Severity string `json:"severity"`
+		} `json:"vulnerability"`
+		Artifact struct {
+			Name string `json:"name"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+// ParseReport returns the vulnerabilities in a trivy or grype JSON report,
+// with their severities in lower case.
+func ParseReport(b []byte) ([]sous.Vulnerability, error) {
+	r := report{}
+	var dst interface{} = &r
+	// Before schema version 2, trivy reported just the array of results.
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '[' {
+		dst = &r.Results
+	}
+	if err := json.Unmarshal(b, dst); err != nil {
+		return nil, errors.Wrapf(err, "parsing vulnerability report")
+	}
+	vs := []sous.Vulnerability{}
+	for _, res := range r.Results {
+		for _, v := range res.Vulnerabilities {
+			vs = append(vs, sous.Vulnerability{ID: v.VulnerabilityID, Package: v.PkgName, Severity: strings.ToLower(v.Severity)})
+		}
+	}
+	for _, m := range r.Matches {
+		vs = append(vs, sous.Vulnerability{ID: m.Vulnerability.ID, Package: m.Artifact.Name, Severity: strings.ToLower(m.Vulnerability.Severity)})
+	}
+	return vs, nil
+}
@@ -0,0 +1,74 @@
+// Package vulnscan scans the images Sous builds for known vulnerabilities,
+// using a Trivy- or Grype-compatible command line scanner, or a report either
+// of them produced.
+package vulnscan
+
+import (
+	"io/ioutil"
+
+	"github.com/opentable/sous/ext/docker"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/shell"
+	"github.com/pkg/errors"
+)
+
+type (
+	// CLIScanner scans images by running a scanner command.
+	CLIScanner struct {
+		// Command is either "trivy" or "grype".
+		Command string
+		Sh      shell.Shell
+	}
+
+	// ReportScanner reads the findings for every image from a report file.
+	ReportScanner struct {
+		Path string
+	}
+)
+
+// New returns the Scanner cfg selects, or nil if it selects none.
+func New(cfg Config, sh shell.Shell) (sous.Scanner, error) {
+	if cfg.Report != "" {
+		return &ReportScanner{Path: cfg.Report}, nil
+	}
+	switch cfg.Scanner {
+	default:
+		return nil, errors.Errorf("unknown vulnerability scanner %q; use trivy or grype", cfg.Scanner)
+	case "":
+		return nil, nil
+	case "trivy", "grype":
+		return &CLIScanner{Command: cfg.Scanner, Sh: sh}, nil
+	}
+}
+
+// Scan implements sous.Scanner.
+func (s *CLIScanner) Scan(bp *sous.BuildProduct) ([]sous.Vulnerability, error) {
+	out, err := s.Sh.Stdout(s.Command, s.args(bp)...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReport([]byte(out))
+}
+
+func (s *CLIScanner) args(bp *sous.BuildProduct) []interface{} {
+	dir, isLayout := docker.OCILayoutDir(bp.ID)
+	if s.Command == "grype" {
+		if isLayout {
+			return []interface{}{"oci-dir:" + dir, "-o", "json", "-q"}
+		}
+		return []interface{}{"docker:" + bp.ID, "-o", "json", "-q"}
+	}
+	if isLayout {
+		return []interface{}{"image", "--format", "json", "--quiet", "--input", dir}
+	}
+	return []interface{}{"image", "--format", "json", "--quiet", bp.ID}
+}
+
+// Scan implements sous.Scanner.
+func (s *ReportScanner) Scan(*sous.BuildProduct) ([]sous.Vulnerability, error) {
+	b, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading vulnerability report")
+	}
+	return ParseReport(b)
+}
@@ -0,0 +1,99 @@
+package vulnscan
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const trivyReport = `{
+  "SchemaVersion": 2,
+  "ArtifactName": "cabba9e",
+  "Results": [
+    {"Target": "cabba9e (debian 10.13)", "Vulnerabilities": [
+      {"VulnerabilityID": "CVE-2022-0778", "PkgName": "openssl", "Severity": "HIGH"},
+      {"VulnerabilityID": "CVE-2022-37434", "PkgName": "zlib1g", "Severity": "CRITICAL"}
+    ]},
+    {"Target": "app/go.sum"}
+  ]
+}`
+
+const grypeReport = `{
+  "matches": [
+    {"vulnerability": {"id": "CVE-2022-0778", "severity": "High"}, "artifact": {"name": "openssl", "version": "1.1.1n"}},
+    {"vulnerability": {"id": "CVE-2005-2541", "severity": "Negligible"}, "artifact": {"name": "tar"}}
+  ],
+  "source": {"type": "image"}
+}`
+
+func TestParseReport(t *testing.T) {
+	vs, err := ParseReport([]byte(trivyReport))
+	require.NoError(t, err)
+	assert.Equal(t, []sous.Vulnerability{
+		{ID: "CVE-2022-0778", Package: "openssl", Severity: "high"},
+		{ID: "CVE-2022-37434", Package: "zlib1g", Severity: "critical"},
+	}, vs)
+
+	vs, err = ParseReport([]byte(`[{"Target": "x", "Vulnerabilities": [{"VulnerabilityID": "CVE-1", "PkgName": "p", "Severity": "LOW"}]}]`))
+	require.NoError(t, err)
+	assert.Equal(t, []sous.Vulnerability{{ID: "CVE-1", Package: "p", Severity: "low"}}, vs)
+
+	vs, err = ParseReport([]byte(grypeReport))
+	require.NoError(t, err)
+	assert.Equal(t, []sous.Vulnerability{
+		{ID: "CVE-2022-0778", Package: "openssl", Severity: "high"},
+		{ID: "CVE-2005-2541", Package: "tar", Severity: "negligible"},
+	}, vs)
+
+	_, err = ParseReport([]byte("Segmentation fault"))
+	assert.Error(t, err)
+}
+
+func TestCLIScanner(t *testing.T) {
+	sh, ctl := shell.NewTestShell()
+	_, cctl := ctl.CmdFor("trivy", "image")
+	cctl.ResultSuccess(trivyReport, "")
+
+	sc, err := New(Config{Scanner: "trivy"}, sh)
+	require.NoError(t, err)
+	vs, err := sc.Scan(&sous.BuildProduct{ID: "cabba9e"})
+	require.NoError(t, err)
+	assert.Len(t, vs, 2)
+	assert.Len(t, ctl.CmdsLike("trivy", "image", "--format", "json", "--quiet", "cabba9e"), 1)
+
+	sh, ctl = shell.NewTestShell()
+	_, cctl = ctl.CmdFor("grype")
+	cctl.ResultSuccess(grypeReport, "")
+	sc, err = New(Config{Scanner: "grype"}, sh)
+	require.NoError(t, err)
+	_, err = sc.Scan(&sous.BuildProduct{ID: "oci:/tmp/layout"})
+	require.NoError(t, err)
+	assert.Len(t, ctl.CmdsLike("grype", "oci-dir:/tmp/layout"), 1)
+}
+
+func TestNew(t *testing.T) {
+	sc, err := New(Config{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, sc)
+
+	_, err = New(Config{Scanner: "clair"}, nil)
+	assert.Error(t, err)
+
+	f, err := ioutil.TempFile("", "sous-scan-report")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(grypeReport)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	sc, err = New(Config{Scanner: "trivy", Report: f.Name()}, nil)
+	require.NoError(t, err)
+	vs, err := sc.Scan(&sous.BuildProduct{ID: "cabba9e"})
+	require.NoError(t, err)
+	assert.Len(t, vs, 2)
+}
@@ -0,0 +1,57 @@
+package webhook
+
+type (
+	// Config describes the webhooks which the server notifies of the outcome
+	// of each rectification.
+	Config struct {
+		// Hooks are the webhooks notified.
+		Hooks []Hook
+		// Retries is the number of times a failed notification is retried.
+		Retries int `env:"SOUS_WEBHOOK_RETRIES"`
+		// RetrySeconds is the delay before the first retry, doubled for
+		// each subsequent retry.
+		RetrySeconds int `env:"SOUS_WEBHOOK_RETRY_SECONDS"`
+	}
+
+	// A Hook is a URL to which events are POSTed.
+	Hook struct {
+		// Owner, if set, limits the events posted to those for deployments
+		// with Owner among their owners.
+		Owner string `yaml:",omitempty"`
+		// URL is where events are posted.
+		URL string
+		// Format selects the payload posted: "json" (the default) posts the
+		// event itself, and "slack" posts a message for a Slack-compatible
+		// incoming webhook.
+		Format string `yaml:",omitempty"`
+		// Template, if set, is a text/template executed on the event to
+		// produce the payload, overriding Format. The "json" function encodes
+		// its argument as JSON.
+		Template string `yaml:",omitempty"`
+	}
+)
+
+// DefaultConfig builds a default configuration, which can be then overridden by
+// client code.
+func DefaultConfig() Config {
+	return Config{
+		Retries:      3,
+		RetrySeconds: 1,
+	}
+}
+
+// Equal returns true if c and other are the same configuration.
+func (c Config) Equal(other Config) bool {
+	if c.Retries != other.Retries || c.RetrySeconds != other.RetrySeconds {
+		return false
+	}
+	if len(c.Hooks) != len(other.Hooks) {
+		return false
+	}
+	for i, h := range c.Hooks {
+		if h != other.Hooks[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Notifier is a sous.Notifier which POSTs each event to the configured
+	// hooks which match it, retrying failed posts.
+	Notifier struct {
+		hooks   []hook
+		retries int
+		delay   time.Duration
+		client  *http.Client
+		log     logging.LogSink
+		wg      sync.WaitGroup
+	}
+
+	// hook is a Hook with its payload template parsed.
+	hook struct {
+		Hook
+		tmpl *template.Template
+	}
+
+	// postError is returned when a webhook responds with an error status.
+	postError struct {
+		URL    string
+		Status string
+		Code   int
+	}
+)
+
+const slackTemplate = `{"text": {{json (summary .)}}}`
+
+var funcs = template.FuncMap{
+	"json":    toJSON,
+	"summary": summary,
+}
+
+// NewNotifier returns a Notifier which posts events to the hooks in c.
+func NewNotifier(c Config, ls logging.LogSink) (*Notifier, error) {
+	n := &Notifier{
+		retries: c.Retries,
+		delay:   time.Duration(c.RetrySeconds) * time.Second,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		log:     ls,
+	}
+	for i, h := range c.Hooks {
+		if h.URL == "" {
+			return nil, errors.Errorf("webhook %d has no URL", i)
+		}
+		text := h.Template
+		switch {
+		case text != "":
+		case h.Format == "slack":
+			text = slackTemplate
+		case h.Format == "" || h.Format == "json":
+		default:
+			return nil, errors.Errorf("webhook %s: unknown format %q", h.URL, h.Format)
+		}
+		wh := hook{Hook: h}
+		if text != "" {
+			t, err := template.New(h.URL).Funcs(funcs).Parse(text)
+			if err != nil {
+				return nil, errors.Wrapf(err, "webhook %s", h.URL)
+			}
+			wh.tmpl = t
+		}
+		n.hooks = append(n.hooks, wh)
+	}
+	return n, nil
+}
+
+// Notify implements sous.Notifier on Notifier. Events are posted in the
+// background.
+func (n *Notifier) Notify(e sous.ResolutionEvent) {
+	for _, h := range n.hooks {
+		if !h.matches(e) {
+			continue
+		}
+		body, err := h.payload(e)
+		if err != nil {
+			logging.ReportError(n.log, errors.Wrapf(err, "rendering notification for %s", h.URL))
+			continue
+		}
+		n.wg.Add(1)
+		go func(url string) {
+			defer n.wg.Done()
+			n.post(url, body)
+		}(h.URL)
+	}
+}
+
+// Wait blocks until every event passed to Notify has been posted, or given
+// up on.
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// post posts body to url, retrying with exponential backoff while the
+// failure might be temporary.
+func (n *Notifier) post(url string, body []byte) {
+	delay := n.delay
+	var err error
+	for attempt := 0; attempt <= n.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		if err = n.postOnce(url, body); err == nil || !retryable(err) {
+			break
+		}
+	}
+	if err != nil {
+		logging.ReportError(n.log, errors.Wrapf(err, "notifying %s", url))
+	}
+}
+
+func (n *Notifier) postOnce(url string, body []byte) error {
+	rz, err := n.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer rz.Body.Close()
+	io.Copy(ioutil.Discard, rz.Body)
+	if rz.StatusCode >= 300 {
+		return &postError{URL: url, Status: rz.Status, Code: rz.StatusCode}
+	}
+	return nil
+}
+
+func (h hook) matches(e sous.ResolutionEvent) bool {
+	if h.Owner == "" {
+		return true
+	}
+	for _, o := range e.Owners {
+		if o == h.Owner {
+			return true
+		}
+	}
+	return false
+}
+
+func (h hook) payload(e sous.ResolutionEvent) ([]byte, error) {
+	if h.tmpl == nil {
+		return json.Marshal(e)
+	}
+	buf := &bytes.Buffer{}
+	err := h.tmpl.Execute(buf, e)
+	return buf.Bytes(), err
+}
+
+// retryable returns false if err is a response which will not change if the
+// post is repeated.
+func retryable(err error) bool {
+	pe, is := err.(*postError)
+	return !is || pe.Code >= 500 || pe.Code == http.StatusTooManyRequests
+}
+
+func (e *postError) Error() string {
+	return fmt.Sprintf("POST %s: %s", e.URL, e.Status)
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// summary describes e in a line of text.
+func summary(e sous.ResolutionEvent) string {
+	s := fmt.Sprintf("%s %s: %s", e.DeploymentID, e.SourceID.Version, e.Outcome)
+	if e.User.Email != "" {
+		s += fmt.Sprintf(" (deployed by %s)", e.User)
+	}
+	if e.Error != "" {
+		s += ": " + e.Error
+	}
+	return s
+}
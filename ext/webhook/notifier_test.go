@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(owners ...string) sous.ResolutionEvent {
+	return sous.ResolutionEvent{
+		DeploymentID: sous.DeploymentID{
+			ManifestID: sous.ManifestID{Source: sous.SourceLocation{Repo: "github.com/user/project"}},
+			Cluster:    "prod",
+		},
+		SourceID: sous.MustNewSourceID("github.com/user/project", "", "1.2.3"),
+		Outcome:  sous.ModifyDiff,
+		User:     sous.User{Name: "Some User", Email: "user@example.com"},
+		TraceID:  "trace-1",
+		Owners:   owners,
+		Time:     time.Now(),
+	}
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	all, team, slack := &Receiver{}, &Receiver{}, &Receiver{}
+	allSrv, teamSrv, slackSrv := httptest.NewServer(all), httptest.NewServer(team), httptest.NewServer(slack)
+	defer allSrv.Close()
+	defer teamSrv.Close()
+	defer slackSrv.Close()
+
+	n, err := NewNotifier(Config{Hooks: []Hook{
+		{URL: allSrv.URL},
+		{URL: teamSrv.URL, Owner: "team@example.com"},
+		{URL: slackSrv.URL, Format: "slack"},
+	}}, logging.SilentLogSet())
+	require.NoError(t, err)
+
+	// Events are posted concurrently, so wait to keep them in order.
+	n.Notify(testEvent("other@example.com"))
+	n.Wait()
+	failed := testEvent("team@example.com")
+	failed.Outcome = sous.CreateDiff
+	failed.Error = "image not found"
+	n.Notify(failed)
+	n.Wait()
+
+	events, err := all.Events()
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "prod", events[0].DeploymentID.Cluster)
+	assert.Equal(t, "1.2.3", events[0].SourceID.Version.String())
+	assert.Equal(t, sous.TraceID("trace-1"), events[0].TraceID)
+	assert.Equal(t, "user@example.com", events[0].User.Email)
+
+	events, err = team.Events()
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "image not found", events[0].Error)
+
+	require.Len(t, slack.Bodies(), 2)
+	msg := struct{ Text string }{}
+	require.NoError(t, json.Unmarshal(slack.Bodies()[1], &msg))
+	assert.Contains(t, msg.Text, "prod")
+	assert.Contains(t, msg.Text, "1.2.3: created")
+	assert.Contains(t, msg.Text, "Some User <user@example.com>")
+	assert.Contains(t, msg.Text, "image not found")
+}
+
+func TestNotifier_Template(t *testing.T) {
+	rcv := &Receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	n, err := NewNotifier(Config{Hooks: []Hook{
+		{URL: srv.URL, Template: `{"deployment": {{json .DeploymentID.String}}, "outcome": "{{.Outcome}}"}`},
+	}}, logging.SilentLogSet())
+	require.NoError(t, err)
+	n.Notify(testEvent())
+	n.Wait()
+
+	require.Len(t, rcv.Bodies(), 1)
+	assert.JSONEq(t, `{"deployment": "prod:github.com/user/project", "outcome": "updated"}`, string(rcv.Bodies()[0]))
+
+	_, err = NewNotifier(Config{Hooks: []Hook{{URL: srv.URL, Template: "{{"}}}, logging.SilentLogSet())
+	assert.Error(t, err)
+	_, err = NewNotifier(Config{Hooks: []Hook{{URL: srv.URL, Format: "morse"}}}, logging.SilentLogSet())
+	assert.Error(t, err)
+	_, err = NewNotifier(Config{Hooks: []Hook{{}}}, logging.SilentLogSet())
+	assert.Error(t, err)
+}
+
+func TestNotifier_Retry(t *testing.T) {
+	rcv := &Receiver{Failures: 2}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	n, err := NewNotifier(Config{Hooks: []Hook{{URL: srv.URL}}, Retries: 2}, logging.SilentLogSet())
+	require.NoError(t, err)
+	n.Notify(testEvent())
+	n.Wait()
+	assert.Len(t, rcv.Bodies(), 1)
+
+	rcv.Failures = 3
+	n.Notify(testEvent())
+	n.Wait()
+	assert.Len(t, rcv.Bodies(), 1, "gave up after 2 retries")
+}
+
+func TestNotifier_NoRetryOnClientError(t *testing.T) {
+	posts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		posts++
+		rw.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	n, err := NewNotifier(Config{Hooks: []Hook{{URL: srv.URL}}, Retries: 3}, logging.SilentLogSet())
+	require.NoError(t, err)
+	n.Notify(testEvent())
+	n.Wait()
+	assert.Equal(t, 1, posts)
+}
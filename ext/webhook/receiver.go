@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	sous "github.com/opentable/sous/lib"
+)
+
+// A Receiver stands in for a webhook, so that notifications can be tried out
+// locally and tested, for instance by serving it with net/http/httptest. It
+// records the body of every POST it accepts.
+type Receiver struct {
+	// Failures is the number of posts to fail with a 500 before accepting any,
+	// to exercise retries.
+	Failures int
+
+	mu     sync.Mutex
+	bodies [][]byte
+}
+
+// ServeHTTP implements http.Handler on Receiver.
+func (r *Receiver) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Failures > 0 {
+		r.Failures--
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	r.bodies = append(r.bodies, body)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// Bodies returns the body of each accepted post, in the order received.
+func (r *Receiver) Bodies() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]byte(nil), r.bodies...)
+}
+
+// Events decodes each accepted post as a JSON ResolutionEvent.
+func (r *Receiver) Events() ([]sous.ResolutionEvent, error) {
+	events := []sous.ResolutionEvent{}
+	for _, b := range r.Bodies() {
+		e := sous.ResolutionEvent{}
+		if err := json.Unmarshal(b, &e); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
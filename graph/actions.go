@@ -3,6 +3,7 @@ package graph
 import (
 	"io"
 	"os"
+	"time"
 
 	"github.com/opentable/sous/cli/actions"
 	"github.com/opentable/sous/config"
@@ -46,6 +47,75 @@ func (di *SousGraph) GetManifestGet(dff config.DeployFilterFlags, out io.Writer,
 	}, nil
 }
 
+// GetQueryHistory injects a QueryHistory instance.
+func (di *SousGraph) GetQueryHistory(dff config.DeployFilterFlags, out io.Writer) (actions.Action, error) {
+	di.guardedAdd("DeployFilterFlags", &dff)
+	di.guardedAdd("Dryrun", DryrunNeither)
+
+	scoop := struct {
+		RF *RefinedResolveFilter
+		HC HTTPClient
+		L  LogSink
+	}{}
+	if err := di.Inject(&scoop); err != nil {
+		return nil, err
+	}
+
+	rf := (*sous.ResolveFilter)(scoop.RF)
+	return &actions.QueryHistory{
+		ResolveFilter: rf,
+		HTTPClient:    scoop.HC.HTTPClient,
+		LogSink:       scoop.L.LogSink.Child("query-history", rf),
+		OutWriter:     out,
+	}, nil
+}
+
+// GetQueryProvenance injects a QueryProvenance instance.
+func (di *SousGraph) GetQueryProvenance(dff config.DeployFilterFlags, out io.Writer) (actions.Action, error) {
+	di.guardedAdd("DeployFilterFlags", &dff)
+	di.guardedAdd("Dryrun", DryrunNeither)
+
+	scoop := struct {
+		RF *RefinedResolveFilter
+		HC HTTPClient
+		L  LogSink
+	}{}
+	if err := di.Inject(&scoop); err != nil {
+		return nil, err
+	}
+
+	rf := (*sous.ResolveFilter)(scoop.RF)
+	return &actions.QueryProvenance{
+		ResolveFilter: rf,
+		HTTPClient:    scoop.HC.HTTPClient,
+		LogSink:       scoop.L.LogSink.Child("query-provenance", rf),
+		OutWriter:     out,
+	}, nil
+}
+
+// GetQueryCapacity injects a QueryCapacity instance, which reports on
+// dff.Cluster, if given, and owner, if not empty.
+func (di *SousGraph) GetQueryCapacity(dff config.DeployFilterFlags, owner string, out io.Writer) (actions.Action, error) {
+	di.guardedAdd("DeployFilterFlags", &dff)
+	di.guardedAdd("Dryrun", DryrunNeither)
+
+	scoop := struct {
+		HC HTTPClient
+		L  LogSink
+	}{}
+	if err := di.Inject(&scoop); err != nil {
+		return nil, err
+	}
+
+	return &actions.QueryCapacity{
+		Cluster:    dff.Cluster,
+		Owner:      owner,
+		HTTPClient: scoop.HC.HTTPClient,
+		LogSink:    scoop.L.LogSink.Child("query-capacity"),
+		OutWriter:  out,
+	}, nil
+}
+
 // GetManifestSet injects a ManifestSet instance.
 func (di *SousGraph) GetManifestSet(dff config.DeployFilterFlags, up *restful.Updater, in io.Reader) (actions.Action, error) {
 	di.guardedAdd("DeployFilterFlags", &dff)
@@ -99,7 +169,7 @@ func (di *SousGraph) GetUpdate(dff config.DeployFilterFlags, otpl config.OTPLFla
 }
 
 // GetDeploy constructs a Deploy Actions.
-func (di *SousGraph) GetDeploy(dff config.DeployFilterFlags, dryrun string, force, waitStable bool) (actions.Action, error) {
+func (di *SousGraph) GetDeploy(dff config.DeployFilterFlags, dryrun string, force, waitStable, emergency bool) (actions.Action, error) {
 	di.guardedAdd("Dryrun", DryrunOption(dryrun))
 	di.guardedAdd("DeployFilterFlags", &dff)
 
@@ -117,19 +187,123 @@ func (di *SousGraph) GetDeploy(dff config.DeployFilterFlags, dryrun string, forc
 	}
 	rf := (*sous.ResolveFilter)(scoop.ResolveFilter)
 	did := sous.DeploymentID(scoop.DeploymentID)
+	user := scoop.User
+	user.Emergency = emergency
 	return &actions.Deploy{
 		ResolveFilter:      rf,
 		HTTPClient:         scoop.HTTP.HTTPClient,
 		TargetDeploymentID: did,
 		StateReader:        scoop.HTTPStateManager,
 		LogSink:            scoop.LogSink.LogSink.Child("deploy", rf, did),
-		User:               scoop.User,
+		User:               user,
 		Config:             scoop.Config.Config,
 		Force:              force,
 		WaitStable:         waitStable,
 	}, nil
 }
 
+// GetRollback produces a Rollback Action, which deploys the previous version
+// of the target deployment.
+func (di *SousGraph) GetRollback(dff config.DeployFilterFlags, dryrun string, waitStable, emergency bool, out io.Writer) (actions.Action, error) {
+	deploy, err := di.GetDeploy(dff, dryrun, false, waitStable, emergency)
+	if err != nil {
+		return nil, err
+	}
+	return &actions.Rollback{
+		Deploy:    deploy.(*actions.Deploy),
+		OutWriter: out,
+	}, nil
+}
+
+// GetPromote produces a Promote Action, which promotes the version of the
+// target manifest deployed to cluster from to cluster to.
+func (di *SousGraph) GetPromote(dff config.DeployFilterFlags, from, to string, env, resources []string, waitStable, emergency bool) (actions.Action, error) {
+	dff.Cluster = to
+	deploy, err := di.GetDeploy(dff, "none", false, waitStable, emergency)
+	if err != nil {
+		return nil, err
+	}
+	return &actions.Promote{
+		Deploy:    deploy.(*actions.Deploy),
+		From:      from,
+		Env:       env,
+		Resources: resources,
+	}, nil
+}
+
+// GetPlan produces a Plan Action.
+func (di *SousGraph) GetPlan(dff config.DeployFilterFlags, out io.Writer) (actions.Action, error) {
+	di.guardedAdd("DeployFilterFlags", &dff)
+	di.guardedAdd("Dryrun", DryrunNeither)
+
+	scoop := struct {
+		RF *sous.ResolveFilter
+		HC HTTPClient
+		L  LogSink
+	}{}
+	if err := di.Inject(&scoop); err != nil {
+		return nil, err
+	}
+
+	return &actions.Plan{
+		ResolveFilter: scoop.RF,
+		HTTPClient:    scoop.HC.HTTPClient,
+		LogSink:       scoop.L.LogSink.Child("plan", scoop.RF),
+		OutWriter:     out,
+	}, nil
+}
+
+// GetRemoteBuild produces a RemoteBuild Action, which has the server build
+// sid.
+func (di *SousGraph) GetRemoteBuild(sid sous.SourceID, out io.Writer) (actions.Action, error) {
+	scoop := struct {
+		HC     HTTPClient
+		L      LogSink
+		U      sous.User
+		Config LocalSousConfig
+	}{}
+	if err := di.Inject(&scoop); err != nil {
+		return nil, err
+	}
+
+	return &actions.RemoteBuild{
+		SourceID:     sid,
+		HTTPClient:   scoop.HC.HTTPClient,
+		User:         scoop.U,
+		PollAttempts: scoop.Config.PollIntervalForClient,
+		PollInterval: time.Second,
+		LogSink:      scoop.L.LogSink.Child("remote-build"),
+		OutWriter:    out,
+	}, nil
+}
+
+// GetApprove produces an Approve Action, which approves or rejects the
+// pending change with ID id, or lists pending changes if id is empty.
+func (di *SousGraph) GetApprove(dff config.DeployFilterFlags, id string, reject bool, out io.Writer) (actions.Action, error) {
+	di.guardedAdd("DeployFilterFlags", &dff)
+	di.guardedAdd("Dryrun", DryrunNeither)
+
+	scoop := struct {
+		RF   *sous.ResolveFilter
+		HTTP *ClusterSpecificHTTPClient
+		L    LogSink
+		U    sous.User
+	}{}
+	if err := di.Inject(&scoop); err != nil {
+		return nil, err
+	}
+
+	return &actions.Approve{
+		ResolveFilter: scoop.RF,
+		HTTPClient:    scoop.HTTP.HTTPClient,
+		User:          scoop.U,
+		ID:            id,
+		Reject:        reject,
+		LogSink:       scoop.L.LogSink.Child("approve", scoop.RF),
+		OutWriter:     out,
+	}, nil
+}
+
 // GetRectify produces a rectify Action.
 func (di *SousGraph) GetRectify(dryrun string, dff config.DeployFilterFlags) (actions.Action, error) {
 	di.guardedAdd("Dryrun", DryrunOption(dryrun))
@@ -192,15 +366,17 @@ func (di *SousGraph) GetServer(
 		Config        *config.Config
 		ServerHandler ServerHandler
 		AutoResolver  *sous.AutoResolver
+		Scaler        *sous.Scaler
+		TagWatcher    *sous.TagWatcher
 	}{}
 
 	if err := di.Inject(&scoop); err != nil {
 		return nil, err
 	}
 
-	ar := scoop.AutoResolver
+	ar, sc, tw := scoop.AutoResolver, scoop.Scaler, scoop.TagWatcher
 	if !enableAutoResolver {
-		ar = nil
+		ar, sc, tw = nil, nil, nil
 	}
 
 	return &actions.Server{
@@ -212,5 +388,7 @@ func (di *SousGraph) GetServer(
 		Config:            scoop.Config,
 		ServerHandler:     scoop.ServerHandler.Handler,
 		AutoResolver:      ar,
+		Scaler:            sc,
+		TagWatcher:        tw,
 	}, nil
 }
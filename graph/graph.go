@@ -8,13 +8,24 @@ import (
 	"net/http"
 	"os"
 	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/ext/autoscale"
+	"github.com/opentable/sous/ext/bitbucket"
 	"github.com/opentable/sous/ext/docker"
 	"github.com/opentable/sous/ext/git"
 	"github.com/opentable/sous/ext/github"
+	"github.com/opentable/sous/ext/gitlab"
+	"github.com/opentable/sous/ext/kubernetes"
+	"github.com/opentable/sous/ext/nomad"
+	"github.com/opentable/sous/ext/secrets"
 	"github.com/opentable/sous/ext/singularity"
 	"github.com/opentable/sous/ext/storage"
+	"github.com/opentable/sous/ext/vulnscan"
+	"github.com/opentable/sous/ext/webhook"
 	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/server"
 	"github.com/opentable/sous/util/docker_registry"
@@ -252,7 +263,8 @@ func AddDocker(graph adder) {
 	)
 }
 
-// AddSingularity adds Singularity clients to the graph.
+// AddSingularity adds the cluster deployers (Singularity, Kubernetes and
+// Nomad) to the graph.
 func AddSingularity(graph adder) {
 	graph.Add(
 		newDeployer,
@@ -277,6 +289,8 @@ func AddInternals(graph adder) {
 		newLabeller,
 		newRegistrar,
 		newBuildManager,
+		newBuildCache,
+		newScanner,
 		newBuildConfig,
 		newBuildContext,
 		newSourceContext,
@@ -292,7 +306,10 @@ func AddInternals(graph adder) {
 		newTargetDeploymentID,
 		newResolveFilter,
 		newResolver,
+		newScaler,
 		newAutoResolver,
+		newRemoteBuilder,
+		newTagWatcher,
 		newInserter,
 		newStatusPoller,
 		newServerComponentLocator,
@@ -301,6 +318,8 @@ func AddInternals(graph adder) {
 		newHTTPClientBundle,
 		newClusterSpecificHTTPClient,
 		NewR11nQueueSet,
+		newPendingChanges,
+		newNotifier,
 	)
 }
 
@@ -312,14 +331,55 @@ func newResolver(filter *sous.ResolveFilter, d sous.Deployer, r sous.Registry, l
 	return sous.NewResolver(d, r, filter, ls.Child("resolver"), qs)
 }
 
-func newAutoResolver(rez *sous.Resolver, sr *ServerStateManager, ls LogSink) *sous.AutoResolver {
+func newAutoResolver(rez *sous.Resolver, sr *ServerStateManager, sc *sous.Scaler, ls LogSink) *sous.AutoResolver {
+	rez.Scaler = sc
 	return sous.NewAutoResolver(rez, sr, ls.Child("autoresolver"))
 }
 
-func newSourceHostChooser() sous.SourceHostChooser {
+// newScaler returns nil unless an autoscaling metric source is configured.
+func newScaler(c LocalSousConfig, d sous.Deployer, sr *ServerStateManager, rf *sous.ResolveFilter, ls LogSink) (*sous.Scaler, error) {
+	ms, err := autoscale.New(c.Autoscale)
+	if err != nil || ms == nil {
+		return nil, err
+	}
+	is, ok := d.(sous.InstanceScaler)
+	if !ok {
+		return nil, fmt.Errorf("deployer %T cannot scale deployments", d)
+	}
+	sc := sous.NewScaler(ms, is, sr, rf, ls.Child("autoscaler"))
+	sc.Interval = c.Autoscale.Interval()
+	return sc, nil
+}
+
+// newTagWatcher returns nil unless some deployments are opted in to being
+// deployed when they are tagged.
+func newTagWatcher(c LocalSousConfig, sm *ServerStateManager, qs *sous.R11nQueueSet, pc *sous.PendingChanges, r sous.Registry, ls LogSink) (*sous.TagWatcher, error) {
+	if !c.TagWatch.Enabled() {
+		return nil, nil
+	}
+	mids, err := c.TagWatch.ManifestIDs()
+	if err != nil {
+		return nil, err
+	}
+	wls := ls.Child("tag-watcher")
+	d := server.NewSingleDeploymentDeployer(sm.StateManager, qs, pc, wls)
+	w := sous.NewTagWatcher(mids, c.TagWatch.Clusters, r, sm.StateManager, d, wls)
+	w.CanonicalRepo = git.CanonicalRepoURL
+	if c.TagWatch.PollSeconds > 0 {
+		w.Tags = &git.TagFetcher{WorkDir: c.TagWatch.WorkDir}
+		w.Interval = time.Duration(c.TagWatch.PollSeconds) * time.Second
+	}
+	return w, nil
+}
+
+// newSourceHostChooser relies only on PossiblyInvalidConfig, since parsing
+// source locations does not depend on the rest of the configuration.
+func newSourceHostChooser(c PossiblyInvalidConfig) sous.SourceHostChooser {
 	return sous.SourceHostChooser{
 		SourceHosts: []sous.SourceHost{
 			github.SourceHost{},
+			gitlab.NewSourceHost(c.GitLab),
+			bitbucket.NewSourceHost(c.Bitbucket),
 		},
 	}
 }
@@ -443,11 +503,19 @@ func newBuildContext(wd LocalWorkDirShell, c *sous.SourceContext) *sous.BuildCon
 	return &sous.BuildContext{Sh: sh, Source: *c}
 }
 
-func newBuildConfig(ls LogSink, f *config.DeployFilterFlags, p *config.PolicyFlags, bc *sous.BuildContext) *sous.BuildConfig {
+func newBuildConfig(ls LogSink, f *config.DeployFilterFlags, p *config.PolicyFlags, bc *sous.BuildContext, c LocalSousConfig) (*sous.BuildConfig, error) {
 	offset := f.Offset
 	if offset == "" {
 		offset = bc.Source.OffsetDir
 	}
+	platforms := p.Platforms
+	if platforms == "" {
+		platforms = c.Docker.Platforms
+	}
+	ps, err := parsePlatforms(platforms)
+	if err != nil {
+		return nil, initErr(err, "parsing build platforms")
+	}
 	cfg := sous.BuildConfig{
 		Repo:       f.Repo,
 		Offset:     offset,
@@ -456,20 +524,39 @@ func newBuildConfig(ls LogSink, f *config.DeployFilterFlags, p *config.PolicyFla
 		Strict:     p.Strict,
 		ForceClone: p.ForceClone,
 		Dev:        p.Dev,
+		Platforms:  ps,
 		Context:    bc,
 		LogSink:    ls,
 	}
 	cfg.Resolve()
 
-	return &cfg
+	return &cfg, nil
 }
 
-func newBuildManager(ls LogSink, bc *sous.BuildConfig, sl sous.Selector, lb sous.Labeller, rg sous.Registrar) *sous.BuildManager {
+// parsePlatforms parses a comma-separated list of platforms.
+func parsePlatforms(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var ps []string
+	for _, f := range strings.Split(s, ",") {
+		p, err := docker_registry.ParsePlatform(strings.TrimSpace(f))
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p.String())
+	}
+	return ps, nil
+}
+
+func newBuildManager(ls LogSink, bc *sous.BuildConfig, sl sous.Selector, lb sous.Labeller, rg sous.Registrar, cache *sous.BuildCache, sc sous.Scanner) *sous.BuildManager {
 	return &sous.BuildManager{
 		BuildConfig: bc,
 		Selector:    sl,
 		Labeller:    lb,
 		Registrar:   rg,
+		Cache:       cache,
+		Scanner:     sc,
 		LogSink:     ls,
 	}
 }
@@ -505,15 +592,50 @@ func newLocalWorkDirShell(verbosity *config.Verbosity, l LocalWorkDir) (v LocalW
 	return v, initErr(err, "getting current working directory")
 }
 
-func newSelector(regClient LocalDockerClient, log LogSink) sous.Selector {
-	return docker.NewBuildStrategySelector(log.Child("docker-build-strategy"), regClient)
+func newSelector(cfg LocalSousConfig, regClient LocalDockerClient, cache *sous.BuildCache, log LogSink) sous.Selector {
+	if cfg.Docker.Daemonless {
+		return docker.NewDaemonlessStrategySelector(log.Child("docker-build-strategy"), regClient)
+	}
+	return docker.NewBuildStrategySelector(log.Child("docker-build-strategy"), regClient, cache)
 }
 
-func newDockerBuilder(cfg LocalSousConfig, nc *docker.NameCache, ctx *sous.SourceContext, source LocalWorkDirShell, scratch ScratchDirShell) (*docker.Builder, error) {
+func newBuildCache(cfg LocalSousConfig) *sous.BuildCache {
+	if cfg.BuildStateDir == "" {
+		return nil
+	}
+	return sous.NewBuildCache(filepath.Join(cfg.BuildStateDir, "cache"))
+}
+
+func newScanner(cfg LocalSousConfig, scratch ScratchDirShell) (sous.Scanner, error) {
+	sc, err := vulnscan.New(cfg.Scanner, scratch.Sh)
+	return sc, initErr(err, "selecting vulnerability scanner")
+}
+
+func newDockerBuilder(cfg LocalSousConfig, nc *docker.NameCache, ctx *sous.SourceContext, source LocalWorkDirShell, scratch ScratchDirShell, rc LocalDockerClient) (*docker.Builder, error) {
 	drh := cfg.Docker.RegistryHost
 	source.Sh = source.Sh.Clone().(*shell.Sh)
 	source.Sh.LongRunning(true)
-	return docker.NewBuilder(nc, drh, source.Sh, scratch.Sh)
+	b, err := docker.NewBuilder(nc, drh, source.Sh, scratch.Sh)
+	if err != nil {
+		return nil, err
+	}
+	b.RegistryClient = rc.Client
+	if b.Signer, err = newArtifactSigner(cfg); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// newArtifactSigner returns nil unless a signing key is configured.
+func newArtifactSigner(cfg LocalSousConfig) (*sous.ArtifactSigner, error) {
+	if cfg.SigningKey == "" {
+		return nil, nil
+	}
+	key, err := ioutil.ReadFile(cfg.SigningKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading signing key")
+	}
+	return sous.NewArtifactSigner(key)
 }
 
 func newLabeller(db *docker.Builder) sous.Labeller {
@@ -532,26 +654,46 @@ func newRegistry(graph *SousGraph, nc lazyNameCache, dryrun DryrunOption) (sous.
 }
 
 func newDeployer(dryrun DryrunOption, nc lazyNameCache, ls LogSink, c LocalSousConfig) (sous.Deployer, error) {
-	// Eventually, based on configuration, we may make different decisions here.
+	// Each cluster is deployed to by the Deployer for its Kind.
 	if dryrun == DryrunBoth || dryrun == DryrunScheduler {
 		drc := sous.NewDummyRectificationClient()
 		drc.SetLogger(ls.Child("rectify"))
-		return singularity.NewDeployer(
-			drc,
-			ls.Child("singularity-deployer"),
-			singularity.OptMaxHTTPReqsPerServer(c.MaxHTTPConcurrencySingularity),
-		), nil
+		return sous.NewDispatchDeployer(map[string]sous.Deployer{
+			"singularity": singularity.NewDeployer(
+				drc,
+				ls.Child("singularity-deployer"),
+				singularity.OptMaxHTTPReqsPerServer(c.MaxHTTPConcurrencySingularity),
+			),
+			"kubernetes": sous.NewDummyDeployer(),
+			"nomad":      sous.NewDummyDeployer(),
+		}), nil
 	}
 	// We need the real name cache.
 	nameCache, err := nc()
 	if err != nil {
 		return nil, err
 	}
-	return singularity.NewDeployer(
-		singularity.NewRectiAgent(nameCache),
-		ls,
-		singularity.OptMaxHTTPReqsPerServer(c.MaxHTTPConcurrencySingularity),
-	), nil
+	sr, err := secrets.New(c.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	return sous.NewDispatchDeployer(map[string]sous.Deployer{
+		"singularity": singularity.NewDeployer(
+			singularity.NewRectiAgent(nameCache, singularity.OptSecretResolver(sr)),
+			ls,
+			singularity.OptMaxHTTPReqsPerServer(c.MaxHTTPConcurrencySingularity),
+		),
+		"kubernetes": kubernetes.NewDeployer(
+			ls.Child("kubernetes-deployer"),
+			kubernetes.OptConfig(c.Kubernetes),
+			kubernetes.OptSecretResolver(sr),
+		),
+		"nomad": nomad.NewDeployer(
+			ls.Child("nomad-deployer"),
+			nomad.OptConfig(c.Nomad),
+			nomad.OptSecretResolver(sr),
+		),
+	}), nil
 }
 
 func newServerHandler(g *SousGraph, ComponentLocator server.ComponentLocator, metrics MetricsHandler, log LogSink) ServerHandler {
@@ -631,11 +773,24 @@ func newServerStateManager(c LocalSousConfig, rf *sous.ResolveFilter, log LogSin
 	}
 
 	dm := storage.NewDiskStateManager(c.StateLocation)
+	dm.HistoryFile = c.HistoryLocation
 	gm := storage.NewGitStateManager(dm)
 	duplex := storage.NewDuplexStateManager(gm, secondary, log.Child("duplex-state"))
 	return &ServerStateManager{StateManager: duplex}
 }
 
+func newPendingChanges(c LocalSousConfig) (*sous.PendingChanges, error) {
+	return sous.NewPendingChanges(c.PendingLocation)
+}
+
+func newNotifier(c LocalSousConfig, ls LogSink) (sous.Notifier, error) {
+	n, err := webhook.NewNotifier(c.Webhooks, ls.Child("webhook"))
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
 func newDistributedStorage(db *sql.DB, c LocalSousConfig, rf *sous.ResolveFilter, log LogSink) (sous.StateManager, error) {
 	localName, err := rf.Cluster.Value()
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/ext/docker"
 	"github.com/opentable/sous/ext/storage"
 	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/server"
@@ -135,6 +136,7 @@ func injectedStateManager(t *testing.T, cfg *config.Config) *StateManager {
 	g.Add(ServerListData{})
 	g.Add(newStateManager)
 	g.Add(LocalSousConfig{Config: cfg})
+	g.Add(PossiblyInvalidConfig{Config: cfg})
 	g.Add(newServerComponentLocator)
 	g.Add(newResolveFilter)
 	g.Add(newSourceHostChooser)
@@ -148,11 +150,17 @@ func injectedStateManager(t *testing.T, cfg *config.Config) *StateManager {
 	g.Add(newServerStateManager)
 	g.Add(&config.DeployFilterFlags{})
 	g.Add(newResolver)
+	g.Add(newScaler)
+	g.Add(newRemoteBuilder)
+	g.Add(newTagWatcher)
+	g.Add(newBuildCache)
 	g.Add(newAutoResolver)
 	g.Add(newServerHandler)
 	g.Add(newHTTPClient)
 	g.Add(newHTTPClientBundle)
 	g.Add(NewR11nQueueSet)
+	g.Add(newPendingChanges)
+	g.Add(newNotifier)
 	g.Add(rff)
 	g.Add(g)
 
@@ -216,12 +224,32 @@ func TestNewBuildConfig(t *testing.T) {
 		},
 	}
 
-	cfg := newBuildConfig(nonDefaultSilentLogSink, f, p, bc)
+	lsc := LocalSousConfig{Config: &config.Config{Docker: docker.Config{Platforms: "linux/amd64"}}}
+	cfg, err := newBuildConfig(nonDefaultSilentLogSink, f, p, bc, lsc)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if cfg.Tag != `1.2.3` {
 		t.Errorf("Build config's tag wasn't 1.2.3: %#v", cfg.Tag)
 	}
 	if err := cfg.Validate(); err != nil {
 		t.Errorf("Not valid build config: %+v", err)
 	}
+	if len(cfg.Platforms) != 1 || cfg.Platforms[0] != "linux/amd64" {
+		t.Errorf("Build config's platforms weren't the configured ones: %q", cfg.Platforms)
+	}
+
+	p.Platforms = "linux/amd64, linux/arm64/v8"
+	cfg, err = newBuildConfig(nonDefaultSilentLogSink, f, p, bc, lsc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Platforms) != 2 || cfg.Platforms[1] != "linux/arm64/v8" {
+		t.Errorf("Build config's platforms weren't those flagged: %q", cfg.Platforms)
+	}
 
+	p.Platforms = "arm64"
+	if _, err := newBuildConfig(nonDefaultSilentLogSink, f, p, bc, lsc); err == nil {
+		t.Errorf("Expected an error for platform %q", p.Platforms)
+	}
 }
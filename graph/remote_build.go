@@ -0,0 +1,97 @@
+package graph
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/opentable/sous/ext/docker"
+	"github.com/opentable/sous/ext/vulnscan"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/shell"
+	"github.com/pkg/errors"
+)
+
+// serverSourceBuilder builds source fetched by the server's RemoteBuilder,
+// each build with its own scratch directory.
+type serverSourceBuilder struct {
+	cfg   LocalSousConfig
+	nc    lazyNameCache
+	rc    LocalDockerClient
+	cache *sous.BuildCache
+	ls    LogSink
+}
+
+func newRemoteBuilder(shc sous.SourceHostChooser, cfg LocalSousConfig, nc lazyNameCache, rc LocalDockerClient, cache *sous.BuildCache, ls LogSink) *sous.RemoteBuilder {
+	sb := &serverSourceBuilder{cfg: cfg, nc: nc, rc: rc, cache: cache, ls: ls}
+	return sous.NewRemoteBuilder(&shc, sb, ls.Child("remote-builder"))
+}
+
+// BuildSource implements sous.SourceBuilder on serverSourceBuilder.
+func (b *serverSourceBuilder) BuildSource(src sous.Source, log io.Writer) (*sous.BuildResult, error) {
+	scratchDir, err := ioutil.TempDir("", "sous")
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting scratch directory")
+	}
+	defer os.RemoveAll(scratchDir)
+
+	scratch, err := shell.DefaultInDir(scratchDir)
+	if err != nil {
+		return nil, err
+	}
+	scratch.TeeOut, scratch.TeeErr = log, log
+	source, err := shell.DefaultInDir(src.LocalRootDir)
+	if err != nil {
+		return nil, err
+	}
+	source.TeeOut, source.TeeErr = log, log
+	source.LongRunning(true)
+
+	nc, err := b.nc()
+	if err != nil {
+		return nil, err
+	}
+	db, err := docker.NewBuilder(nc, b.cfg.Docker.RegistryHost, source, scratch)
+	if err != nil {
+		return nil, err
+	}
+	db.RegistryClient = b.rc.Client
+	if db.Signer, err = newArtifactSigner(b.cfg); err != nil {
+		return nil, err
+	}
+	sc, err := vulnscan.New(b.cfg.Scanner, scratch)
+	if err != nil {
+		return nil, errors.Wrapf(err, "selecting vulnerability scanner")
+	}
+	ps, err := parsePlatforms(b.cfg.Docker.Platforms)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing build platforms")
+	}
+
+	// The tag fetched may have a "v" prefix which the version does not.
+	tag := src.Context.NearestTagName
+	if tag == "" {
+		tag = src.ID.Tag()
+	}
+	bc := &sous.BuildConfig{
+		Repo:      src.ID.Location.Repo,
+		Offset:    src.ID.Location.Dir,
+		Tag:       tag,
+		Revision:  src.ID.RevID(),
+		Platforms: ps,
+		Context:   &sous.BuildContext{Sh: source, Source: src.Context},
+		LogSink:   b.ls,
+	}
+	bc.Resolve()
+
+	bm := &sous.BuildManager{
+		BuildConfig: bc,
+		Selector:    newSelector(b.cfg, b.rc, b.cache, b.ls),
+		Labeller:    db,
+		Registrar:   db,
+		Cache:       b.cache,
+		Scanner:     sc,
+		LogSink:     b.ls,
+	}
+	return bm.Build()
+}
@@ -1,13 +1,21 @@
 package graph
 
 import (
+	"time"
+
 	sous "github.com/opentable/sous/lib"
 	"github.com/opentable/sous/server"
+	"github.com/opentable/sous/util/logging"
 	"github.com/samsalisbury/semv"
 )
 
-func newServerComponentLocator(ls LogSink, cfg LocalSousConfig, ins sous.Inserter, sm *ServerStateManager, rf *sous.ResolveFilter, ar *sous.AutoResolver, v semv.Version, qs *sous.R11nQueueSet) server.ComponentLocator {
-	cm := sous.MakeClusterManager(sm.StateManager)
+func newServerComponentLocator(ls LogSink, cfg LocalSousConfig, ins sous.Inserter, sm *ServerStateManager, rf *sous.ResolveFilter, ar *sous.AutoResolver, v semv.Version, qs *sous.R11nQueueSet, pc *sous.PendingChanges, rb *sous.RemoteBuilder, tw *sous.TagWatcher) server.ComponentLocator {
+	facts := &sous.RecordedPromotionFacts{}
+	facts.History, _ = sm.StateManager.(sous.HistoryReader)
+	if ar != nil {
+		facts.Deployer, facts.Registry = ar.Deployer, ar.Registry
+	}
+	cm := sous.MakeClusterManager(sm.StateManager, facts)
 	dm := sous.MakeDeploymentManager(sm.StateManager)
 	return server.ComponentLocator{
 
@@ -21,17 +29,31 @@ func newServerComponentLocator(ls LogSink, cfg LocalSousConfig, ins sous.Inserte
 		AutoResolver:      ar,
 		Version:           v,
 		QueueSet:          qs,
+		PendingChanges:    pc,
+		RemoteBuilder:     rb,
+		TagWatcher:        tw,
+		PromotionFacts:    facts,
 	}
 
 }
 
 // NewR11nQueueSet returns a new queue set configured to start processing r11ns
-// immediately.
-func NewR11nQueueSet(d sous.Deployer, r sous.Registry, rf *sous.ResolveFilter, sm *ServerStateManager) *sous.R11nQueueSet {
+// immediately. Failed deployments which ask to be rolled back are reverted in
+// the GDM and their rollback pushed onto the same queue set. The outcome of
+// each r11n is sent to n.
+func NewR11nQueueSet(d sous.Deployer, r sous.Registry, rf *sous.ResolveFilter, sm *ServerStateManager, ls LogSink, n sous.Notifier) *sous.R11nQueueSet {
 	sr := sm.StateManager
-	return sous.NewR11nQueueSet(sous.R11nQueueStartWithHandler(
+	rb := sous.NewRollbacker(sous.MakeDeploymentManager(sr), ls.LogSink)
+	var qs *sous.R11nQueueSet
+	qs = sous.NewR11nQueueSet(sous.R11nQueueStartWithHandler(
 		func(qr *sous.QueuedR11n) sous.DiffResolution {
 			qr.Rectification.Begin(d, r, rf, sr)
-			return qr.Rectification.Wait()
+			rez := qr.Rectification.Wait()
+			if _, err := rb.RollBack(qr.Rectification, qs); err != nil {
+				logging.ReportError(ls.LogSink, err)
+			}
+			n.Notify(sous.NewResolutionEvent(qr.Rectification, time.Now()))
+			return rez
 		}))
+	return qs
 }
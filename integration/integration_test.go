@@ -417,7 +417,7 @@ func (suite *integrationSuite) TestMissingImage() {
 	rf := &sous.ResolveFilter{}
 	sr := sous.NewDummyStateManager()
 	sr.State = &stateOne
-	qs := graph.NewR11nQueueSet(suite.deployer, suite.nameCache, rf, &graph.ServerStateManager{sr})
+	qs := graph.NewR11nQueueSet(suite.deployer, suite.nameCache, rf, &graph.ServerStateManager{sr}, graph.LogSink{logging.SilentLogSet()}, sous.DummyNotifier{})
 	r := sous.NewResolver(suite.deployer, suite.nameCache, rf, logging.SilentLogSet(), qs)
 
 	deploymentsOne, err := stateOne.Deployments()
@@ -477,7 +477,7 @@ func (suite *integrationSuite) TestResolve() {
 	rf := &sous.ResolveFilter{}
 	sr := sous.NewDummyStateManager()
 	sr.State = &stateOneTwo
-	qs := graph.NewR11nQueueSet(suite.deployer, suite.nameCache, rf, &graph.ServerStateManager{sr})
+	qs := graph.NewR11nQueueSet(suite.deployer, suite.nameCache, rf, &graph.ServerStateManager{sr}, graph.LogSink{logging.SilentLogSet()}, sous.DummyNotifier{})
 	r := sous.NewResolver(suite.deployer, suite.nameCache, rf, logsink, qs)
 
 	suite.T().Log("Begining OneTwo")
@@ -550,7 +550,7 @@ func (suite *integrationSuite) TestResolve() {
 		rf := &sous.ResolveFilter{}
 		sr := sous.NewDummyStateManager()
 		sr.State = &stateOneTwo
-		qs := graph.NewR11nQueueSet(suite.deployer, suite.nameCache, rf, &graph.ServerStateManager{sr})
+		qs := graph.NewR11nQueueSet(suite.deployer, suite.nameCache, rf, &graph.ServerStateManager{sr}, graph.LogSink{logging.SilentLogSet()}, sous.DummyNotifier{})
 		r := sous.NewResolver(deployer, suite.nameCache, rf, logging.SilentLogSet(), qs)
 
 		err := r.Begin(deploymentsTwoThree, clusterDefs.Clusters).Wait()
@@ -0,0 +1,201 @@
+package sous
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+type (
+	// A PendingChange is a change to the version deployed to a cluster which
+	// requires approval, waiting for a second user to approve or reject it.
+	// Until it is approved it is not written to the GDM, so it is not part of
+	// the intended deployments.
+	PendingChange struct {
+		// ID identifies the change.
+		ID           string
+		DeploymentID DeploymentID
+		// Prior is the deploy spec the change replaces.
+		Prior DeploySpec
+		// Post is the requested deploy spec.
+		Post DeploySpec
+		// User is the user who requested the change.
+		User User
+		// Time is when the change was requested.
+		Time time.Time
+	}
+
+	// PendingChanges holds the changes waiting for approval, at most one for
+	// each DeploymentID. It is safe for concurrent use.
+	PendingChanges struct {
+		file    string
+		mu      sync.Mutex
+		changes map[string]PendingChange
+	}
+
+	// An ApprovalError is returned when a user may not approve a change.
+	ApprovalError struct {
+		Change PendingChange
+		User   User
+	}
+
+	// An UnapprovedError is returned when a change of version which requires
+	// approval is written without it.
+	UnapprovedError struct {
+		DeploymentID DeploymentID
+	}
+)
+
+// RequiresApproval returns true if changing the version of the deployment did
+// in state s needs a second user's approval.
+func (s *State) RequiresApproval(did DeploymentID) bool {
+	c, ok := s.Defs.Clusters[did.Cluster]
+	return ok && c != nil && c.RequireApproval
+}
+
+// CheckUnapproved returns an UnapprovedError if any deployment which differs
+// between prior and post, other than those approved, changes its SourceID in
+// a cluster of s which requires approval: such changes must be made as
+// PendingChanges.
+func (s *State) CheckUnapproved(prior, post Deployments, approved ...DeploymentID) error {
+	for _, p := range prior.Diff(post).Collect() {
+		if p.Kind() != ModifiedKind || !s.RequiresApproval(p.ID()) {
+			continue
+		}
+		if !p.Prior.SourceID.Equal(p.Post.SourceID) && !containsDeploymentID(approved, p.ID()) {
+			return &UnapprovedError{DeploymentID: p.ID()}
+		}
+	}
+	return nil
+}
+
+func containsDeploymentID(ids []DeploymentID, id DeploymentID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// NewPendingChanges returns a PendingChanges which keeps the changes in the
+// JSON file named file, loading any already there. If file is empty, the
+// changes are only kept in memory.
+func NewPendingChanges(file string) (*PendingChanges, error) {
+	pc := &PendingChanges{file: file, changes: map[string]PendingChange{}}
+	if file == "" {
+		return pc, nil
+	}
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return pc, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading pending changes")
+	}
+	changes := []PendingChange{}
+	if err := json.Unmarshal(b, &changes); err != nil {
+		return nil, errors.Wrapf(err, "parsing pending changes in %s", file)
+	}
+	for _, c := range changes {
+		pc.changes[c.ID] = c
+	}
+	return pc, nil
+}
+
+// Add records c, replacing any change pending for the same deployment, and
+// returns it with its ID set.
+func (pc *PendingChanges) Add(c PendingChange) (PendingChange, error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	for id, old := range pc.changes {
+		if old.DeploymentID == c.DeploymentID {
+			delete(pc.changes, id)
+		}
+	}
+	c.ID = uuid.New()
+	pc.changes[c.ID] = c
+	return c, pc.save()
+}
+
+// Get returns the change with ID id, or false if there is none.
+func (pc *PendingChanges) Get(id string) (PendingChange, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	c, ok := pc.changes[id]
+	return c, ok
+}
+
+// List returns the pending changes matching rf, oldest first.
+func (pc *PendingChanges) List(rf *ResolveFilter) []PendingChange {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	list := []PendingChange{}
+	for _, c := range pc.changes {
+		if rf.FilterManifestID(c.DeploymentID.ManifestID) && rf.FilterClusterName(c.DeploymentID.Cluster) {
+			list = append(list, c)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Time.Before(list[j].Time) })
+	return list
+}
+
+// Remove removes the change with ID id, returning it, or false if there is
+// none.
+func (pc *PendingChanges) Remove(id string) (PendingChange, bool, error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	c, ok := pc.changes[id]
+	if !ok {
+		return c, false, nil
+	}
+	delete(pc.changes, id)
+	return c, true, pc.save()
+}
+
+// save writes the changes to pc.file; callers must hold pc.mu.
+func (pc *PendingChanges) save() error {
+	if pc.file == "" {
+		return nil
+	}
+	changes := make([]PendingChange, 0, len(pc.changes))
+	for _, c := range pc.changes {
+		changes = append(changes, c)
+	}
+	b, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(pc.file), 0777); err != nil {
+		return errors.Wrapf(err, "saving pending changes")
+	}
+	return errors.Wrapf(ioutil.WriteFile(pc.file, b, 0666), "saving pending changes")
+}
+
+// CheckApprover returns an ApprovalError if u may not approve c: nobody may
+// approve their own change.
+func (c PendingChange) CheckApprover(u User) error {
+	if u.Email == "" || u.Email == c.User.Email {
+		return &ApprovalError{Change: c, User: u}
+	}
+	return nil
+}
+
+func (e *ApprovalError) Error() string {
+	if e.User.Email == "" {
+		return fmt.Sprintf("approving %s requires a user email", e.Change.DeploymentID)
+	}
+	return fmt.Sprintf("%s may not approve their own change to %s", e.User, e.Change.DeploymentID)
+}
+
+func (e *UnapprovedError) Error() string {
+	return fmt.Sprintf("changing the version of %s requires approval: use sous deploy to request it", e.DeploymentID)
+}
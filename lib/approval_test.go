@@ -0,0 +1,101 @@
+package sous
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-pending")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "sous", "pending")
+
+	did := func(repo, cluster string) DeploymentID {
+		return DeploymentID{ManifestID: ManifestID{Source: SourceLocation{Repo: repo}}, Cluster: cluster}
+	}
+	now := time.Now()
+
+	pc, err := NewPendingChanges(file)
+	require.NoError(t, err)
+	first, err := pc.Add(PendingChange{DeploymentID: did("github.com/user/one", "prod"), Time: now})
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	_, err = pc.Add(PendingChange{DeploymentID: did("github.com/user/two", "prod"), Time: now.Add(time.Second)})
+	require.NoError(t, err)
+	// A newer change to the same deployment replaces the older one.
+	replaced, err := pc.Add(PendingChange{DeploymentID: did("github.com/user/one", "prod"), Time: now.Add(2 * time.Second)})
+	require.NoError(t, err)
+	_, ok := pc.Get(first.ID)
+	assert.False(t, ok)
+
+	all := pc.List(&ResolveFilter{})
+	require.Len(t, all, 2)
+	assert.Equal(t, "github.com/user/two", all[0].DeploymentID.ManifestID.Source.Repo)
+	assert.Equal(t, replaced.ID, all[1].ID)
+	assert.Len(t, pc.List(&ResolveFilter{Repo: NewResolveFieldMatcher("github.com/user/one")}), 1)
+	assert.Len(t, pc.List(&ResolveFilter{Cluster: NewResolveFieldMatcher("ci")}), 0)
+
+	removed, ok, err := pc.Remove(replaced.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, replaced.ID, removed.ID)
+	_, ok, err = pc.Remove(replaced.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	reloaded, err := NewPendingChanges(file)
+	require.NoError(t, err)
+	list := reloaded.List(&ResolveFilter{})
+	require.Len(t, list, 1)
+	assert.Equal(t, "github.com/user/two", list[0].DeploymentID.ManifestID.Source.Repo)
+}
+
+func TestPendingChange_CheckApprover(t *testing.T) {
+	c := PendingChange{User: User{Name: "Requester", Email: "requester@example.com"}}
+	assert.Error(t, c.CheckApprover(User{Name: "Requester", Email: "requester@example.com"}))
+	assert.Error(t, c.CheckApprover(User{Name: "Nobody"}))
+	assert.NoError(t, c.CheckApprover(User{Name: "Approver", Email: "approver@example.com"}))
+}
+
+func TestState_CheckUnapproved(t *testing.T) {
+	state := DefaultStateFixture()
+	state.Defs.Clusters["cluster1"].RequireApproval = true
+	prior, err := state.Deployments()
+	require.NoError(t, err)
+
+	change := func(cluster string, fn func(*Deployment)) Deployments {
+		post := prior.Clone()
+		for _, d := range post.Snapshot() {
+			if d.ClusterName == cluster {
+				changed := d.Clone()
+				fn(changed)
+				post.Set(d.ID(), changed)
+				break
+			}
+		}
+		return post
+	}
+	upgrade := func(d *Deployment) { d.SourceID.Version = semv.MustParse("2.0.0") }
+	scale := func(d *Deployment) { d.NumInstances++ }
+
+	assert.NoError(t, state.CheckUnapproved(prior, change("cluster0", upgrade)))
+	assert.NoError(t, state.CheckUnapproved(prior, change("cluster1", scale)))
+	assert.Error(t, state.CheckUnapproved(prior, change("cluster1", upgrade)))
+
+	upgraded := change("cluster1", upgrade)
+	var approved []DeploymentID
+	for _, d := range upgraded.Snapshot() {
+		if d.ClusterName == "cluster1" {
+			approved = append(approved, d.ID())
+		}
+	}
+	assert.NoError(t, state.CheckUnapproved(prior, upgraded, approved...))
+}
@@ -178,6 +178,7 @@ func (ar *AutoResolver) resolveOnce(ac announceChannel) {
 	}
 
 	ar.write(func() {
+		ar.Resolver.Freezes = state.Defs.Freezes
 		ar.currentRecorder = ar.Resolver.Begin(ar.GDM, state.Defs.Clusters)
 	})
 	defer ar.write(func() {
@@ -0,0 +1,292 @@
+package sous
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/opentable/sous/util/logging"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Autoscale configures horizontal autoscaling of a deployment. c.f.
+	// DeployConfig for use.
+	//
+	// A server with a MetricSource configured reads Metric for each
+	// autoscaled deployment it resolves, and scales it to enough instances
+	// that each has Target of it, within MinInstances and MaxInstances.
+	// NumInstances is the number of instances the deployment starts with.
+	// The GDM is not changed: the instance count chosen is kept by the
+	// server's Scaler.
+	Autoscale struct {
+		// MinInstances is the fewest instances the deployment is scaled to.
+		MinInstances int `yaml:",omitempty"`
+		// MaxInstances is the most instances the deployment is scaled to.
+		// Autoscaling is enabled by setting it.
+		MaxInstances int `yaml:",omitempty"`
+		// Metric is a query, understood by the server's MetricSource, for the
+		// total load on the deployment, e.g. its request rate. It is a
+		// text/template, executed with the deployment's Cluster, Repo, Offset
+		// and Flavor.
+		Metric string `yaml:",omitempty"`
+		// Target is the value of Metric each instance should handle.
+		Target float64 `yaml:",omitempty"`
+		// CooldownSeconds is the least time between changes to the
+		// deployment's instance count.
+		CooldownSeconds int `yaml:",omitempty"`
+	}
+
+	// A MetricSource reads the current values of autoscaling metrics.
+	MetricSource interface {
+		// Metric returns the current value of query.
+		Metric(query string) (float64, error)
+	}
+
+	// An InstanceScaler changes the number of instances of a running
+	// deployment without redeploying it.
+	InstanceScaler interface {
+		ScaleDeployment(d *Deployment, instances int, message string) error
+	}
+
+	// A Scaler scales autoscaled deployments according to their metrics, and
+	// keeps the instance counts it has chosen so that resolution does not
+	// undo them.
+	Scaler struct {
+		Metrics  MetricSource
+		Scaler   InstanceScaler
+		Interval time.Duration
+		StateReader
+		*ResolveFilter
+		ls     logging.LogSink
+		mu     sync.Mutex
+		scaled map[DeploymentID]scaledCount
+	}
+
+	scaledCount struct {
+		instances int
+		at        time.Time
+	}
+
+	autoscaleQueryData struct {
+		Cluster, Repo, Offset, Flavor string
+	}
+)
+
+// Enabled reports whether a is set.
+func (a Autoscale) Enabled() bool {
+	return a.MaxInstances != 0
+}
+
+// IsZero reports whether this Autoscale is entirely unset.
+func (a Autoscale) IsZero() bool {
+	return a == Autoscale{}
+}
+
+// Clamp returns n, limited to between a's MinInstances and MaxInstances.
+func (a Autoscale) Clamp(n int) int {
+	if n < a.MinInstances {
+		n = a.MinInstances
+	}
+	if n > a.MaxInstances {
+		n = a.MaxInstances
+	}
+	return n
+}
+
+// Desired returns the number of instances needed for Metric to have the
+// value v.
+func (a Autoscale) Desired(v float64) int {
+	if a.Target <= 0 || v <= 0 {
+		return a.Clamp(0)
+	}
+	return a.Clamp(int(math.Ceil(v / a.Target)))
+}
+
+// Query returns Metric for the deployment did.
+func (a Autoscale) Query(did DeploymentID) (string, error) {
+	tmpl, err := template.New("metric").Option("missingkey=error").Parse(a.Metric)
+	if err != nil {
+		return "", errors.Wrapf(err, "Autoscale Metric")
+	}
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, autoscaleQueryData{
+		Cluster: did.Cluster,
+		Repo:    did.ManifestID.Source.Repo,
+		Offset:  did.ManifestID.Source.Dir,
+		Flavor:  did.ManifestID.Flavor,
+	})
+	return buf.String(), errors.Wrapf(err, "Autoscale Metric")
+}
+
+// Validate implements Flawed on Autoscale.
+func (a *Autoscale) Validate() []Flaw {
+	flaws := []Flaw{}
+	if a.IsZero() {
+		return flaws
+	}
+
+	if !a.Enabled() {
+		flaws = append(flaws, FatalFlaw("Autoscale MaxInstances must be set to autoscale."))
+	}
+	if a.MinInstances < 0 {
+		flaws = append(flaws, FatalFlaw("Autoscale MinInstances less than zero: %d!", a.MinInstances))
+	}
+	if a.Enabled() && a.MinInstances > a.MaxInstances {
+		flaws = append(flaws, FatalFlaw("Autoscale MinInstances (%d) must not be more than MaxInstances (%d).",
+			a.MinInstances, a.MaxInstances))
+	}
+	if a.Metric == "" {
+		flaws = append(flaws, FatalFlaw("Autoscale Metric must be set."))
+	} else if _, err := template.New("metric").Parse(a.Metric); err != nil {
+		flaws = append(flaws, FatalFlaw("Autoscale Metric is not a valid template: %v", err))
+	}
+	if a.Target <= 0 {
+		flaws = append(flaws, FatalFlaw("Autoscale Target must be more than zero, got %g.", a.Target))
+	}
+	if a.CooldownSeconds < 0 {
+		flaws = append(flaws, FatalFlaw("Autoscale CooldownSeconds less than zero: %d!", a.CooldownSeconds))
+	}
+
+	return flaws
+}
+
+// NewScaler returns a Scaler which scales the deployments in the state sr
+// reads which rf matches with is, according to the metrics ms reads.
+func NewScaler(ms MetricSource, is InstanceScaler, sr StateReader, rf *ResolveFilter, ls logging.LogSink) *Scaler {
+	return &Scaler{
+		Metrics:       ms,
+		Scaler:        is,
+		Interval:      60 * time.Second,
+		StateReader:   sr,
+		ResolveFilter: rf,
+		ls:            ls,
+		scaled:        map[DeploymentID]scaledCount{},
+	}
+}
+
+// Apply returns ds, with the NumInstances of each autoscaled deployment
+// replaced by the count s has scaled it to, if any, and kept within its
+// bounds.
+func (s *Scaler) Apply(ds Deployments) Deployments {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	applied := ds.Clone()
+	for id, d := range ds.Snapshot() {
+		if !d.Autoscale.Enabled() {
+			continue
+		}
+		instances := d.NumInstances
+		if sc, ok := s.scaled[id]; ok {
+			instances = sc.instances
+		}
+		scaled := d.Clone()
+		scaled.NumInstances = d.Autoscale.Clamp(instances)
+		applied.Set(id, scaled)
+	}
+	return applied
+}
+
+// Kickoff starts scaling deployments every Interval, until the returned
+// channel is closed.
+func (s *Scaler) Kickoff() TriggerChannel {
+	done := make(TriggerChannel)
+	go func() {
+		for {
+			if err := s.ScaleOnce(time.Now()); err != nil {
+				logging.ReportError(s.ls, err)
+			}
+			select {
+			case <-done:
+				return
+			case <-time.After(s.Interval):
+			}
+		}
+	}()
+	return done
+}
+
+// ScaleOnce scales each autoscaled deployment whose metric calls for a
+// different number of instances, unless it was scaled within its cooldown
+// before at, or a Freeze is in effect for it at at.
+func (s *Scaler) ScaleOnce(at time.Time) error {
+	state, err := s.StateReader.ReadState()
+	if err != nil {
+		return errors.Wrapf(err, "reading state to autoscale")
+	}
+	ds, err := state.Deployments()
+	if err != nil {
+		return errors.Wrapf(err, "reading state to autoscale")
+	}
+	if s.ResolveFilter != nil {
+		ds = ds.Filter(s.FilterDeployment)
+	}
+
+	autoscaled := map[DeploymentID]struct{}{}
+	for id, d := range ds.Snapshot() {
+		if !d.Autoscale.Enabled() {
+			continue
+		}
+		autoscaled[id] = struct{}{}
+		if state.Defs.Freezes.Frozen(id, at) != nil {
+			continue
+		}
+		if err := s.scale(d, at); err != nil {
+			logging.ReportError(s.ls, errors.Wrapf(err, "autoscaling %s", id))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.scaled {
+		if _, ok := autoscaled[id]; !ok {
+			delete(s.scaled, id)
+		}
+	}
+	return nil
+}
+
+func (s *Scaler) scale(d *Deployment, at time.Time) error {
+	id := d.ID()
+	s.mu.Lock()
+	sc, scaled := s.scaled[id]
+	s.mu.Unlock()
+
+	current := d.Autoscale.Clamp(d.NumInstances)
+	if scaled {
+		cooldown := time.Duration(d.Autoscale.CooldownSeconds) * time.Second
+		if at.Sub(sc.at) < cooldown {
+			return nil
+		}
+		current = d.Autoscale.Clamp(sc.instances)
+	}
+
+	query, err := d.Autoscale.Query(id)
+	if err != nil {
+		return err
+	}
+	v, err := s.Metrics.Metric(query)
+	if err != nil {
+		return errors.Wrapf(err, "reading metric %q", query)
+	}
+	desired := d.Autoscale.Desired(v)
+	if desired == current {
+		return nil
+	}
+
+	msg := fmt.Sprintf("autoscaling from %d to %d instances: %s is %g, target %g per instance",
+		current, desired, query, v, d.Autoscale.Target)
+	logging.ReportMsg(s.ls, logging.InformationLevel, fmt.Sprintf("%s %s", id, msg))
+	if err := s.Scaler.ScaleDeployment(d, desired, msg); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scaled[id] = scaledCount{instances: desired, at: at}
+	return nil
+}
@@ -0,0 +1,134 @@
+package sous
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opentable/sous/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	autoscaleTestMetrics map[string]float64
+
+	autoscaleTestScaler struct {
+		scaled map[DeploymentID]int
+	}
+)
+
+func (m autoscaleTestMetrics) Metric(query string) (float64, error) {
+	return m[query], nil
+}
+
+func (s *autoscaleTestScaler) ScaleDeployment(d *Deployment, instances int, message string) error {
+	s.scaled[d.ID()] = instances
+	return nil
+}
+
+func autoscaleTestState(a Autoscale) *State {
+	s := StateFixture(StateFixtureOpts{ClusterCount: 1, ManifestCount: 1})
+	for _, m := range s.Manifests.Snapshot() {
+		spec := m.Deployments["cluster0"]
+		spec.Autoscale = a
+		m.Deployments["cluster0"] = spec
+	}
+	return s
+}
+
+func TestAutoscale_Validate(t *testing.T) {
+	a := Autoscale{}
+	assert.Empty(t, a.Validate())
+
+	a = Autoscale{MinInstances: 1, MaxInstances: 4, Metric: "rps.{{.Cluster}}", Target: 100}
+	assert.Empty(t, a.Validate())
+
+	bad := []Autoscale{
+		{MinInstances: 1, Metric: "rps", Target: 100},
+		{MinInstances: -1, MaxInstances: 4, Metric: "rps", Target: 100},
+		{MinInstances: 5, MaxInstances: 4, Metric: "rps", Target: 100},
+		{MaxInstances: 4, Target: 100},
+		{MaxInstances: 4, Metric: "rps.{{.Cluster", Target: 100},
+		{MaxInstances: 4, Metric: "rps"},
+		{MaxInstances: 4, Metric: "rps", Target: 100, CooldownSeconds: -1},
+	}
+	for _, a := range bad {
+		assert.Len(t, a.Validate(), 1, "%+v", a)
+	}
+}
+
+func TestAutoscale_Desired(t *testing.T) {
+	a := Autoscale{MinInstances: 2, MaxInstances: 10, Target: 100}
+	assert.Equal(t, 2, a.Desired(0))
+	assert.Equal(t, 2, a.Desired(150))
+	assert.Equal(t, 4, a.Desired(301))
+	assert.Equal(t, 10, a.Desired(5000))
+}
+
+func TestAutoscale_Query(t *testing.T) {
+	a := Autoscale{Metric: "sum(rate(requests{cluster=\"{{.Cluster}}\",repo=\"{{.Repo}}\",flavor=\"{{.Flavor}}\"}[1m]))"}
+	did := DeploymentID{
+		Cluster: "ci",
+		ManifestID: ManifestID{
+			Source: SourceLocation{Repo: "github.com/user/repo", Dir: "api"},
+			Flavor: "canary",
+		},
+	}
+	q, err := a.Query(did)
+	require.NoError(t, err)
+	assert.Equal(t, `sum(rate(requests{cluster="ci",repo="github.com/user/repo",flavor="canary"}[1m]))`, q)
+
+	a.Metric = "{{.Nope}}"
+	_, err = a.Query(did)
+	assert.Error(t, err)
+}
+
+func TestScaler_ScaleOnce(t *testing.T) {
+	state := autoscaleTestState(Autoscale{
+		MinInstances:    1,
+		MaxInstances:    10,
+		Metric:          "rps.{{.Cluster}}",
+		Target:          100,
+		CooldownSeconds: 60,
+	})
+	sm := NewDummyStateManager()
+	sm.State = state
+	metrics := autoscaleTestMetrics{"rps.cluster0": 550}
+	is := &autoscaleTestScaler{scaled: map[DeploymentID]int{}}
+	s := NewScaler(metrics, is, sm, nil, logging.SilentLogSet())
+
+	ds, err := state.Deployments()
+	require.NoError(t, err)
+	require.Equal(t, 1, ds.Len())
+	id := ds.Keys()[0]
+
+	now := time.Now()
+	require.NoError(t, s.ScaleOnce(now))
+	assert.Equal(t, 6, is.scaled[id])
+
+	applied, ok := s.Apply(ds).Get(id)
+	require.True(t, ok)
+	assert.Equal(t, 6, applied.NumInstances)
+	original, _ := ds.Get(id)
+	assert.Equal(t, 3, original.NumInstances, "Apply should not change its argument")
+
+	// Within the cooldown, the deployment is left alone.
+	metrics["rps.cluster0"] = 150
+	require.NoError(t, s.ScaleOnce(now.Add(30*time.Second)))
+	assert.Equal(t, 6, is.scaled[id])
+
+	// While frozen, the deployment is left alone too.
+	state.Defs.Freezes = Freezes{{Start: now, End: now.Add(time.Hour)}}
+	require.NoError(t, s.ScaleOnce(now.Add(90*time.Second)))
+	assert.Equal(t, 6, is.scaled[id])
+	state.Defs.Freezes = nil
+
+	require.NoError(t, s.ScaleOnce(now.Add(90*time.Second)))
+	assert.Equal(t, 2, is.scaled[id])
+
+	// Once autoscaling is turned off, the recorded count is forgotten.
+	sm.State = autoscaleTestState(Autoscale{})
+	require.NoError(t, s.ScaleOnce(now.Add(time.Hour)))
+	applied, _ = s.Apply(ds).Get(id)
+	assert.Equal(t, 3, applied.NumInstances)
+}
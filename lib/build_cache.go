@@ -0,0 +1,179 @@
+package sous
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// A BuildCache records the products of builds, so that building the same
+	// source again can reuse them rather than building from scratch. Entries
+	// are addressed by a BuildCacheKey, which covers both the SourceID and
+	// the content of the build context.
+	//
+	// A nil *BuildCache caches nothing.
+	BuildCache struct {
+		Dir string
+	}
+
+	// A BuildCacheEntry is the record of a single build in a BuildCache.
+	BuildCacheEntry struct {
+		Key    string
+		Source SourceID
+		// Products are the products as the buildpack returned them, before
+		// they were contextualized, labelled and registered.
+		Products []*BuildProduct
+		Time     time.Time
+	}
+)
+
+// NewBuildCache returns a BuildCache which keeps its entries in dir, or nil
+// if dir is empty.
+func NewBuildCache(dir string) *BuildCache {
+	if dir == "" {
+		return nil
+	}
+	return &BuildCache{Dir: dir}
+}
+
+// BuildCacheKey returns the key of a build of kind from sid, whose build
+// context has the hash contextHash (see HashBuildContext).
+func BuildCacheKey(sid SourceID, kind, contextHash string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n", sid, sid.RevID(), kind, contextHash)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HashBuildContext returns a hash of the names, permissions and contents of
+// the files under dir, other than those in .git directories and those whose
+// paths relative to dir are in exclude.
+func HashBuildContext(dir string, exclude ...string) (string, error) {
+	skip := map[string]bool{}
+	for _, e := range exclude {
+		skip[filepath.Clean(e)] = true
+	}
+	h := sha256.New()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if skip[rel] {
+			return nil
+		}
+		fmt.Fprintf(h, "%s\x00%o\x00", rel, info.Mode())
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(h, target)
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "hashing build context %s", dir)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Lookup returns the entry with key, or false if there is none.
+func (c *BuildCache) Lookup(key string) (BuildCacheEntry, bool) {
+	e := BuildCacheEntry{}
+	if c == nil || key == "" {
+		return e, false
+	}
+	b, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return e, false
+	}
+	if err := json.Unmarshal(b, &e); err != nil || e.Key != key {
+		return BuildCacheEntry{}, false
+	}
+	return e, true
+}
+
+// Store records e, replacing any entry with the same key.
+func (c *BuildCache) Store(e BuildCacheEntry) error {
+	if c == nil || e.Key == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0777); err != nil {
+		return errors.Wrapf(err, "storing build cache entry")
+	}
+	// Write then rename, so that concurrent builds never read a partial entry.
+	tmp, err := ioutil.TempFile(c.Dir, "entry")
+	if err != nil {
+		return errors.Wrapf(err, "storing build cache entry")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "storing build cache entry")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "storing build cache entry")
+	}
+	return errors.Wrapf(os.Rename(tmp.Name(), c.path(e.Key)), "storing build cache entry")
+}
+
+func (c *BuildCache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+// cachedProducts returns copies of ps as they should be cached: as the
+// buildpack returned them.
+func cachedProducts(ps []*BuildProduct) []*BuildProduct {
+	cached := make([]*BuildProduct, 0, len(ps))
+	for _, p := range ps {
+		c := &BuildProduct{Source: p.Source, Kind: p.Kind, ID: p.ID}
+		if p.Advisories != nil {
+			c.Advisories = append([]string{}, p.Advisories...)
+		}
+		if p.Platforms != nil {
+			c.Platforms = map[string]string{}
+			for k, v := range p.Platforms {
+				c.Platforms[k] = v
+			}
+		}
+		// A reused build's provenance is that of the build which made it.
+		if p.Provenance != nil {
+			pv := *p.Provenance
+			c.Provenance = &pv
+		}
+		cached = append(cached, c)
+	}
+	return cached
+}
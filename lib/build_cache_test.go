@@ -0,0 +1,153 @@
+package sous
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashBuildContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-build-context")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	hash := func(exclude ...string) string {
+		h, err := HashBuildContext(dir, exclude...)
+		require.NoError(t, err)
+		return h
+	}
+
+	write("Dockerfile", "FROM scratch")
+	write("conf/runspec.json", "{}")
+	first := hash()
+	assert.Equal(t, first, hash())
+
+	write(".git/HEAD", "ref: refs/heads/master")
+	assert.Equal(t, first, hash(), ".git should not affect the hash")
+
+	write("conf/runspec.json", `{"images": []}`)
+	assert.NotEqual(t, first, hash())
+	withoutRunspec := hash("conf/runspec.json")
+	write("conf/runspec.json", "{}")
+	assert.Equal(t, withoutRunspec, hash("conf/runspec.json"))
+}
+
+func TestBuildCache(t *testing.T) {
+	var nilCache *BuildCache
+	_, ok := nilCache.Lookup("key")
+	assert.False(t, ok)
+	assert.NoError(t, nilCache.Store(BuildCacheEntry{Key: "key"}))
+	assert.Nil(t, NewBuildCache(""))
+
+	dir, err := ioutil.TempDir("", "sous-build-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	c := NewBuildCache(filepath.Join(dir, "cache"))
+
+	sid := MustNewSourceID("github.com/opentable/example", "", "1.2.3+abc")
+	key := BuildCacheKey(sid, "", "hash")
+	assert.NotEqual(t, key, BuildCacheKey(sid, "builder", "hash"))
+	assert.NotEqual(t, key, BuildCacheKey(sid, "", "other"))
+	assert.NotEqual(t, key, BuildCacheKey(MustNewSourceID("github.com/opentable/example", "", "1.2.3+def"), "", "hash"))
+
+	_, ok = c.Lookup(key)
+	assert.False(t, ok)
+	require.NoError(t, c.Store(BuildCacheEntry{Key: key, Source: sid, Products: []*BuildProduct{{ID: "cabba9e"}}}))
+	e, ok := c.Lookup(key)
+	require.True(t, ok)
+	assert.Equal(t, "cabba9e", e.Products[0].ID)
+	assert.Equal(t, sid.String(), e.Source.String())
+}
+
+type cacheTestBuildpack struct{ builds int }
+
+func (bp *cacheTestBuildpack) Detect(*BuildContext) (*DetectResult, error) {
+	return &DetectResult{Compatible: true}, nil
+}
+
+func (bp *cacheTestBuildpack) Build(*BuildContext) (*BuildResult, error) {
+	bp.builds++
+	return &BuildResult{Products: []*BuildProduct{{ID: "image-id", Advisories: []string{"from buildpack"}}}}, nil
+}
+
+func (bp *cacheTestBuildpack) SelectBuildpack(*BuildContext) (Buildpack, error) { return bp, nil }
+
+type cacheTestRegistrar struct {
+	registered []*BuildResult
+	fail       bool
+}
+
+func (r *cacheTestRegistrar) ApplyMetadata(*BuildResult) error {
+	if r.fail {
+		return errors.New("no such image")
+	}
+	return nil
+}
+
+func (r *cacheTestRegistrar) Register(br *BuildResult) error {
+	r.registered = append(r.registered, br)
+	return nil
+}
+
+func TestBuildManager_Build_Cache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-build-manager")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	require.NoError(t, os.MkdirAll(src, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, "Dockerfile"), []byte("FROM scratch"), 0644))
+
+	sh, _ := shell.NewTestShell()
+	bp := &cacheTestBuildpack{}
+	reg := &cacheTestRegistrar{}
+	m := &BuildManager{
+		BuildConfig: &BuildConfig{
+			Tag:  "1.2.3",
+			Repo: "github.com/opentable/example",
+			Context: &BuildContext{
+				Sh:     sh,
+				Source: SourceContext{RootDir: src, Revision: "abc"},
+			},
+			LogSink: logging.SilentLogSet(),
+		},
+		Selector:  bp,
+		Labeller:  reg,
+		Registrar: reg,
+		Cache:     NewBuildCache(filepath.Join(dir, "cache")),
+		LogSink:   logging.SilentLogSet(),
+	}
+
+	_, err = m.Build()
+	require.NoError(t, err)
+	br, err := m.Build()
+	require.NoError(t, err)
+	assert.Equal(t, 1, bp.builds, "the second build should reuse the first")
+	require.Len(t, reg.registered, 2)
+	assert.Equal(t, "image-id", br.Products[0].ID)
+	assert.Equal(t, "from buildpack", br.Products[0].Advisories[0])
+	assert.Equal(t, "github.com/opentable/example", br.Products[0].Source.Location.Repo)
+
+	// A changed build context misses the cache.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, "Dockerfile"), []byte("FROM other"), 0644))
+	_, err = m.Build()
+	require.NoError(t, err)
+	assert.Equal(t, 2, bp.builds)
+
+	// So does a cached artifact which can no longer be labelled; the build
+	// runs again.
+	reg.fail = true
+	_, err = m.Build()
+	assert.Error(t, err)
+	assert.Equal(t, 3, bp.builds)
+}
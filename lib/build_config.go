@@ -14,8 +14,11 @@ type (
 	BuildConfig struct {
 		Repo, Offset, Tag, Revision string
 		Strict, ForceClone, Dev     bool
-		Context                     *BuildContext
-		LogSink                     logging.LogSink
+		// Platforms, if not empty, are the platforms (e.g. "linux/arm64")
+		// to build images for, rather than that of the build host.
+		Platforms []string
+		Context   *BuildContext
+		LogSink   logging.LogSink
 	}
 
 	// An AdvisoryName is the type for advisory tokens.
@@ -84,6 +87,10 @@ func AllAdvisories() []AdvisoryName {
 		BogusRev,
 		DirtyWS,
 		DeveloperBuild,
+		CriticalVulnerability,
+		HighVulnerability,
+		MediumVulnerability,
+		LowVulnerability,
 	}
 }
 
@@ -195,7 +202,7 @@ func (c *BuildConfig) GuardRegister(br *BuildResult) error {
 	for _, p := range br.Products {
 		for _, a := range p.Advisories {
 			switch AdvisoryName(a) {
-			case DirtyWS, UnpushedRev, NoRepoAdv, NotRequestedRevision:
+			case DirtyWS, UnpushedRev, NoRepoAdv, NotRequestedRevision, CriticalVulnerability:
 				blockers = append(blockers, fmt.Sprintf("%s: %s", p.Source.String(), a))
 			}
 		}
@@ -1,11 +1,14 @@
 package sous
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/opentable/sous/util/firsterr"
 	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/logging/messages"
 	"github.com/pkg/errors"
 )
 
@@ -17,6 +20,14 @@ type (
 		Selector
 		Labeller
 		Registrar
+		// Cache, if not nil, records the products of each build, so that
+		// building the same revision from the same build context again
+		// re-registers those products rather than building them again.
+		Cache *BuildCache
+		// Scanner, if not nil, scans each deployable product for
+		// vulnerabilities before it is labelled, recording an advisory for
+		// the severity of each finding.
+		Scanner Scanner
 		LogSink logging.LogSink
 	}
 )
@@ -25,21 +36,95 @@ type (
 func (m *BuildManager) Build() (*BuildResult, error) {
 	// TODO if BuildConfig.ForceClone, then clone
 	var (
-		bp Buildpack
-		bc *BuildContext
-		br *BuildResult
+		bc  *BuildContext
+		br  *BuildResult
+		key string
 	)
 	err := firsterr.Set(
 		func(e *error) { *e = m.BuildConfig.Validate() },
 		func(e *error) { bc = m.BuildConfig.NewContext() },
 		func(e *error) { *e = m.BuildConfig.GuardStrict(bc) },
+		func(e *error) { key, *e = m.cacheKey(bc) },
+		func(e *error) {
+			if br = m.rebuild(bc, key); br == nil {
+				br, *e = m.build(bc, key)
+			}
+		},
+	)
+	return br, errors.Wrap(err, "unable to build")
+}
+
+// build runs the whole build pipeline, recording the products under key in
+// the cache once they are registered.
+func (m *BuildManager) build(bc *BuildContext, key string) (*BuildResult, error) {
+	var (
+		bp     Buildpack
+		br     *BuildResult
+		cached []*BuildProduct
+	)
+	err := firsterr.Set(
 		func(e *error) { bp, *e = m.SelectBuildpack(bc) },
-		func(e *error) { br, *e = bp.Build(bc) },
+		func(e *error) { br, *e = buildPlatforms(bp, bc, m.BuildConfig.Platforms) },
+		func(e *error) { m.attest(bc, br) },
+		func(e *error) { cached = cachedProducts(br.Products) },
 		func(e *error) { br.Contextualize(bc) },
+		func(e *error) { *e = m.scan(br) },
 		func(e *error) { *e = m.ApplyMetadata(br) },
 		func(e *error) { *e = m.RegisterAndWarnAdvisories(br) },
 	)
-	return br, errors.Wrap(err, "unable to build")
+	if err != nil {
+		return br, err
+	}
+	entry := BuildCacheEntry{Key: key, Source: bc.Version(), Products: cached, Time: time.Now()}
+	if err := m.Cache.Store(entry); err != nil {
+		logging.ReportError(m.LogSink, errors.Wrapf(err, "caching build of %s", bc.Version()))
+	}
+	return br, nil
+}
+
+// rebuild re-registers the products cached under key, if any. It returns nil
+// if there are none, or they could not be registered (e.g. because the images
+// have since been removed), in which case the build must be run again.
+func (m *BuildManager) rebuild(bc *BuildContext, key string) *BuildResult {
+	entry, ok := m.Cache.Lookup(key)
+	if !ok {
+		return nil
+	}
+	start := time.Now()
+	br := &BuildResult{Products: cachedProducts(entry.Products)}
+	br.Contextualize(bc)
+	// Scan again: new vulnerabilities may have been published since the
+	// cached build.
+	if err := m.scan(br); err != nil {
+		messages.ReportLogFieldsMessage("Cached build unusable; building again", logging.WarningLevel, m.LogSink, key, err)
+		return nil
+	}
+	if err := m.ApplyMetadata(br); err != nil {
+		messages.ReportLogFieldsMessage("Cached build unusable; building again", logging.WarningLevel, m.LogSink, key, err)
+		return nil
+	}
+	if err := m.RegisterAndWarnAdvisories(br); err != nil {
+		messages.ReportLogFieldsMessage("Cached build unusable; building again", logging.WarningLevel, m.LogSink, key, err)
+		return nil
+	}
+	messages.ReportLogFieldsMessageToConsole(fmt.Sprintf("Reused the build of %s from %s", entry.Source, entry.Time.Format(time.RFC3339)),
+		logging.InformationLevel, m.LogSink)
+	br.Elapsed = time.Since(start)
+	return br
+}
+
+// cacheKey returns the key under which bc's build is cached, or "" if there
+// is no cache.
+func (m *BuildManager) cacheKey(bc *BuildContext) (string, error) {
+	if m.Cache == nil {
+		return "", nil
+	}
+	hash, err := HashBuildContext(filepath.Join(bc.Source.RootDir, bc.Source.OffsetDir))
+	if err != nil {
+		return "", err
+	}
+	// Builds for specific platforms produce different images.
+	return BuildCacheKey(bc.Version(), strings.Join(m.BuildConfig.Platforms, ","), hash), nil
 }
 
 // RegisterAndWarnAdvisories registers the image if there are no blocking
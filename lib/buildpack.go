@@ -55,6 +55,15 @@ type (
 		Build(*BuildContext) (*BuildResult, error)
 	}
 
+	// A PlatformBuildpack is a Buildpack which can build images for platforms
+	// other than that of the build host.
+	PlatformBuildpack interface {
+		Buildpack
+		// BuildPlatform is like Build, but builds for platform, e.g.
+		// "linux/arm64".
+		BuildPlatform(c *BuildContext, platform string) (*BuildResult, error)
+	}
+
 	// DetectResult represents the result of a detection.
 	DetectResult struct {
 		// Compatible is true when the buildpack is compatible with the source
@@ -86,6 +95,16 @@ type (
 		// prescriptive advice about how the image might be deployed.
 		ID         string // was ImageID
 		Advisories []string
+		// Vulnerabilities are the findings of scanning the product, if it was
+		// scanned.
+		Vulnerabilities []Vulnerability
+		// Platforms maps each platform the product was built for to the ID
+		// of its image, if it was built for specific platforms (see
+		// BuildConfig.Platforms). ID is then that of the first platform.
+		Platforms map[string]string
+		// Provenance records how the product was built. The Registrar pushes
+		// it alongside the image.
+		Provenance *Provenance
 
 		// VersionName and RevisionName cache computations about how to refer to the image.
 		VersionName  string
@@ -128,6 +147,12 @@ func (bp *BuildProduct) String() string {
 	if len(bp.Advisories) > 0 {
 		str = str + "\nAdvisories:\n  " + strings.Join(bp.Advisories, "  \n")
 	}
+	if len(bp.Vulnerabilities) > 0 {
+		str = str + "\nVulnerabilities:"
+		for _, v := range bp.Vulnerabilities {
+			str = str + "\n  " + v.String()
+		}
+	}
 	return str
 }
 
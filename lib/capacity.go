@@ -0,0 +1,227 @@
+package sous
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+type (
+	// A Quota limits the resources which deployments may claim in total. A
+	// zero limit is no limit.
+	Quota struct {
+		// Cpus is the number of CPUs which may be claimed.
+		Cpus float64 `yaml:",omitempty"`
+		// Memory is the memory, in MB, which may be claimed.
+		Memory float64 `yaml:",omitempty"`
+	}
+
+	// OwnerQuotas is a list of OwnerQuota.
+	OwnerQuotas []OwnerQuota
+
+	// An OwnerQuota limits the resources claimed by the deployments of one
+	// owner in each of some clusters.
+	OwnerQuota struct {
+		// Owner is the owner, as listed in the Owners of manifests, whose
+		// deployments are limited.
+		Owner string
+		// Clusters are patterns, as for path.Match, which select the names of
+		// the clusters the quota applies in. If empty, it applies in all of
+		// them.
+		Clusters []string `yaml:",omitempty"`
+		Quota    `yaml:",inline"`
+	}
+
+	// Usage is the total of the resources claimed by some deployments. An
+	// autoscaled deployment claims the resources of its MaxInstances, since
+	// its Scaler may scale it that far without changing the GDM.
+	Usage struct {
+		Cpus      float64
+		Memory    float64
+		Instances int
+	}
+
+	// A CapacityEntry compares the resources claimed in a cluster, either by
+	// all deployments or by one owner's, with the quota for them.
+	CapacityEntry struct {
+		Cluster string
+		// Owner is empty for the entry for the whole cluster.
+		Owner string `json:",omitempty"`
+		Used  Usage
+		Quota Quota
+	}
+
+	// A CapacityReport lists a CapacityEntry for each cluster, followed by
+	// one for each owner with deployments or a quota in it.
+	CapacityReport []CapacityEntry
+
+	// A QuotaError is returned when a change would claim more resources than
+	// a quota allows.
+	QuotaError struct {
+		Exceeded []CapacityEntry
+	}
+)
+
+// Clone returns a deep copy of this OwnerQuotas.
+func (oqs OwnerQuotas) Clone() OwnerQuotas {
+	if oqs == nil {
+		return nil
+	}
+	c := make(OwnerQuotas, len(oqs))
+	for i, oq := range oqs {
+		oq.Clusters = append([]string(nil), oq.Clusters...)
+		c[i] = oq
+	}
+	return c
+}
+
+// Matches returns true if oq applies to owner's deployments in cluster.
+func (oq OwnerQuota) Matches(owner, cluster string) bool {
+	if oq.Owner != owner {
+		return false
+	}
+	if len(oq.Clusters) == 0 {
+		return true
+	}
+	for _, pattern := range oq.Clusters {
+		if ok, _ := path.Match(pattern, cluster); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the first quota which applies to owner in cluster, or the
+// zero Quota if there is none.
+func (oqs OwnerQuotas) Get(owner, cluster string) Quota {
+	for _, oq := range oqs {
+		if oq.Matches(owner, cluster) {
+			return oq.Quota
+		}
+	}
+	return Quota{}
+}
+
+// Add adds the resources claimed by d to u.
+func (u *Usage) Add(d *Deployment) {
+	instances := d.NumInstances
+	if d.Autoscale.Enabled() && d.Autoscale.MaxInstances > instances {
+		instances = d.Autoscale.MaxInstances
+	}
+	u.Cpus += d.Resources.Cpus() * float64(instances)
+	u.Memory += d.Resources.Memory() * float64(instances)
+	u.Instances += instances
+}
+
+// Headroom returns the resources which may yet be claimed under q by
+// deployments which have claimed u. Limits which q leaves unset are zero, and
+// limits already exceeded are negative.
+func (q Quota) Headroom(u Usage) Quota {
+	var h Quota
+	if q.Cpus != 0 {
+		h.Cpus = q.Cpus - u.Cpus
+	}
+	if q.Memory != 0 {
+		h.Memory = q.Memory - u.Memory
+	}
+	return h
+}
+
+// Exceeded returns true if u claims more than q allows.
+func (q Quota) Exceeded(u Usage) bool {
+	return (q.Cpus != 0 && u.Cpus > q.Cpus) || (q.Memory != 0 && u.Memory > q.Memory)
+}
+
+// Exceeded returns true if e's usage is more than its quota allows.
+func (e CapacityEntry) Exceeded() bool {
+	return e.Quota.Exceeded(e.Used)
+}
+
+func (e CapacityEntry) String() string {
+	what := "cluster " + e.Cluster
+	if e.Owner != "" {
+		what = fmt.Sprintf("%s in cluster %s", e.Owner, e.Cluster)
+	}
+	return fmt.Sprintf("%s claims %g cpus and %gMB memory; quota is %g cpus and %gMB memory",
+		what, e.Used.Cpus, e.Used.Memory, e.Quota.Cpus, e.Quota.Memory)
+}
+
+// Capacity reports the resources claimed by ds in each cluster in defs, and
+// by each owner of them, along with the quotas for them.
+func (defs Defs) Capacity(ds Deployments) CapacityReport {
+	type key struct{ cluster, owner string }
+	used := map[key]*Usage{}
+	usage := func(k key) *Usage {
+		if used[k] == nil {
+			used[k] = &Usage{}
+		}
+		return used[k]
+	}
+
+	for _, name := range defs.Clusters.Names() {
+		usage(key{cluster: name})
+		for _, oq := range defs.OwnerQuotas {
+			if oq.Matches(oq.Owner, name) {
+				usage(key{name, oq.Owner})
+			}
+		}
+	}
+	for _, d := range ds.Snapshot() {
+		usage(key{cluster: d.ClusterName}).Add(d)
+		for _, owner := range d.Owners.Slice() {
+			usage(key{d.ClusterName, owner}).Add(d)
+		}
+	}
+
+	report := make(CapacityReport, 0, len(used))
+	for k, u := range used {
+		e := CapacityEntry{Cluster: k.cluster, Owner: k.owner, Used: *u}
+		if k.owner != "" {
+			e.Quota = defs.OwnerQuotas.Get(k.owner, k.cluster)
+		} else if c := defs.Clusters[k.cluster]; c != nil {
+			e.Quota = c.Quota
+		}
+		report = append(report, e)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Cluster != report[j].Cluster {
+			return report[i].Cluster < report[j].Cluster
+		}
+		return report[i].Owner < report[j].Owner
+	})
+	return report
+}
+
+// CheckCapacity returns a QuotaError if post claims more resources than a
+// quota in defs allows, where it claims more than prior did. Deployments
+// already over quota do not block changes which do not add to their claims.
+func (defs Defs) CheckCapacity(prior, post Deployments) error {
+	before := map[[2]string]Usage{}
+	for _, e := range defs.Capacity(prior) {
+		before[[2]string{e.Cluster, e.Owner}] = e.Used
+	}
+
+	var exceeded []CapacityEntry
+	for _, e := range defs.Capacity(post) {
+		if !e.Exceeded() {
+			continue
+		}
+		b := before[[2]string{e.Cluster, e.Owner}]
+		if e.Used.Cpus > b.Cpus || e.Used.Memory > b.Memory {
+			exceeded = append(exceeded, e)
+		}
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+	return &QuotaError{Exceeded: exceeded}
+}
+
+func (e *QuotaError) Error() string {
+	msgs := make([]string, len(e.Exceeded))
+	for i, x := range e.Exceeded {
+		msgs[i] = x.String()
+	}
+	return "quota exceeded: " + strings.Join(msgs, "; ")
+}
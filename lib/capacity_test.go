@@ -0,0 +1,93 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func capacityTestDeployment(repo, cluster string, instances int, owners ...string) *Deployment {
+	return &Deployment{
+		ClusterName: cluster,
+		SourceID:    MustParseSourceID(repo + ",1.0.0"),
+		Owners:      NewOwnerSet(owners...),
+		DeployConfig: DeployConfig{
+			NumInstances: instances,
+			Resources:    Resources{"cpus": "0.5", "memory": "256", "ports": "1"},
+		},
+	}
+}
+
+func capacityTestDefs() Defs {
+	return Defs{
+		Clusters: Clusters{
+			"one": &Cluster{Quota: Quota{Cpus: 4}},
+			"two": &Cluster{},
+		},
+		OwnerQuotas: OwnerQuotas{
+			{Owner: "team-a", Clusters: []string{"o*"}, Quota: Quota{Memory: 1024}},
+			{Owner: "team-b", Quota: Quota{Cpus: 1}},
+		},
+	}
+}
+
+func TestDefs_Capacity(t *testing.T) {
+	defs := capacityTestDefs()
+	ds := NewDeployments(
+		capacityTestDeployment("github.com/ot/a", "one", 2, "team-a"),
+		capacityTestDeployment("github.com/ot/ab", "one", 2, "team-a", "team-b"),
+		capacityTestDeployment("github.com/ot/a", "two", 3, "team-a"),
+	)
+
+	report := defs.Capacity(ds)
+	require.Len(t, report, 6)
+
+	assert.Equal(t, CapacityEntry{Cluster: "one", Used: Usage{Cpus: 2, Memory: 1024, Instances: 4}, Quota: Quota{Cpus: 4}}, report[0])
+	assert.Equal(t, CapacityEntry{Cluster: "one", Owner: "team-a", Used: Usage{Cpus: 2, Memory: 1024, Instances: 4}, Quota: Quota{Memory: 1024}}, report[1])
+	assert.Equal(t, CapacityEntry{Cluster: "one", Owner: "team-b", Used: Usage{Cpus: 1, Memory: 512, Instances: 2}, Quota: Quota{Cpus: 1}}, report[2])
+	assert.Equal(t, CapacityEntry{Cluster: "two", Used: Usage{Cpus: 1.5, Memory: 768, Instances: 3}}, report[3])
+	assert.Equal(t, CapacityEntry{Cluster: "two", Owner: "team-a", Used: Usage{Cpus: 1.5, Memory: 768, Instances: 3}}, report[4])
+	assert.Equal(t, CapacityEntry{Cluster: "two", Owner: "team-b", Quota: Quota{Cpus: 1}}, report[5])
+
+	for _, e := range report {
+		assert.False(t, e.Exceeded(), "%s", e)
+	}
+	assert.Equal(t, Quota{Cpus: 2}, report[0].Quota.Headroom(report[0].Used))
+	assert.Equal(t, Quota{Memory: 0}, report[1].Quota.Headroom(report[1].Used))
+}
+
+func TestDefs_CheckCapacity(t *testing.T) {
+	defs := capacityTestDefs()
+	d := capacityTestDeployment("github.com/ot/a", "one", 2, "team-a")
+	prior := NewDeployments(d)
+
+	post := prior.Clone()
+	grown := d.Clone()
+	grown.NumInstances = 6
+	post.Set(grown.ID(), grown)
+
+	err := defs.CheckCapacity(prior, post)
+	require.IsType(t, &QuotaError{}, err)
+	exceeded := err.(*QuotaError).Exceeded
+	require.Len(t, exceeded, 1)
+	assert.Equal(t, "team-a", exceeded[0].Owner)
+	assert.Contains(t, err.Error(), "team-a in cluster one claims 3 cpus and 1536MB memory")
+
+	// Changes which do not add to a claim already over quota are allowed.
+	shrunk := grown.Clone()
+	shrunk.NumInstances = 5
+	after := post.Clone()
+	after.Set(shrunk.ID(), shrunk)
+	assert.NoError(t, defs.CheckCapacity(post, after))
+
+	// Autoscaled deployments claim as many instances as they may be scaled to.
+	autoscaled := d.Clone()
+	autoscaled.Autoscale = Autoscale{MinInstances: 1, MaxInstances: 10, Metric: "rps", Target: 100}
+	scaling := prior.Clone()
+	scaling.Set(autoscaled.ID(), autoscaled)
+	assert.IsType(t, &QuotaError{}, defs.CheckCapacity(prior, scaling))
+
+	// So are changes in clusters without quotas.
+	assert.NoError(t, defs.CheckCapacity(NewDeployments(), NewDeployments(capacityTestDeployment("github.com/ot/a", "two", 100))))
+}
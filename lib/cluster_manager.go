@@ -1,6 +1,10 @@
 package sous
 
-import "github.com/nyarly/spies"
+import (
+	"time"
+
+	"github.com/nyarly/spies"
+)
 
 type (
 	// ClusterManager reads and writes deployments as scoped by cluster
@@ -14,7 +18,8 @@ type (
 	}
 
 	clusterManagerDecorator struct {
-		sm StateManager
+		sm    StateManager
+		facts PromotionFactFinder
 	}
 )
 
@@ -36,8 +41,9 @@ func (cm clusterManagerSpy) WriteCluster(clusterName string, deps Deployments, u
 
 // MakeClusterManager wraps a StateManager in a ClusterManager. This is the easy way to get a ClusterManager;
 // It's assumed that more effecient ClusterManager implementations could be added to specific StateManagers.
-func MakeClusterManager(sm StateManager) ClusterManager {
-	return &clusterManagerDecorator{sm: sm}
+// Writes are checked against the promotion rules with what facts finds out.
+func MakeClusterManager(sm StateManager, facts PromotionFactFinder) ClusterManager {
+	return &clusterManagerDecorator{sm: sm, facts: facts}
 }
 
 // ReadCluster implements ClusterManager on the MakeClusterManager implementation
@@ -63,12 +69,12 @@ func (deco *clusterManagerDecorator) WriteCluster(clusterName string, wds Deploy
 		return err
 	}
 
-	deps, err := state.Deployments()
+	prior, err := state.Deployments()
 	if err != nil {
 		return err
 	}
 	//cut out the deps we know about with the supplied name...
-	deps = deps.Filter(func(d *Deployment) bool {
+	deps := prior.Filter(func(d *Deployment) bool {
 		return d.ClusterName != clusterName
 	})
 	ds := []*Deployment{}
@@ -79,6 +85,13 @@ func (deco *clusterManagerDecorator) WriteCluster(clusterName string, wds Deploy
 	if err != nil {
 		return err
 	}
+	post, err := state.Deployments()
+	if err != nil {
+		return err
+	}
+	if err := state.CheckChanges(prior, post, user, time.Now(), deco.facts); err != nil {
+		return err
+	}
 
 	return deco.sm.WriteState(state, user)
 
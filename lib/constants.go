@@ -10,6 +10,10 @@ const ClusterNameLabel = "com.opentable.sous.clustername"
 // SingularityDeployMetadataFlavor defines the namespace for storing a Sous Flavor in SingularityDeploy metadata.
 const FlavorLabel = "com.opentable.sous.flavor"
 
+// SecretRefsLabel is the metadata fieldname that records, as JSON, the secret references the values of a
+// deployment's environment variables were resolved from.
+const SecretRefsLabel = "com.opentable.sous.secret_refs"
+
 // RepoLabel is the metadata fieldname that records the version control repository URL of a Sous-controlled service.
 const RepoLabel = "com.opentable.sous.repo_url"
 
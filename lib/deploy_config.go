@@ -29,8 +29,8 @@ type (
 		//Args []string `yaml:",omitempty" validate:"values=nonempty"`
 		// NumInstances is a guide to the number of instances that should be
 		// deployed in this cluster, note that the actual number may differ due
-		// to decisions made by Sous. If set to zero, Sous will decide how many
-		// instances to launch.
+		// to decisions made by Sous, e.g. to Autoscale. If set to zero, Sous
+		// will decide how many instances to launch.
 		NumInstances int
 		// Volumes lists the volume mappings for this deploy
 		Volumes Volumes
@@ -38,6 +38,10 @@ type (
 		Startup Startup `yaml:",omitempty"`
 		// Schedule is a cronjob-format schedule for jobs.
 		Schedule string
+		// Rollout configures progressive rollout of new versions.
+		Rollout Rollout `yaml:",omitempty"`
+		// Autoscale configures scaling of NumInstances according to load.
+		Autoscale Autoscale `yaml:",omitempty"`
 	}
 
 	// A DeployConfigs is a map from cluster name to DeployConfig
@@ -79,6 +83,15 @@ func (dc *DeployConfig) Validate() []Flaw {
 
 	flaws = append(flaws, dc.Startup.Validate()...)
 
+	flaws = append(flaws, dc.Rollout.Validate()...)
+	flaws = append(flaws, dc.Env.validateSecretRefs()...)
+	if dc.Rollout.CanaryInstances > 0 && dc.NumInstances > 0 && dc.Rollout.CanaryInstances >= dc.NumInstances {
+		flaws = append(flaws, FatalFlaw("Rollout CanaryInstances (%d) must be fewer than NumInstances (%d).",
+			dc.Rollout.CanaryInstances, dc.NumInstances))
+	}
+
+	flaws = append(flaws, dc.Autoscale.Validate()...)
+
 	for _, f := range flaws {
 		f.AddContext("deploy config", dc)
 	}
@@ -150,6 +163,8 @@ func (dc *DeployConfig) Diff(o DeployConfig) (bool, []string) {
 		}
 	}
 	diffs = append(diffs, dc.Startup.diff(o.Startup)...)
+	// Rollout and Autoscale are deliberately not compared: they govern how
+	// changes are applied, and schedulers do not report them back.
 	// TODO: Compare Args
 	return len(diffs) == 0, diffs
 }
@@ -174,6 +189,8 @@ func (dc DeployConfig) Clone() (c DeployConfig) {
 	c.Volumes = dc.Volumes.Clone()
 	c.Startup = dc.Startup
 	c.Schedule = dc.Schedule
+	c.Rollout = dc.Rollout.Clone()
+	c.Autoscale = dc.Autoscale
 
 	return
 }
@@ -246,6 +263,18 @@ func flattenDeployConfigs(dcs []DeployConfig) DeployConfig {
 			break
 		}
 	}
+	for _, c := range dcs {
+		if !c.Rollout.IsZero() {
+			dc.Rollout = c.Rollout.Clone()
+			break
+		}
+	}
+	for _, c := range dcs {
+		if !c.Autoscale.IsZero() {
+			dc.Autoscale = c.Autoscale
+			break
+		}
+	}
 	for _, c := range dcs {
 		for n, v := range c.Resources {
 			if _, set := dc.Resources[n]; !set {
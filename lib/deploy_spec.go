@@ -73,6 +73,15 @@ func (spec DeploySpec) Diff(other DeploySpec) (bool, []string) {
 	for _, d := range configDiffs {
 		diff(d)
 	}
+	// Rollout and Autoscale are not part of DeployConfig.Diff, since they
+	// cannot be read back from a running deployment, but they are part of
+	// the manifest.
+	if !spec.Rollout.Equal(other.Rollout) {
+		diff("rollout; this: %+v; other: %+v", spec.Rollout, other.Rollout)
+	}
+	if spec.Autoscale != other.Autoscale {
+		diff("autoscale; this: %+v; other: %+v", spec.Autoscale, other.Autoscale)
+	}
 	return len(diffs) != 0, diffs
 }
 
@@ -62,6 +62,12 @@ func (dp *DeployablePair) Diffs() Differences {
 	return diffs
 }
 
+// uninitialized returns true if Post has zero instances or version 0.0.0, in
+// which case adding it is a no-op.
+func (dp *DeployablePair) uninitialized() bool {
+	return dp.Post.NumInstances == 0 || dp.Post.DeploySpec().Version.String() == "0.0.0"
+}
+
 // SameResolution returns a DiffResolution indicating that there is no intended
 // change. The deployment may either be stable or in the process of being
 // deployed.
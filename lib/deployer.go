@@ -11,10 +11,29 @@ type (
 		Status(Registry, Clusters, *DeployablePair) (*DeployState, error)
 	}
 
+	// A CanaryDeployer is a Deployer which can run a canary of a new version
+	// as a separate deployment beside the current one, leaving the current
+	// one at full capacity. Staged Rollouts require a CanaryDeployer.
+	CanaryDeployer interface {
+		Deployer
+		// DeployCanary deploys pair.Post as the canary of its deployment,
+		// replacing any canary already running.
+		DeployCanary(*DeployablePair) error
+		// CanaryStatus returns the state of the canary of pair.Post.
+		CanaryStatus(Registry, Clusters, *DeployablePair) (*DeployState, error)
+		// RemoveCanary removes the canary of d, if there is one.
+		RemoveCanary(d *Deployable) error
+	}
+
 	// DeployerSpy is a noop deployer.
 	DeployerSpy struct {
 		*spies.Spy
 	}
+
+	// CanaryDeployerSpy is a noop canary deployer.
+	CanaryDeployerSpy struct {
+		DeployerSpy
+	}
 )
 
 // NewDummyDeployer creates a DummyDeployer
@@ -50,3 +69,28 @@ func (dd *DeployerSpy) Status(r Registry, c Clusters, p *DeployablePair) (*Deplo
 	res := dd.Called(r, c, p)
 	return res.Get(0).(*DeployState), res.Error(1)
 }
+
+// NewCanaryDeployerSpy returns a spy implementation of CanaryDeployer.
+func NewCanaryDeployerSpy() (CanaryDeployer, *spies.Spy) {
+	spy := spies.NewSpy()
+
+	return &CanaryDeployerSpy{DeployerSpy{Spy: spy}}, spy
+}
+
+// DeployCanary implements CanaryDeployer
+func (dd *CanaryDeployerSpy) DeployCanary(p *DeployablePair) error {
+	res := dd.Called(p)
+	return res.Error(0)
+}
+
+// CanaryStatus implements CanaryDeployer
+func (dd *CanaryDeployerSpy) CanaryStatus(r Registry, c Clusters, p *DeployablePair) (*DeployState, error) {
+	res := dd.Called(r, c, p)
+	return res.Get(0).(*DeployState), res.Error(1)
+}
+
+// RemoveCanary implements CanaryDeployer
+func (dd *CanaryDeployerSpy) RemoveCanary(d *Deployable) error {
+	res := dd.Called(d)
+	return res.Error(0)
+}
@@ -68,16 +68,15 @@ func (dm *deploymentManagerDecorator) ReadDeployment(did DeploymentID) (*Deploym
 	return dep, nil
 }
 
+// WriteDeployment implements DeploymentManager on deploymentManagerDecorator
 func (dm *deploymentManagerDecorator) WriteDeployment(dep *Deployment, user User) error {
 	state, err := dm.ReadState()
 	if err != nil {
 		return err
 	}
 
-	deps, err := state.Deployments()
-	if err != nil {
+	if err := state.UpdateDeployments(dep); err != nil {
 		return err
 	}
-	deps.Set(dep.ID(), dep)
 	return dm.WriteState(state, user)
 }
@@ -19,6 +19,12 @@ func TestDeploymentDiffAnalysis(t *testing.T) {
 		"Deployment.Cluster.BaseURL",
 		"Deployment.Cluster.Env",
 		"Deployment.Cluster.AllowedAdvisories",
+		"Deployment.Cluster.RequireApproval",
+		"Deployment.Cluster.SigningKeys",
+		"Deployment.Cluster.Platforms",
+		"Deployment.Cluster.Quota",
+		"Deployment.Cluster.Quota.Cpus",
+		"Deployment.Cluster.Quota.Memory",
 		"Deployment.Cluster.Startup",
 		"Deployment.Cluster.Startup.SkipCheck",
 		"Deployment.Cluster.Startup.CheckReadyURIPath",
@@ -35,6 +41,34 @@ func TestDeploymentDiffAnalysis(t *testing.T) {
 		// is is compared directly - Repo and Dir are compared implicitly thereby
 		"Deployment.SourceID.Location.Repo",
 		"Deployment.SourceID.Location.Dir",
+		// Rollout describes how changes are applied rather than what is
+		// deployed, and schedulers don't report it back, so it isn't compared.
+		"Deployment.Rollout",
+		"Deployment.Rollout.CanaryInstances",
+		"Deployment.Rollout.Steps",
+		"Deployment.Rollout.PauseSeconds",
+		"Deployment.Rollout.AbortOnFailure",
+		"Deployment.Rollout.RollbackOnFailure",
+		"Deployment.DeployConfig.Rollout",
+		"Deployment.DeployConfig.Rollout.CanaryInstances",
+		"Deployment.DeployConfig.Rollout.Steps",
+		"Deployment.DeployConfig.Rollout.PauseSeconds",
+		"Deployment.DeployConfig.Rollout.AbortOnFailure",
+		"Deployment.DeployConfig.Rollout.RollbackOnFailure",
+		// Autoscale bounds the instance count, which the server's Scaler
+		// chooses; the running deployment does not record it.
+		"Deployment.Autoscale",
+		"Deployment.Autoscale.MinInstances",
+		"Deployment.Autoscale.MaxInstances",
+		"Deployment.Autoscale.Metric",
+		"Deployment.Autoscale.Target",
+		"Deployment.Autoscale.CooldownSeconds",
+		"Deployment.DeployConfig.Autoscale",
+		"Deployment.DeployConfig.Autoscale.MinInstances",
+		"Deployment.DeployConfig.Autoscale.MaxInstances",
+		"Deployment.DeployConfig.Autoscale.Metric",
+		"Deployment.DeployConfig.Autoscale.Target",
+		"Deployment.DeployConfig.Autoscale.CooldownSeconds",
 		/*
 			"Deployment.Owners",
 			"Deployment.DeployConfig.Args",
@@ -0,0 +1,170 @@
+package sous
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultClusterKind is the Cluster.Kind assumed when a cluster does not
+// declare one.
+const DefaultClusterKind = "singularity"
+
+// A DispatchDeployer handles dispatching deployment operations to the
+// Deployer responsible for each kind of cluster.
+type DispatchDeployer struct {
+	deployers map[string]Deployer
+}
+
+// NewDispatchDeployer builds a DispatchDeployer from a map of Cluster.Kind to
+// the Deployer responsible for clusters of that kind.
+func NewDispatchDeployer(deployers map[string]Deployer) *DispatchDeployer {
+	dd := &DispatchDeployer{deployers: map[string]Deployer{}}
+	for kind, d := range deployers {
+		dd.deployers[kind] = d
+	}
+	return dd
+}
+
+// EffectiveKind returns the Kind of this cluster, or DefaultClusterKind if
+// none is set.
+func (c *Cluster) EffectiveKind() string {
+	if c == nil || c.Kind == "" {
+		return DefaultClusterKind
+	}
+	return c.Kind
+}
+
+func (dd *DispatchDeployer) deployerFor(kind string) (Deployer, error) {
+	d, ok := dd.deployers[kind]
+	if !ok {
+		return nil, errors.Errorf("No deployer for cluster kind %q", kind)
+	}
+	return d, nil
+}
+
+// RunningDeployments implements Deployer on DispatchDeployer. Each cluster is
+// queried by the Deployer for its Kind, and the results are merged.
+func (dd *DispatchDeployer) RunningDeployments(reg Registry, from Clusters) (DeployStates, error) {
+	byKind := map[string]Clusters{}
+	for name, c := range from {
+		kind := c.EffectiveKind()
+		if _, ok := byKind[kind]; !ok {
+			byKind[kind] = Clusters{}
+		}
+		byKind[kind][name] = c
+	}
+
+	kinds := make([]string, 0, len(byKind))
+	for kind := range byKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	states := NewDeployStates()
+	for _, kind := range kinds {
+		d, err := dd.deployerFor(kind)
+		if err != nil {
+			return states, errors.Wrapf(err, "clusters %s", byKind[kind])
+		}
+		ds, err := d.RunningDeployments(reg, byKind[kind])
+		if err != nil {
+			return states, errors.Wrapf(err, "%s clusters", kind)
+		}
+		for _, s := range ds.Snapshot() {
+			states.Add(s)
+		}
+	}
+	return states, nil
+}
+
+// Rectify implements Deployer on DispatchDeployer.
+func (dd *DispatchDeployer) Rectify(pair *DeployablePair) DiffResolution {
+	var cluster *Cluster
+	switch {
+	case pair.Post != nil && pair.Post.Deployment != nil:
+		cluster = pair.Post.Deployment.Cluster
+	case pair.Prior != nil && pair.Prior.Deployment != nil:
+		cluster = pair.Prior.Deployment.Cluster
+	}
+	d, err := dd.deployerFor(cluster.EffectiveKind())
+	if err != nil {
+		return DiffResolution{DeploymentID: pair.ID(), Desc: "not rectified", Error: WrapResolveError(err)}
+	}
+	return d.Rectify(pair)
+}
+
+// Status implements Deployer on DispatchDeployer.
+func (dd *DispatchDeployer) Status(reg Registry, clusters Clusters, pair *DeployablePair) (*DeployState, error) {
+	clusterName := pair.Post.Deployment.ClusterName
+	cluster, has := clusters[clusterName]
+	if !has {
+		return nil, errors.Errorf("No cluster found for %q. Known are: %q.", clusterName, clusters.Names())
+	}
+	d, err := dd.deployerFor(cluster.EffectiveKind())
+	if err != nil {
+		return nil, err
+	}
+	return d.Status(reg, clusters, pair)
+}
+
+// ScaleDeployment implements InstanceScaler on DispatchDeployer, if the
+// Deployer for the Kind of d's cluster does.
+func (dd *DispatchDeployer) ScaleDeployment(d *Deployment, instances int, message string) error {
+	dep, err := dd.deployerFor(d.Cluster.EffectiveKind())
+	if err != nil {
+		return err
+	}
+	is, ok := dep.(InstanceScaler)
+	if !ok {
+		return errors.Errorf("%s clusters cannot be autoscaled", d.Cluster.EffectiveKind())
+	}
+	return is.ScaleDeployment(d, instances, message)
+}
+
+// canaryDeployerFor returns the CanaryDeployer for clusters of kind, or a
+// RolloutUnsupportedError if their Deployer cannot run canaries.
+func (dd *DispatchDeployer) canaryDeployerFor(kind string) (CanaryDeployer, error) {
+	d, err := dd.deployerFor(kind)
+	if err != nil {
+		return nil, err
+	}
+	cd, ok := d.(CanaryDeployer)
+	if !ok {
+		return nil, &RolloutUnsupportedError{Kind: kind}
+	}
+	return cd, nil
+}
+
+// DeployCanary implements CanaryDeployer on DispatchDeployer, if the Deployer
+// for the Kind of pair.Post's cluster does.
+func (dd *DispatchDeployer) DeployCanary(pair *DeployablePair) error {
+	cd, err := dd.canaryDeployerFor(pair.Post.Deployment.Cluster.EffectiveKind())
+	if err != nil {
+		return err
+	}
+	return cd.DeployCanary(pair)
+}
+
+// CanaryStatus implements CanaryDeployer on DispatchDeployer.
+func (dd *DispatchDeployer) CanaryStatus(reg Registry, clusters Clusters, pair *DeployablePair) (*DeployState, error) {
+	clusterName := pair.Post.Deployment.ClusterName
+	cluster, has := clusters[clusterName]
+	if !has {
+		return nil, errors.Errorf("No cluster found for %q. Known are: %q.", clusterName, clusters.Names())
+	}
+	cd, err := dd.canaryDeployerFor(cluster.EffectiveKind())
+	if err != nil {
+		return nil, err
+	}
+	return cd.CanaryStatus(reg, clusters, pair)
+}
+
+// RemoveCanary implements CanaryDeployer on DispatchDeployer.
+func (dd *DispatchDeployer) RemoveCanary(d *Deployable) error {
+	cd, err := dd.canaryDeployerFor(d.Deployment.Cluster.EffectiveKind())
+	if err != nil {
+		return err
+	}
+	return cd.RemoveCanary(d)
+}
@@ -0,0 +1,125 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/nyarly/spies"
+	"github.com/stretchr/testify/assert"
+)
+
+func dispatchDeployerScenario() (*DispatchDeployer, *spies.Spy, *spies.Spy) {
+	sing, singCtrl := NewCanaryDeployerSpy()
+	kube, kubeCtrl := NewDeployerSpy()
+
+	singStates := NewDeployStates()
+	singStates.Add(&DeployState{Deployment: Deployment{ClusterName: "left", SourceID: MustParseSourceID("github.com/ot/one,1.0.0")}})
+	kubeStates := NewDeployStates()
+	kubeStates.Add(&DeployState{Deployment: Deployment{ClusterName: "right", SourceID: MustParseSourceID("github.com/ot/two,1.0.0")}})
+
+	singCtrl.MatchMethod("RunningDeployments", spies.AnyArgs, singStates, nil)
+	singCtrl.MatchMethod("Rectify", spies.AnyArgs, DiffResolution{Desc: "singularity"})
+	singCtrl.MatchMethod("Status", spies.AnyArgs, &DeployState{}, nil)
+	singCtrl.MatchMethod("DeployCanary", spies.AnyArgs, nil)
+	singCtrl.MatchMethod("RemoveCanary", spies.AnyArgs, nil)
+	kubeCtrl.MatchMethod("RunningDeployments", spies.AnyArgs, kubeStates, nil)
+	kubeCtrl.MatchMethod("Rectify", spies.AnyArgs, DiffResolution{Desc: "kubernetes"})
+	kubeCtrl.MatchMethod("Status", spies.AnyArgs, &DeployState{}, nil)
+
+	dd := NewDispatchDeployer(map[string]Deployer{
+		"singularity": sing,
+		"kubernetes":  kube,
+	})
+	return dd, singCtrl, kubeCtrl
+}
+
+func TestDispatchDeployer_RunningDeployments(t *testing.T) {
+	dd, singCtrl, kubeCtrl := dispatchDeployerScenario()
+
+	clusters := Clusters{
+		"left":  &Cluster{Name: "left"},
+		"right": &Cluster{Name: "right", Kind: "kubernetes"},
+	}
+
+	states, err := dd.RunningDeployments(NewDummyRegistry(), clusters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, states.Len())
+
+	if assert.Len(t, singCtrl.CallsTo("RunningDeployments"), 1) {
+		passed := singCtrl.CallsTo("RunningDeployments")[0].PassedArgs().Get(1).(Clusters)
+		assert.Equal(t, []string{"left"}, passed.Names())
+	}
+	if assert.Len(t, kubeCtrl.CallsTo("RunningDeployments"), 1) {
+		passed := kubeCtrl.CallsTo("RunningDeployments")[0].PassedArgs().Get(1).(Clusters)
+		assert.Equal(t, []string{"right"}, passed.Names())
+	}
+}
+
+func TestDispatchDeployer_UnknownKind(t *testing.T) {
+	dd, _, _ := dispatchDeployerScenario()
+
+	clusters := Clusters{"odd": &Cluster{Name: "odd", Kind: "mesos"}}
+	_, err := dd.RunningDeployments(NewDummyRegistry(), clusters)
+	assert.Error(t, err)
+
+	pair := &DeployablePair{Post: &Deployable{Deployment: &Deployment{ClusterName: "odd", Cluster: clusters["odd"]}}}
+	rez := dd.Rectify(pair)
+	assert.NotNil(t, rez.Error)
+}
+
+func TestDispatchDeployer_Rectify(t *testing.T) {
+	dd, singCtrl, kubeCtrl := dispatchDeployerScenario()
+
+	kubePair := &DeployablePair{Post: &Deployable{Deployment: &Deployment{
+		ClusterName: "right",
+		Cluster:     &Cluster{Name: "right", Kind: "kubernetes"},
+	}}}
+	assert.Equal(t, ResolutionType("kubernetes"), dd.Rectify(kubePair).Desc)
+
+	// Actual deployments may not carry a Kind; they default to singularity.
+	removed := &DeployablePair{Prior: &Deployable{Deployment: &Deployment{
+		ClusterName: "left",
+		Cluster:     &Cluster{BaseURL: "http://singularity.example.com"},
+	}}}
+	assert.Equal(t, ResolutionType("singularity"), dd.Rectify(removed).Desc)
+
+	assert.Len(t, kubeCtrl.CallsTo("Rectify"), 1)
+	assert.Len(t, singCtrl.CallsTo("Rectify"), 1)
+}
+
+func TestDispatchDeployer_Status(t *testing.T) {
+	dd, singCtrl, kubeCtrl := dispatchDeployerScenario()
+
+	clusters := Clusters{
+		"left":  &Cluster{Name: "left"},
+		"right": &Cluster{Name: "right", Kind: "kubernetes"},
+	}
+	pair := &DeployablePair{Post: &Deployable{Deployment: &Deployment{ClusterName: "right"}}}
+
+	_, err := dd.Status(NewDummyRegistry(), clusters, pair)
+	assert.NoError(t, err)
+	assert.Len(t, kubeCtrl.CallsTo("Status"), 1)
+	assert.Len(t, singCtrl.CallsTo("Status"), 0)
+}
+
+func TestDispatchDeployer_Canary(t *testing.T) {
+	dd, singCtrl, kubeCtrl := dispatchDeployerScenario()
+
+	singPair := &DeployablePair{Post: &Deployable{Deployment: &Deployment{
+		ClusterName: "left",
+		Cluster:     &Cluster{Name: "left"},
+	}}}
+	assert.NoError(t, dd.DeployCanary(singPair))
+	assert.NoError(t, dd.RemoveCanary(singPair.Post))
+	assert.Len(t, singCtrl.CallsTo("DeployCanary"), 1)
+	assert.Len(t, singCtrl.CallsTo("RemoveCanary"), 1)
+
+	kubePair := &DeployablePair{Post: &Deployable{Deployment: &Deployment{
+		ClusterName: "right",
+		Cluster:     &Cluster{Name: "right", Kind: "kubernetes"},
+	}}}
+	err := dd.DeployCanary(kubePair)
+	assert.IsType(t, &RolloutUnsupportedError{}, err)
+	assert.Len(t, kubeCtrl.Calls(), 0)
+}
@@ -19,7 +19,9 @@ func NewDispatchStateManager(localCluster string, clusters []string, local State
 	}
 	switch lcm := local.(type) {
 	default:
-		dsm.clusters[localCluster] = MakeClusterManager(local)
+		facts := &RecordedPromotionFacts{}
+		facts.History, _ = local.(HistoryReader)
+		dsm.clusters[localCluster] = MakeClusterManager(local, facts)
 	case ClusterManager:
 		dsm.clusters[localCluster] = lcm
 	}
@@ -82,3 +84,13 @@ func (dsm *DispatchStateManager) WriteCluster(clusterName string, deps Deploymen
 	}
 	return cm.WriteCluster(clusterName, deps, user)
 }
+
+// ReadHistory implements HistoryReader on DispatchStateManager, reading the
+// history kept by its local StateManager.
+func (dsm *DispatchStateManager) ReadHistory(filter *ResolveFilter) ([]ManifestChange, error) {
+	hr, ok := dsm.local.(HistoryReader)
+	if !ok {
+		return nil, errors.Errorf("%T does not keep history", dsm.local)
+	}
+	return hr.ReadHistory(filter)
+}
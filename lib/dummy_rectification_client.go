@@ -13,11 +13,17 @@ type (
 		Created  []Deployable
 		Deployed []Deployable
 		Deleted  []dummyDelete
+		Scaled   []dummyScale
 	}
 
 	dummyDelete struct {
 		Cluster, Reqid, Message string
 	}
+
+	dummyScale struct {
+		Cluster, Reqid string
+		Instances      int
+	}
 )
 
 // NewDummyRectificationClient builds a new DummyRectificationClient
@@ -58,3 +64,10 @@ func (drc *DummyRectificationClient) DeleteRequest(
 	drc.Deleted = append(drc.Deleted, dummyDelete{cluster, reqid, message})
 	return nil
 }
+
+// Scale (cluster url, request id, instance count, message)
+func (drc *DummyRectificationClient) Scale(cluster, reqid string, instanceCount int, message string) error {
+	drc.logf("Scaling application %s %s to %d: %s", cluster, reqid, instanceCount, message)
+	drc.Scaled = append(drc.Scaled, dummyScale{cluster, reqid, instanceCount})
+	return nil
+}
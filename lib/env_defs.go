@@ -0,0 +1,346 @@
+package sous
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The types an EnvDef may give its variable. Type names are not case
+// sensitive. An enumeration is written "enum(a|b|c)".
+const (
+	VarTypeString     = VarType("string")
+	VarTypeInt        = VarType("int")
+	VarTypeFloat      = VarType("float")
+	VarTypeBool       = VarType("bool")
+	VarTypeDuration   = VarType("duration")
+	VarTypeURL        = VarType("url")
+	VarTypeMemorySize = VarType("memory_size")
+)
+
+// The scopes an EnvDef may give its variable.
+const (
+	// EnvScopeAny variables may be set by clusters or manifests, or not at
+	// all.
+	EnvScopeAny = ""
+	// EnvScopeCluster variables must be set by every cluster. Manifests may
+	// override them.
+	EnvScopeCluster = "cluster"
+	// EnvScopeRequired variables must be set for every deployment, by its
+	// cluster or its manifest.
+	EnvScopeRequired = "required"
+)
+
+var (
+	varTypeAliases = map[string]VarType{
+		"":            VarTypeString,
+		"string":      VarTypeString,
+		"int":         VarTypeInt,
+		"integer":     VarTypeInt,
+		"float":       VarTypeFloat,
+		"bool":        VarTypeBool,
+		"boolean":     VarTypeBool,
+		"duration":    VarTypeDuration,
+		"url":         VarTypeURL,
+		"memory_size": VarTypeMemorySize,
+		"memorysize":  VarTypeMemorySize,
+	}
+
+	varTypeExamples = map[VarType]string{
+		VarTypeInt:        "8080",
+		VarTypeFloat:      "0.5",
+		VarTypeBool:       "true",
+		VarTypeDuration:   "30s",
+		VarTypeURL:        "https://example.com/path",
+		VarTypeMemorySize: "512Mi",
+	}
+
+	memorySizeRE = regexp.MustCompile(`(?i)^[0-9]+(\.[0-9]+)?([kmgt]i?b?|b)?$`)
+	enumTypeRE   = regexp.MustCompile(`(?i)^enum\((.*)\)$`)
+)
+
+type (
+	// An EnvFlaw reports an environment variable which breaks the rules of
+	// its definition in Defs.EnvVars.
+	EnvFlaw struct {
+		// Name is the name of the variable.
+		Name string
+		// Where is where the variable is, or ought to be, set, e.g.
+		// "cluster ci".
+		Where string
+		// Problem describes what is wrong.
+		Problem string
+		// Suggestion describes how to put it right.
+		Suggestion string
+		repair     func()
+	}
+)
+
+// canonical returns the name t is known by, and whether it is known at all.
+func (t VarType) canonical() (VarType, bool) {
+	name := strings.ToLower(strings.TrimSpace(string(t)))
+	if ct, ok := varTypeAliases[name]; ok {
+		return ct, true
+	}
+	if _, ok := t.enumValues(); ok {
+		return t, true
+	}
+	return t, false
+}
+
+// enumValues returns the values of an enumeration type, e.g. "enum(a|b)".
+func (t VarType) enumValues() ([]string, bool) {
+	m := enumTypeRE.FindStringSubmatch(strings.TrimSpace(string(t)))
+	if m == nil {
+		return nil, false
+	}
+	var vals []string
+	for _, v := range strings.Split(m[1], "|") {
+		if v = strings.TrimSpace(v); v != "" {
+			vals = append(vals, v)
+		}
+	}
+	return vals, len(vals) > 0
+}
+
+// Check returns an error if v is not a value of type t.
+func (t VarType) Check(v string) error {
+	ct, known := t.canonical()
+	if !known {
+		return errors.Errorf("unknown type %q", t)
+	}
+	if vals, ok := ct.enumValues(); ok {
+		for _, ev := range vals {
+			if v == ev {
+				return nil
+			}
+		}
+		return errors.Errorf("%q is not one of %s", v, strings.Join(vals, ", "))
+	}
+
+	var err error
+	switch ct {
+	case VarTypeString:
+	case VarTypeInt:
+		_, err = strconv.ParseInt(v, 10, 64)
+	case VarTypeFloat:
+		_, err = strconv.ParseFloat(v, 64)
+	case VarTypeBool:
+		_, err = strconv.ParseBool(v)
+	case VarTypeDuration:
+		_, err = time.ParseDuration(v)
+	case VarTypeURL:
+		var u *url.URL
+		u, err = url.Parse(v)
+		if err == nil && (u.Scheme == "" || u.Host == "") {
+			err = errors.New("missing scheme or host")
+		}
+	case VarTypeMemorySize:
+		if !memorySizeRE.MatchString(v) {
+			err = errors.New("want a number with an optional unit, e.g. 512M or 1Gi")
+		}
+	}
+	if err != nil {
+		return errors.Errorf("%q is not a valid %s", v, ct)
+	}
+	return nil
+}
+
+// example returns an example value of type t.
+func (t VarType) example() string {
+	ct, _ := t.canonical()
+	if vals, ok := ct.enumValues(); ok {
+		return vals[0]
+	}
+	if ex, ok := varTypeExamples[ct]; ok {
+		return ex
+	}
+	return "some text"
+}
+
+// fix returns a value of type t which v was probably meant to be, if there is
+// one.
+func (t VarType) fix(v string) (string, bool) {
+	ct, _ := t.canonical()
+	if trimmed := strings.TrimSpace(v); trimmed != v && ct.Check(trimmed) == nil {
+		return trimmed, true
+	}
+	v = strings.TrimSpace(v)
+	if vals, ok := ct.enumValues(); ok {
+		for _, ev := range vals {
+			if strings.EqualFold(v, ev) {
+				return ev, true
+			}
+		}
+		return "", false
+	}
+	if ct == VarTypeBool {
+		switch strings.ToLower(v) {
+		case "yes", "y", "on":
+			return "true", true
+		case "no", "n", "off":
+			return "false", true
+		}
+	}
+	return "", false
+}
+
+// Get returns the definition of the variable named name, if there is one.
+func (evs EnvDefs) Get(name string) (EnvDef, bool) {
+	for _, ev := range evs {
+		if ev.Name == name {
+			return ev, true
+		}
+	}
+	return EnvDef{}, false
+}
+
+// checkValue returns a flaw if v, the value of the variable defined by ev
+// where, is not of the type ev requires. Secret references are not checked,
+// since their values are not known until deployment. set repairs the value,
+// if the flaw can be repaired.
+func (ev EnvDef) checkValue(where, v string, set func(string)) Flaw {
+	if IsSecretRef(v) {
+		return nil
+	}
+	if _, known := ev.Type.canonical(); !known {
+		return nil
+	}
+	err := ev.Type.Check(v)
+	if err == nil {
+		return nil
+	}
+	f := &EnvFlaw{
+		Name:       ev.Name,
+		Where:      where,
+		Problem:    err.Error(),
+		Suggestion: fmt.Sprintf("set it to a %s, e.g. %s", ev.Type, ev.Type.example()),
+	}
+	if fixed, ok := ev.Type.fix(v); ok {
+		f.Suggestion = fmt.Sprintf("set it to %q", fixed)
+		f.repair = func() { set(fixed) }
+	}
+	return f
+}
+
+// ValidateEnv checks the definitions of defs.EnvVars, and the Env of each
+// cluster against them.
+func (defs Defs) ValidateEnv() []Flaw {
+	var flaws []Flaw
+	for _, ev := range defs.EnvVars {
+		if _, known := ev.Type.canonical(); !known {
+			flaws = append(flaws, &EnvFlaw{
+				Name:       ev.Name,
+				Where:      "defs",
+				Problem:    fmt.Sprintf("unknown type %q", ev.Type),
+				Suggestion: "use one of string, int, float, bool, duration, url, memory_size or enum(a|b|...)",
+			})
+		}
+		switch ev.Scope {
+		default:
+			flaws = append(flaws, &EnvFlaw{
+				Name:       ev.Name,
+				Where:      "defs",
+				Problem:    fmt.Sprintf("unknown scope %q", ev.Scope),
+				Suggestion: fmt.Sprintf("use %q, %q or leave it empty", EnvScopeCluster, EnvScopeRequired),
+			})
+		case EnvScopeAny, EnvScopeCluster, EnvScopeRequired:
+		}
+	}
+
+	for _, name := range defs.Clusters.Names() {
+		cluster := defs.Clusters[name]
+		if cluster == nil {
+			continue
+		}
+		where := "cluster " + name
+		for _, ev := range defs.EnvVars {
+			v, set := cluster.Env[ev.Name]
+			if !set {
+				if ev.Scope == EnvScopeCluster {
+					flaws = append(flaws, &EnvFlaw{
+						Name:       ev.Name,
+						Where:      where,
+						Problem:    "not set",
+						Suggestion: fmt.Sprintf("add it to the Env of %s", where),
+					})
+				}
+				continue
+			}
+			env, varName := cluster.Env, ev.Name
+			if f := ev.checkValue(where, string(v), func(fixed string) { env[varName] = Var(fixed) }); f != nil {
+				flaws = append(flaws, f)
+			}
+		}
+	}
+	return flaws
+}
+
+// ValidateManifestEnv checks the Env of each of m's deployments against
+// defs.EnvVars, taking account of the Env of the clusters they are deployed
+// to.
+func (defs Defs) ValidateManifestEnv(m *Manifest) []Flaw {
+	var flaws []Flaw
+	clusterNames := make([]string, 0, len(m.Deployments))
+	for name := range m.Deployments {
+		clusterNames = append(clusterNames, name)
+	}
+	sort.Strings(clusterNames)
+
+	for _, clusterName := range clusterNames {
+		env := m.Deployments[clusterName].Env
+		where := fmt.Sprintf("manifest %q in cluster %s", m.ID(), clusterName)
+		cluster := defs.Clusters[clusterName]
+		for _, ev := range defs.EnvVars {
+			if v, set := env[ev.Name]; set {
+				varName := ev.Name
+				if f := ev.checkValue(where, v, func(fixed string) { env[varName] = fixed }); f != nil {
+					flaws = append(flaws, f)
+				}
+				continue
+			}
+			if ev.Scope != EnvScopeRequired || cluster == nil {
+				continue
+			}
+			if _, set := cluster.Env[ev.Name]; !set {
+				flaws = append(flaws, &EnvFlaw{
+					Name:       ev.Name,
+					Where:      where,
+					Problem:    "required but not set",
+					Suggestion: fmt.Sprintf("set it to a %s, e.g. %s, in the manifest or in the Env of cluster %s", ev.Type, ev.Type.example(), clusterName),
+				})
+			}
+		}
+	}
+
+	for _, f := range flaws {
+		f.AddContext("manifest", m)
+	}
+	return flaws
+}
+
+func (f *EnvFlaw) String() string {
+	return fmt.Sprintf("env %s in %s: %s; %s", f.Name, f.Where, f.Problem, f.Suggestion)
+}
+
+// AddContext implements Flaw. An EnvFlaw records its context when it is
+// created.
+func (f *EnvFlaw) AddContext(string, interface{}) {
+}
+
+// Repair implements Flaw. Values which are only mistyped, e.g. "yes" for a
+// bool, are set to what they were meant to be; others have to be put right
+// by hand.
+func (f *EnvFlaw) Repair() error {
+	if f.repair == nil {
+		return errors.Errorf("%s: cannot be repaired automatically", f)
+	}
+	f.repair()
+	return nil
+}
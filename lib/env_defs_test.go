@@ -0,0 +1,144 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarType_Check(t *testing.T) {
+	good := []struct {
+		t VarType
+		v string
+	}{
+		{"", "anything at all"},
+		{"string", ""},
+		{"int", "8080"},
+		{"Integer", "-1"},
+		{"float", "0.5"},
+		{"bool", "false"},
+		{"duration", "1m30s"},
+		{"url", "https://example.com/path"},
+		{"memory_size", "512"},
+		{"memory_size", "512Mi"},
+		{"MemorySize", "1.5GB"},
+		{"enum(debug|info|warn)", "info"},
+	}
+	for _, c := range good {
+		assert.NoError(t, c.t.Check(c.v), "%s %q", c.t, c.v)
+	}
+
+	bad := []struct {
+		t VarType
+		v string
+	}{
+		{"int", "eight"},
+		{"int", "8.0"},
+		{"float", "half"},
+		{"bool", "yes"},
+		{"duration", "30"},
+		{"url", "example.com"},
+		{"memory_size", "lots"},
+		{"enum(debug|info|warn)", "INFO"},
+		{"wibble", "x"},
+	}
+	for _, c := range bad {
+		assert.Error(t, c.t.Check(c.v), "%s %q", c.t, c.v)
+	}
+}
+
+func envTestState() *State {
+	return &State{
+		Defs: Defs{
+			Clusters: Clusters{
+				"one": &Cluster{Env: EnvDefaults{"REGION": "one", "PORT": "8080"}},
+				"two": &Cluster{Env: EnvDefaults{"REGION": "two"}},
+			},
+			EnvVars: EnvDefs{
+				{Name: "REGION", Scope: EnvScopeCluster},
+				{Name: "PORT", Scope: EnvScopeRequired, Type: VarTypeInt},
+				{Name: "VERBOSE", Type: VarTypeBool},
+				{Name: "LOG_LEVEL", Type: "enum(debug|info)"},
+			},
+		},
+		Manifests: NewManifests(),
+	}
+}
+
+func envTestManifest(env map[string]Env) *Manifest {
+	m := &Manifest{
+		Source:      MustParseSourceLocation("github.com/user/repo"),
+		Kind:        ManifestKindService,
+		Deployments: DeploySpecs{},
+	}
+	for cluster, e := range env {
+		m.Deployments[cluster] = DeploySpec{DeployConfig: DeployConfig{Env: e}}
+	}
+	return m
+}
+
+func TestDefs_ValidateEnv(t *testing.T) {
+	s := envTestState()
+	assert.Empty(t, s.Defs.ValidateEnv())
+
+	s.Defs.Clusters["two"].Env = EnvDefaults{"PORT": "eight"}
+	s.Defs.EnvVars = append(s.Defs.EnvVars, EnvDef{Name: "X", Type: "wibble", Scope: "global"})
+	flaws := s.Defs.ValidateEnv()
+	require.Len(t, flaws, 4)
+	assert.Contains(t, flaws[0].(*EnvFlaw).Problem, `unknown type "wibble"`)
+	assert.Contains(t, flaws[1].(*EnvFlaw).Problem, `unknown scope "global"`)
+	assert.Equal(t, "cluster two", flaws[2].(*EnvFlaw).Where)
+	assert.Equal(t, "REGION", flaws[2].(*EnvFlaw).Name)
+	assert.Equal(t, "PORT", flaws[3].(*EnvFlaw).Name)
+	assert.Contains(t, flaws[3].(*EnvFlaw).Suggestion, "e.g. 8080")
+	for _, f := range flaws {
+		assert.Error(t, f.Repair())
+	}
+}
+
+func TestDefs_ValidateManifestEnv(t *testing.T) {
+	s := envTestState()
+
+	ok := envTestManifest(map[string]Env{
+		"one": {},
+		"two": {"PORT": "9000", "REGION": "elsewhere", "VERBOSE": "true", "UNDEFINED": "x"},
+	})
+	assert.Empty(t, s.Defs.ValidateManifestEnv(ok))
+
+	m := envTestManifest(map[string]Env{
+		"one": {"PORT": "eight", "LOG_LEVEL": "secret://app/config#level"},
+		"two": {"VERBOSE": "yes", "LOG_LEVEL": "Debug"},
+	})
+	flaws := s.Defs.ValidateManifestEnv(m)
+	require.Len(t, flaws, 4)
+
+	assert.Equal(t, "PORT", flaws[0].(*EnvFlaw).Name)
+	assert.Error(t, flaws[0].Repair())
+
+	assert.Equal(t, "PORT", flaws[1].(*EnvFlaw).Name)
+	assert.Equal(t, "required but not set", flaws[1].(*EnvFlaw).Problem)
+	assert.Error(t, flaws[1].Repair())
+
+	assert.Equal(t, "VERBOSE", flaws[2].(*EnvFlaw).Name)
+	assert.NoError(t, flaws[2].Repair())
+	assert.Equal(t, "LOG_LEVEL", flaws[3].(*EnvFlaw).Name)
+	assert.NoError(t, flaws[3].Repair())
+	assert.Equal(t, "true", m.Deployments["two"].Env["VERBOSE"])
+	assert.Equal(t, "debug", m.Deployments["two"].Env["LOG_LEVEL"])
+}
+
+func TestState_Validate_env(t *testing.T) {
+	s := envTestState()
+	s.Manifests.Add(envTestManifest(map[string]Env{
+		"one": {"PORT": "eight"},
+	}))
+	var envFlaws []Flaw
+	for _, f := range s.Validate() {
+		if _, is := f.(*EnvFlaw); is {
+			envFlaws = append(envFlaws, f)
+		}
+	}
+	require.Len(t, envFlaws, 1)
+	assert.Contains(t, envFlaws[0].(*EnvFlaw).String(), `env PORT in manifest "github.com/user/repo" in cluster one: "eight" is not a valid int`)
+}
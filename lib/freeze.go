@@ -0,0 +1,130 @@
+package sous
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+type (
+	// Freezes is a list of Freeze.
+	Freezes []Freeze
+
+	// A Freeze blocks changes to the deployments in some clusters for a window
+	// of time: the server rejects writes which would change them, and
+	// suspends their rectification. Users may override a Freeze in an
+	// emergency.
+	Freeze struct {
+		// Clusters are patterns, as for path.Match, which select the names of
+		// the clusters frozen. If empty, all clusters are frozen.
+		Clusters []string `yaml:",omitempty"`
+		// Start and End bound the window during which the Freeze is in effect.
+		Start, End time.Time
+		// Reason explains the Freeze to users whose changes it blocks.
+		Reason string
+		// Overriders are the emails of the users who may override the Freeze
+		// in an emergency. If empty, any user may.
+		Overriders []string `yaml:",omitempty"`
+	}
+
+	// A FreezeError is returned when a change is blocked by a Freeze.
+	FreezeError struct {
+		DeploymentID DeploymentID
+		Freeze       Freeze
+		User         User
+	}
+)
+
+// Clone returns a deep copy of this Freezes.
+func (fs Freezes) Clone() Freezes {
+	if fs == nil {
+		return nil
+	}
+	c := make(Freezes, len(fs))
+	for i, f := range fs {
+		f.Clusters = append([]string(nil), f.Clusters...)
+		f.Overriders = append([]string(nil), f.Overriders...)
+		c[i] = f
+	}
+	return c
+}
+
+// Frozen returns the first Freeze which is in effect at time at for the
+// deployment did, or nil if there is none.
+func (fs Freezes) Frozen(did DeploymentID, at time.Time) *Freeze {
+	for i := range fs {
+		if fs[i].Matches(did.Cluster, at) {
+			return &fs[i]
+		}
+	}
+	return nil
+}
+
+// Check returns a FreezeError if a Freeze in effect at time at blocks u from
+// changing the deployment did.
+func (fs Freezes) Check(did DeploymentID, u User, at time.Time) error {
+	for _, f := range fs {
+		if f.Matches(did.Cluster, at) && !f.OverriddenBy(u) {
+			return &FreezeError{DeploymentID: did, Freeze: f, User: u}
+		}
+	}
+	return nil
+}
+
+// CheckChanges calls Check for each deployment which differs between prior
+// and post.
+func (fs Freezes) CheckChanges(prior, post Deployments, u User, at time.Time) error {
+	if len(fs) == 0 {
+		return nil
+	}
+	for _, p := range prior.Diff(post).Collect() {
+		if p.Kind() == SameKind {
+			continue
+		}
+		if err := fs.Check(p.ID(), u, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches returns true if f is in effect for cluster at time at.
+func (f Freeze) Matches(cluster string, at time.Time) bool {
+	if at.Before(f.Start) || !at.Before(f.End) {
+		return false
+	}
+	if len(f.Clusters) == 0 {
+		return true
+	}
+	for _, pattern := range f.Clusters {
+		if ok, _ := path.Match(pattern, cluster); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// OverriddenBy returns true if u has declared an emergency and is allowed to
+// override f.
+func (f Freeze) OverriddenBy(u User) bool {
+	if !u.Emergency {
+		return false
+	}
+	if len(f.Overriders) == 0 {
+		return true
+	}
+	for _, email := range f.Overriders {
+		if email == u.Email {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *FreezeError) Error() string {
+	msg := fmt.Sprintf("%s is frozen until %s: %s", e.DeploymentID, e.Freeze.End.Format(time.RFC3339), e.Freeze.Reason)
+	if e.User.Emergency {
+		return msg + fmt.Sprintf("; %s may not override this freeze", e.User)
+	}
+	return msg + "; in an emergency, use -emergency to override it"
+}
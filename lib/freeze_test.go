@@ -0,0 +1,111 @@
+package sous
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nyarly/spies"
+	"github.com/opentable/sous/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreeze_Matches(t *testing.T) {
+	now := time.Now()
+	f := Freeze{
+		Clusters: []string{"prod-*", "staging"},
+		Start:    now.Add(-time.Hour),
+		End:      now.Add(time.Hour),
+	}
+
+	assert.True(t, f.Matches("prod-sf", now))
+	assert.True(t, f.Matches("staging", now))
+	assert.False(t, f.Matches("ci", now))
+	assert.False(t, f.Matches("prod-sf", now.Add(-2*time.Hour)))
+	assert.False(t, f.Matches("prod-sf", now.Add(time.Hour)))
+
+	f.Clusters = nil
+	assert.True(t, f.Matches("ci", now))
+}
+
+func TestFreeze_OverriddenBy(t *testing.T) {
+	user := User{Name: "Judson", Email: "jlester@opentable.com"}
+	emergency := user
+	emergency.Emergency = true
+
+	f := Freeze{}
+	assert.False(t, f.OverriddenBy(user))
+	assert.True(t, f.OverriddenBy(emergency))
+
+	f.Overriders = []string{"oncall@opentable.com"}
+	assert.False(t, f.OverriddenBy(emergency))
+	f.Overriders = append(f.Overriders, "jlester@opentable.com")
+	assert.True(t, f.OverriddenBy(emergency))
+}
+
+func TestFreezes_CheckChanges(t *testing.T) {
+	now := time.Now()
+	fs := Freezes{{
+		Clusters: []string{"cluster1"},
+		Start:    now.Add(-time.Hour),
+		End:      now.Add(time.Hour),
+		Reason:   "holidays",
+	}}
+
+	state := DefaultStateFixture()
+	prior, err := state.Deployments()
+	require.NoError(t, err)
+
+	post := prior.Clone()
+	for _, d := range post.Snapshot() {
+		if d.ClusterName == "cluster0" {
+			changed := d.Clone()
+			changed.NumInstances++
+			post.Set(d.ID(), changed)
+			break
+		}
+	}
+	assert.NoError(t, fs.CheckChanges(prior, post, User{}, now))
+
+	for _, d := range post.Snapshot() {
+		if d.ClusterName == "cluster1" {
+			changed := d.Clone()
+			changed.NumInstances++
+			post.Set(d.ID(), changed)
+			break
+		}
+	}
+	err = fs.CheckChanges(prior, post, User{}, now)
+	require.IsType(t, &FreezeError{}, err)
+	assert.Contains(t, err.Error(), "holidays")
+	assert.Equal(t, "cluster1", err.(*FreezeError).DeploymentID.Cluster)
+
+	assert.NoError(t, fs.CheckChanges(prior, post, User{Emergency: true}, now))
+}
+
+func TestResolver_Begin_Frozen(t *testing.T) {
+	cluster := &Cluster{Name: "x"}
+	dep := &Deployment{
+		ClusterName:  "x",
+		Cluster:      cluster,
+		SourceID:     MustParseSourceID("github.com/ot/frozen,1.0.0"),
+		DeployConfig: DeployConfig{NumInstances: 1},
+	}
+	running := NewDeployStates(&DeployState{Deployment: *dep, Status: DeployStatusActive})
+	intended := dep.Clone()
+	intended.SourceID = MustParseSourceID("github.com/ot/frozen,2.0.0")
+
+	dpr, c := NewDeployerSpy()
+	c.MatchMethod("RunningDeployments", spies.AnyArgs, running, nil)
+	log, _ := logging.NewLogSinkSpy()
+	r := NewResolver(dpr, NewDummyRegistry(), &ResolveFilter{}, log, NewR11nQueueSet())
+	r.Freezes = Freezes{{Start: time.Now().Add(-time.Hour), End: time.Now().Add(time.Hour)}}
+
+	rec := r.Begin(NewDeployments(intended), Clusters{"x": cluster})
+	require.NoError(t, rec.Wait())
+
+	status := rec.CurrentStatus()
+	require.Len(t, status.Log, 1)
+	assert.Equal(t, FrozenDiff, status.Log[0].Desc)
+	assert.Len(t, c.CallsTo("Rectify"), 0)
+}
@@ -0,0 +1,143 @@
+package sous
+
+import (
+	"sort"
+	"time"
+
+	"github.com/samsalisbury/semv"
+)
+
+type (
+	// A ManifestChange records a single change to a Manifest in the GDM: who
+	// made it, when, and what changed.
+	ManifestChange struct {
+		ManifestID ManifestID
+		// Time is when the change was written.
+		Time time.Time
+		// User is the user who made the change.
+		User User
+		// TraceID identifies the request which made the change, if known.
+		TraceID TraceID `json:",omitempty"`
+		// Prior is the manifest before the change, or nil if it was added.
+		Prior *Manifest `json:",omitempty"`
+		// Post is the manifest after the change, or nil if it was removed.
+		Post *Manifest `json:",omitempty"`
+		// Diffs describes the change, as reported by Manifest.Diff.
+		Diffs []string
+	}
+
+	// A HistoryReader reads the changes recorded by a StateManager which
+	// keeps an audit log of changes to the GDM.
+	HistoryReader interface {
+		// ReadHistory returns the recorded changes matching filter, oldest
+		// first.
+		ReadHistory(filter *ResolveFilter) ([]ManifestChange, error)
+	}
+)
+
+// ManifestChanges returns a ManifestChange for each manifest which differs
+// between prior and post, in ManifestID order, attributed to u at time at.
+func ManifestChanges(prior, post *State, u User, at time.Time) []ManifestChange {
+	ids := map[ManifestID]struct{}{}
+	for _, id := range prior.Manifests.Keys() {
+		ids[id] = struct{}{}
+	}
+	for _, id := range post.Manifests.Keys() {
+		ids[id] = struct{}{}
+	}
+	sorted := make([]ManifestID, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].String() < sorted[j].String() })
+
+	changes := []ManifestChange{}
+	for _, id := range sorted {
+		change := ManifestChange{
+			ManifestID: id,
+			Time:       at,
+			User:       User{Name: u.Name, Email: u.Email, Emergency: u.Emergency},
+			TraceID:    u.TraceID,
+		}
+		before, hadBefore := prior.Manifests.Get(id)
+		after, hasAfter := post.Manifests.Get(id)
+		switch {
+		case !hadBefore:
+			change.Post = after.Clone()
+			change.Diffs = []string{"manifest added"}
+		case !hasAfter:
+			change.Prior = before.Clone()
+			change.Diffs = []string{"manifest removed"}
+		default:
+			different, diffs := before.Diff(after)
+			if !different {
+				continue
+			}
+			change.Prior, change.Post = before.Clone(), after.Clone()
+			change.Diffs = diffs
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// PreviousVersion searches changes, oldest first, for the change which
+// deployed the version now deployed to cluster. It returns that change and
+// the version it replaced, or false if no recorded change replaced a version.
+//
+// A change back to the version a change replaced undoes that change, as a
+// rollback does, so that rolling back again goes further back rather than
+// returning to the version rolled back from.
+func PreviousVersion(changes []ManifestChange, cluster string) (ManifestChange, semv.Version, bool) {
+	type deployed struct {
+		version semv.Version
+		by      ManifestChange
+	}
+	var stack []deployed
+	for _, c := range changes {
+		if c.Prior == nil || c.Post == nil {
+			continue
+		}
+		before, hadBefore := c.Prior.Deployments[cluster]
+		after, hasAfter := c.Post.Deployments[cluster]
+		if !hadBefore || !hasAfter || before.Version.Equals(after.Version) {
+			continue
+		}
+		if n := len(stack); n >= 2 && stack[n-2].version.Equals(after.Version) {
+			stack = stack[:n-1]
+			continue
+		}
+		if len(stack) == 0 {
+			stack = append(stack, deployed{version: before.Version})
+		}
+		stack = append(stack, deployed{version: after.Version, by: c})
+	}
+	if len(stack) < 2 {
+		return ManifestChange{}, semv.Version{}, false
+	}
+	top := stack[len(stack)-1]
+	return top.by, stack[len(stack)-2].version, true
+}
+
+// VersionDeployedAt searches changes, oldest first, for the latest which
+// deployed version v to cluster. It returns the time of that change, or false
+// if no recorded change deployed v there.
+func VersionDeployedAt(changes []ManifestChange, cluster string, v semv.Version) (time.Time, bool) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		if c.Post == nil {
+			continue
+		}
+		after, hasAfter := c.Post.Deployments[cluster]
+		if !hasAfter || !after.Version.Equals(v) {
+			continue
+		}
+		if c.Prior != nil {
+			if before, hadBefore := c.Prior.Deployments[cluster]; hadBefore && before.Version.Equals(v) {
+				continue
+			}
+		}
+		return c.Time, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,128 @@
+package sous
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func historyManifest(repo, version string) *Manifest {
+	return &Manifest{
+		Source: SourceLocation{Repo: repo},
+		Kind:   ManifestKindService,
+		Deployments: DeploySpecs{
+			"left": DeploySpec{Version: semv.MustParse(version)},
+		},
+	}
+}
+
+func TestManifestChanges(t *testing.T) {
+	prior, post := NewState(), NewState()
+	prior.Manifests.Add(historyManifest("github.com/example/changed", "1.0.0"))
+	prior.Manifests.Add(historyManifest("github.com/example/removed", "1.0.0"))
+	prior.Manifests.Add(historyManifest("github.com/example/same", "1.0.0"))
+	post.Manifests.Add(historyManifest("github.com/example/added", "1.0.0"))
+	post.Manifests.Add(historyManifest("github.com/example/changed", "2.0.0"))
+	post.Manifests.Add(historyManifest("github.com/example/same", "1.0.0"))
+
+	at := time.Now()
+	user := User{Name: "Judson", Email: "jlester@opentable.com", TraceID: "trace-1"}
+	changes := ManifestChanges(prior, post, user, at)
+	require.Len(t, changes, 3)
+
+	added, changed, removed := changes[0], changes[1], changes[2]
+	assert.Equal(t, "github.com/example/added", added.ManifestID.Source.Repo)
+	assert.Nil(t, added.Prior)
+	assert.NotNil(t, added.Post)
+	assert.Equal(t, "github.com/example/changed", changed.ManifestID.Source.Repo)
+	assert.NotEmpty(t, changed.Diffs)
+	assert.Equal(t, "github.com/example/removed", removed.ManifestID.Source.Repo)
+	assert.Nil(t, removed.Post)
+
+	for _, c := range changes {
+		assert.Equal(t, at, c.Time)
+		assert.Equal(t, User{Name: "Judson", Email: "jlester@opentable.com"}, c.User)
+		assert.Equal(t, TraceID("trace-1"), c.TraceID)
+	}
+}
+
+func TestPreviousVersion(t *testing.T) {
+	const repo = "github.com/example/project"
+	changes := []ManifestChange{
+		{Post: historyManifest(repo, "1.0.0")},
+		{Prior: historyManifest(repo, "1.0.0"), Post: historyManifest(repo, "2.0.0")},
+		{Prior: historyManifest(repo, "2.0.0"), Post: historyManifest(repo, "2.0.0")},
+	}
+
+	change, version, ok := PreviousVersion(changes, "left")
+	require.True(t, ok)
+	assert.Equal(t, "1.0.0", version.String())
+	assert.Equal(t, changes[1], change)
+
+	_, _, ok = PreviousVersion(changes, "right")
+	assert.False(t, ok)
+	_, _, ok = PreviousVersion(changes[:1], "left")
+	assert.False(t, ok)
+}
+
+func TestPreviousVersion_RollbackTwice(t *testing.T) {
+	const repo = "github.com/example/project"
+	change := func(from, to string) ManifestChange {
+		return ManifestChange{Prior: historyManifest(repo, from), Post: historyManifest(repo, to)}
+	}
+	changes := []ManifestChange{
+		change("1.0.0", "2.0.0"),
+		change("2.0.0", "3.0.0"),
+	}
+
+	c, version, ok := PreviousVersion(changes, "left")
+	require.True(t, ok)
+	assert.Equal(t, "2.0.0", version.String())
+	assert.Equal(t, changes[1], c)
+
+	// The first rollback.
+	changes = append(changes, change("3.0.0", "2.0.0"))
+	c, version, ok = PreviousVersion(changes, "left")
+	require.True(t, ok)
+	assert.Equal(t, "1.0.0", version.String())
+	assert.Equal(t, changes[0], c)
+
+	// The second rollback.
+	changes = append(changes, change("2.0.0", "1.0.0"))
+	_, _, ok = PreviousVersion(changes, "left")
+	assert.False(t, ok)
+
+	// A new version after rolling back is rolled back to the version it
+	// replaced.
+	changes = append(changes, change("1.0.0", "4.0.0"))
+	c, version, ok = PreviousVersion(changes, "left")
+	require.True(t, ok)
+	assert.Equal(t, "1.0.0", version.String())
+	assert.Equal(t, changes[4], c)
+}
+
+func TestVersionDeployedAt(t *testing.T) {
+	const repo = "github.com/example/project"
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	changes := []ManifestChange{
+		{Time: t0, Post: historyManifest(repo, "1.0.0")},
+		{Time: t0.Add(time.Hour), Prior: historyManifest(repo, "1.0.0"), Post: historyManifest(repo, "2.0.0")},
+		{Time: t0.Add(2 * time.Hour), Prior: historyManifest(repo, "2.0.0"), Post: historyManifest(repo, "2.0.0")},
+	}
+
+	at, ok := VersionDeployedAt(changes, "left", semv.MustParse("2.0.0"))
+	require.True(t, ok)
+	assert.Equal(t, t0.Add(time.Hour), at)
+
+	at, ok = VersionDeployedAt(changes, "left", semv.MustParse("1.0.0"))
+	require.True(t, ok)
+	assert.Equal(t, t0, at)
+
+	_, ok = VersionDeployedAt(changes, "left", semv.MustParse("3.0.0"))
+	assert.False(t, ok)
+	_, ok = VersionDeployedAt(changes, "right", semv.MustParse("2.0.0"))
+	assert.False(t, ok)
+}
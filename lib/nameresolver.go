@@ -3,6 +3,7 @@ package sous
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/opentable/sous/util/logging"
 	"github.com/opentable/sous/util/logging/messages"
@@ -103,5 +104,38 @@ func guardImage(r Registry, d *Deployment) (*BuildArtifact, error) {
 			return nil, &UnacceptableAdvisory{q, &d.SourceID}
 		}
 	}
+	if d.Cluster != nil && len(d.Cluster.Platforms) > 0 {
+		if err := guardPlatforms(art, d); err != nil {
+			return nil, err
+		}
+	}
+	if d.Cluster != nil && len(d.Cluster.SigningKeys) > 0 {
+		return guardSignature(art, d)
+	}
 	return art, err
 }
+
+// guardSignature returns art if it is signed by a key d's cluster trusts,
+// naming it by the digest which was signed, so that the signed image is the
+// one deployed.
+func guardSignature(art *BuildArtifact, d *Deployment) (*BuildArtifact, error) {
+	digest, err := art.VerifySignature(d.SourceID, d.Cluster.SigningKeys)
+	if err != nil {
+		return nil, err
+	}
+	name := art.Name
+	if i := strings.Index(name, "@"); i >= 0 {
+		if name[i+1:] != digest {
+			return nil, &ArtifactSignatureError{SourceID: d.SourceID, Name: art.Name}
+		}
+	} else {
+		// Any tag is dropped: the digest identifies the image.
+		if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+			name = name[:i]
+		}
+		name = name + "@" + digest
+	}
+	signed := *art
+	signed.Name = name
+	return &signed, nil
+}
@@ -0,0 +1,59 @@
+package sous
+
+import "time"
+
+type (
+	// A ResolutionEvent reports the outcome of a rectification to the owners
+	// of the deployment it changed.
+	ResolutionEvent struct {
+		DeploymentID DeploymentID
+		SourceID     SourceID
+		// Outcome describes how the rectification resolved the deployment.
+		Outcome ResolutionType
+		// Error is the error which stopped the rectification, if any.
+		Error string `json:",omitempty"`
+		// User is the user whose change was deployed, if known.
+		User User
+		// TraceID identifies the request which made the change, if known.
+		TraceID TraceID `json:",omitempty"`
+		// Owners are the owners of the deployment.
+		Owners []string
+		// Time is when the rectification completed.
+		Time time.Time
+	}
+
+	// A Notifier tells interested parties about the outcome of rectifications.
+	// Notify must not block on slow or unavailable recipients.
+	Notifier interface {
+		Notify(ResolutionEvent)
+	}
+
+	// DummyNotifier is a Notifier which discards every event.
+	DummyNotifier struct{}
+)
+
+// NewResolutionEvent returns a ResolutionEvent describing the outcome of the
+// completed Rectification r at time at.
+func NewResolutionEvent(r *Rectification, at time.Time) ResolutionEvent {
+	r.RLock()
+	defer r.RUnlock()
+	e := ResolutionEvent{
+		DeploymentID: r.Pair.ID(),
+		Outcome:      r.Resolution.Desc,
+		User:         r.User,
+		TraceID:      r.User.TraceID,
+		Owners:       []string{},
+		Time:         at,
+	}
+	if post := r.Pair.Post; post != nil && post.Deployment != nil {
+		e.SourceID = post.SourceID
+		e.Owners = post.Owners.Slice()
+	}
+	if r.Resolution.Error != nil {
+		e.Error = r.Resolution.Error.Error()
+	}
+	return e
+}
+
+// Notify implements Notifier on DummyNotifier.
+func (DummyNotifier) Notify(ResolutionEvent) {}
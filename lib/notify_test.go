@@ -0,0 +1,30 @@
+package sous
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opentable/sous/util/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResolutionEvent(t *testing.T) {
+	pair := rolloutPair(Rollout{})
+	pair.Post.Owners = NewOwnerSet("b@example.com", "a@example.com")
+	log, _ := logging.NewLogSinkSpy()
+	r := NewRectification(pair, log)
+	r.User = User{Name: "Deployer", Email: "deployer@example.com", TraceID: "trace-1"}
+	r.Resolution = DiffResolution{DeploymentID: pair.ID(), Desc: ModifyDiff, Error: WrapResolveError(errors.New("boom"))}
+
+	at := time.Now()
+	e := NewResolutionEvent(r, at)
+	assert.Equal(t, pair.ID(), e.DeploymentID)
+	assert.Equal(t, pair.Post.SourceID, e.SourceID)
+	assert.Equal(t, ModifyDiff, e.Outcome)
+	assert.Contains(t, e.Error, "boom")
+	assert.Equal(t, "deployer@example.com", e.User.Email)
+	assert.Equal(t, TraceID("trace-1"), e.TraceID)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, e.Owners)
+	assert.Equal(t, at, e.Time)
+}
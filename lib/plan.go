@@ -0,0 +1,104 @@
+package sous
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+type (
+	// A ResolvePlan describes what a Resolver would do to make the running
+	// deployments match the intended ones, without doing any of it.
+	ResolvePlan struct {
+		Added, Removed, Modified, Unchanged []PlannedPair
+		// Frozen are the pairs which would have been added, removed or
+		// modified, but which a freeze stops the Resolver from rectifying.
+		Frozen []PlannedPair `json:",omitempty"`
+		// Errors are the resolutions of deployments which could not be
+		// planned, for instance because they have no artifact.
+		Errors []DiffResolution
+	}
+
+	// A PlannedPair is a DeployablePair in a ResolvePlan.
+	PlannedPair struct {
+		DeploymentID DeploymentID
+		// Prior is the running deployment, or nil if it would be added.
+		Prior *Deployment `json:",omitempty"`
+		// Post is the intended deployment, or nil if it would be removed.
+		Post *Deployment `json:",omitempty"`
+		// Diffs are the differences from Prior to Post.
+		Diffs Differences `json:",omitempty"`
+	}
+)
+
+// Plan compares intended with the deployments running in clusters as Begin
+// does, passing them through the same filters and name resolution, but
+// returns the resulting pairs grouped by kind instead of rectifying them.
+// Pairs which Begin would skip because of a freeze are grouped as Frozen.
+func (r *Resolver) Plan(intended Deployments, clusters Clusters) (*ResolvePlan, error) {
+	intended = intended.Filter(r.FilterDeployment)
+	if r.Scaler != nil {
+		intended = r.Scaler.Apply(intended)
+	}
+	clusters = r.FilteredClusters(clusters)
+
+	actual, err := r.Deployer.RunningDeployments(r.Registry, clusters)
+	if err != nil {
+		return nil, err
+	}
+	actual = actual.Filter(r.FilterDeployStates)
+
+	now := time.Now()
+	resolved := actual.Diff(intended).ResolveNames(context.Background(), r.Registry)
+
+	plan := &ResolvePlan{}
+	errsDone := make(chan struct{})
+	go func() {
+		defer close(errsDone)
+		for rez := range resolved.Errs {
+			plan.Errors = append(plan.Errors, *rez)
+		}
+	}()
+
+	for p := range resolved.Pairs {
+		if p.Kind() == AddedKind && p.uninitialized() {
+			continue
+		}
+		planned := PlannedPair{DeploymentID: p.ID()}
+		if p.Prior != nil {
+			planned.Prior = p.Prior.Deployment
+		}
+		if p.Post != nil {
+			planned.Post = p.Post.Deployment
+		}
+		if p.Kind() != SameKind && r.Freezes.Frozen(p.ID(), now) != nil {
+			if p.Kind() == ModifiedKind {
+				planned.Diffs = p.Diffs()
+			}
+			plan.Frozen = append(plan.Frozen, planned)
+			continue
+		}
+		switch p.Kind() {
+		case AddedKind:
+			plan.Added = append(plan.Added, planned)
+		case RemovedKind:
+			plan.Removed = append(plan.Removed, planned)
+		case ModifiedKind:
+			planned.Diffs = p.Diffs()
+			plan.Modified = append(plan.Modified, planned)
+		case SameKind:
+			plan.Unchanged = append(plan.Unchanged, planned)
+		}
+	}
+	<-errsDone
+
+	for _, pairs := range [][]PlannedPair{plan.Added, plan.Removed, plan.Modified, plan.Unchanged, plan.Frozen} {
+		sort.Slice(pairs, func(i, j int) bool {
+			return pairs[i].DeploymentID.String() < pairs[j].DeploymentID.String()
+		})
+	}
+	sort.Slice(plan.Errors, func(i, j int) bool {
+		return plan.Errors[i].DeploymentID.String() < plan.Errors[j].DeploymentID.String()
+	})
+	return plan, nil
+}
@@ -0,0 +1,106 @@
+package sous
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nyarly/spies"
+	"github.com/opentable/sous/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_Plan(t *testing.T) {
+	cluster := &Cluster{Name: "x"}
+	dep := func(sid string, instances int) *Deployment {
+		return &Deployment{
+			ClusterName:  "x",
+			Cluster:      cluster,
+			SourceID:     MustParseSourceID(sid),
+			DeployConfig: DeployConfig{NumInstances: instances},
+		}
+	}
+	running := NewDeployStates()
+	for _, d := range []*Deployment{
+		dep("github.com/ot/same,1.0.0", 1),
+		dep("github.com/ot/modified,1.0.0", 1),
+		dep("github.com/ot/removed,1.0.0", 1),
+	} {
+		running.Add(&DeployState{Deployment: *d, Status: DeployStatusActive})
+	}
+	intended := NewDeployments(
+		dep("github.com/ot/same,1.0.0", 1),
+		dep("github.com/ot/modified,2.0.0", 1),
+		dep("github.com/ot/added,1.0.0", 1),
+		dep("github.com/ot/uninitialized,1.0.0", 0),
+	)
+
+	dpr, c := NewDeployerSpy()
+	c.MatchMethod("RunningDeployments", spies.AnyArgs, running, nil)
+	log, _ := logging.NewLogSinkSpy()
+	r := NewResolver(dpr, NewDummyRegistry(), &ResolveFilter{}, log, nil)
+
+	plan, err := r.Plan(intended, Clusters{"x": cluster})
+	require.NoError(t, err)
+
+	ids := func(pairs []PlannedPair) []string {
+		repos := []string{}
+		for _, p := range pairs {
+			repos = append(repos, p.DeploymentID.ManifestID.Source.Repo)
+		}
+		return repos
+	}
+	assert.Equal(t, []string{"github.com/ot/added"}, ids(plan.Added))
+	assert.Equal(t, []string{"github.com/ot/removed"}, ids(plan.Removed))
+	assert.Equal(t, []string{"github.com/ot/modified"}, ids(plan.Modified))
+	assert.Equal(t, []string{"github.com/ot/same"}, ids(plan.Unchanged))
+	assert.NotEmpty(t, plan.Modified[0].Diffs)
+	assert.Nil(t, plan.Added[0].Prior)
+	assert.Nil(t, plan.Removed[0].Post)
+	assert.Empty(t, plan.Errors)
+
+	assert.Len(t, c.CallsTo("Rectify"), 0)
+}
+
+func TestResolver_Plan_Frozen(t *testing.T) {
+	cluster := &Cluster{Name: "x"}
+	dep := func(sid string) *Deployment {
+		return &Deployment{
+			ClusterName:  "x",
+			Cluster:      cluster,
+			SourceID:     MustParseSourceID(sid),
+			DeployConfig: DeployConfig{NumInstances: 1},
+		}
+	}
+	running := NewDeployStates()
+	for _, d := range []*Deployment{
+		dep("github.com/ot/same,1.0.0"),
+		dep("github.com/ot/modified,1.0.0"),
+	} {
+		running.Add(&DeployState{Deployment: *d, Status: DeployStatusActive})
+	}
+	intended := NewDeployments(
+		dep("github.com/ot/same,1.0.0"),
+		dep("github.com/ot/modified,2.0.0"),
+		dep("github.com/ot/added,1.0.0"),
+	)
+
+	dpr, c := NewDeployerSpy()
+	c.MatchMethod("RunningDeployments", spies.AnyArgs, running, nil)
+	log, _ := logging.NewLogSinkSpy()
+	r := NewResolver(dpr, NewDummyRegistry(), &ResolveFilter{}, log, nil)
+	r.Freezes = Freezes{{Start: time.Now().Add(-time.Hour), End: time.Now().Add(time.Hour)}}
+
+	plan, err := r.Plan(intended, Clusters{"x": cluster})
+	require.NoError(t, err)
+
+	repos := []string{}
+	for _, p := range plan.Frozen {
+		repos = append(repos, p.DeploymentID.ManifestID.Source.Repo)
+	}
+	assert.Equal(t, []string{"github.com/ot/added", "github.com/ot/modified"}, repos)
+	assert.NotEmpty(t, plan.Frozen[1].Diffs)
+	assert.Empty(t, plan.Added)
+	assert.Empty(t, plan.Modified)
+	assert.Len(t, plan.Unchanged, 1)
+}
@@ -0,0 +1,82 @@
+package sous
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PlatformQuality is the Kind of the Qualities which record the platforms an
+// artifact was built for.
+const PlatformQuality = "platform"
+
+// An ArtifactPlatformError reports that an artifact was not built for all the
+// platforms its cluster requires.
+type ArtifactPlatformError struct {
+	SourceID SourceID
+	Name     string
+	Missing  []string
+}
+
+// buildPlatforms builds with bp for each of platforms, or with bp.Build if
+// there are none. Each product of the result records its image for each
+// platform.
+func buildPlatforms(bp Buildpack, bc *BuildContext, platforms []string) (*BuildResult, error) {
+	if len(platforms) == 0 {
+		return bp.Build(bc)
+	}
+	pbp, ok := bp.(PlatformBuildpack)
+	if !ok {
+		return nil, errors.Errorf("%T cannot build for other platforms", bp)
+	}
+	start := time.Now()
+	var br *BuildResult
+	for _, p := range platforms {
+		pbr, err := pbp.BuildPlatform(bc, p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building for %s", p)
+		}
+		if br == nil {
+			br = pbr
+			for _, prod := range br.Products {
+				prod.Platforms = map[string]string{p: prod.ID}
+			}
+			continue
+		}
+		if len(pbr.Products) != len(br.Products) {
+			return nil, errors.Errorf("build for %s produced %d images, but for %s %d", p, len(pbr.Products), platforms[0], len(br.Products))
+		}
+		for i, prod := range pbr.Products {
+			br.Products[i].Platforms[p] = prod.ID
+		}
+	}
+	br.Elapsed = time.Since(start)
+	return br, nil
+}
+
+// guardPlatforms returns an *ArtifactPlatformError unless art was built for
+// all the platforms d's cluster requires.
+func guardPlatforms(art *BuildArtifact, d *Deployment) error {
+	built := map[string]bool{}
+	for _, q := range art.Qualities {
+		if q.Kind == PlatformQuality {
+			built[q.Name] = true
+		}
+	}
+	var missing []string
+	for _, p := range d.Cluster.Platforms {
+		if !built[p] {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		return &ArtifactPlatformError{SourceID: d.SourceID, Name: art.Name, Missing: missing}
+	}
+	return nil
+}
+
+func (e *ArtifactPlatformError) Error() string {
+	return fmt.Sprintf("Image %s for %v was not built for %s, which its cluster requires", e.Name, e.SourceID, strings.Join(e.Missing, ", "))
+}
@@ -0,0 +1,71 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type platformTestBuildpack struct {
+	cacheTestBuildpack
+	platforms []string
+}
+
+func (bp *platformTestBuildpack) BuildPlatform(c *BuildContext, platform string) (*BuildResult, error) {
+	bp.platforms = append(bp.platforms, platform)
+	return &BuildResult{Products: []*BuildProduct{
+		{ID: "build-" + platform, Kind: "builder"},
+		{ID: "run-" + platform},
+	}}, nil
+}
+
+func TestBuildPlatforms(t *testing.T) {
+	bp := &platformTestBuildpack{}
+	br, err := buildPlatforms(bp, &BuildContext{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, bp.builds)
+	assert.Nil(t, br.Products[0].Platforms)
+
+	br, err = buildPlatforms(bp, &BuildContext{}, []string{"linux/amd64", "linux/arm64"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, bp.platforms)
+	require.Len(t, br.Products, 2)
+	assert.Equal(t, "build-linux/amd64", br.Products[0].ID)
+	assert.Equal(t, map[string]string{"linux/amd64": "build-linux/amd64", "linux/arm64": "build-linux/arm64"}, br.Products[0].Platforms)
+	assert.Equal(t, map[string]string{"linux/amd64": "run-linux/amd64", "linux/arm64": "run-linux/arm64"}, br.Products[1].Platforms)
+
+	_, err = buildPlatforms(&cacheTestBuildpack{}, &BuildContext{}, []string{"linux/arm64"})
+	assert.Error(t, err, "a buildpack which cannot build for other platforms should fail")
+}
+
+func TestGuardImagePlatforms(t *testing.T) {
+	dep := Deployment{
+		ClusterName:  "x",
+		Cluster:      &Cluster{Name: "x", Platforms: []string{"linux/amd64", "linux/arm64"}},
+		SourceID:     MustParseSourceID("github.com/ot/one,1.3.5"),
+		DeployConfig: DeployConfig{NumInstances: 1},
+	}
+	guard := func(platforms ...string) error {
+		qs := []Quality{}
+		for _, p := range platforms {
+			qs = append(qs, Quality{Name: p, Kind: PlatformQuality})
+		}
+		dr := NewDummyRegistry()
+		dr.FeedArtifact(&BuildArtifact{Name: "one:1.3.5", Type: "docker", Qualities: qs}, nil)
+		_, err := guardImage(dr, &dep)
+		return err
+	}
+
+	assert.NoError(t, guard("linux/arm64", "linux/amd64", "linux/s390x"))
+
+	err := guard("linux/amd64")
+	require.IsType(t, &ArtifactPlatformError{}, err)
+	assert.Equal(t, []string{"linux/arm64"}, err.(*ArtifactPlatformError).Missing)
+	assert.False(t, IsTransientResolveError(err))
+
+	assert.IsType(t, &ArtifactPlatformError{}, guard())
+
+	dep.Cluster.Platforms = nil
+	assert.NoError(t, guard())
+}
@@ -0,0 +1,278 @@
+package sous
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samsalisbury/semv"
+)
+
+type (
+	// Promotions is a list of PromotionRule.
+	Promotions []PromotionRule
+
+	// A PromotionRule governs promotions to some clusters: the version
+	// promoted must come from a particular cluster, may have to have been
+	// deployed there for a while, and may have to be running there.
+	PromotionRule struct {
+		// To are patterns, as for path.Match, which select the names of the
+		// clusters the rule governs promotions to.
+		To []string
+		// From is the cluster versions must be promoted from.
+		From string
+		// MinTime is how long a version must have been deployed to From
+		// before it may be promoted, e.g. "24h".
+		MinTime time.Duration `yaml:",omitempty"`
+		// RequireActive, if true, means a version may only be promoted while
+		// its deployment to From is active.
+		RequireActive bool `yaml:",omitempty"`
+	}
+
+	// A Promotion copies the version deployed to one cluster, and optionally
+	// some of its environment and resources, to the deployment of the same
+	// manifest to another cluster.
+	Promotion struct {
+		ManifestID ManifestID
+		From, To   string
+		// Env names the environment variables copied along with the version.
+		Env []string `json:",omitempty"`
+		// Resources names the resources copied along with the version.
+		Resources []string `json:",omitempty"`
+	}
+
+	// PromotionFacts are what is known about the deployment a version is
+	// promoted from, for checking the rules governing its promotion.
+	PromotionFacts struct {
+		// DeployedAt is when the version promoted was deployed to the
+		// cluster promoted from, or zero if that is not known.
+		DeployedAt time.Time
+		// Status is the status of the deployment promoted from, or
+		// DeployStatusAny if that is not known.
+		Status DeployStatus
+	}
+
+	// A PromotionFactFinder finds out the PromotionFacts about from, a
+	// deployment a version is promoted from. Only the facts asked for need
+	// be found out, since some are costly to.
+	PromotionFactFinder interface {
+		PromotionFacts(from *Deployment, deployedAt, status bool) (PromotionFacts, error)
+	}
+
+	// RecordedPromotionFacts is a PromotionFactFinder which finds out when
+	// versions were deployed from History, and the status of deployments
+	// from the deployments Deployer reports running. Facts it has no means
+	// to find out are left unknown.
+	RecordedPromotionFacts struct {
+		History  HistoryReader
+		Deployer Deployer
+		Registry Registry
+	}
+
+	// A PromotionError is returned when a change of version breaks each of
+	// the rules governing promotions to its cluster.
+	PromotionError struct {
+		DeploymentID DeploymentID
+		Version      semv.Version
+		Reasons      []string
+	}
+)
+
+// ToID returns the DeploymentID p promotes to.
+func (p Promotion) ToID() DeploymentID {
+	return DeploymentID{ManifestID: p.ManifestID, Cluster: p.To}
+}
+
+// Apply returns a copy of to, the deployment promoted to, with the version
+// of from, the deployment promoted from, and the environment variables and
+// resources named by p. Those which from does not set are removed.
+func (p Promotion) Apply(from, to DeploySpec) DeploySpec {
+	spec := to.Clone()
+	spec.Version = from.Version
+	for _, name := range p.Env {
+		if v, ok := from.Env[name]; ok {
+			if spec.Env == nil {
+				spec.Env = Env{}
+			}
+			spec.Env[name] = v
+		} else {
+			delete(spec.Env, name)
+		}
+	}
+	for _, name := range p.Resources {
+		if v, ok := from.Resources[name]; ok {
+			if spec.Resources == nil {
+				spec.Resources = Resources{}
+			}
+			spec.Resources[name] = v
+		} else {
+			delete(spec.Resources, name)
+		}
+	}
+	return spec
+}
+
+// Clone returns a deep copy of this Promotions.
+func (ps Promotions) Clone() Promotions {
+	if ps == nil {
+		return nil
+	}
+	c := make(Promotions, len(ps))
+	for i, r := range ps {
+		r.To = append([]string(nil), r.To...)
+		c[i] = r
+	}
+	return c
+}
+
+// For returns the rules in ps which govern promotions to cluster.
+func (ps Promotions) For(cluster string) Promotions {
+	var rules Promotions
+	for _, r := range ps {
+		if r.Governs(cluster) {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// CheckChanges returns a PromotionError if a deployment in post, to a cluster
+// which ps governs promotions to, has a version it did not have in prior,
+// unless the version keeps at least one of the rules governing that cluster:
+// it must be the version deployed in prior to the rule's From cluster, which
+// facts may show has been deployed there long enough, and is active. Facts
+// may be nil, in which case they are all unknown.
+func (ps Promotions) CheckChanges(prior, post Deployments, facts PromotionFactFinder, at time.Time) error {
+	if len(ps) == 0 {
+		return nil
+	}
+	for _, p := range prior.Diff(post).Collect() {
+		if p.Post == nil || p.Post.SourceID.Version.String() == "0.0.0" {
+			continue
+		}
+		if p.Prior != nil && p.Prior.SourceID.Version.Equals(p.Post.SourceID.Version) {
+			continue
+		}
+		if err := ps.check(p.Post.Deployment, prior, facts, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check returns a PromotionError unless the version of to keeps at least one
+// of the rules in ps governing promotions to its cluster.
+func (ps Promotions) check(to *Deployment, prior Deployments, facts PromotionFactFinder, at time.Time) error {
+	rules := ps.For(to.ClusterName)
+	if len(rules) == 0 {
+		return nil
+	}
+	var reasons []string
+	for _, r := range rules {
+		broken, err := r.brokenBy(to, prior, facts, at)
+		if err != nil {
+			return err
+		}
+		if len(broken) == 0 {
+			return nil
+		}
+		reasons = append(reasons, broken...)
+	}
+	return &PromotionError{DeploymentID: to.ID(), Version: to.SourceID.Version, Reasons: reasons}
+}
+
+// PromotionFacts implements PromotionFactFinder on RecordedPromotionFacts.
+func (f *RecordedPromotionFacts) PromotionFacts(from *Deployment, deployedAt, status bool) (PromotionFacts, error) {
+	facts := PromotionFacts{}
+	version := from.SourceID.Version
+
+	if deployedAt && f.History != nil {
+		mid := from.ManifestID()
+		changes, err := f.History.ReadHistory(&ResolveFilter{
+			Repo:    NewResolveFieldMatcher(mid.Source.Repo),
+			Offset:  NewResolveFieldMatcher(mid.Source.Dir),
+			Flavor:  NewResolveFieldMatcher(mid.Flavor),
+			Cluster: NewResolveFieldMatcher(from.ClusterName),
+		})
+		if err != nil {
+			return facts, errors.Wrapf(err, "reading history")
+		}
+		facts.DeployedAt, _ = VersionDeployedAt(changes, from.ClusterName, version)
+	}
+
+	if status && f.Deployer != nil && f.Registry != nil {
+		states, err := f.Deployer.RunningDeployments(f.Registry, Clusters{from.ClusterName: from.Cluster})
+		if err != nil {
+			return facts, errors.Wrapf(err, "reading running deployments")
+		}
+		// The status of a deployment still running an older version says
+		// nothing of the version promoted.
+		if ds, ok := states.Get(from.ID()); ok && ds.SourceID.Version.Equals(version) {
+			facts.Status = ds.Status
+		}
+	}
+	return facts, nil
+}
+
+// Governs returns true if r governs promotions to cluster.
+func (r PromotionRule) Governs(cluster string) bool {
+	for _, pattern := range r.To {
+		if ok, _ := path.Match(pattern, cluster); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// brokenBy returns the ways the version of to breaks r, given the
+// deployments in prior.
+func (r PromotionRule) brokenBy(to *Deployment, prior Deployments, facts PromotionFactFinder, at time.Time) ([]string, error) {
+	version := to.SourceID.Version
+	from, ok := prior.Get(DeploymentID{ManifestID: to.ManifestID(), Cluster: r.From})
+	if !ok || !from.SourceID.Version.Equals(version) {
+		return []string{fmt.Sprintf("versions must be promoted to %s from %s, where %s is not deployed",
+			to.ClusterName, r.From, version)}, nil
+	}
+
+	known := PromotionFacts{}
+	if facts != nil && (r.MinTime > 0 || r.RequireActive) {
+		var err error
+		if known, err = facts.PromotionFacts(from, r.MinTime > 0, r.RequireActive); err != nil {
+			return nil, err
+		}
+	}
+
+	var reasons []string
+	if r.MinTime > 0 {
+		switch {
+		case known.DeployedAt.IsZero():
+			reasons = append(reasons, fmt.Sprintf("there is no record of when the version was deployed to %s", r.From))
+		case at.Sub(known.DeployedAt) < r.MinTime:
+			reasons = append(reasons, fmt.Sprintf("the version has been deployed to %s for %s, less than the %s required",
+				r.From, at.Sub(known.DeployedAt).Round(time.Second), r.MinTime))
+		}
+	}
+	if r.RequireActive && known.Status != DeployStatusActive {
+		reasons = append(reasons, fmt.Sprintf("the deployment to %s is not active: its status is %s", r.From, known.Status))
+	}
+	return reasons, nil
+}
+
+func (e *PromotionError) Error() string {
+	return fmt.Sprintf("version %s may not be deployed to %s: %s",
+		e.Version, e.DeploymentID, strings.Join(e.Reasons, "; "))
+}
+
+// SplitPromotedNames splits a comma separated list of the names of
+// environment variables or resources promoted, ignoring empty ones.
+func SplitPromotedNames(s string) []string {
+	var names []string
+	for _, n := range strings.Split(s, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	return names
+}
@@ -0,0 +1,142 @@
+package sous
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nyarly/spies"
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedPromotionFacts PromotionFacts
+
+func (f fixedPromotionFacts) PromotionFacts(*Deployment, bool, bool) (PromotionFacts, error) {
+	return PromotionFacts(f), nil
+}
+
+func promotionDeployments(versions map[string]string) Deployments {
+	ds := NewDeployments()
+	for cluster, version := range versions {
+		ds.Add(&Deployment{
+			ClusterName: cluster,
+			Cluster:     &Cluster{Name: cluster},
+			SourceID:    MustNewSourceID("github.com/example/project", "", version),
+		})
+	}
+	return ds
+}
+
+func TestPromotions_CheckChanges(t *testing.T) {
+	now := time.Now()
+	ps := Promotions{
+		{To: []string{"prod-*"}, From: "staging", MinTime: 24 * time.Hour, RequireActive: true},
+		{To: []string{"staging"}, From: "dev"},
+	}
+	prior := promotionDeployments(map[string]string{"dev": "3.0.0", "staging": "2.0.0", "prod-sf": "1.0.0", "ci": "1.0.0"})
+	post := func(cluster, version string) Deployments {
+		ds := prior.Clone()
+		d, _ := ds.Get(DeploymentID{ManifestID: ManifestID{Source: SourceLocation{Repo: "github.com/example/project"}}, Cluster: cluster})
+		d = d.Clone()
+		d.SourceID.Version = semv.MustParse(version)
+		ds.Set(d.ID(), d)
+		return ds
+	}
+	settled := fixedPromotionFacts{DeployedAt: now.Add(-48 * time.Hour), Status: DeployStatusActive}
+
+	assert.NoError(t, ps.CheckChanges(prior, post("prod-sf", "2.0.0"), settled, now))
+
+	err := ps.CheckChanges(prior, post("prod-sf", "2.0.0"), fixedPromotionFacts{DeployedAt: now.Add(-time.Hour), Status: DeployStatusActive}, now)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "less than the 24h0m0s required")
+
+	err = ps.CheckChanges(prior, post("prod-sf", "2.0.0"), nil, now)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no record")
+	assert.Contains(t, err.Error(), "not active")
+
+	err = ps.CheckChanges(prior, post("prod-sf", "2.0.0"), fixedPromotionFacts{DeployedAt: settled.DeployedAt, Status: DeployStatusFailed}, now)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not active")
+
+	err = ps.CheckChanges(prior, post("prod-sf", "3.0.0"), settled, now)
+	require.IsType(t, &PromotionError{}, err)
+	assert.Equal(t, "prod-sf", err.(*PromotionError).DeploymentID.Cluster)
+	assert.Equal(t, []string{"versions must be promoted to prod-sf from staging, where 3.0.0 is not deployed"}, err.(*PromotionError).Reasons)
+
+	assert.NoError(t, ps.CheckChanges(prior, post("staging", "3.0.0"), nil, now))
+	assert.Error(t, ps.CheckChanges(prior, post("staging", "4.0.0"), nil, now))
+	assert.NoError(t, ps.CheckChanges(prior, post("ci", "4.0.0"), nil, now), "ungoverned")
+	assert.NoError(t, Promotions{}.CheckChanges(prior, post("prod-sf", "4.0.0"), nil, now))
+
+	unchanged := prior.Clone()
+	d, _ := unchanged.Get(DeploymentID{ManifestID: ManifestID{Source: SourceLocation{Repo: "github.com/example/project"}}, Cluster: "prod-sf"})
+	d = d.Clone()
+	d.NumInstances = 5
+	unchanged.Set(d.ID(), d)
+	assert.NoError(t, ps.CheckChanges(prior, unchanged, nil, now), "a change which keeps the version")
+}
+
+type promotionHistory []ManifestChange
+
+func (h promotionHistory) ReadHistory(*ResolveFilter) ([]ManifestChange, error) {
+	return h, nil
+}
+
+func TestRecordedPromotionFacts(t *testing.T) {
+	deployedAt := time.Now().Add(-time.Hour)
+	from, _ := promotionDeployments(map[string]string{"staging": "2.0.0"}).Get(DeploymentID{
+		ManifestID: ManifestID{Source: SourceLocation{Repo: "github.com/example/project"}},
+		Cluster:    "staging",
+	})
+	m := &Manifest{Source: SourceLocation{Repo: "github.com/example/project"}}
+	history := promotionHistory{{
+		Time:  deployedAt,
+		Prior: &Manifest{Source: m.Source, Deployments: DeploySpecs{"staging": {Version: semv.MustParse("1.0.0")}}},
+		Post:  &Manifest{Source: m.Source, Deployments: DeploySpecs{"staging": {Version: semv.MustParse("2.0.0")}}},
+	}}
+	dpr, c := NewDeployerSpy()
+	c.MatchMethod("RunningDeployments", spies.AnyArgs, NewDeployStates(&DeployState{Deployment: *from, Status: DeployStatusActive}), nil)
+
+	facts, err := (&RecordedPromotionFacts{}).PromotionFacts(from, true, true)
+	require.NoError(t, err)
+	assert.Equal(t, PromotionFacts{}, facts, "facts it has no means to find out are unknown")
+
+	f := &RecordedPromotionFacts{History: history, Deployer: dpr, Registry: NewDummyRegistry()}
+	facts, err = f.PromotionFacts(from, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, PromotionFacts{}, facts)
+	assert.Empty(t, c.CallsTo("RunningDeployments"), "only the facts asked for are found out")
+
+	facts, err = f.PromotionFacts(from, true, true)
+	require.NoError(t, err)
+	assert.Equal(t, deployedAt, facts.DeployedAt)
+	assert.Equal(t, DeployStatusActive, facts.Status)
+}
+
+func TestPromotion_Apply(t *testing.T) {
+	from := DeploySpec{
+		DeployConfig: DeployConfig{
+			Env:       Env{"FEATURE": "on", "SHARED": "staging"},
+			Resources: Resources{"cpus": "1", "memory": "512"},
+		},
+		Version: semv.MustParse("2.0.0"),
+	}
+	to := DeploySpec{
+		DeployConfig: DeployConfig{
+			Env:          Env{"SHARED": "prod", "OLD": "x"},
+			Resources:    Resources{"cpus": "2", "memory": "1024"},
+			NumInstances: 3,
+		},
+		Version: semv.MustParse("1.0.0"),
+	}
+	p := Promotion{Env: []string{"FEATURE", "OLD"}, Resources: []string{"memory"}}
+
+	got := p.Apply(from, to)
+	assert.Equal(t, "2.0.0", got.Version.String())
+	assert.Equal(t, Env{"SHARED": "prod", "FEATURE": "on"}, got.Env)
+	assert.Equal(t, Resources{"cpus": "2", "memory": "512"}, got.Resources)
+	assert.Equal(t, 3, got.NumInstances)
+	assert.Equal(t, "x", to.Env["OLD"], "the deployment promoted to is not changed")
+}
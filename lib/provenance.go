@@ -0,0 +1,170 @@
+package sous
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ProvenanceMediaType is the media type of a Provenance document.
+	ProvenanceMediaType = "application/vnd.in-toto+json"
+	// ProvenanceStatementType identifies the in-toto statement format.
+	ProvenanceStatementType = "https://in-toto.io/Statement/v0.1"
+	// ProvenancePredicateType identifies the SLSA provenance format.
+	ProvenancePredicateType = "https://slsa.dev/provenance/v0.2"
+	// ProvenanceBuildType identifies a build by Sous, and so what the
+	// invocation parameters of a Provenance mean.
+	ProvenanceBuildType = "https://github.com/opentable/sous/build@v1"
+)
+
+type (
+	// Provenance is an in-toto statement of SLSA provenance: it records how,
+	// where and from what source the images of a build were built. Builders
+	// push it alongside the image it describes.
+	Provenance struct {
+		Type          string              `json:"_type"`
+		Subject       []ProvenanceSubject `json:"subject"`
+		PredicateType string              `json:"predicateType"`
+		Predicate     ProvenancePredicate `json:"predicate"`
+	}
+
+	// A ProvenanceSubject is an image a Provenance describes.
+	ProvenanceSubject struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	}
+
+	// ProvenancePredicate describes the build.
+	ProvenancePredicate struct {
+		Builder    ProvenanceBuilder    `json:"builder"`
+		BuildType  string               `json:"buildType"`
+		Invocation ProvenanceInvocation `json:"invocation"`
+		Metadata   ProvenanceMetadata   `json:"metadata"`
+		Materials  []ProvenanceMaterial `json:"materials"`
+	}
+
+	// ProvenanceBuilder identifies who ran the build, and where.
+	ProvenanceBuilder struct {
+		ID string `json:"id"`
+	}
+
+	// ProvenanceInvocation records what was built, and how.
+	ProvenanceInvocation struct {
+		ConfigSource ProvenanceMaterial   `json:"configSource"`
+		Parameters   ProvenanceParameters `json:"parameters"`
+		Environment  map[string]string    `json:"environment"`
+	}
+
+	// ProvenanceParameters are the parameters of a build.
+	ProvenanceParameters struct {
+		Version    string   `json:"version"`
+		Kind       string   `json:"kind,omitempty"`
+		Platforms  []string `json:"platforms,omitempty"`
+		Advisories []string `json:"advisories,omitempty"`
+		Dirty      bool     `json:"dirty"`
+		Unpushed   bool     `json:"unpushed"`
+		Dev        bool     `json:"dev"`
+	}
+
+	// ProvenanceMetadata records when the build ran.
+	ProvenanceMetadata struct {
+		BuildStartedOn  time.Time `json:"buildStartedOn"`
+		BuildFinishedOn time.Time `json:"buildFinishedOn"`
+	}
+
+	// A ProvenanceMaterial is a source the build read, e.g. a git commit.
+	ProvenanceMaterial struct {
+		URI        string            `json:"uri"`
+		Digest     map[string]string `json:"digest"`
+		EntryPoint string            `json:"entryPoint,omitempty"`
+	}
+
+	// A ProvenanceReader retrieves the provenance of registered artifacts.
+	ProvenanceReader interface {
+		// Provenance returns the provenance of the artifact built from sid,
+		// or a NoProvenanceError if none was recorded.
+		Provenance(sid SourceID) (*Provenance, error)
+	}
+
+	// NoProvenanceError reports that there is no provenance for an artifact,
+	// either because there is no artifact, or because it was built without
+	// one.
+	NoProvenanceError struct {
+		SourceID SourceID
+	}
+)
+
+// NewProvenance returns the provenance of bp, a product of the build of bc
+// which finished at finished, taking elapsed. Its subject is left for the
+// Registrar to fill in once the image is pushed, and its digest known.
+func NewProvenance(bc *BuildContext, bp *BuildProduct, finished time.Time, elapsed time.Duration) *Provenance {
+	sid := bc.Version()
+	source := ProvenanceMaterial{
+		URI:    "git+https://" + sid.Location.Repo,
+		Digest: map[string]string{"sha1": bc.Source.Revision},
+	}
+	platforms := make([]string, 0, len(bp.Platforms))
+	for p := range bp.Platforms {
+		platforms = append(platforms, p)
+	}
+	sort.Strings(platforms)
+
+	invocation := ProvenanceInvocation{
+		ConfigSource: source,
+		Parameters: ProvenanceParameters{
+			Version:    sid.Version.String(),
+			Kind:       bp.Kind,
+			Platforms:  platforms,
+			Advisories: append([]string{}, bc.Advisories...),
+			Dirty:      bc.Source.DirtyWorkingTree,
+			Unpushed:   bc.Source.RevisionUnpushed,
+			Dev:        bc.Source.DevBuild,
+		},
+		Environment: map[string]string{
+			"user": bc.User.Username,
+			"host": bc.Machine.FullHost,
+		},
+	}
+	invocation.ConfigSource.EntryPoint = sid.Location.Dir
+
+	return &Provenance{
+		Type:          ProvenanceStatementType,
+		Subject:       []ProvenanceSubject{},
+		PredicateType: ProvenancePredicateType,
+		Predicate: ProvenancePredicate{
+			Builder:    ProvenanceBuilder{ID: fmt.Sprintf("sous://%s@%s", bc.User.Username, bc.Machine.FullHost)},
+			BuildType:  ProvenanceBuildType,
+			Invocation: invocation,
+			Metadata: ProvenanceMetadata{
+				BuildStartedOn:  finished.Add(-elapsed).UTC(),
+				BuildFinishedOn: finished.UTC(),
+			},
+			Materials: []ProvenanceMaterial{source},
+		},
+	}
+}
+
+// SetSubject records that p describes the image named name, whose digest is
+// digest, e.g. "sha256:abc...".
+func (p *Provenance) SetSubject(name, digest string) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		parts = []string{"sha256", digest}
+	}
+	p.Subject = []ProvenanceSubject{{Name: name, Digest: map[string]string{parts[0]: parts[1]}}}
+}
+
+// attest records the provenance of each product of br, which was built from
+// bc and has just finished.
+func (m *BuildManager) attest(bc *BuildContext, br *BuildResult) {
+	finished := time.Now()
+	for _, bp := range br.Products {
+		bp.Provenance = NewProvenance(bc, bp, finished, br.Elapsed)
+	}
+}
+
+func (e NoProvenanceError) Error() string {
+	return fmt.Sprintf("no provenance recorded for %v", e.SourceID)
+}
@@ -0,0 +1,46 @@
+package sous
+
+import (
+	"encoding/json"
+	"os/user"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvenance(t *testing.T) {
+	bc := &BuildContext{
+		Source: SourceContext{
+			OffsetDir:        "api",
+			Revision:         "abcd",
+			NearestTag:       Tag{Name: "1.2.3", Revision: "abcd"},
+			RemoteURL:        "github.com/opentable/one",
+			DirtyWorkingTree: true,
+		},
+		User:       user.User{Username: "builder"},
+		Machine:    Machine{Host: "ci", FullHost: "ci.example.com"},
+		Advisories: []string{string(DirtyWS)},
+	}
+	bp := &BuildProduct{Kind: "", Platforms: map[string]string{"linux/arm64": "b", "linux/amd64": "a"}}
+	finished := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	p := NewProvenance(bc, bp, finished, time.Minute)
+	assert.Equal(t, "sous://builder@ci.example.com", p.Predicate.Builder.ID)
+	assert.Equal(t, finished.Add(-time.Minute), p.Predicate.Metadata.BuildStartedOn)
+	assert.Equal(t, finished, p.Predicate.Metadata.BuildFinishedOn)
+	inv := p.Predicate.Invocation
+	assert.Equal(t, "git+https://github.com/opentable/one", inv.ConfigSource.URI)
+	assert.Equal(t, "abcd", inv.ConfigSource.Digest["sha1"])
+	assert.Equal(t, "api", inv.ConfigSource.EntryPoint)
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, inv.Parameters.Platforms)
+	assert.Equal(t, []string{string(DirtyWS)}, inv.Parameters.Advisories)
+	assert.True(t, inv.Parameters.Dirty)
+
+	p.SetSubject("docker.example.com/one:1.2.3", "sha256:cabba9e")
+	b, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"_type":"https://in-toto.io/Statement/v0.1"`)
+	assert.Contains(t, string(b), `"subject":[{"name":"docker.example.com/one:1.2.3","digest":{"sha256":"cabba9e"}}]`)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/logging/messages"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -17,12 +18,24 @@ type Rectification struct {
 	// Resolution is the final resolution of this single rectification.
 	sync.RWMutex
 	Resolution DiffResolution
+	// User is the user whose change this rectification deploys, if known.
+	User User
 
 	log    logging.LogSink
 	uuid   uuid.UUID
 	once   sync.Once
 	ctx    context.Context
 	cancel func()
+
+	// progress is the stage reached by a progressive rollout, if any.
+	progress *RolloutProgress
+	// stageLog receives the resolution of each intermediate rollout stage.
+	stageLog chan<- DiffResolution
+	// original is the deployment to roll back to: Pair.Prior unless set by
+	// RollBackTo.
+	original *Deployable
+	// rollback is true if this rectification reverts a failed deployment.
+	rollback bool
 }
 
 // NewRectification is used to rectify differences on a single Deployment.
@@ -32,11 +45,12 @@ func NewRectification(dp DeployablePair, l logging.LogSink) *Rectification {
 	u := uuid.NewV4()
 
 	return &Rectification{
-		Pair:   dp,
-		ctx:    c,
-		cancel: cancel,
-		uuid:   u,
-		log:    l,
+		Pair:     dp,
+		ctx:      c,
+		cancel:   cancel,
+		uuid:     u,
+		log:      l,
+		original: dp.Prior,
 	}
 }
 
@@ -46,6 +60,32 @@ func (r *Rectification) EachField(fn logging.FieldReportFn) {
 	r.Resolution.EachField(fn)
 }
 
+// LogStagesTo arranges for the DiffResolution of each intermediate stage of a
+// progressive rollout to be sent to c. The final stage is reported by Wait as
+// usual. LogStagesTo must be called before Begin.
+func (r *Rectification) LogStagesTo(c chan<- DiffResolution) {
+	r.stageLog = c
+}
+
+// RollBackTo records prior as the deployment to return to should this
+// rectification fail and its Post ask for RollbackOnFailure. It is only needed
+// when Pair has no Prior, and must be called before Begin.
+func (r *Rectification) RollBackTo(prior *Deployable) {
+	r.original = prior
+}
+
+// RolloutProgress returns the stage reached by a progressive rollout, or nil
+// if this rectification is not being rolled out in stages.
+func (r *Rectification) RolloutProgress() *RolloutProgress {
+	r.RLock()
+	defer r.RUnlock()
+	if r.progress == nil {
+		return nil
+	}
+	p := *r.progress
+	return &p
+}
+
 // Begin begins applying sr.Pair using d Deployer. Call Result to get the
 // result. Begin can be called multiple times but performs its function only
 // once.
@@ -54,6 +94,9 @@ func (r *Rectification) Begin(d Deployer, reg Registry, rf *ResolveFilter, state
 		go func() {
 			defer r.cancel()
 
+			if !r.rollOut(d, reg, rf, stateReader) {
+				return
+			}
 			r.rectify(d, reg)
 			r.awaitDone(d, reg, rf, stateReader)
 
@@ -61,31 +104,208 @@ func (r *Rectification) Begin(d Deployer, reg Registry, rf *ResolveFilter, state
 	})
 }
 
+// resolveArtifact ensures r.Pair.Post has a BuildArtifact, recording an error
+// in the Resolution and returning false if it cannot.
+func (r *Rectification) resolveArtifact(reg Registry) bool {
+	if r.Pair.Post.BuildArtifact != nil {
+		return true
+	}
+	pair, diff := HandlePairsByRegistry(reg, &r.Pair)
+	if diff != nil && diff.Error != nil {
+		r.Lock()
+		r.Resolution.Error = WrapResolveError(diff.Error)
+		r.Unlock()
+		return false
+	}
+	if pair == nil {
+		r.Lock()
+		r.Resolution.Error = WrapResolveError(fmt.Errorf("Unknown Error Occurred, no resolve error and no pair present"))
+		r.Unlock()
+		return false
+	}
+	r.Pair = *pair
+	return true
+}
+
 func (r *Rectification) rectify(d Deployer, reg Registry) {
-	if r.Pair.Post.BuildArtifact == nil {
-		pair, diff := HandlePairsByRegistry(reg, &r.Pair)
-		if diff != nil && diff.Error != nil {
+	if !r.resolveArtifact(reg) {
+		return
+	}
+	rez := d.Rectify(&r.Pair)
+	r.Lock()
+	r.Resolution = rez
+	if r.progress != nil {
+		p := *r.progress
+		r.Resolution.Rollout = &p
+	}
+	r.Unlock()
+}
+
+// rolloutStages returns the instance counts of each stage of a progressive
+// rollout of r.Pair, or nil if it should be applied all at once. Only version
+// changes to long-running deployments are rolled out in stages.
+func (r *Rectification) rolloutStages() []int {
+	if r.Pair.Kind() != ModifiedKind {
+		return nil
+	}
+	prior, post := r.Pair.Prior, r.Pair.Post
+	if post.Kind != ManifestKindService && post.Kind != ManifestKindWorker {
+		return nil
+	}
+	if r.rollback || !post.Rollout.Staged() || prior.SourceID.Equal(post.SourceID) {
+		return nil
+	}
+	return post.Rollout.Stages(post.NumInstances)
+}
+
+// stagePair returns a copy of r.Pair deploying only instances instances, as a
+// canary beside r.Pair.Prior.
+func (r *Rectification) stagePair(instances int) *DeployablePair {
+	post := *r.Pair.Post
+	post.Deployment = r.Pair.Post.Deployment.Clone()
+	post.NumInstances = instances
+	stage := r.Pair
+	stage.Post = &post
+	stage.UUID = uuid.NewV4()
+	return &stage
+}
+
+// rollOut performs all but the final stage of a progressive rollout, leaving
+// r.Pair ready for the final stage. Each stage runs as a canary beside
+// r.Pair.Prior, which keeps all of its instances throughout, and the canary is
+// removed before rollOut returns. It returns false if the rectification
+// should go no further.
+func (r *Rectification) rollOut(d Deployer, reg Registry, rf *ResolveFilter, stateReader StateReader) bool {
+	stages := r.rolloutStages()
+	if len(stages) < 2 {
+		return true
+	}
+	cd, ok := d.(CanaryDeployer)
+	if !ok {
+		r.Lock()
+		r.Resolution.Error = WrapResolveError(&RolloutUnsupportedError{Kind: r.Pair.Post.Cluster.EffectiveKind()})
+		r.Unlock()
+		return false
+	}
+	if !r.resolveArtifact(reg) {
+		return false
+	}
+	clusters, err := r.clusters(rf, stateReader)
+	if err != nil {
+		r.Lock()
+		r.Resolution.Error = WrapResolveError(err)
+		r.Unlock()
+		return false
+	}
+	ro := r.Pair.Post.Rollout
+
+	for i, instances := range stages {
+		progress := RolloutProgress{Stage: i + 1, Stages: len(stages), Instances: instances}
+		r.Lock()
+		r.progress = &progress
+		r.Unlock()
+		if i == len(stages)-1 {
+			r.removeCanary(cd)
+			return true
+		}
+
+		stage := r.stagePair(instances)
+		rez := DiffResolution{DeploymentID: stage.ID(), Desc: ModifyDiff, Rollout: &progress}
+		err := cd.DeployCanary(stage)
+		if unsupported, is := err.(*RolloutUnsupportedError); is {
 			r.Lock()
-			r.Resolution.Error = WrapResolveError(diff.Error)
+			r.Resolution.Error = WrapResolveError(unsupported)
 			r.Unlock()
-			return
+			return false
 		}
-		if pair != nil {
-			r.Pair = *pair
+		abort := err != nil
+		if abort {
+			rez.Error = WrapResolveError(err)
 		} else {
-			r.Lock()
-			r.Resolution.Error = WrapResolveError(fmt.Errorf("Unknown Error Occurred, no resolve error and no pair present"))
-			r.Unlock()
-			return
+			state, err := r.waitFor(cd.CanaryStatus, reg, clusters, stage)
+			switch {
+			case err != nil:
+				rez.Error, abort = WrapResolveError(err), true
+			case state == nil:
+				rez.Error, abort = WrapResolveError(fmt.Errorf("timed out waiting for %s", progress)), true
+			default:
+				rez.DeployState = state
+				if state.Status != DeployStatusActive {
+					rez.Error = WrapResolveError(&FailedStatusError{})
+					abort = ro.AbortOnFailure
+				}
+			}
+		}
+
+		messages.ReportLogFieldsMessage("Rollout stage complete", logging.InformationLevel, r.log, progress, rez)
+		if r.stageLog != nil {
+			r.stageLog <- rez
+		}
+
+		if abort {
+			r.abortRollout(cd, reg, clusters, progress, rez)
+			return false
+		}
+
+		select {
+		case <-time.After(time.Duration(ro.PauseSeconds) * time.Second):
+		case <-r.ctx.Done():
+			r.removeCanary(cd)
+			return false
+		}
+	}
+	return true
+}
+
+// abortRollout removes the canary of a failed rollout stage, restores the prior
+// deployment to its original instance count should it have lost any, and
+// records the failure in the Resolution along with the prior's state.
+func (r *Rectification) abortRollout(cd CanaryDeployer, reg Registry, clusters Clusters, progress RolloutProgress, rez DiffResolution) {
+	r.removeCanary(cd)
+	state, err := r.pollOnce(cd.Status, reg, clusters, &r.Pair)
+	if err != nil {
+		logging.ReportError(r.log, err)
+	}
+	if state != nil && state.NumInstances != r.original.NumInstances {
+		restore := DeployablePair{
+			Prior:        &Deployable{Status: state.Status, Deployment: state.Deployment.Clone()},
+			Post:         r.original,
+			ExecutorData: state.ExecutorData,
+		}
+		restore.SetID(r.Pair.ID())
+		if restored := cd.Rectify(&restore); restored.Error != nil {
+			logging.ReportError(r.log, restored.Error)
 		}
 	}
 	r.Lock()
-	r.Resolution = d.Rectify(&r.Pair)
+	r.Resolution = DiffResolution{
+		DeploymentID: r.Pair.ID(),
+		Desc:         rez.Desc,
+		DeployState:  state,
+		Rollout:      &progress,
+		Error:        WrapResolveError(&RolloutAbortedError{Progress: progress, Cause: rez.Error}),
+	}
 	r.Unlock()
 }
 
-func (r *Rectification) awaitDone(d Deployer, reg Registry, rf *ResolveFilter, stateReader StateReader) {
+// removeCanary removes the canary run by rollOut, logging any failure to do
+// so, since the rectification proceeds regardless.
+func (r *Rectification) removeCanary(cd CanaryDeployer) {
+	if err := cd.RemoveCanary(r.Pair.Post); err != nil {
+		logging.ReportError(r.log, err)
+	}
+}
+
+func (r *Rectification) clusters(rf *ResolveFilter, stateReader StateReader) (Clusters, error) {
 	state, err := stateReader.ReadState()
+	if err != nil {
+		return nil, err
+	}
+	return rf.FilteredClusters(state.Defs.Clusters), nil
+}
+
+func (r *Rectification) awaitDone(d Deployer, reg Registry, rf *ResolveFilter, stateReader StateReader) {
+	clusters, err := r.clusters(rf, stateReader)
 	if err != nil {
 		r.Lock()
 		r.Resolution.Error = WrapResolveError(err)
@@ -93,16 +313,6 @@ func (r *Rectification) awaitDone(d Deployer, reg Registry, rf *ResolveFilter, s
 		return
 	}
 
-	clusters := state.Defs.Clusters
-	clusters = rf.FilteredClusters(clusters)
-
-	// TODO constants / configs
-	tick := time.NewTicker(250 * time.Millisecond)
-	defer tick.Stop()
-
-	end, ec := context.WithTimeout(r.ctx, 20*time.Minute)
-	defer ec()
-
 	logging.Deliver(r.log,
 		logging.SousGenericV1,
 		logging.GetCallerInfo(logging.NotHere()),
@@ -111,48 +321,74 @@ func (r *Rectification) awaitDone(d Deployer, reg Registry, rf *ResolveFilter, s
 		r.Pair,
 	)
 
-	for {
-		s, err := r.pollOnce(d, reg, clusters)
-		if err != nil {
-			r.Lock()
-			r.Resolution.Error = &ErrorWrapper{error: err}
-			r.Unlock()
-			return
+	s, err := r.waitFor(d.Status, reg, clusters, &r.Pair)
+	if err != nil {
+		r.Lock()
+		r.Resolution.Error = &ErrorWrapper{error: err}
+		r.Unlock()
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	if s == nil {
+		if r.Resolution.DeployState == nil {
+			r.Resolution.DeployState = &DeployState{}
 		}
-		if s.Final() && s.SourceID.Equal(r.Pair.Post.SourceID) {
-			r.Lock()
+		return
+	}
 
-			r.Resolution.DeployState = s
+	r.Resolution.DeployState = s
 
-			//If failed to deploy, make sure to include executor message in resolution error
-			if r.Resolution.DeployState.Status != DeployStatusActive && r.Resolution.DeployState.ExecutorMessage != "" {
-				if r.Resolution.Error == nil {
-					r.Resolution.Error = &ErrorWrapper{error: fmt.Errorf("%s", r.Resolution.DeployState.ExecutorMessage)}
-				} else {
-					r.Resolution.Error = &ErrorWrapper{error: fmt.Errorf("%s:%s", r.Resolution.Error.Error(), r.Resolution.DeployState.ExecutorMessage)}
-				}
-			}
+	// Deployers report a failed Prior as an error, but when rolling back that
+	// failure is what has just been fixed.
+	if r.rollback && s.Status == DeployStatusActive {
+		r.Resolution.Desc = RollbackDiff
+		r.Resolution.Error = nil
+	}
 
-			r.Unlock()
-			return
+	//If failed to deploy, make sure to include executor message in resolution error
+	if r.Resolution.DeployState.Status != DeployStatusActive && r.Resolution.DeployState.ExecutorMessage != "" {
+		if r.Resolution.Error == nil {
+			r.Resolution.Error = &ErrorWrapper{error: fmt.Errorf("%s", r.Resolution.DeployState.ExecutorMessage)}
+		} else {
+			r.Resolution.Error = &ErrorWrapper{error: fmt.Errorf("%s:%s", r.Resolution.Error.Error(), r.Resolution.DeployState.ExecutorMessage)}
+		}
+	}
+}
+
+// A statusFunc returns the state of pair.Post, as Deployer.Status does.
+type statusFunc func(Registry, Clusters, *DeployablePair) (*DeployState, error)
+
+// waitFor polls status until pair.Post is deployed with a final status, which
+// it returns. It returns nil if that does not happen before the timeout.
+func (r *Rectification) waitFor(status statusFunc, reg Registry, clusters Clusters, pair *DeployablePair) (*DeployState, error) {
+	// TODO constants / configs
+	tick := time.NewTicker(250 * time.Millisecond)
+	defer tick.Stop()
+
+	end, ec := context.WithTimeout(r.ctx, 20*time.Minute)
+	defer ec()
+
+	for {
+		s, err := r.pollOnce(status, reg, clusters, pair)
+		if err != nil {
+			return nil, err
+		}
+		if s.Final() && s.SourceID.Equal(pair.Post.SourceID) {
+			return s, nil
 		}
 		select {
 		case <-tick.C:
 		case <-end.Done():
-			r.Lock()
-			defer r.Unlock()
-			if r.Resolution.DeployState == nil {
-				r.Resolution.DeployState = &DeployState{}
-			}
-			return
+			return nil, nil
 		}
 	}
-
 }
 
-func (r *Rectification) pollOnce(d Deployer, reg Registry, clusters Clusters) (*DeployState, error) {
+func (r *Rectification) pollOnce(status statusFunc, reg Registry, clusters Clusters, pair *DeployablePair) (*DeployState, error) {
 	// XXX thread the context from Begin into Deployer.Status
-	depState, err := d.Status(reg, clusters, &r.Pair)
+	depState, err := status(reg, clusters, pair)
 	if err != nil {
 		return nil, err
 	}
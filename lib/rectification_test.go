@@ -6,6 +6,7 @@ import (
 
 	"github.com/nyarly/spies"
 	"github.com/opentable/sous/util/logging"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSingleRectification_Resolve_completes(t *testing.T) {
@@ -52,3 +53,176 @@ func TestSingleRectification_Resolve_completes(t *testing.T) {
 		t.Errorf("got DeployStatus %q; want %q", sr.Resolution.DeployState.Status, DeployStatusActive)
 	}
 }
+
+func rolloutPair(ro Rollout) DeployablePair {
+	prior := &Deployment{
+		ClusterName:  "cluster",
+		SourceID:     MustNewSourceID("github.com/opentable/example", "", "1.0.0"),
+		Kind:         ManifestKindService,
+		DeployConfig: DeployConfig{NumInstances: 4},
+	}
+	post := prior.Clone()
+	post.SourceID = MustNewSourceID("github.com/opentable/example", "", "2.0.0")
+	post.Rollout = ro
+
+	dp := DeployablePair{
+		Prior: &Deployable{Status: DeployStatusActive, Deployment: prior},
+		Post:  &Deployable{Status: DeployStatusActive, Deployment: post, BuildArtifact: &BuildArtifact{Name: "example:2.0.0"}},
+	}
+	dp.SetID(post.ID())
+	return dp
+}
+
+func TestRectification_Rollout(t *testing.T) {
+	log, _ := logging.NewLogSinkSpy()
+	pair := rolloutPair(Rollout{CanaryInstances: 1, Steps: []int{50}})
+	sr := NewRectification(pair, log)
+	stages := make(chan DiffResolution, 10)
+	sr.LogStagesTo(stages)
+
+	dpr, c := NewCanaryDeployerSpy()
+	active := &DeployState{
+		Status:     DeployStatusActive,
+		Deployment: Deployment{SourceID: pair.Post.SourceID},
+	}
+	c.MatchMethod("Status", spies.AnyArgs, active, nil)
+	c.MatchMethod("CanaryStatus", spies.AnyArgs, active, nil)
+	c.MatchMethod("DeployCanary", spies.AnyArgs, nil)
+	c.MatchMethod("RemoveCanary", spies.AnyArgs, nil)
+	c.MatchMethod("Rectify", spies.AnyArgs, DiffResolution{Desc: ModifyDiff}, nil)
+
+	sr.Begin(dpr, &DummyRegistry{}, &ResolveFilter{}, NewDummyStateManager())
+	rez := sr.Wait()
+
+	if rez.Error != nil {
+		t.Fatalf("unexpected error: %s", rez.Error)
+	}
+	var counts []int
+	for _, call := range c.CallsTo("DeployCanary") {
+		counts = append(counts, call.PassedArgs().Get(0).(*DeployablePair).Post.NumInstances)
+	}
+	assert.Equal(t, []int{1, 2}, counts)
+
+	// The prior version keeps all its instances until the final stage
+	// replaces it.
+	rectified := c.CallsTo("Rectify")
+	if assert.Len(t, rectified, 1) {
+		final := rectified[0].PassedArgs().Get(0).(*DeployablePair)
+		assert.Equal(t, 4, final.Prior.NumInstances)
+		assert.Equal(t, pair.Prior.SourceID, final.Prior.SourceID)
+		assert.Equal(t, 4, final.Post.NumInstances)
+	}
+	assert.Len(t, c.CallsTo("RemoveCanary"), 1)
+
+	assert.Equal(t, &RolloutProgress{Stage: 3, Stages: 3, Instances: 4}, rez.Rollout)
+	assert.Equal(t, &RolloutProgress{Stage: 3, Stages: 3, Instances: 4}, sr.RolloutProgress())
+	if assert.Len(t, stages, 2) {
+		first := <-stages
+		assert.Equal(t, 1, first.Rollout.Stage)
+		assert.Equal(t, ModifyDiff, first.Desc)
+	}
+}
+
+func TestRectification_Rollout_AbortOnFailure(t *testing.T) {
+	log, _ := logging.NewLogSinkSpy()
+	pair := rolloutPair(Rollout{CanaryInstances: 1, AbortOnFailure: true})
+	sr := NewRectification(pair, log)
+
+	dpr, c := NewCanaryDeployerSpy()
+	c.MatchMethod("CanaryStatus", spies.AnyArgs, &DeployState{
+		Status:     DeployStatusFailed,
+		Deployment: Deployment{SourceID: pair.Post.SourceID, DeployConfig: DeployConfig{NumInstances: 1}},
+	}, nil)
+	c.MatchMethod("Status", spies.AnyArgs, &DeployState{
+		Status:     DeployStatusActive,
+		Deployment: *pair.Prior.Deployment.Clone(),
+	}, nil)
+	c.MatchMethod("DeployCanary", spies.AnyArgs, nil)
+	c.MatchMethod("RemoveCanary", spies.AnyArgs, nil)
+	c.MatchMethod("Rectify", spies.AnyArgs, DiffResolution{Desc: ModifyDiff}, nil)
+
+	sr.Begin(dpr, &DummyRegistry{}, &ResolveFilter{}, NewDummyStateManager())
+	rez := sr.Wait()
+
+	assert.Len(t, c.CallsTo("DeployCanary"), 1)
+	assert.Len(t, c.CallsTo("RemoveCanary"), 1)
+	assert.Len(t, c.CallsTo("Rectify"), 0)
+	if assert.NotNil(t, rez.Error) {
+		_, isAbort := rez.Error.error.(*RolloutAbortedError)
+		assert.True(t, isAbort, "got %T", rez.Error.error)
+	}
+	assert.Equal(t, 1, rez.Rollout.Stage)
+	// The prior version is left running as it was.
+	if assert.NotNil(t, rez.DeployState) {
+		assert.Equal(t, pair.Prior.SourceID, rez.DeployState.SourceID)
+		assert.Equal(t, 4, rez.DeployState.NumInstances)
+	}
+}
+
+func TestRectification_Rollout_AbortRestoresPrior(t *testing.T) {
+	log, _ := logging.NewLogSinkSpy()
+	pair := rolloutPair(Rollout{CanaryInstances: 1, AbortOnFailure: true})
+	sr := NewRectification(pair, log)
+
+	shrunk := pair.Prior.Deployment.Clone()
+	shrunk.NumInstances = 1
+	dpr, c := NewCanaryDeployerSpy()
+	c.MatchMethod("CanaryStatus", spies.AnyArgs, &DeployState{
+		Status:     DeployStatusFailed,
+		Deployment: *pair.Post.Deployment.Clone(),
+	}, nil)
+	c.MatchMethod("Status", spies.AnyArgs, &DeployState{Status: DeployStatusActive, Deployment: *shrunk}, nil)
+	c.MatchMethod("DeployCanary", spies.AnyArgs, nil)
+	c.MatchMethod("RemoveCanary", spies.AnyArgs, nil)
+	c.MatchMethod("Rectify", spies.AnyArgs, DiffResolution{Desc: ModifyDiff}, nil)
+
+	sr.Begin(dpr, &DummyRegistry{}, &ResolveFilter{}, NewDummyStateManager())
+	sr.Wait()
+
+	rectified := c.CallsTo("Rectify")
+	if assert.Len(t, rectified, 1) {
+		restore := rectified[0].PassedArgs().Get(0).(*DeployablePair)
+		assert.Equal(t, pair.Prior.SourceID, restore.Post.SourceID)
+		assert.Equal(t, 4, restore.Post.NumInstances)
+	}
+}
+
+func TestRectification_Rollout_Unsupported(t *testing.T) {
+	log, _ := logging.NewLogSinkSpy()
+	pair := rolloutPair(Rollout{CanaryInstances: 1})
+	sr := NewRectification(pair, log)
+
+	dpr, c := NewDeployerSpy()
+	c.MatchMethod("Status", spies.AnyArgs, &DeployState{Status: DeployStatusActive}, nil)
+	c.MatchMethod("Rectify", spies.AnyArgs, DiffResolution{Desc: ModifyDiff}, nil)
+
+	sr.Begin(dpr, &DummyRegistry{}, &ResolveFilter{}, NewDummyStateManager())
+	rez := sr.Wait()
+
+	assert.Len(t, c.CallsTo("Rectify"), 0)
+	if assert.NotNil(t, rez.Error) {
+		_, unsupported := rez.Error.error.(*RolloutUnsupportedError)
+		assert.True(t, unsupported, "got %T", rez.Error.error)
+	}
+}
+
+func TestRectification_Rollout_OnlyVersionChanges(t *testing.T) {
+	log, _ := logging.NewLogSinkSpy()
+	pair := rolloutPair(Rollout{CanaryInstances: 1})
+	pair.Post.SourceID = pair.Prior.SourceID
+	pair.Post.NumInstances = 6
+	sr := NewRectification(pair, log)
+
+	dpr, c := NewDeployerSpy()
+	c.MatchMethod("Status", spies.AnyArgs, &DeployState{
+		Status:     DeployStatusActive,
+		Deployment: Deployment{SourceID: pair.Post.SourceID},
+	}, nil)
+	c.MatchMethod("Rectify", spies.AnyArgs, DiffResolution{Desc: ModifyDiff}, nil)
+
+	sr.Begin(dpr, &DummyRegistry{}, &ResolveFilter{}, NewDummyStateManager())
+	rez := sr.Wait()
+
+	assert.Len(t, c.CallsTo("Rectify"), 1)
+	assert.Nil(t, rez.Rollout)
+}
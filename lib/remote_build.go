@@ -0,0 +1,243 @@
+package sous
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/opentable/sous/util/logging"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+// MaxRemoteBuilds is the number of builds a RemoteBuilder keeps the records of.
+const MaxRemoteBuilds = 100
+
+// The states of a RemoteBuild.
+const (
+	RemoteBuildQueued    = RemoteBuildState("queued")
+	RemoteBuildRunning   = RemoteBuildState("building")
+	RemoteBuildSucceeded = RemoteBuildState("succeeded")
+	RemoteBuildFailed    = RemoteBuildState("failed")
+)
+
+type (
+	// A SourceGetter fetches source code, e.g. a SourceHostChooser.
+	SourceGetter interface {
+		GetSource(SourceID) (Source, error)
+	}
+
+	// A SourceBuilder builds and registers the artifacts of fetched source,
+	// writing the output of the build to log.
+	SourceBuilder interface {
+		BuildSource(src Source, log io.Writer) (*BuildResult, error)
+	}
+
+	// RemoteBuildID identifies a RemoteBuild.
+	RemoteBuildID string
+
+	// RemoteBuildState is the progress of a RemoteBuild.
+	RemoteBuildState string
+
+	// A RemoteBuild is a build made by a RemoteBuilder on behalf of a client.
+	RemoteBuild struct {
+		ID     RemoteBuildID
+		Source SourceID
+		User   User
+		State  RemoteBuildState
+		// Queued, Started and Finished are when the build was requested,
+		// began and ended. The latter two are zero until then.
+		Queued, Started, Finished time.Time
+		// Error describes why the build failed, if it did.
+		Error string `json:",omitempty"`
+		// Result is the result of a successful build.
+		Result *BuildResult `json:",omitempty"`
+		// Log is the output of the build, a line at a time. Copies of a
+		// RemoteBuild returned by RemoteBuilder.Get may hold only its later
+		// lines; LogOffset is the number of lines left out.
+		Log       []string
+		LogOffset int
+		// partial is the last line of output, until it is ended.
+		partial []byte
+	}
+
+	// A RemoteBuilder fetches and builds source for clients, one build at a
+	// time, and keeps the records of recent builds.
+	RemoteBuilder struct {
+		Sources SourceGetter
+		Builder SourceBuilder
+		ls      logging.LogSink
+		mu      sync.Mutex
+		builds  map[RemoteBuildID]*RemoteBuild
+		order   []RemoteBuildID
+		queue   chan *RemoteBuild
+	}
+
+	remoteBuildLog struct {
+		rb *RemoteBuilder
+		b  *RemoteBuild
+	}
+)
+
+// Done returns true if a build in state s has finished.
+func (s RemoteBuildState) Done() bool {
+	return s == RemoteBuildSucceeded || s == RemoteBuildFailed
+}
+
+// NewRemoteBuilder returns a RemoteBuilder which fetches source with sg and
+// builds it with sb, and starts it building.
+func NewRemoteBuilder(sg SourceGetter, sb SourceBuilder, ls logging.LogSink) *RemoteBuilder {
+	rb := &RemoteBuilder{
+		Sources: sg,
+		Builder: sb,
+		ls:      ls,
+		builds:  map[RemoteBuildID]*RemoteBuild{},
+		queue:   make(chan *RemoteBuild, MaxRemoteBuilds),
+	}
+	go func() {
+		for b := range rb.queue {
+			rb.run(b)
+		}
+	}()
+	return rb
+}
+
+// Start queues a build of sid requested by user, and returns a copy of its
+// record. It returns an error if too many builds are queued already.
+func (rb *RemoteBuilder) Start(sid SourceID, user User) (RemoteBuild, error) {
+	if sid.Location.Repo == "" {
+		return RemoteBuild{}, errors.Errorf("cannot build %q: no repo", sid)
+	}
+	b := &RemoteBuild{
+		ID:     RemoteBuildID(uuid.New()),
+		Source: sid,
+		User:   user,
+		State:  RemoteBuildQueued,
+		Queued: time.Now(),
+		Log:    []string{},
+	}
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	select {
+	default:
+		return RemoteBuild{}, errors.Errorf("cannot build %q: too many builds queued", sid)
+	case rb.queue <- b:
+	}
+	rb.builds[b.ID] = b
+	rb.order = append(rb.order, b.ID)
+	rb.prune()
+	return b.copy(0), nil
+}
+
+// Get returns a copy of the record of the build id, with its log from line
+// from onwards, and true if there is such a build.
+func (rb *RemoteBuilder) Get(id RemoteBuildID, from int) (RemoteBuild, bool) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	b, ok := rb.builds[id]
+	if !ok {
+		return RemoteBuild{}, false
+	}
+	return b.copy(from), true
+}
+
+// prune forgets the oldest finished builds while there are more than
+// MaxRemoteBuilds. rb.mu must be held.
+func (rb *RemoteBuilder) prune() {
+	for i := 0; len(rb.order) > MaxRemoteBuilds && i < len(rb.order); {
+		id := rb.order[i]
+		if !rb.builds[id].State.Done() {
+			i++
+			continue
+		}
+		delete(rb.builds, id)
+		rb.order = append(rb.order[:i], rb.order[i+1:]...)
+	}
+}
+
+// locked calls f with rb.mu held.
+func (rb *RemoteBuilder) locked(f func()) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	f()
+}
+
+func (rb *RemoteBuilder) run(b *RemoteBuild) {
+	rb.locked(func() {
+		b.State = RemoteBuildRunning
+		b.Started = time.Now()
+	})
+	logging.ReportMsg(rb.ls, logging.InformationLevel, fmt.Sprintf("Remote build %s of %s started for %s", b.ID, b.Source, b.User))
+
+	result, err := rb.build(b)
+
+	rb.locked(func() {
+		b.endLine()
+		b.Finished = time.Now()
+		b.Result = result
+		b.State = RemoteBuildSucceeded
+		if err != nil {
+			b.State = RemoteBuildFailed
+			b.Error = err.Error()
+		}
+	})
+	if err != nil {
+		logging.ReportError(rb.ls, errors.Wrapf(err, "remote build %s of %s", b.ID, b.Source))
+		return
+	}
+	logging.ReportMsg(rb.ls, logging.InformationLevel, fmt.Sprintf("Remote build %s of %s succeeded", b.ID, b.Source))
+}
+
+func (rb *RemoteBuilder) build(b *RemoteBuild) (*BuildResult, error) {
+	log := remoteBuildLog{rb: rb, b: b}
+	fmt.Fprintf(log, "Fetching %s\n", b.Source)
+	src, err := rb.Sources.GetSource(b.Source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching source")
+	}
+	defer os.RemoveAll(src.LocalRootDir)
+	return rb.Builder.BuildSource(src, log)
+}
+
+// Write implements io.Writer on remoteBuildLog, adding each complete line
+// written to the build's Log.
+func (l remoteBuildLog) Write(p []byte) (int, error) {
+	l.rb.locked(func() {
+		l.b.partial = append(l.b.partial, p...)
+		for {
+			i := bytes.IndexByte(l.b.partial, '\n')
+			if i < 0 {
+				break
+			}
+			l.b.Log = append(l.b.Log, string(l.b.partial[:i]))
+			l.b.partial = l.b.partial[i+1:]
+		}
+	})
+	return len(p), nil
+}
+
+// endLine adds any unfinished last line of output to b's Log.
+func (b *RemoteBuild) endLine() {
+	if len(b.partial) != 0 {
+		b.Log = append(b.Log, string(b.partial))
+		b.partial = nil
+	}
+}
+
+func (b *RemoteBuild) copy(from int) RemoteBuild {
+	c := *b
+	c.partial = nil
+	if from < 0 {
+		from = 0
+	}
+	if from > len(b.Log) {
+		from = len(b.Log)
+	}
+	c.Log = append([]string{}, b.Log[from:]...)
+	c.LogOffset = from
+	return c
+}
@@ -0,0 +1,100 @@
+package sous
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/opentable/sous/util/logging"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	remoteBuildTestSources struct{}
+
+	remoteBuildTestBuilder struct {
+		release chan struct{}
+	}
+)
+
+func (remoteBuildTestSources) GetSource(id SourceID) (Source, error) {
+	if id.Location.Repo == "github.com/user/missing" {
+		return Source{}, errors.New("no such repo")
+	}
+	return Source{ID: id}, nil
+}
+
+func (b remoteBuildTestBuilder) BuildSource(src Source, log io.Writer) (*BuildResult, error) {
+	fmt.Fprint(log, "step 1\nstep")
+	<-b.release
+	fmt.Fprint(log, " 2\nunfinished")
+	if src.ID.Version.Major == 0 {
+		return nil, errors.New("build broke")
+	}
+	return &BuildResult{Products: []*BuildProduct{{Source: src.ID, Kind: "docker"}}}, nil
+}
+
+func waitForRemoteBuild(t *testing.T, rb *RemoteBuilder, id RemoteBuildID) RemoteBuild {
+	for i := 0; i < 200; i++ {
+		b, ok := rb.Get(id, 0)
+		require.True(t, ok)
+		if b.State.Done() {
+			return b
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("build %s did not finish", id)
+	return RemoteBuild{}
+}
+
+func TestRemoteBuilder(t *testing.T) {
+	release := make(chan struct{})
+	rb := NewRemoteBuilder(remoteBuildTestSources{}, remoteBuildTestBuilder{release: release}, logging.SilentLogSet())
+
+	_, err := rb.Start(SourceID{}, User{})
+	assert.Error(t, err)
+
+	sid := MakeSourceID("github.com/user/repo", "", "1.2.3")
+	b, err := rb.Start(sid, User{Name: "Someone"})
+	require.NoError(t, err)
+	assert.Equal(t, sid, b.Source)
+
+	for i := 0; i < 200; i++ {
+		if b, _ = rb.Get(b.ID, 0); len(b.Log) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, RemoteBuildRunning, b.State)
+	assert.Equal(t, []string{"Fetching github.com/user/repo,1.2.3", "step 1"}, b.Log)
+	close(release)
+
+	b = waitForRemoteBuild(t, rb, b.ID)
+	assert.Equal(t, RemoteBuildSucceeded, b.State)
+	assert.Empty(t, b.Error)
+	require.NotNil(t, b.Result)
+	assert.Len(t, b.Result.Products, 1)
+
+	later, ok := rb.Get(b.ID, 2)
+	require.True(t, ok)
+	assert.Equal(t, 2, later.LogOffset)
+	assert.Equal(t, []string{"step 2", "unfinished"}, later.Log)
+
+	failed, err := rb.Start(MakeSourceID("github.com/user/repo", "", "0.1.0"), User{})
+	require.NoError(t, err)
+	failed = waitForRemoteBuild(t, rb, failed.ID)
+	assert.Equal(t, RemoteBuildFailed, failed.State)
+	assert.Equal(t, "build broke", failed.Error)
+
+	missing, err := rb.Start(MakeSourceID("github.com/user/missing", "", "1.0.0"), User{})
+	require.NoError(t, err)
+	missing = waitForRemoteBuild(t, rb, missing.ID)
+	assert.Equal(t, RemoteBuildFailed, missing.State)
+	assert.Contains(t, missing.Error, "no such repo")
+
+	_, ok = rb.Get("nope", 0)
+	assert.False(t, ok)
+}
@@ -172,6 +172,15 @@ func IsTransientResolveError(err error) bool {
 		// intervention: either the image needs to be rebuilt clean, or the cluster
 		// reconfigured to accept the advisory.
 		return false
+	case *ArtifactPlatformError:
+		// ArtifactPlatformError requires that the image be rebuilt for the
+		// platforms the cluster requires.
+		return false
+	case *ArtifactSignatureError:
+		// ArtifactSignatureError requires that the image be rebuilt and signed
+		// by a trusted Sous pipeline, or the cluster reconfigured to trust its
+		// signer.
+		return false
 	case *MissingImageNameError:
 		// MissingImageNameError isn't transient: it requires that an appropriate
 		// image be built with the desired name and the server needs to be able to
@@ -216,6 +216,28 @@ func (rf *ResolveFilter) FilterManifestID(m ManifestID) bool {
 		rf.matchFlavor(m.Flavor)
 }
 
+// FilterManifestChange returns true if c changes a manifest matched by rf,
+// and, if rf names a cluster, that manifest's deployment to the cluster.
+func (rf *ResolveFilter) FilterManifestChange(c ManifestChange) bool {
+	if !rf.FilterManifestID(c.ManifestID) {
+		return false
+	}
+	if rf.Cluster.All() {
+		return true
+	}
+	for _, m := range []*Manifest{c.Prior, c.Post} {
+		if m == nil {
+			continue
+		}
+		for cluster := range m.Deployments {
+			if rf.matchCluster(cluster) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // EachField implement logging.EachFielder on ResolveFilter.
 func (rf *ResolveFilter) EachField(fn logging.FieldReportFn) {
 	fn(logging.FilterCluster, rf.Cluster.ValueOr("*"))
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/opentable/sous/util/logging"
 	"github.com/opentable/sous/util/logging/messages"
@@ -18,6 +19,12 @@ type (
 		*ResolveFilter
 		ls       logging.LogSink
 		QueueSet *R11nQueueSet
+		// Freezes suspend rectification of the deployments they match while
+		// they are in effect.
+		Freezes Freezes
+		// Scaler, if set, supplies the instance counts of autoscaled
+		// deployments.
+		Scaler *Scaler
 	}
 
 	// DeploymentPredicate takes a *Deployment and returns true if the
@@ -56,13 +63,19 @@ func (r *Resolver) queueDiffs(dcs *DeployableChans, results chan DiffResolution)
 		}
 		// Zero instances or version 0.0.0 on brand new deployments is a no-op,
 		// so do not add to queue.
-		if p.Kind() == AddedKind && (p.Post.NumInstances == 0 ||
-			p.Post.DeploySpec().Version.String() == "0.0.0") {
+		if p.Kind() == AddedKind && p.uninitialized() {
 			messages.ReportLogFieldsMessageWithIDs("Not adding uninitialized new diff",
 				logging.ExtraDebug1Level, r.ls, p)
 			continue
 		}
+		if f := r.Freezes.Frozen(p.ID(), time.Now()); f != nil {
+			messages.ReportLogFieldsMessageWithIDs("Not adding diff to frozen cluster",
+				logging.InformationLevel, r.ls, p)
+			results <- DiffResolution{DeploymentID: p.ID(), Desc: FrozenDiff}
+			continue
+		}
 		sr := NewRectification(*p, r.ls)
+		sr.LogStagesTo(results)
 		r.reportQSWait("Adding to queue set", logging.NotHere(), sr)
 		queued, ok := r.QueueSet.PushIfEmpty(sr)
 		if !ok {
@@ -107,6 +120,9 @@ func (r *Resolver) reportQSWait(msg string, notThere logging.Excluder, fields ..
 // those differences.
 func (r *Resolver) Begin(intended Deployments, clusters Clusters) *ResolveRecorder {
 	intended = intended.Filter(r.FilterDeployment)
+	if r.Scaler != nil {
+		intended = r.Scaler.Apply(intended)
+	}
 
 	return NewResolveRecorder(intended, r.ls, func(recorder *ResolveRecorder) {
 		var actual DeployStates
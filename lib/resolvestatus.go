@@ -55,6 +55,10 @@ type (
 
 		// SchedulerURL is a URL where this deployment can be seen.
 		SchedulerURL string
+
+		// Rollout is the stage of a progressive rollout this resolution
+		// describes, or nil if the change was not rolled out in stages.
+		Rollout *RolloutProgress
 	}
 
 	// ResolutionType marks the kind of a DiffResolution
@@ -73,6 +77,10 @@ const (
 	ModifyDiff = ResolutionType("updated")
 	// DeleteDiff - a deployment was active that wasn't intended at all, and was deleted.
 	DeleteDiff = ResolutionType("deleted")
+	// RollbackDiff - a new version failed, and the deployment was returned to the version it replaced.
+	RollbackDiff = ResolutionType("rolled back")
+	// FrozenDiff - the deployment differed from the intended, but was left as it was because its cluster is frozen.
+	FrozenDiff = ResolutionType("frozen")
 )
 
 func (rez DiffResolution) String() string {
@@ -112,7 +120,9 @@ func NewResolveRecorder(intended Deployments, ls logging.LogSink, f func(*Resolv
 	}
 
 	// Update status incrementally.
+	logged := make(chan struct{})
 	go func() {
+		defer close(logged)
 		for rez := range rr.Log {
 			rr.write(func() {
 				rr.status.Log = append(rr.status.Log, rez)
@@ -128,6 +138,7 @@ func NewResolveRecorder(intended Deployments, ls logging.LogSink, f func(*Resolv
 	go func() {
 		f(rr)
 		close(rr.Log)
+		<-logged
 		rr.write(func() {
 			rr.status.Finished = time.Now()
 			if rr.err == nil {
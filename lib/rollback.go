@@ -0,0 +1,112 @@
+package sous
+
+import (
+	"fmt"
+
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/logging/messages"
+	"github.com/pkg/errors"
+)
+
+// SystemUser is recorded as the author of changes Sous makes to the GDM on
+// its own behalf, for instance when rolling back a failed deployment.
+var SystemUser = User{Name: "Sous", Email: "sous@localhost"}
+
+// A Rollbacker reverts failed deployments whose Rollout asks for
+// RollbackOnFailure.
+type Rollbacker struct {
+	dm  DeploymentManager
+	log logging.LogSink
+}
+
+// NewRollbacker returns a Rollbacker which records rollbacks in the GDM using
+// dm.
+func NewRollbacker(dm DeploymentManager, ls logging.LogSink) *Rollbacker {
+	return &Rollbacker{dm: dm, log: ls}
+}
+
+// NewRollbackRectification returns a Rectification which reverts a failed
+// deployment, currently in state current, to the intended deployment to. Its
+// Resolution is described as RollbackDiff once to is active, and it never
+// itself triggers a rollback or a staged rollout.
+func NewRollbackRectification(current *DeployState, to *Deployment, ls logging.LogSink) *Rectification {
+	pair := DeployablePair{
+		Prior:        &Deployable{Status: current.Status, Deployment: current.Deployment.Clone()},
+		Post:         &Deployable{Deployment: to},
+		ExecutorData: current.ExecutorData,
+	}
+	pair.SetID(to.ID())
+	r := NewRectification(pair, ls)
+	r.rollback = true
+	r.User = SystemUser
+	return r
+}
+
+// shouldRollBack returns true if r is a version change which failed, or whose
+// rollout was aborted, and its Post Rollout asks for RollbackOnFailure.
+func shouldRollBack(r *Rectification) bool {
+	prior, post := r.original, r.Pair.Post
+	if r.rollback || prior == nil || post == nil {
+		return false
+	}
+	if !post.Rollout.RollbackOnFailure || prior.Status == DeployStatusFailed ||
+		prior.SourceID.Equal(post.SourceID) {
+		return false
+	}
+	rez := r.Resolution
+	return rez.DeployState != nil && (rez.DeployState.Status == DeployStatusFailed || rolloutAborted(rez))
+}
+
+// rolloutAborted returns true if rez records an aborted rollout. An aborted
+// rollout leaves the prior version running, so only the GDM needs rolling
+// back.
+func rolloutAborted(rez DiffResolution) bool {
+	if rez.Error == nil {
+		return false
+	}
+	_, aborted := rez.Error.error.(*RolloutAbortedError)
+	return aborted
+}
+
+// RollBack checks the completed Rectification r and, if it failed to deploy a
+// new version with RollbackOnFailure set, writes the prior version back to
+// the GDM as SystemUser and pushes a rectification to it onto qs. It returns
+// the queued rollback, or nil if no rollback was needed.
+//
+// If the GDM no longer intends the version r deployed, someone has already
+// changed it, and no rollback is made.
+func (rb *Rollbacker) RollBack(r *Rectification, qs QueueSet) (*QueuedR11n, error) {
+	r.RLock()
+	should := shouldRollBack(r)
+	failed := r.Resolution.DeployState
+	r.RUnlock()
+	if !should {
+		return nil, nil
+	}
+
+	did := r.Pair.ID()
+	intended, err := rb.dm.ReadDeployment(did)
+	if err != nil {
+		return nil, errors.Wrapf(err, "rolling back %s", did)
+	}
+	if !intended.SourceID.Equal(r.Pair.Post.SourceID) {
+		messages.ReportLogFieldsMessage("Not rolling back: GDM changed since failed deployment",
+			logging.WarningLevel, rb.log, did, intended.SourceID)
+		return nil, nil
+	}
+
+	reverted := intended.Clone()
+	reverted.SourceID = r.original.SourceID
+	if err := rb.dm.WriteDeployment(reverted, SystemUser); err != nil {
+		return nil, errors.Wrapf(err, "rolling back %s", did)
+	}
+
+	messages.ReportLogFieldsMessageToConsole(fmt.Sprintf("Rolling back %s from %s to %s",
+		did, r.Pair.Post.SourceID.Version, reverted.SourceID.Version), logging.WarningLevel, rb.log, did)
+
+	qr, ok := qs.Push(NewRollbackRectification(failed, reverted, rb.log))
+	if !ok {
+		return nil, errors.Errorf("rolling back %s: queue full", did)
+	}
+	return qr, nil
+}
@@ -0,0 +1,168 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/nyarly/spies"
+	"github.com/opentable/sous/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failedRectification runs a rectification of pair whose new version fails.
+func failedRectification(t *testing.T, pair DeployablePair) *Rectification {
+	log, _ := logging.NewLogSinkSpy()
+	r := NewRectification(pair, log)
+
+	dpr, c := NewDeployerSpy()
+	c.MatchMethod("Status", spies.AnyArgs, &DeployState{
+		Status:       DeployStatusFailed,
+		Deployment:   *pair.Post.Deployment.Clone(),
+		ExecutorData: "failed-data",
+	}, nil)
+	c.MatchMethod("Rectify", spies.AnyArgs, DiffResolution{Desc: ModifyDiff}, nil)
+
+	r.Begin(dpr, &DummyRegistry{}, &ResolveFilter{}, NewDummyStateManager())
+	r.Wait()
+	return r
+}
+
+func TestRollbacker_RollBack(t *testing.T) {
+	pair := rolloutPair(Rollout{RollbackOnFailure: true})
+	failed := failedRectification(t, pair)
+
+	dm, dmc := NewDeploymentManagerSpy()
+	dmc.MatchMethod("ReadDeployment", spies.AnyArgs, pair.Post.Deployment.Clone(), nil)
+	dmc.MatchMethod("WriteDeployment", spies.AnyArgs, nil)
+	qs, qsc := NewQueueSetSpy()
+	qsc.MatchMethod("Push", spies.AnyArgs, &QueuedR11n{ID: "rollback"}, true)
+
+	log, _ := logging.NewLogSinkSpy()
+	qr, err := NewRollbacker(dm, log).RollBack(failed, qs)
+	require.NoError(t, err)
+	require.NotNil(t, qr)
+
+	writes := dmc.CallsTo("WriteDeployment")
+	require.Len(t, writes, 1)
+	written := writes[0].PassedArgs().Get(0).(*Deployment)
+	assert.Equal(t, pair.Prior.SourceID, written.SourceID)
+	assert.Equal(t, SystemUser, writes[0].PassedArgs().Get(1).(User))
+
+	pushes := qsc.CallsTo("Push")
+	require.Len(t, pushes, 1)
+	rollback := pushes[0].PassedArgs().Get(0).(*Rectification)
+	assert.Equal(t, pair.Post.SourceID, rollback.Pair.Prior.SourceID)
+	assert.Equal(t, pair.Prior.SourceID, rollback.Pair.Post.SourceID)
+	assert.Equal(t, "failed-data", rollback.Pair.ExecutorData)
+
+	// The rollback itself is described as such, and is never rolled back.
+	dpr, c := NewDeployerSpy()
+	c.MatchMethod("Status", spies.AnyArgs, &DeployState{
+		Status:     DeployStatusActive,
+		Deployment: *written,
+	}, nil)
+	c.MatchMethod("Rectify", spies.AnyArgs, DiffResolution{Desc: ModifyDiff, Error: WrapResolveError(&FailedStatusError{})}, nil)
+	rollback.Pair.Post.BuildArtifact = &BuildArtifact{Name: "example:1.0.0"}
+	rollback.Begin(dpr, &DummyRegistry{}, &ResolveFilter{}, NewDummyStateManager())
+	rez := rollback.Wait()
+	assert.Equal(t, RollbackDiff, rez.Desc)
+	assert.Nil(t, rez.Error)
+	assert.False(t, shouldRollBack(rollback))
+}
+
+func TestRollbacker_RollBack_NotNeeded(t *testing.T) {
+	optedOut := failedRectification(t, rolloutPair(Rollout{}))
+
+	changed := rolloutPair(Rollout{RollbackOnFailure: true})
+	gdmChanged := failedRectification(t, changed)
+	newer := changed.Post.Deployment.Clone()
+	newer.SourceID = MustNewSourceID("github.com/opentable/example", "", "3.0.0")
+
+	dm, dmc := NewDeploymentManagerSpy()
+	dmc.MatchMethod("ReadDeployment", spies.AnyArgs, newer, nil)
+	qs, qsc := NewQueueSetSpy()
+
+	log, _ := logging.NewLogSinkSpy()
+	rb := NewRollbacker(dm, log)
+	for _, r := range []*Rectification{optedOut, gdmChanged} {
+		qr, err := rb.RollBack(r, qs)
+		assert.NoError(t, err)
+		assert.Nil(t, qr)
+	}
+	assert.Len(t, dmc.CallsTo("WriteDeployment"), 0)
+	assert.Len(t, qsc.CallsTo("Push"), 0)
+}
+
+func TestRollbacker_RollBack_RolloutAborted(t *testing.T) {
+	log, _ := logging.NewLogSinkSpy()
+	pair := rolloutPair(Rollout{CanaryInstances: 1, AbortOnFailure: true, RollbackOnFailure: true})
+	r := NewRectification(pair, log)
+
+	dpr, c := NewCanaryDeployerSpy()
+	c.MatchMethod("CanaryStatus", spies.AnyArgs, &DeployState{
+		Status:     DeployStatusFailed,
+		Deployment: *pair.Post.Deployment.Clone(),
+	}, nil)
+	c.MatchMethod("Status", spies.AnyArgs, &DeployState{
+		Status:       DeployStatusActive,
+		Deployment:   *pair.Prior.Deployment.Clone(),
+		ExecutorData: "prior-data",
+	}, nil)
+	c.MatchMethod("DeployCanary", spies.AnyArgs, nil)
+	c.MatchMethod("RemoveCanary", spies.AnyArgs, nil)
+	r.Begin(dpr, &DummyRegistry{}, &ResolveFilter{}, NewDummyStateManager())
+	r.Wait()
+	require.True(t, shouldRollBack(r))
+
+	dm, dmc := NewDeploymentManagerSpy()
+	dmc.MatchMethod("ReadDeployment", spies.AnyArgs, pair.Post.Deployment.Clone(), nil)
+	dmc.MatchMethod("WriteDeployment", spies.AnyArgs, nil)
+	qs, qsc := NewQueueSetSpy()
+	qsc.MatchMethod("Push", spies.AnyArgs, &QueuedR11n{ID: "rollback"}, true)
+
+	_, err := NewRollbacker(dm, log).RollBack(r, qs)
+	require.NoError(t, err)
+
+	// The prior version never stopped running, so the rollback starts from it.
+	pushes := qsc.CallsTo("Push")
+	require.Len(t, pushes, 1)
+	rollback := pushes[0].PassedArgs().Get(0).(*Rectification)
+	assert.Equal(t, pair.Prior.SourceID, rollback.Pair.Prior.SourceID)
+	assert.Equal(t, "prior-data", rollback.Pair.ExecutorData)
+}
+
+func TestDeploymentManager_WriteDeployment(t *testing.T) {
+	sm := NewDummyStateManager()
+	sm.State = DefaultStateFixture()
+	dm := MakeDeploymentManager(sm)
+
+	deps, err := sm.State.Deployments()
+	require.NoError(t, err)
+	dep := deps.Snapshot()[deps.Keys()[0]].Clone()
+	dep.NumInstances++
+	require.NoError(t, dm.WriteDeployment(dep, SystemUser))
+
+	got, err := dm.ReadDeployment(dep.ID())
+	require.NoError(t, err)
+	assert.Equal(t, dep.NumInstances, got.NumInstances)
+}
+
+func TestRollbacker_RollBack_RollBackTo(t *testing.T) {
+	pair := rolloutPair(Rollout{RollbackOnFailure: true})
+	prior := pair.Prior
+	pair.Prior = nil
+	log, _ := logging.NewLogSinkSpy()
+	r := NewRectification(pair, log)
+	r.RollBackTo(prior)
+
+	dpr, c := NewDeployerSpy()
+	c.MatchMethod("Status", spies.AnyArgs, &DeployState{
+		Status:     DeployStatusFailed,
+		Deployment: *pair.Post.Deployment.Clone(),
+	}, nil)
+	c.MatchMethod("Rectify", spies.AnyArgs, DiffResolution{Desc: CreateDiff}, nil)
+	r.Begin(dpr, &DummyRegistry{}, &ResolveFilter{}, NewDummyStateManager())
+	r.Wait()
+
+	assert.True(t, shouldRollBack(r))
+}
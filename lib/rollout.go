@@ -0,0 +1,157 @@
+package sous
+
+import "fmt"
+
+type (
+	// Rollout configures a progressive rollout of new versions of a
+	// deployment. c.f. DeployConfig for use.
+	//
+	// When a deployment changes version, the new version is first run as a
+	// canary of CanaryInstances instances, then of each of Steps percent of
+	// NumInstances, beside the prior version, which keeps all of its
+	// instances. Each stage must become active before the next begins. The
+	// new version then replaces the prior one with NumInstances, and the
+	// canary is removed. Only clusters whose Deployer is a CanaryDeployer can
+	// run a staged Rollout; elsewhere the new version is refused.
+	Rollout struct {
+		// CanaryInstances is the number of instances deployed in the first
+		// stage. Zero means no canary stage.
+		CanaryInstances int `yaml:",omitempty"`
+		// Steps are percentages of NumInstances to deploy after the canary
+		// stage, in increasing order. 100 is implied as the final step.
+		Steps []int `yaml:",omitempty"`
+		// PauseSeconds is the time to wait after each stage becomes active
+		// before starting the next.
+		PauseSeconds int `yaml:",omitempty"`
+		// AbortOnFailure stops the rollout if a stage fails, removing the
+		// canary and leaving the prior version as it was, rather than
+		// continuing to the next stage.
+		AbortOnFailure bool `yaml:",omitempty"`
+		// RollbackOnFailure reverts a new version which fails to become
+		// healthy (including an aborted rollout) to the version it replaced,
+		// both in the cluster and in the GDM.
+		RollbackOnFailure bool `yaml:",omitempty"`
+	}
+
+	// RolloutProgress describes the stage a progressive rollout has reached.
+	RolloutProgress struct {
+		// Stage is the current stage, counting from 1.
+		Stage int
+		// Stages is the total number of stages.
+		Stages int
+		// Instances is the number of instances deployed in this stage.
+		Instances int
+	}
+
+	// RolloutAbortedError is returned when a stage of a Rollout with
+	// AbortOnFailure fails.
+	RolloutAbortedError struct {
+		Progress RolloutProgress
+		Cause    error
+	}
+
+	// RolloutUnsupportedError is returned when a staged Rollout is asked of a
+	// cluster whose Deployer cannot run canaries.
+	RolloutUnsupportedError struct {
+		Kind string
+	}
+)
+
+func (e *RolloutAbortedError) Error() string {
+	return fmt.Sprintf("rollout aborted at stage %d of %d (%d instances): %v",
+		e.Progress.Stage, e.Progress.Stages, e.Progress.Instances, e.Cause)
+}
+
+func (e *RolloutUnsupportedError) Error() string {
+	return fmt.Sprintf("%s clusters cannot run a canary beside the current version, so staged rollouts are refused", e.Kind)
+}
+
+func (p RolloutProgress) String() string {
+	return fmt.Sprintf("stage %d/%d (%d instances)", p.Stage, p.Stages, p.Instances)
+}
+
+// IsZero reports whether this Rollout is entirely unset.
+func (ro Rollout) IsZero() bool {
+	return !ro.Staged() && ro.PauseSeconds == 0 && !ro.AbortOnFailure && !ro.RollbackOnFailure
+}
+
+// Staged reports whether this Rollout has stages configured. If not, new
+// versions are deployed all at once.
+func (ro Rollout) Staged() bool {
+	return ro.CanaryInstances != 0 || len(ro.Steps) != 0
+}
+
+// Clone returns a deep copy of this Rollout.
+func (ro Rollout) Clone() Rollout {
+	if ro.Steps != nil {
+		steps := make([]int, len(ro.Steps))
+		copy(steps, ro.Steps)
+		ro.Steps = steps
+	}
+	return ro
+}
+
+// Equal compares Rollouts.
+func (ro Rollout) Equal(o Rollout) bool {
+	return ro.CanaryInstances == o.CanaryInstances &&
+		ro.PauseSeconds == o.PauseSeconds &&
+		ro.AbortOnFailure == o.AbortOnFailure &&
+		ro.RollbackOnFailure == o.RollbackOnFailure &&
+		intSlicesEqual(ro.Steps, o.Steps)
+}
+
+// Stages returns the instance count of each stage of rolling out a
+// deployment of the given number of instances. Stages that would not increase
+// the instance count are dropped, so the result always ends with instances.
+func (ro Rollout) Stages(instances int) []int {
+	stages := []int{}
+	last := 0
+	add := func(n int) {
+		if n > last && n < instances {
+			stages = append(stages, n)
+			last = n
+		}
+	}
+	add(ro.CanaryInstances)
+	for _, pct := range ro.Steps {
+		add((instances*pct + 99) / 100)
+	}
+	return append(stages, instances)
+}
+
+// Validate implements Flawed on Rollout.
+func (ro *Rollout) Validate() []Flaw {
+	flaws := []Flaw{}
+
+	if ro.CanaryInstances < 0 {
+		flaws = append(flaws, FatalFlaw("Rollout CanaryInstances less than zero: %d!", ro.CanaryInstances))
+	}
+	if ro.PauseSeconds < 0 {
+		flaws = append(flaws, FatalFlaw("Rollout PauseSeconds less than zero: %d!", ro.PauseSeconds))
+	}
+	prev := 0
+	for _, pct := range ro.Steps {
+		if pct <= 0 || pct > 100 {
+			flaws = append(flaws, FatalFlaw("Rollout Steps must be percentages between 1 and 100, got %d.", pct))
+			continue
+		}
+		if pct <= prev {
+			flaws = append(flaws, FatalFlaw("Rollout Steps must be increasing, got %d after %d.", pct, prev))
+		}
+		prev = pct
+	}
+
+	return flaws
+}
+
+func intSlicesEqual(left, right []int) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for idx := range left {
+		if left[idx] != right[idx] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollout_Stages(t *testing.T) {
+	cases := []struct {
+		rollout   Rollout
+		instances int
+		want      []int
+	}{
+		{Rollout{}, 10, []int{10}},
+		{Rollout{CanaryInstances: 1}, 10, []int{1, 10}},
+		{Rollout{CanaryInstances: 1, Steps: []int{25, 50}}, 10, []int{1, 3, 5, 10}},
+		{Rollout{Steps: []int{50, 100}}, 4, []int{2, 4}},
+		// Stages which would not add instances are dropped.
+		{Rollout{CanaryInstances: 2, Steps: []int{10, 20}}, 10, []int{2, 10}},
+		{Rollout{CanaryInstances: 5}, 3, []int{3}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, c.rollout.Stages(c.instances), "%+v of %d", c.rollout, c.instances)
+	}
+}
+
+func TestRollout_Validate(t *testing.T) {
+	good := Rollout{CanaryInstances: 1, Steps: []int{10, 50, 100}, PauseSeconds: 30}
+	assert.Len(t, good.Validate(), 0)
+
+	cases := []Rollout{
+		{CanaryInstances: -1},
+		{PauseSeconds: -1},
+		{Steps: []int{0}},
+		{Steps: []int{101}},
+		{Steps: []int{50, 25}},
+	}
+	for _, ro := range cases {
+		assert.Len(t, ro.Validate(), 1, "%+v", ro)
+	}
+}
+
+func TestDeployConfig_Validate_RolloutCanary(t *testing.T) {
+	dc := DeployConfig{
+		Resources:    Resources{"cpus": "0.1", "memory": "32", "ports": "1"},
+		NumInstances: 2,
+		Startup:      Startup{SkipCheck: true},
+		Rollout:      Rollout{CanaryInstances: 2},
+	}
+	assert.Len(t, dc.Validate(), 1)
+
+	dc.NumInstances = 3
+	assert.Len(t, dc.Validate(), 0)
+}
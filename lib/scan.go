@@ -0,0 +1,100 @@
+package sous
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// A Scanner inspects the image a build produced for known vulnerabilities.
+	Scanner interface {
+		Scan(*BuildProduct) ([]Vulnerability, error)
+	}
+
+	// A Vulnerability is a single finding of a Scanner.
+	Vulnerability struct {
+		// ID identifies the vulnerability, e.g. a CVE number.
+		ID string
+		// Package is the name of the vulnerable package.
+		Package string
+		// Severity is one of "critical", "high", "medium", "low" or
+		// "unknown".
+		Severity string
+	}
+)
+
+const (
+	// CriticalVulnerability means that scanning the image found at least one
+	// vulnerability of critical severity.
+	CriticalVulnerability = AdvisoryName(`critical vulnerability`)
+	// HighVulnerability means that scanning the image found at least one
+	// vulnerability of high severity.
+	HighVulnerability = AdvisoryName(`high vulnerability`)
+	// MediumVulnerability means that scanning the image found at least one
+	// vulnerability of medium severity.
+	MediumVulnerability = AdvisoryName(`medium vulnerability`)
+	// LowVulnerability means that scanning the image found at least one
+	// vulnerability of low severity.
+	LowVulnerability = AdvisoryName(`low vulnerability`)
+)
+
+// VulnerabilityAdvisory returns the advisory for a vulnerability of
+// severity, or false if there is none (e.g. for "unknown" or "negligible").
+func VulnerabilityAdvisory(severity string) (AdvisoryName, bool) {
+	switch strings.ToLower(severity) {
+	default:
+		return "", false
+	case "critical":
+		return CriticalVulnerability, true
+	case "high":
+		return HighVulnerability, true
+	case "medium":
+		return MediumVulnerability, true
+	case "low":
+		return LowVulnerability, true
+	}
+}
+
+// AddVulnerabilities records vs on bp, adding the advisory for the severity
+// of each, once.
+func (bp *BuildProduct) AddVulnerabilities(vs []Vulnerability) {
+	bp.Vulnerabilities = append(bp.Vulnerabilities, vs...)
+	for _, v := range vs {
+		adv, ok := VulnerabilityAdvisory(v.Severity)
+		if !ok || bp.hasAdvisory(adv) {
+			continue
+		}
+		bp.Advisories = append(bp.Advisories, string(adv))
+	}
+}
+
+func (bp *BuildProduct) hasAdvisory(adv AdvisoryName) bool {
+	for _, a := range bp.Advisories {
+		if a == string(adv) {
+			return true
+		}
+	}
+	return false
+}
+
+// scan runs m.Scanner, if any, over each product of br which may be deployed.
+func (m *BuildManager) scan(br *BuildResult) error {
+	if m.Scanner == nil {
+		return nil
+	}
+	for _, bp := range br.Products {
+		if bp.hasAdvisory(IsBuilder) {
+			continue
+		}
+		vs, err := m.Scanner.Scan(bp)
+		if err != nil {
+			return fmt.Errorf("scanning %s image %s: %v", bp.Source, bp.ID, err)
+		}
+		bp.AddVulnerabilities(vs)
+	}
+	return nil
+}
+
+func (v Vulnerability) String() string {
+	return fmt.Sprintf("%s %s in %s", v.Severity, v.ID, v.Package)
+}
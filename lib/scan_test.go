@@ -0,0 +1,85 @@
+package sous
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type scanTestScanner struct {
+	scanned []string
+	err     error
+}
+
+func (s *scanTestScanner) Scan(bp *BuildProduct) ([]Vulnerability, error) {
+	s.scanned = append(s.scanned, bp.ID)
+	return []Vulnerability{
+		{ID: "CVE-1", Package: "openssl", Severity: "critical"},
+		{ID: "CVE-2", Package: "openssl", Severity: "critical"},
+		{ID: "CVE-3", Package: "zlib", Severity: "unknown"},
+	}, s.err
+}
+
+func TestBuildManager_Build_Scan(t *testing.T) {
+	sh, _ := shell.NewTestShell()
+	bp := &cacheTestBuildpack{}
+	reg := &cacheTestRegistrar{}
+	sc := &scanTestScanner{}
+	m := &BuildManager{
+		BuildConfig: &BuildConfig{
+			Tag:  "1.2.3",
+			Repo: "github.com/opentable/example",
+			Context: &BuildContext{
+				Sh:     sh,
+				Source: SourceContext{Revision: "abc"},
+			},
+			LogSink: logging.SilentLogSet(),
+		},
+		Selector:  bp,
+		Labeller:  reg,
+		Registrar: reg,
+		Scanner:   sc,
+		LogSink:   logging.SilentLogSet(),
+	}
+
+	br, err := m.Build()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"image-id"}, sc.scanned)
+	prod := br.Products[0]
+	assert.Len(t, prod.Vulnerabilities, 3)
+	assert.Contains(t, prod.Advisories, string(CriticalVulnerability))
+	count := 0
+	for _, a := range prod.Advisories {
+		if a == string(CriticalVulnerability) {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count, "each advisory should be recorded once")
+
+	sc.err = errors.New("scanner not installed")
+	_, err = m.Build()
+	assert.Error(t, err)
+	assert.Len(t, reg.registered, 1, "an unscanned build should not be registered")
+}
+
+func TestGuardImage_Vulnerabilities(t *testing.T) {
+	dep := Deployment{
+		ClusterName:  "x",
+		Cluster:      &Cluster{Name: "x", AllowedAdvisories: []string{string(HighVulnerability)}},
+		SourceID:     MustParseSourceID("github.com/ot/one,1.3.5"),
+		DeployConfig: DeployConfig{NumInstances: 1},
+	}
+	guard := func(adv AdvisoryName) error {
+		dr := NewDummyRegistry()
+		dr.FeedArtifact(&BuildArtifact{Name: "one:1.3.5", Type: "docker", Qualities: []Quality{{Name: string(adv), Kind: "advisory"}}}, nil)
+		_, err := guardImage(dr, &dep)
+		return err
+	}
+
+	assert.NoError(t, guard(HighVulnerability))
+	assert.Error(t, guard(CriticalVulnerability))
+}
@@ -0,0 +1,119 @@
+package sous
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SecretRefScheme prefixes environment variable values which are references
+// to secrets, rather than the values themselves.
+const SecretRefScheme = "secret://"
+
+type (
+	// A SecretRef refers to a secret held outside Sous, written in Env as
+	// "secret://path#key". The key is optional: without one, the reference
+	// is to the whole of the secret at path.
+	SecretRef struct {
+		Path, Key string
+	}
+
+	// A SecretResolver looks up the values of secret references. Deployers
+	// resolve the references in a deployment's Env when they deploy it, so
+	// that only the references are kept in the GDM.
+	SecretResolver interface {
+		ResolveSecret(SecretRef) (string, error)
+	}
+)
+
+// IsSecretRef returns true if the environment variable value v is a secret
+// reference.
+func IsSecretRef(v string) bool {
+	return strings.HasPrefix(v, SecretRefScheme)
+}
+
+// ParseSecretRef parses a reference in the form "secret://path#key".
+func ParseSecretRef(v string) (SecretRef, error) {
+	if !IsSecretRef(v) {
+		return SecretRef{}, errors.Errorf("%q is not a secret reference: it must begin with %s", v, SecretRefScheme)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(v, SecretRefScheme), "#", 2)
+	ref := SecretRef{Path: strings.Trim(parts[0], "/")}
+	if len(parts) == 2 {
+		ref.Key = parts[1]
+		if ref.Key == "" {
+			return SecretRef{}, errors.Errorf("secret reference %q has an empty key", v)
+		}
+	}
+	if ref.Path == "" {
+		return SecretRef{}, errors.Errorf("secret reference %q has no path", v)
+	}
+	for _, seg := range strings.Split(ref.Path, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return SecretRef{}, errors.Errorf("secret reference %q has an invalid path", v)
+		}
+	}
+	return ref, nil
+}
+
+func (r SecretRef) String() string {
+	if r.Key == "" {
+		return SecretRefScheme + r.Path
+	}
+	return fmt.Sprintf("%s%s#%s", SecretRefScheme, r.Path, r.Key)
+}
+
+// ResolveSecrets returns a copy of e in which each secret reference is
+// replaced by the value r resolves it to, and the references replaced, by
+// variable name. It is an error for e to hold references if r is nil.
+func (e Env) ResolveSecrets(r SecretResolver) (Env, map[string]string, error) {
+	resolved := make(Env, len(e))
+	refs := map[string]string{}
+	for name, v := range e {
+		resolved[name] = v
+		if !IsSecretRef(v) {
+			continue
+		}
+		if r == nil {
+			return nil, nil, errors.Errorf("env %s refers to a secret, but no secret resolver is configured", name)
+		}
+		ref, err := ParseSecretRef(v)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "env %s", name)
+		}
+		value, err := r.ResolveSecret(ref)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "resolving %s for env %s", ref, name)
+		}
+		resolved[name] = value
+		refs[name] = v
+	}
+	return resolved, refs, nil
+}
+
+// RestoreSecretRefs replaces the values of the variables named in refs, as
+// returned by ResolveSecrets, with the references they were resolved from.
+// Deployers apply it to the Env of deployments they read back, so that they
+// compare equal to the deployments which were intended.
+func (e Env) RestoreSecretRefs(refs map[string]string) {
+	for name, ref := range refs {
+		if _, ok := e[name]; ok {
+			e[name] = ref
+		}
+	}
+}
+
+// validateSecretRefs returns a flaw for each malformed secret reference in e.
+func (e Env) validateSecretRefs() []Flaw {
+	var flaws []Flaw
+	for name, v := range e {
+		if !IsSecretRef(v) {
+			continue
+		}
+		if _, err := ParseSecretRef(v); err != nil {
+			flaws = append(flaws, FatalFlaw("Env %s: %v", name, err))
+		}
+	}
+	return flaws
+}
@@ -0,0 +1,70 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapSecretResolver map[string]string
+
+func (m mapSecretResolver) ResolveSecret(ref SecretRef) (string, error) {
+	v, ok := m[ref.String()]
+	if !ok {
+		return "", errors.Errorf("no secret %s", ref)
+	}
+	return v, nil
+}
+
+func TestParseSecretRef(t *testing.T) {
+	ref, err := ParseSecretRef("secret://db/app#password")
+	require.NoError(t, err)
+	assert.Equal(t, SecretRef{Path: "db/app", Key: "password"}, ref)
+	assert.Equal(t, "secret://db/app#password", ref.String())
+
+	ref, err = ParseSecretRef("secret://token")
+	require.NoError(t, err)
+	assert.Equal(t, SecretRef{Path: "token"}, ref)
+
+	for _, bad := range []string{"db/app#password", "secret://", "secret://#key", "secret://db/app#", "secret://db/../etc/passwd"} {
+		_, err := ParseSecretRef(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestEnv_ResolveSecrets(t *testing.T) {
+	env := Env{"PLAIN": "value", "DB_PASSWORD": "secret://db/app#password"}
+	r := mapSecretResolver{"secret://db/app#password": "hunter2"}
+
+	resolved, refs, err := env.ResolveSecrets(r)
+	require.NoError(t, err)
+	assert.Equal(t, Env{"PLAIN": "value", "DB_PASSWORD": "hunter2"}, resolved)
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "secret://db/app#password"}, refs)
+	assert.Equal(t, "secret://db/app#password", env["DB_PASSWORD"], "the original Env is unchanged")
+
+	resolved.RestoreSecretRefs(refs)
+	assert.True(t, env.Equal(resolved))
+
+	_, _, err = env.ResolveSecrets(nil)
+	assert.Error(t, err)
+	_, _, err = Env{"X": "secret://db/other#password"}.ResolveSecrets(r)
+	assert.Error(t, err)
+
+	resolved, refs, err = Env{"PLAIN": "value"}.ResolveSecrets(nil)
+	require.NoError(t, err)
+	assert.Equal(t, Env{"PLAIN": "value"}, resolved)
+	assert.Empty(t, refs)
+}
+
+func TestDeployConfig_ValidateSecretRefs(t *testing.T) {
+	dc := DeployConfig{
+		Resources: Resources{"cpus": "1", "memory": "100", "ports": "1"},
+		Startup:   Startup{SkipCheck: true},
+		Env:       Env{"X": "secret://#key"},
+	}
+	assert.Len(t, dc.Validate(), 1)
+	dc.Env["X"] = "secret://db/app#key"
+	assert.Empty(t, dc.Validate())
+}
@@ -0,0 +1,138 @@
+package sous
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SignatureQuality is the Kind of the Quality which carries an artifact's
+	// signature.
+	SignatureQuality = "signature"
+	// SignatureMediaType is the media type of the signature attached to an
+	// image in its registry: the Name of its signature Quality.
+	SignatureMediaType = "application/vnd.opentable.sous.signature.v1"
+)
+
+type (
+	// An ArtifactSigner signs build artifacts, proving that they were built
+	// by a Sous pipeline which holds its key.
+	ArtifactSigner struct {
+		key crypto.Signer
+	}
+
+	// An ArtifactSignatureError reports that an artifact is not signed by any
+	// key its cluster trusts.
+	ArtifactSignatureError struct {
+		SourceID SourceID
+		Name     string
+		// Unsigned is true if the artifact has no signature at all.
+		Unsigned bool
+	}
+)
+
+// NewArtifactSigner returns an ArtifactSigner which signs with the ECDSA
+// private key in keyPEM, in either SEC 1 ("EC PRIVATE KEY") or PKCS #8
+// ("PRIVATE KEY") form.
+func NewArtifactSigner(keyPEM []byte) (*ArtifactSigner, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM block in signing key")
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return &ArtifactSigner{key: key}, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing signing key")
+	}
+	ec, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("signing key is a %T, not an ECDSA key", key)
+	}
+	return &ArtifactSigner{key: ec}, nil
+}
+
+// Sign returns a Quality carrying a signature over the image digest and sid.
+func (s *ArtifactSigner) Sign(digest string, sid SourceID) (Quality, error) {
+	sum := signaturePayload(digest, sid)
+	sig, err := s.key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	if err != nil {
+		return Quality{}, errors.Wrapf(err, "signing %s", sid)
+	}
+	return Quality{Name: digest + " " + base64.StdEncoding.EncodeToString(sig), Kind: SignatureQuality}, nil
+}
+
+// signaturePayload returns the hash which is signed for the image with digest
+// built from sid.
+func signaturePayload(digest string, sid SourceID) [sha256.Size]byte {
+	return sha256.Sum256([]byte(fmt.Sprintf("%s\n%s\n%s\n", digest, sid, sid.RevID())))
+}
+
+// VerifySignature checks that art carries a signature, for the SourceID sid,
+// by one of the PEM-encoded ECDSA public keys in trusted. It returns the
+// digest of the signed image, or an *ArtifactSignatureError.
+func (art *BuildArtifact) VerifySignature(sid SourceID, trusted []string) (string, error) {
+	keys, err := parseTrustedKeys(trusted)
+	if err != nil {
+		return "", err
+	}
+	unsigned := true
+	for _, q := range art.Qualities {
+		if q.Kind != SignatureQuality {
+			continue
+		}
+		unsigned = false
+		parts := strings.SplitN(q.Name, " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			continue
+		}
+		sum := signaturePayload(parts[0], sid)
+		for _, k := range keys {
+			if ecdsa.VerifyASN1(k, sum[:], sig) {
+				return parts[0], nil
+			}
+		}
+	}
+	return "", &ArtifactSignatureError{SourceID: sid, Name: art.Name, Unsigned: unsigned}
+}
+
+func parseTrustedKeys(trusted []string) ([]*ecdsa.PublicKey, error) {
+	keys := make([]*ecdsa.PublicKey, 0, len(trusted))
+	for _, t := range trusted {
+		block, _ := pem.Decode([]byte(t))
+		if block == nil {
+			return nil, errors.New("no PEM block in trusted signing key")
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing trusted signing key")
+		}
+		ec, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, errors.Errorf("trusted signing key is a %T, not an ECDSA key", key)
+		}
+		keys = append(keys, ec)
+	}
+	return keys, nil
+}
+
+func (e *ArtifactSignatureError) Error() string {
+	if e.Unsigned {
+		return fmt.Sprintf("Image %s for %v is unsigned, and its cluster requires signed images", e.Name, e.SourceID)
+	}
+	return fmt.Sprintf("Image %s for %v is not signed by a key its cluster trusts", e.Name, e.SourceID)
+}
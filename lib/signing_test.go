@@ -0,0 +1,98 @@
+package sous
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSigningKey returns a new PEM-encoded private key and its public key.
+func testSigningKey(t *testing.T) ([]byte, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	priv, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: priv}),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+}
+
+func TestBuildArtifact_VerifySignature(t *testing.T) {
+	priv, pub := testSigningKey(t)
+	_, otherPub := testSigningKey(t)
+	signer, err := NewArtifactSigner(priv)
+	require.NoError(t, err)
+
+	sid := MustParseSourceID("github.com/ot/one,1.3.5")
+	const digest = "sha256:cabba9e"
+	q, err := signer.Sign(digest, sid)
+	require.NoError(t, err)
+	assert.Equal(t, SignatureQuality, q.Kind)
+	art := &BuildArtifact{Name: "docker.example.com/one:1.3.5", Type: "docker", Qualities: []Quality{q}}
+
+	signed, err := art.VerifySignature(sid, []string{otherPub, pub})
+	require.NoError(t, err)
+	assert.Equal(t, digest, signed)
+
+	_, err = art.VerifySignature(sid, []string{otherPub})
+	require.IsType(t, &ArtifactSignatureError{}, err)
+	assert.False(t, err.(*ArtifactSignatureError).Unsigned)
+
+	_, err = art.VerifySignature(MustParseSourceID("github.com/ot/one,1.3.6"), []string{pub})
+	assert.Error(t, err, "the signature should not cover another SourceID")
+
+	_, err = (&BuildArtifact{Name: "one:1.3.5"}).VerifySignature(sid, []string{pub})
+	require.IsType(t, &ArtifactSignatureError{}, err)
+	assert.True(t, err.(*ArtifactSignatureError).Unsigned)
+	assert.False(t, IsTransientResolveError(err))
+
+	_, err = NewArtifactSigner([]byte(pub))
+	assert.Error(t, err)
+}
+
+func TestGuardImageSignature(t *testing.T) {
+	priv, pub := testSigningKey(t)
+	signer, err := NewArtifactSigner(priv)
+	require.NoError(t, err)
+	sid := MustParseSourceID("github.com/ot/one,1.3.5")
+	q, err := signer.Sign("sha256:cabba9e", sid)
+	require.NoError(t, err)
+
+	dep := Deployment{
+		ClusterName:  "x",
+		Cluster:      &Cluster{Name: "x", SigningKeys: []string{pub}},
+		SourceID:     sid,
+		DeployConfig: DeployConfig{NumInstances: 1},
+	}
+	guard := func(name string, qs ...Quality) (*BuildArtifact, error) {
+		dr := NewDummyRegistry()
+		dr.FeedArtifact(&BuildArtifact{Name: name, Type: "docker", Qualities: qs}, nil)
+		return guardImage(dr, &dep)
+	}
+
+	art, err := guard("docker.example.com:5000/one:1.3.5", q)
+	require.NoError(t, err)
+	assert.Equal(t, "docker.example.com:5000/one@sha256:cabba9e", art.Name)
+
+	art, err = guard("docker.example.com/one@sha256:cabba9e", q)
+	require.NoError(t, err)
+	assert.Equal(t, "docker.example.com/one@sha256:cabba9e", art.Name)
+
+	_, err = guard("docker.example.com/one@sha256:deadbeef", q)
+	assert.IsType(t, &ArtifactSignatureError{}, err)
+
+	_, err = guard("docker.example.com/one:1.3.5")
+	assert.IsType(t, &ArtifactSignatureError{}, err)
+
+	dep.Cluster.SigningKeys = nil
+	art, err = guard("docker.example.com/one:1.3.5")
+	require.NoError(t, err)
+	assert.Equal(t, "docker.example.com/one:1.3.5", art.Name)
+}
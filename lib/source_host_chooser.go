@@ -23,3 +23,14 @@ func (e *SourceHostChooser) ParseSourceLocation(s string) (SourceLocation, error
 	}
 	return SourceLocation{}, fmt.Errorf("source location not recognised: %q", s)
 }
+
+// GetSource gets the source for id from the first SourceHost that owns
+// id.Location.
+func (e *SourceHostChooser) GetSource(id SourceID) (Source, error) {
+	for _, h := range e.SourceHosts {
+		if h.Owns(id.Location) {
+			return h.GetSource(id)
+		}
+	}
+	return Source{}, fmt.Errorf("no source host owns %q", id.Location)
+}
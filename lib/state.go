@@ -3,6 +3,7 @@ package sous
 import (
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -34,14 +35,27 @@ type (
 		Resources FieldDefinitions
 		// Metadata contains the definitions for metadata fields
 		Metadata FieldDefinitions
+		// Freezes block changes to deployments in some clusters for a time.
+		Freezes Freezes `yaml:",omitempty"`
+		// OwnerQuotas limit the resources the deployments of each owner may
+		// claim in some clusters.
+		OwnerQuotas OwnerQuotas `yaml:",omitempty"`
+		// Promotions govern which versions may be promoted to some
+		// clusters from others.
+		Promotions Promotions `yaml:",omitempty"`
 	}
 
 	// EnvDefs is a collection of EnvDef
 	EnvDefs []EnvDef
 	// EnvDef is an environment variable definition.
 	EnvDef struct {
-		Name, Desc, Scope string
-		Type              VarType
+		Name, Desc string
+		// Scope says where the variable must be set: see EnvScopeCluster
+		// and EnvScopeRequired.
+		Scope string
+		// Type is the type values of the variable must have, e.g. int or
+		// enum(a|b). See VarType.Check.
+		Type VarType
 	}
 
 	// FieldDefinitions is just a type alias for a slice of FieldDefinition-s
@@ -70,8 +84,9 @@ type (
 	Cluster struct {
 		// Name is the unique name of this cluster.
 		Name string
-		// Kind is the kind of cluster. Currently the only legal value is
-		// "singularity"
+		// Kind is the kind of cluster, which selects the Deployer used for it.
+		// Legal values are "singularity" (the default when empty),
+		// "kubernetes" and "nomad".
 		Kind string
 		// BaseURL is the main entrypoint URL for interacting with this cluster.
 		BaseURL string
@@ -82,6 +97,20 @@ type (
 		// AllowedAdvisories lists the artifact advisories which are permissible in
 		// this cluster
 		AllowedAdvisories []string
+		// RequireApproval, if true, means that changes to the version deployed
+		// to this cluster must be approved by a second user before they are
+		// written to the GDM.
+		RequireApproval bool `yaml:",omitempty"`
+		// SigningKeys, if not empty, lists the PEM-encoded public keys which
+		// this cluster trusts: artifacts are only deployed to it if they are
+		// signed by one of them.
+		SigningKeys []string `yaml:",omitempty"`
+		// Platforms, if not empty, lists the platforms (e.g. "linux/arm64")
+		// which images deployed to this cluster must have been built for.
+		Platforms []string `yaml:",omitempty"`
+		// Quota limits the resources all deployments to this cluster may
+		// claim.
+		Quota Quota `yaml:",omitempty"`
 	}
 
 	// EnvDefaults is a list of named environment variables along with their values.
@@ -90,7 +119,7 @@ type (
 	// files. It will implement sane YAML marshalling and unmarshalling. (Not
 	// yet implemented.)
 	Var string
-	// VarType represents the type of a Var (not yet implemented).
+	// VarType represents the type of a Var.
 	VarType string
 )
 
@@ -137,6 +166,9 @@ func (d Defs) Clone() Defs {
 	d.EnvVars = d.EnvVars.Clone()
 	d.Resources = d.Resources.Clone()
 	d.Metadata = d.Metadata.Clone()
+	d.Freezes = d.Freezes.Clone()
+	d.OwnerQuotas = d.OwnerQuotas.Clone()
+	d.Promotions = d.Promotions.Clone()
 	return d
 }
 
@@ -166,6 +198,12 @@ func (c Cluster) Clone() *Cluster {
 	allowedAdvisories := make([]string, len(c.AllowedAdvisories))
 	copy(allowedAdvisories, c.AllowedAdvisories)
 	c.AllowedAdvisories = allowedAdvisories
+	if c.SigningKeys != nil {
+		c.SigningKeys = append([]string{}, c.SigningKeys...)
+	}
+	if c.Platforms != nil {
+		c.Platforms = append([]string{}, c.Platforms...)
+	}
 	return &c
 }
 
@@ -207,8 +245,11 @@ func (s *State) BaseURLs() []string {
 func (s *State) Validate() []Flaw {
 	var flaws []Flaw
 
+	flaws = append(flaws, s.Defs.ValidateEnv()...)
+
 	for _, m := range s.Manifests.Snapshot() {
 		flaws = append(flaws, m.Validate()...)
+		flaws = append(flaws, s.Defs.ValidateManifestEnv(m)...)
 	}
 
 	ds, err := s.Deployments()
@@ -225,6 +266,25 @@ func (s *State) Validate() []Flaw {
 	return flaws
 }
 
+// CheckChanges returns an error if the Defs of s forbid u changing its
+// deployments from prior to post at time at: if a Freeze blocks the change,
+// it claims more than a quota allows, it changes a version in a way the
+// promotion rules forbid, as far as facts shows, or it changes a version
+// which requires approval, unless of a deployment approved. Every write of
+// the GDM on behalf of a user should be checked with it.
+func (s *State) CheckChanges(prior, post Deployments, u User, at time.Time, facts PromotionFactFinder, approved ...DeploymentID) error {
+	if err := s.Defs.Freezes.CheckChanges(prior, post, u, at); err != nil {
+		return err
+	}
+	if err := s.Defs.CheckCapacity(prior, post); err != nil {
+		return err
+	}
+	if err := s.Defs.Promotions.CheckChanges(prior, post, facts, at); err != nil {
+		return err
+	}
+	return s.CheckUnapproved(prior, post, approved...)
+}
+
 // UpdateDeployments upserts ds into the State
 func (s *State) UpdateDeployments(ds ...*Deployment) error {
 	stateDeps, err := s.Deployments()
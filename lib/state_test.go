@@ -2,6 +2,7 @@ package sous
 
 import (
 	"testing"
+	"time"
 
 	"github.com/samsalisbury/semv"
 	"github.com/stretchr/testify/assert"
@@ -74,3 +75,40 @@ func TestState_Validate(t *testing.T) {
 	}
 
 }
+
+func TestState_CheckChanges(t *testing.T) {
+	state := DefaultStateFixture()
+	prior, err := state.Deployments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := prior.Clone()
+	var did DeploymentID
+	for _, d := range post.Snapshot() {
+		if d.ClusterName == "cluster1" {
+			changed := d.Clone()
+			changed.SourceID.Version = semv.MustParse("2.0.0")
+			changed.NumInstances = 1000
+			post.Set(d.ID(), changed)
+			did = d.ID()
+			break
+		}
+	}
+	now := time.Now()
+	user := User{Name: "Someone", Email: "someone@example.com"}
+
+	assert.NoError(t, state.CheckChanges(prior, post, user, now, nil))
+
+	state.Defs.Clusters["cluster1"].RequireApproval = true
+	assert.IsType(t, &UnapprovedError{}, state.CheckChanges(prior, post, user, now, nil))
+	assert.NoError(t, state.CheckChanges(prior, post, user, now, nil, did))
+
+	state.Defs.Promotions = Promotions{{To: []string{"cluster1"}, From: "cluster2"}}
+	assert.IsType(t, &PromotionError{}, state.CheckChanges(prior, post, user, now, nil, did))
+
+	state.Defs.Clusters["cluster1"].Quota = Quota{Cpus: 10}
+	assert.IsType(t, &QuotaError{}, state.CheckChanges(prior, post, user, now, nil, did))
+
+	state.Defs.Freezes = Freezes{{Start: now.Add(-time.Hour), End: now.Add(time.Hour)}}
+	assert.IsType(t, &FreezeError{}, state.CheckChanges(prior, post, user, now, nil, did))
+}
@@ -0,0 +1,226 @@
+package sous
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opentable/sous/util/logging"
+	"github.com/pkg/errors"
+	"github.com/samsalisbury/semv"
+)
+
+// TagWatchUser is the user the TagWatcher deploys as when no user pushed the
+// tag, e.g. when it was found by polling.
+var TagWatchUser = User{Name: "sous tag watcher"}
+
+type (
+	// A TagLister lists the tags of a repository.
+	TagLister interface {
+		ListTags(repo string) ([]Tag, error)
+	}
+
+	// A VersionDeployer changes the version of a single deployment in the
+	// GDM and queues its rectification.
+	VersionDeployer interface {
+		DeployVersion(did DeploymentID, v semv.Version, user User) error
+	}
+
+	// A TagWatcher deploys each new version tagged in the repository of one
+	// of its Manifests to the manifest's deployments in its Clusters, once
+	// an artifact has been built for it. It learns of tags by polling Tags,
+	// or from webhooks passed to Tagged.
+	TagWatcher struct {
+		Manifests []ManifestID
+		Clusters  []string
+		// Tags lists the tags polled for. If nil, only tags passed to
+		// Tagged are deployed.
+		Tags        TagLister
+		Interval    time.Duration
+		Registry    Registry
+		StateReader StateReader
+		Deployer    VersionDeployer
+		// CanonicalRepo, if not nil, converts the repository URLs passed to
+		// Tagged into the form of the Repo of a SourceLocation.
+		CanonicalRepo func(repoURL string) (string, error)
+		ls            logging.LogSink
+		mu            sync.Mutex
+		deployed      map[DeploymentID]semv.Version
+	}
+)
+
+// NewTagWatcher returns a TagWatcher which deploys new tags of ms to their
+// deployments in clusters, as found in the state sr reads, if r has an
+// artifact for them.
+func NewTagWatcher(ms []ManifestID, clusters []string, r Registry, sr StateReader, d VersionDeployer, ls logging.LogSink) *TagWatcher {
+	return &TagWatcher{
+		Manifests:   ms,
+		Clusters:    clusters,
+		Interval:    60 * time.Second,
+		Registry:    r,
+		StateReader: sr,
+		Deployer:    d,
+		ls:          ls,
+		deployed:    map[DeploymentID]semv.Version{},
+	}
+}
+
+// Repos returns the repositories of w's Manifests.
+func (w *TagWatcher) Repos() []string {
+	var repos []string
+	seen := map[string]bool{}
+	for _, mid := range w.Manifests {
+		if !seen[mid.Source.Repo] {
+			seen[mid.Source.Repo] = true
+			repos = append(repos, mid.Source.Repo)
+		}
+	}
+	return repos
+}
+
+// Kickoff starts polling Tags every Interval, until the returned channel is
+// closed. It does nothing if w has no Tags.
+func (w *TagWatcher) Kickoff() TriggerChannel {
+	done := make(TriggerChannel)
+	if w.Tags == nil {
+		return done
+	}
+	go func() {
+		for {
+			if err := w.PollOnce(); err != nil {
+				logging.ReportError(w.ls, err)
+			}
+			select {
+			case <-done:
+				return
+			case <-time.After(w.Interval):
+			}
+		}
+	}()
+	return done
+}
+
+// PollOnce lists the tags of each of w's Repos, and deploys the newest
+// version among them as Tagged does.
+func (w *TagWatcher) PollOnce() error {
+	var errs []string
+	for _, repo := range w.Repos() {
+		tags, err := w.Tags.ListTags(repo)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("listing tags of %s: %s", repo, err))
+			continue
+		}
+		names := make([]string, len(tags))
+		for i, t := range tags {
+			names[i] = t.Name
+		}
+		if err := w.deployNewest(repo, names, TagWatchUser); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) != 0 {
+		return errors.Errorf("polling for tags: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Tagged deploys the newest version named by tags, which were found in the
+// repository at repoURL, as user, to each deployment w watches in that
+// repository which is at an older version. Tags which are not semantic
+// versions, with or without a prefix such as "v", are ignored. A version
+// with no artifact yet is left until the tags are passed again, and one
+// which has been deployed already is not deployed again.
+func (w *TagWatcher) Tagged(repoURL string, tags []string, user User) error {
+	repo := repoURL
+	if w.CanonicalRepo != nil {
+		var err error
+		if repo, err = w.CanonicalRepo(repoURL); err != nil {
+			return errors.Wrapf(err, "tagged repository %q", repoURL)
+		}
+	}
+	return w.deployNewest(repo, tags, user)
+}
+
+// deployNewest deploys the newest version named by tags to the deployments
+// w watches in repo.
+func (w *TagWatcher) deployNewest(repo string, tags []string, user User) error {
+	var newest *semv.Version
+	for _, t := range tags {
+		v, err := parseSemverTagWithOptionalPrefix(t)
+		if err != nil {
+			continue
+		}
+		if newest == nil || newest.Less(v) {
+			newest = &v
+		}
+	}
+	if newest == nil {
+		return nil
+	}
+
+	dids := w.deploymentIDs(repo)
+	if len(dids) == 0 {
+		return nil
+	}
+	state, err := w.StateReader.ReadState()
+	if err != nil {
+		return errors.Wrapf(err, "reading state to deploy %s %s", repo, newest)
+	}
+	var errs []string
+	for _, did := range dids {
+		if err := w.deploy(state, did, *newest, user); err != nil {
+			errs = append(errs, fmt.Sprintf("deploying %s to %s: %s", newest, did, err))
+		}
+	}
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// deploymentIDs returns the IDs of the deployments w watches in repo.
+func (w *TagWatcher) deploymentIDs(repo string) []DeploymentID {
+	var dids []DeploymentID
+	for _, mid := range w.Manifests {
+		if mid.Source.Repo != repo {
+			continue
+		}
+		for _, c := range w.Clusters {
+			dids = append(dids, DeploymentID{ManifestID: mid, Cluster: c})
+		}
+	}
+	return dids
+}
+
+func (w *TagWatcher) deploy(state *State, did DeploymentID, v semv.Version, user User) error {
+	m, ok := state.Manifests.Get(did.ManifestID)
+	if !ok {
+		return nil
+	}
+	spec, ok := m.Deployments[did.Cluster]
+	if !ok || !spec.Version.Less(v) {
+		return nil
+	}
+	w.mu.Lock()
+	deployed, ok := w.deployed[did]
+	w.mu.Unlock()
+	if ok && !deployed.Less(v) {
+		return nil
+	}
+
+	sid := SourceID{Location: did.ManifestID.Source, Version: v}
+	if _, err := w.Registry.GetArtifact(sid); err != nil {
+		logging.ReportMsg(w.ls, logging.InformationLevel, fmt.Sprintf("Not deploying %s to %s yet: no artifact: %s", v, did, err))
+		return nil
+	}
+	if err := w.Deployer.DeployVersion(did, v, user); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.deployed[did] = v
+	w.mu.Unlock()
+	logging.ReportMsg(w.ls, logging.InformationLevel, fmt.Sprintf("Deployed tagged version %s to %s for %s", v, did, user))
+	return nil
+}
@@ -0,0 +1,90 @@
+package sous
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nyarly/spies"
+	"github.com/opentable/sous/util/logging"
+	"github.com/pkg/errors"
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	tagWatchTestTags map[string][]Tag
+
+	tagWatchTestDeploy struct {
+		DeploymentID
+		Version string
+		User    User
+	}
+
+	tagWatchTestDeployer struct {
+		deploys []tagWatchTestDeploy
+	}
+)
+
+func (t tagWatchTestTags) ListTags(repo string) ([]Tag, error) {
+	return t[repo], nil
+}
+
+func (d *tagWatchTestDeployer) DeployVersion(did DeploymentID, v semv.Version, user User) error {
+	d.deploys = append(d.deploys, tagWatchTestDeploy{did, v.String(), user})
+	return nil
+}
+
+func TestTagWatcher(t *testing.T) {
+	state := StateFixture(StateFixtureOpts{ClusterCount: 2, ManifestCount: 2})
+	ms := state.Manifests.Snapshot()
+	var mid ManifestID
+	for id := range ms {
+		mid = id
+		break
+	}
+	current := ms[mid].Deployments["cluster0"].Version
+
+	sm := NewDummyStateManager()
+	sm.State = state
+	reg, regc := NewRegistrySpy()
+	regc.MatchMethod("GetArtifact", func(args mock.Arguments) bool {
+		return args.Get(0).(SourceID).Version.String() == "99.0.0"
+	}, (*BuildArtifact)(nil), errors.New("no artifact"))
+	regc.MatchMethod("GetArtifact", spies.AnyArgs, &BuildArtifact{}, nil)
+
+	newer := current
+	newer.Major++
+	tags := tagWatchTestTags{mid.Source.Repo: {{Name: "latest"}, {Name: "v" + current.String()}, {Name: "v" + newer.String()}}}
+	d := &tagWatchTestDeployer{}
+	w := NewTagWatcher([]ManifestID{mid}, []string{"cluster0"}, reg, sm, d, logging.SilentLogSet())
+	w.Tags = tags
+	assert.Equal(t, []string{mid.Source.Repo}, w.Repos())
+
+	require.NoError(t, w.PollOnce())
+	require.Len(t, d.deploys, 1)
+	assert.Equal(t, DeploymentID{ManifestID: mid, Cluster: "cluster0"}, d.deploys[0].DeploymentID)
+	assert.Equal(t, newer.String(), d.deploys[0].Version)
+	assert.Equal(t, TagWatchUser, d.deploys[0].User)
+
+	// The version deployed already is not deployed again.
+	require.NoError(t, w.PollOnce())
+	assert.Len(t, d.deploys, 1)
+
+	// Webhooks name repositories by URL.
+	w.CanonicalRepo = func(u string) (string, error) {
+		return strings.TrimSuffix(strings.TrimPrefix(u, "https://"), ".git"), nil
+	}
+	someone := User{Name: "Someone"}
+	require.NoError(t, w.Tagged("https://"+mid.Source.Repo+".git", []string{"v99.0.0"}, someone))
+	assert.Len(t, d.deploys, 1, "no artifact for 99.0.0")
+
+	require.NoError(t, w.Tagged("https://"+mid.Source.Repo+".git", []string{"100.0.0"}, someone))
+	require.Len(t, d.deploys, 2)
+	assert.Equal(t, "100.0.0", d.deploys[1].Version)
+	assert.Equal(t, someone, d.deploys[1].User)
+
+	require.NoError(t, w.Tagged("https://github.com/user/unwatched.git", []string{"200.0.0"}, someone))
+	assert.Len(t, d.deploys, 2)
+}
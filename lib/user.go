@@ -8,6 +8,13 @@ type User struct {
 	Name string `env:"SOUS_USER_NAME"`
 	// Email is the email address of this user.
 	Email string `env:"SOUS_USER_EMAIL"`
+	// TraceID identifies the request in which this user is acting, if any.
+	// It is not configured, but set by the server from each request so that
+	// changes can be traced back to it.
+	TraceID TraceID `yaml:"-" json:"-"`
+	// Emergency is set when the user overrides any Freeze blocking their
+	// change. It is recorded with the change.
+	Emergency bool `yaml:"-" json:",omitempty"`
 }
 
 // String returns the name and email in standard email address format, i.e.:
@@ -34,8 +41,12 @@ func (u User) Complete() bool {
 
 // HTTPHeaders returns a map suitable to use as HTTP headers to be consumed by the server.
 func (u User) HTTPHeaders() map[string]string {
-	return map[string]string{
+	headers := map[string]string{
 		"Sous-User-Name":  u.Name,
 		"Sous-User-Email": u.Email,
 	}
+	if u.Emergency {
+		headers["Sous-Emergency"] = "true"
+	}
+	return headers
 }
@@ -53,6 +53,33 @@ type (
 		Meta       ResponseMeta
 		Deployment *sous.DeploySpec
 	}
+
+	// HistoryData is the DTO for the recorded changes to the GDM.
+	HistoryData struct {
+		Changes []sous.ManifestChange
+	}
+
+	// CapacityData is the DTO for the resources claimed in each cluster,
+	// and their quotas.
+	CapacityData struct {
+		Entries sous.CapacityReport
+	}
+
+	// ApprovalsData is the DTO for the changes awaiting approval.
+	ApprovalsData struct {
+		Changes []sous.PendingChange
+	}
+
+	// ApprovalData is the DTO for a single change awaiting approval.
+	ApprovalData struct {
+		Change sous.PendingChange
+	}
+
+	// BuildData is the DTO for a build made by the server.
+	BuildData struct {
+		Meta  ResponseMeta
+		Build sous.RemoteBuild
+	}
 )
 
 // EmptyReceiver implements Comparable on ServerListData
@@ -92,6 +119,17 @@ func (b SingleDeploymentBody) AddHeaders(headers http.Header) {
 	if ok {
 		headers.Add("Location", queuedURL)
 	}
+	approvalURL, ok := b.Meta.Links["pendingApproval"]
+	if ok {
+		headers.Add("Location", approvalURL)
+	}
+}
+
+// AddHeaders implements HeaderAdder on BuildData.
+func (b BuildData) AddHeaders(headers http.Header) {
+	if buildURL, ok := b.Meta.Links["build"]; ok {
+		headers.Add("Location", buildURL)
+	}
 }
 
 // EmptyReceiver implements Comparable on SingleDeploymentBody
@@ -123,3 +161,20 @@ func (b *SingleDeploymentBody) etag() string {
 	hash.Write(ds)
 	return "w/" + base64.URLEncoding.EncodeToString(hash.Sum(nil))
 }
+
+// EmptyReceiver implements Comparable on ApprovalData
+func (ad *ApprovalData) EmptyReceiver() restful.Comparable {
+	return &ApprovalData{}
+}
+
+// VariancesFrom implements Comparable on ApprovalData
+func (ad *ApprovalData) VariancesFrom(other restful.Comparable) restful.Variances {
+	od, ok := other.(*ApprovalData)
+	if !ok {
+		return restful.Variances{"not an ApprovalData"}
+	}
+	if ad.Change.ID != od.Change.ID {
+		return restful.Variances{"pending change IDs differ"}
+	}
+	return restful.Variances{}
+}
@@ -37,4 +37,11 @@ func TestResourcesFulfillInterfaces(t *testing.T) {
 
 	assert.Implements(t, (*restful.Getable)(nil), newSingleDeploymentResource(ComponentLocator{}))
 	assert.Implements(t, (*restful.Putable)(nil), newSingleDeploymentResource(ComponentLocator{}))
+
+	assert.Implements(t, (*restful.Getable)(nil), newBuildResource(ComponentLocator{}))
+	assert.Implements(t, (*restful.Putable)(nil), newBuildResource(ComponentLocator{}))
+
+	assert.Implements(t, (*restful.Postable)(nil), newTagWebhookResource(ComponentLocator{}))
+
+	assert.Implements(t, (*restful.Putable)(nil), newPromotionResource(ComponentLocator{}))
 }
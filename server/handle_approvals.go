@@ -0,0 +1,207 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/logging/messages"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// ApprovalsResource provides the /approvals endpoint, which lists the
+	// changes awaiting approval.
+	ApprovalsResource struct {
+		restful.QueryParser
+		context ComponentLocator
+	}
+
+	// GETApprovalsHandler handles GET requests to /approvals.
+	GETApprovalsHandler struct {
+		restful.QueryValues
+		PendingChanges *sous.PendingChanges
+	}
+
+	// ApprovalResource provides the /approval endpoint, which approves (PUT)
+	// or rejects (DELETE) a single change awaiting approval.
+	ApprovalResource struct {
+		restful.QueryParser
+		userExtractor
+		context ComponentLocator
+	}
+
+	// GETApprovalHandler handles GET requests to /approval.
+	GETApprovalHandler struct {
+		restful.QueryValues
+		PendingChanges *sous.PendingChanges
+	}
+
+	// PUTApprovalHandler handles PUT requests to /approval, which approve a
+	// change: it is written to the GDM and its rectification queued.
+	PUTApprovalHandler struct {
+		restful.QueryValues
+		User           sous.User
+		PendingChanges *sous.PendingChanges
+		LogSink        logging.LogSink
+		deployer       *PUTSingleDeploymentHandler
+	}
+
+	// DELETEApprovalHandler handles DELETE requests to /approval, which reject
+	// a change.
+	DELETEApprovalHandler struct {
+		restful.QueryValues
+		User           sous.User
+		PendingChanges *sous.PendingChanges
+		LogSink        logging.LogSink
+	}
+)
+
+func newApprovalsResource(ctx ComponentLocator) *ApprovalsResource {
+	return &ApprovalsResource{context: ctx}
+}
+
+func newApprovalResource(ctx ComponentLocator) *ApprovalResource {
+	return &ApprovalResource{context: ctx}
+}
+
+// Get implements Getable on ApprovalsResource.
+func (ar *ApprovalsResource) Get(_ *restful.RouteMap, _ logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	return &GETApprovalsHandler{
+		QueryValues:    ar.ParseQuery(req),
+		PendingChanges: ar.context.PendingChanges,
+	}
+}
+
+// Get implements Getable on ApprovalResource.
+func (ar *ApprovalResource) Get(_ *restful.RouteMap, _ logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	return &GETApprovalHandler{
+		QueryValues:    ar.ParseQuery(req),
+		PendingChanges: ar.context.PendingChanges,
+	}
+}
+
+// Put implements Putable on ApprovalResource.
+func (ar *ApprovalResource) Put(rm *restful.RouteMap, ls logging.LogSink, rw http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	deployer := newSingleDeploymentResource(ar.context).Put(rm, ls, rw, req, nil).(*PUTSingleDeploymentHandler)
+	return &PUTApprovalHandler{
+		QueryValues:    ar.ParseQuery(req),
+		User:           sous.User(ar.GetUser(req)),
+		PendingChanges: ar.context.PendingChanges,
+		LogSink:        ls,
+		deployer:       deployer,
+	}
+}
+
+// Delete implements Deleteable on ApprovalResource.
+func (ar *ApprovalResource) Delete(_ *restful.RouteMap, ls logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	return &DELETEApprovalHandler{
+		QueryValues:    ar.ParseQuery(req),
+		User:           sous.User(ar.GetUser(req)),
+		PendingChanges: ar.context.PendingChanges,
+		LogSink:        ls,
+	}
+}
+
+// Exchange implements Exchanger on GETApprovalsHandler. The repo, offset,
+// flavor and cluster query parameters each narrow the changes returned.
+func (h *GETApprovalsHandler) Exchange() (interface{}, int) {
+	if h.PendingChanges == nil {
+		return errors.New("this server does not hold changes for approval"), http.StatusNotImplemented
+	}
+	rf, err := resolveFilterFromValues(h.QueryValues, nil)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+	return ApprovalsData{Changes: h.PendingChanges.List(rf)}, http.StatusOK
+}
+
+// Exchange implements Exchanger on GETApprovalHandler.
+func (h *GETApprovalHandler) Exchange() (interface{}, int) {
+	change, status, err := pendingChange(h.QueryValues, h.PendingChanges)
+	if err != nil {
+		return err, status
+	}
+	return &ApprovalData{Change: change}, http.StatusOK
+}
+
+// Exchange implements Exchanger on PUTApprovalHandler. The change is only
+// written if the deployment's version is still the one it replaces, and no
+// Freeze blocks the approving user.
+func (h *PUTApprovalHandler) Exchange() (interface{}, int) {
+	change, status, err := pendingChange(h.QueryValues, h.PendingChanges)
+	if err != nil {
+		return err, status
+	}
+	if err := change.CheckApprover(h.User); err != nil {
+		return err.Error(), http.StatusForbidden
+	}
+
+	did := change.DeploymentID
+	gdm := h.deployer.GDM
+	if gdm == nil {
+		return "Error reading state.", http.StatusInternalServerError
+	}
+	if err := gdm.Defs.Freezes.Check(did, h.User, time.Now()); err != nil {
+		return fmt.Sprintf("Cannot approve: %s.", err), http.StatusForbidden
+	}
+	m, ok := gdm.Manifests.Get(did.ManifestID)
+	if !ok {
+		return fmt.Sprintf("No manifest with ID %q.", did.ManifestID), http.StatusNotFound
+	}
+	current, ok := m.Deployments[did.Cluster]
+	if !ok {
+		return fmt.Sprintf("Manifest %q has no deployment for cluster %q.", did.ManifestID, did.Cluster), http.StatusNotFound
+	}
+	if !current.Version.Equals(change.Prior.Version) {
+		return fmt.Sprintf("%s is now at version %s, not %s; the change must be requested again.",
+			did, current.Version, change.Prior.Version), http.StatusConflict
+	}
+
+	h.deployer.Body.Deployment = &change.Post
+	body, status := h.deployer.deploy(did, current, h.User, did)
+	if status >= 300 {
+		return body, status
+	}
+	if _, _, err := h.PendingChanges.Remove(change.ID); err != nil {
+		logging.ReportError(h.LogSink, errors.Wrapf(err, "removing approved change %s", change.ID))
+	}
+	messages.ReportLogFieldsMessageToConsole(fmt.Sprintf("%s approved %s's change of %s to version %s",
+		h.User, change.User, did, change.Post.Version), logging.InformationLevel, h.LogSink, did)
+	return body, status
+}
+
+// Exchange implements Exchanger on DELETEApprovalHandler.
+func (h *DELETEApprovalHandler) Exchange() (interface{}, int) {
+	change, status, err := pendingChange(h.QueryValues, h.PendingChanges)
+	if err != nil {
+		return err, status
+	}
+	if _, _, err := h.PendingChanges.Remove(change.ID); err != nil {
+		return err, http.StatusInternalServerError
+	}
+	messages.ReportLogFieldsMessageToConsole(fmt.Sprintf("%s rejected %s's change of %s to version %s",
+		h.User, change.User, change.DeploymentID, change.Post.Version), logging.InformationLevel, h.LogSink, change.DeploymentID)
+	return nil, http.StatusNoContent
+}
+
+// pendingChange returns the change in pc selected by the id query parameter
+// in qv, or an error and the status code to report it with.
+func pendingChange(qv restful.QueryValues, pc *sous.PendingChanges) (sous.PendingChange, int, error) {
+	if pc == nil {
+		return sous.PendingChange{}, http.StatusNotImplemented, errors.New("this server does not hold changes for approval")
+	}
+	id, err := qv.Single("id")
+	if err != nil {
+		return sous.PendingChange{}, http.StatusBadRequest, err
+	}
+	change, ok := pc.Get(id)
+	if !ok {
+		return sous.PendingChange{}, http.StatusNotFound, errors.Errorf("no pending change with ID %q", id)
+	}
+	return change, http.StatusOK, nil
+}
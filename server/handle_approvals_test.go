@@ -0,0 +1,136 @@
+package server
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/nyarly/spies"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalResource(t *testing.T) {
+	did := sous.DeploymentID{
+		ManifestID: sous.ManifestID{
+			Source: sous.SourceLocation{Repo: "github.com/user1/repo1", Dir: "dir1"},
+			Flavor: "flavor1",
+		},
+		Cluster: "cluster1",
+	}
+
+	setup := func(t *testing.T) (ComponentLocator, *sous.DummyStateManager, *spies.Spy, sous.PendingChange) {
+		state := sous.DefaultStateFixture()
+		state.Defs.Clusters["cluster1"].RequireApproval = true
+		m, ok := state.Manifests.Get(did.ManifestID)
+		require.True(t, ok)
+		post := m.Deployments["cluster1"]
+		post.Version = semv.MustParse("2.0.0")
+
+		pc, err := sous.NewPendingChanges("")
+		require.NoError(t, err)
+		change, err := pc.Add(sous.PendingChange{
+			DeploymentID: did,
+			Prior:        m.Deployments["cluster1"],
+			Post:         post,
+			User:         sous.User{Name: "Requester", Email: "requester@example.com"},
+			Time:         time.Now(),
+		})
+		require.NoError(t, err)
+
+		qs, qsc := sous.NewQueueSetSpy()
+		qsc.MatchMethod("Push", spies.AnyArgs, &sous.QueuedR11n{ID: "actionid1"}, true)
+		sm := &sous.DummyStateManager{State: state}
+		return ComponentLocator{
+			StateManager:   sm,
+			QueueSet:       qs,
+			PendingChanges: pc,
+			LogSink:        logging.SilentLogSet(),
+		}, sm, qsc, change
+	}
+
+	exchange := func(cl ComponentLocator, method, id, email string) (interface{}, int) {
+		req := httptest.NewRequest(method, "http://sous.example.com/approval?"+url.Values{"id": {id}}.Encode(), nil)
+		req.Header.Set("Sous-User-Name", "Someone")
+		req.Header.Set("Sous-User-Email", email)
+		rw := httptest.NewRecorder()
+		r := newApprovalResource(cl)
+		rm := routemap(cl)
+		var ex restful.Exchanger
+		switch method {
+		case "GET":
+			ex = r.Get(rm, logging.SilentLogSet(), rw, req, nil)
+		case "PUT":
+			ex = r.Put(rm, logging.SilentLogSet(), rw, req, nil)
+		case "DELETE":
+			ex = r.Delete(rm, logging.SilentLogSet(), rw, req, nil)
+		}
+		return ex.Exchange()
+	}
+
+	t.Run("list", func(t *testing.T) {
+		cl, _, _, change := setup(t)
+		h := &GETApprovalsHandler{
+			QueryValues:    restful.QueryValues{Values: url.Values{"cluster": {"cluster1"}}},
+			PendingChanges: cl.PendingChanges,
+		}
+		data, status := h.Exchange()
+		assert.Equal(t, 200, status)
+		require.IsType(t, ApprovalsData{}, data)
+		require.Len(t, data.(ApprovalsData).Changes, 1)
+		assert.Equal(t, change.ID, data.(ApprovalsData).Changes[0].ID)
+
+		data, status = exchange(cl, "GET", change.ID, "anyone@example.com")
+		assert.Equal(t, 200, status)
+		assert.Equal(t, change.ID, data.(*ApprovalData).Change.ID)
+
+		_, status = exchange(cl, "GET", "no-such-change", "anyone@example.com")
+		assert.Equal(t, 404, status)
+	})
+
+	t.Run("approve", func(t *testing.T) {
+		cl, sm, qsc, change := setup(t)
+		body, status := exchange(cl, "PUT", change.ID, "approver@example.com")
+		require.Equal(t, 201, status, "%v", body)
+		assert.Equal(t, 1, sm.WriteCount)
+		assert.Len(t, qsc.CallsTo("Push"), 1)
+		m, _ := sm.State.Manifests.Get(did.ManifestID)
+		assert.Equal(t, "2.0.0", m.Deployments["cluster1"].Version.String())
+		_, pending := cl.PendingChanges.Get(change.ID)
+		assert.False(t, pending)
+	})
+
+	t.Run("approve_own_change", func(t *testing.T) {
+		cl, sm, _, change := setup(t)
+		_, status := exchange(cl, "PUT", change.ID, "requester@example.com")
+		assert.Equal(t, 403, status)
+		assert.Equal(t, 0, sm.WriteCount)
+		_, pending := cl.PendingChanges.Get(change.ID)
+		assert.True(t, pending)
+	})
+
+	t.Run("approve_stale_change", func(t *testing.T) {
+		cl, sm, _, change := setup(t)
+		m, _ := sm.State.Manifests.Get(did.ManifestID)
+		current := m.Deployments["cluster1"]
+		current.Version = semv.MustParse("1.5.0")
+		m.Deployments["cluster1"] = current
+		_, status := exchange(cl, "PUT", change.ID, "approver@example.com")
+		assert.Equal(t, 409, status)
+		assert.Equal(t, 0, sm.WriteCount)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		cl, sm, _, change := setup(t)
+		_, status := exchange(cl, "DELETE", change.ID, "approver@example.com")
+		assert.Equal(t, 204, status)
+		assert.Equal(t, 0, sm.WriteCount)
+		_, pending := cl.PendingChanges.Get(change.ID)
+		assert.False(t, pending)
+	})
+}
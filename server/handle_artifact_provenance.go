@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// ArtifactProvenanceResource provides the /artifact/provenance endpoint.
+	ArtifactProvenanceResource struct {
+		restful.QueryParser
+		context ComponentLocator
+	}
+
+	// GETArtifactProvenanceHandler handles GET requests to
+	// /artifact/provenance.
+	GETArtifactProvenanceHandler struct {
+		restful.QueryValues
+		Inserter sous.Inserter
+		LogSink  logging.LogSink
+	}
+)
+
+func newArtifactProvenanceResource(ctx ComponentLocator) *ArtifactProvenanceResource {
+	return &ArtifactProvenanceResource{context: ctx}
+}
+
+// Get implements Getable on ArtifactProvenanceResource, which marks it as
+// accepting GET requests.
+func (ar *ArtifactProvenanceResource) Get(_ *restful.RouteMap, ls logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	return &GETArtifactProvenanceHandler{
+		QueryValues: ar.ParseQuery(req),
+		Inserter:    ar.context.Inserter,
+		LogSink:     ls,
+	}
+}
+
+// Exchange implements Exchanger on GETArtifactProvenanceHandler. The repo,
+// offset and version query parameters identify the artifact.
+func (h *GETArtifactProvenanceHandler) Exchange() (interface{}, int) {
+	sid, err := sourceIDFromValues(h.QueryValues)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+
+	pr, ok := h.Inserter.(sous.ProvenanceReader)
+	if !ok {
+		return errors.Errorf("%T does not read provenance", h.Inserter), http.StatusNotImplemented
+	}
+
+	p, err := pr.Provenance(sid)
+	if _, ok := errors.Cause(err).(sous.NoProvenanceError); ok {
+		return err, http.StatusNotFound
+	}
+	if err != nil {
+		logging.ReportError(h.LogSink, errors.Wrapf(err, "reading provenance of %s", sid))
+		return err, http.StatusInternalServerError
+	}
+	return p, http.StatusOK
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/url"
+	"testing"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+type provenanceInserter struct {
+	sous.InserterSpy
+	provenance map[string]*sous.Provenance
+}
+
+func (pi provenanceInserter) Provenance(sid sous.SourceID) (*sous.Provenance, error) {
+	if p, ok := pi.provenance[sid.String()]; ok {
+		return p, nil
+	}
+	return nil, sous.NoProvenanceError{SourceID: sid}
+}
+
+func TestHandlesArtifactProvenanceGet(t *testing.T) {
+	sid := sous.MustParseSourceID("github.com/opentable/test,1.2.3")
+	p := &sous.Provenance{Type: sous.ProvenanceStatementType}
+	h := &GETArtifactProvenanceHandler{
+		QueryValues: restful.QueryValues{Values: url.Values{"repo": {"github.com/opentable/test"}, "offset": {""}, "version": {"1.2.3"}}},
+		Inserter:    provenanceInserter{InserterSpy: sous.NewInserterSpy(), provenance: map[string]*sous.Provenance{sid.String(): p}},
+		LogSink:     logging.SilentLogSet(),
+	}
+	data, status := h.Exchange()
+	assert.Equal(t, 200, status)
+	assert.Equal(t, p, data)
+
+	h.QueryValues.Values.Set("version", "1.2.4")
+	_, status = h.Exchange()
+	assert.Equal(t, 404, status)
+
+	h.QueryValues.Values.Del("repo")
+	_, status = h.Exchange()
+	assert.Equal(t, 400, status)
+
+	h.QueryValues.Values.Set("repo", "github.com/opentable/test")
+	h.Inserter = sous.NewInserterSpy()
+	_, status = h.Exchange()
+	assert.Equal(t, 501, status)
+}
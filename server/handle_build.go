@@ -0,0 +1,109 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// BuildResource provides the /build endpoint, which starts (PUT) builds
+	// made by the server, and reports on (GET) their progress.
+	BuildResource struct {
+		restful.QueryParser
+		userExtractor
+		context ComponentLocator
+	}
+
+	// GETBuildHandler handles GET requests to /build.
+	GETBuildHandler struct {
+		restful.QueryValues
+		RemoteBuilder *sous.RemoteBuilder
+	}
+
+	// PUTBuildHandler handles PUT requests to /build, which queue a build of
+	// the source ID given by the repo, offset and version query parameters.
+	PUTBuildHandler struct {
+		restful.QueryValues
+		User          sous.User
+		RemoteBuilder *sous.RemoteBuilder
+		routeMap      *restful.RouteMap
+		req           *http.Request
+	}
+)
+
+func newBuildResource(ctx ComponentLocator) *BuildResource {
+	return &BuildResource{context: ctx}
+}
+
+// Get implements Getable on BuildResource.
+func (br *BuildResource) Get(_ *restful.RouteMap, _ logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	return &GETBuildHandler{
+		QueryValues:   br.ParseQuery(req),
+		RemoteBuilder: br.context.RemoteBuilder,
+	}
+}
+
+// Put implements Putable on BuildResource.
+func (br *BuildResource) Put(rm *restful.RouteMap, _ logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	return &PUTBuildHandler{
+		QueryValues:   br.ParseQuery(req),
+		User:          sous.User(br.GetUser(req)),
+		RemoteBuilder: br.context.RemoteBuilder,
+		routeMap:      rm,
+		req:           req,
+	}
+}
+
+// Exchange implements Exchanger on GETBuildHandler. The log of the build is
+// returned from the line given by the from query parameter onwards.
+func (h *GETBuildHandler) Exchange() (interface{}, int) {
+	id, err := h.Single("id")
+	if err != nil {
+		return err, http.StatusNotFound
+	}
+	if h.RemoteBuilder == nil {
+		return errors.New("this server does not build source"), http.StatusNotImplemented
+	}
+	fromStr, err := h.Single("from", "0")
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+	from, err := strconv.Atoi(fromStr)
+	if err != nil {
+		return errors.Wrapf(err, "parsing from"), http.StatusBadRequest
+	}
+	b, ok := h.RemoteBuilder.Get(sous.RemoteBuildID(id), from)
+	if !ok {
+		return errors.Errorf("no build %q", id), http.StatusNotFound
+	}
+	return BuildData{Build: b}, http.StatusOK
+}
+
+// Exchange implements Exchanger on PUTBuildHandler.
+func (h *PUTBuildHandler) Exchange() (interface{}, int) {
+	if h.RemoteBuilder == nil {
+		return errors.New("this server does not build source"), http.StatusNotImplemented
+	}
+	sid, err := sourceIDFromValues(h.QueryValues)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+	b, err := h.RemoteBuilder.Start(sid, h.User)
+	if err != nil {
+		return err, http.StatusServiceUnavailable
+	}
+	buildURI, err := h.routeMap.FullURIFor(h.req.Host, "build", nil, restful.KV{"id", string(b.ID)})
+	if err != nil {
+		return errors.Wrapf(err, "determining build URL"), http.StatusInternalServerError
+	}
+	return BuildData{
+		Meta:  ResponseMeta{Links: map[string]string{"build": buildURI}},
+		Build: b,
+	}, http.StatusCreated
+}
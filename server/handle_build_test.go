@@ -0,0 +1,89 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type buildTestSource struct{}
+
+func (buildTestSource) GetSource(id sous.SourceID) (sous.Source, error) {
+	return sous.Source{ID: id}, nil
+}
+
+func (buildTestSource) BuildSource(src sous.Source, log io.Writer) (*sous.BuildResult, error) {
+	fmt.Fprintln(log, "built")
+	return &sous.BuildResult{}, nil
+}
+
+func TestBuildResource(t *testing.T) {
+	exchange := func(cl ComponentLocator, method string, query url.Values) (interface{}, int) {
+		req := httptest.NewRequest(method, "http://sous.example.com/build?"+query.Encode(), nil)
+		req.Header.Set("Sous-User-Name", "Someone")
+		rw := httptest.NewRecorder()
+		r := newBuildResource(cl)
+		rm := routemap(cl)
+		var ex restful.Exchanger
+		switch method {
+		case "GET":
+			ex = r.Get(rm, logging.SilentLogSet(), rw, req, nil)
+		case "PUT":
+			ex = r.Put(rm, logging.SilentLogSet(), rw, req, nil)
+		}
+		return ex.Exchange()
+	}
+	sidQuery := url.Values{"repo": {"github.com/user/repo"}, "version": {"1.2.3"}}
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, status := exchange(ComponentLocator{}, "PUT", sidQuery)
+		assert.Equal(t, 501, status)
+		_, status = exchange(ComponentLocator{}, "GET", url.Values{})
+		assert.Equal(t, 404, status, "creating builds relies on GET without an id being 404")
+	})
+
+	cl := ComponentLocator{
+		RemoteBuilder: sous.NewRemoteBuilder(buildTestSource{}, buildTestSource{}, logging.SilentLogSet()),
+	}
+
+	t.Run("bad_source", func(t *testing.T) {
+		_, status := exchange(cl, "PUT", url.Values{"repo": {"github.com/user/repo"}, "version": {"nope"}})
+		assert.Equal(t, 400, status)
+	})
+
+	t.Run("build", func(t *testing.T) {
+		data, status := exchange(cl, "PUT", sidQuery)
+		require.Equal(t, 201, status, "%v", data)
+		require.IsType(t, BuildData{}, data)
+		created := data.(BuildData)
+		assert.Equal(t, "Someone", created.Build.User.Name)
+		assert.Equal(t, "1.2.3", created.Build.Source.Version.String())
+		assert.Equal(t, "sous.example.com/build?id="+string(created.Build.ID), created.Meta.Links["build"])
+
+		var b sous.RemoteBuild
+		for i := 0; i < 200; i++ {
+			data, status = exchange(cl, "GET", url.Values{"id": {string(created.Build.ID)}, "from": {"1"}})
+			require.Equal(t, 200, status, "%v", data)
+			if b = data.(BuildData).Build; b.State.Done() {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, sous.RemoteBuildSucceeded, b.State)
+		assert.Equal(t, []string{"built"}, b.Log)
+
+		_, status = exchange(cl, "GET", url.Values{"id": {"no-such-build"}})
+		assert.Equal(t, 404, status)
+		_, status = exchange(cl, "GET", url.Values{"id": {string(created.Build.ID)}, "from": {"x"}})
+		assert.Equal(t, 400, status)
+	})
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+)
+
+type (
+	// CapacityResource provides the /capacity endpoint.
+	CapacityResource struct {
+		restful.QueryParser
+		context ComponentLocator
+	}
+
+	// GETCapacityHandler handles GET requests to /capacity.
+	GETCapacityHandler struct {
+		restful.QueryValues
+		GDM *sous.State
+	}
+)
+
+func newCapacityResource(ctx ComponentLocator) *CapacityResource {
+	return &CapacityResource{context: ctx}
+}
+
+// Get implements Getable on CapacityResource, which marks it as accepting GET requests.
+func (cr *CapacityResource) Get(_ *restful.RouteMap, _ logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	return &GETCapacityHandler{
+		QueryValues: cr.ParseQuery(req),
+		GDM:         cr.context.liveState(),
+	}
+}
+
+// Exchange implements Exchanger on GETCapacityHandler. It returns the
+// resources claimed in each cluster by the deployments in the GDM, and by
+// each of their owners, against their quotas. The cluster and owner query
+// parameters each narrow the report.
+func (h *GETCapacityHandler) Exchange() (interface{}, int) {
+	cluster, err := h.Single("cluster", "")
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+	owner, err := h.Single("owner", "")
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+
+	ds, err := h.GDM.Deployments()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+
+	entries := sous.CapacityReport{}
+	for _, e := range h.GDM.Defs.Capacity(ds) {
+		if cluster != "" && e.Cluster != cluster {
+			continue
+		}
+		if owner != "" && e.Owner != owner {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return CapacityData{Entries: entries}, http.StatusOK
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/url"
+	"testing"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlesCapacityGet(t *testing.T) {
+	state := sous.DefaultStateFixture()
+	state.Defs.OwnerQuotas = sous.OwnerQuotas{{Owner: "judson", Quota: sous.Quota{Cpus: 1}}}
+
+	th := &GETCapacityHandler{
+		QueryValues: restful.QueryValues{Values: url.Values{}},
+		GDM:         state,
+	}
+	data, status := th.Exchange()
+	assert.Equal(t, 200, status)
+	require.IsType(t, CapacityData{}, data)
+	all := data.(CapacityData).Entries
+	assert.Len(t, all, len(state.Defs.Clusters)*2)
+
+	th.QueryValues = restful.QueryValues{Values: url.Values{"cluster": {"cluster1"}, "owner": {"judson"}}}
+	data, status = th.Exchange()
+	assert.Equal(t, 200, status)
+	entries := data.(CapacityData).Entries
+	require.Len(t, entries, 1)
+	assert.Equal(t, "cluster1", entries[0].Cluster)
+	assert.Equal(t, sous.Quota{Cpus: 1}, entries[0].Quota)
+
+	th.QueryValues = restful.QueryValues{Values: url.Values{"cluster": {"a", "b"}}}
+	_, status = th.Exchange()
+	assert.Equal(t, 400, status)
+}
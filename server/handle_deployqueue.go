@@ -51,7 +51,8 @@ func (h *GETDeployQueueHandler) Exchange() (interface{}, int) {
 	var queued = make([]queuedDeployment, queue.Len())
 	for i, qr := range queue.Snapshot() {
 		queued[i] = queuedDeployment{
-			ID: qr.ID,
+			ID:      qr.ID,
+			Rollout: qr.Rectification.RolloutProgress(),
 		}
 	}
 	return deployQueueResponse{Queue: queued}, 200
@@ -63,4 +64,6 @@ type deployQueueResponse struct {
 
 type queuedDeployment struct {
 	ID sous.R11nID
+	// Rollout is the stage reached by a progressive rollout, if any.
+	Rollout *sous.RolloutProgress
 }
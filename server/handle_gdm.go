@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/opentable/sous/dto"
@@ -30,9 +31,10 @@ type (
 	PUTGDMHandler struct {
 		*http.Request
 		logging.LogSink
-		GDM          *sous.State
-		StateManager sous.StateManager
-		User         ClientUser
+		GDM            *sous.State
+		StateManager   sous.StateManager
+		PromotionFacts sous.PromotionFactFinder
+		User           ClientUser
 	}
 )
 
@@ -75,11 +77,12 @@ func (h *GETGDMHandler) Exchange() (interface{}, int) {
 // Put implements Putable on GDMResource
 func (gr *GDMResource) Put(_ *restful.RouteMap, ls logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
 	return &PUTGDMHandler{
-		Request:      req,
-		LogSink:      ls,
-		GDM:          gr.context.liveState(),
-		StateManager: gr.context.StateManager,
-		User:         gr.GetUser(req),
+		Request:        req,
+		LogSink:        ls,
+		GDM:            gr.context.liveState(),
+		StateManager:   gr.context.StateManager,
+		PromotionFacts: gr.context.PromotionFacts,
+		User:           gr.GetUser(req),
 	}
 }
 
@@ -99,6 +102,13 @@ func (h *PUTGDMHandler) Exchange() (interface{}, int) {
 		return msg, http.StatusInternalServerError
 	}
 
+	prior, err := state.Deployments()
+	if err != nil {
+		msg := "Error getting state"
+		reportHandleGDMMessage(msg, nil, err, h.LogSink)
+		return msg, http.StatusInternalServerError
+	}
+
 	state.Manifests, err = deps.PutbackManifests(state.Defs, state.Manifests)
 	if err != nil {
 		msg := "Error getting state"
@@ -110,14 +120,26 @@ func (h *PUTGDMHandler) Exchange() (interface{}, int) {
 	if len(flaws) > 0 {
 		msg := "Invalid GDM"
 		reportHandleGDMMessage(msg, flaws, nil, h.LogSink)
-		return msg, http.StatusBadRequest
+		return msg + ":" + sous.FlawMessage{Flaws: flaws}.ReturnFlawMsg(), http.StatusBadRequest
+	}
+
+	post, err := state.Deployments()
+	if err != nil {
+		msg := "Error getting state"
+		reportHandleGDMMessage(msg, nil, err, h.LogSink)
+		return msg, http.StatusInternalServerError
+	}
+	user := sous.User(h.User)
+	if err := state.CheckChanges(prior, post, user, time.Now(), h.PromotionFacts); err != nil {
+		reportHandleGDMMessage("Change refused", nil, err, h.LogSink)
+		return err.Error(), http.StatusForbidden
 	}
 
 	if _, got := h.Header["Etag"]; got {
 		state.SetEtag(h.Header.Get("Etag"))
 	}
 
-	if err := h.StateManager.WriteState(state, sous.User(h.User)); err != nil {
+	if err := h.StateManager.WriteState(state, user); err != nil {
 		msg := "Error committing state"
 		reportHandleGDMMessage(msg, flaws, err, h.LogSink)
 		return msg, http.StatusInternalServerError
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// HistoryResource provides the /history endpoint.
+	HistoryResource struct {
+		restful.QueryParser
+		context ComponentLocator
+	}
+
+	// GETHistoryHandler handles GET requests to /history.
+	GETHistoryHandler struct {
+		restful.QueryValues
+		StateManager sous.StateManager
+		LogSink      logging.LogSink
+	}
+)
+
+func newHistoryResource(ctx ComponentLocator) *HistoryResource {
+	return &HistoryResource{context: ctx}
+}
+
+// Get implements Getable on HistoryResource, which marks it as accepting GET requests.
+func (hr *HistoryResource) Get(_ *restful.RouteMap, ls logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	return &GETHistoryHandler{
+		QueryValues:  hr.ParseQuery(req),
+		StateManager: hr.context.StateManager,
+		LogSink:      ls,
+	}
+}
+
+// Exchange implements Exchanger on GETHistoryHandler. The repo, offset,
+// flavor and cluster query parameters each narrow the changes returned.
+func (h *GETHistoryHandler) Exchange() (interface{}, int) {
+	rf, err := resolveFilterFromValues(h.QueryValues, nil)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+
+	hr, ok := h.StateManager.(sous.HistoryReader)
+	if !ok {
+		return errors.Errorf("%T does not keep history", h.StateManager), http.StatusNotImplemented
+	}
+
+	changes, err := hr.ReadHistory(rf)
+	if err != nil {
+		logging.ReportError(h.LogSink, errors.Wrapf(err, "reading history"))
+		return err, http.StatusInternalServerError
+	}
+	return HistoryData{Changes: changes}, http.StatusOK
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/url"
+	"testing"
+
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type historyStateManager struct {
+	*sous.DummyStateManager
+	changes []sous.ManifestChange
+}
+
+func (h historyStateManager) ReadHistory(rf *sous.ResolveFilter) ([]sous.ManifestChange, error) {
+	changes := []sous.ManifestChange{}
+	for _, c := range h.changes {
+		if rf.FilterManifestChange(c) {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+func TestHandlesHistoryGet(t *testing.T) {
+	change := func(repo string) sous.ManifestChange {
+		mid := sous.ManifestID{Source: sous.SourceLocation{Repo: repo}}
+		return sous.ManifestChange{
+			ManifestID: mid,
+			Post:       &sous.Manifest{Source: mid.Source},
+			Diffs:      []string{"manifest added"},
+		}
+	}
+	sm := historyStateManager{
+		DummyStateManager: sous.NewDummyStateManager(),
+		changes:           []sous.ManifestChange{change("github.com/example/one"), change("github.com/example/two")},
+	}
+
+	th := &GETHistoryHandler{
+		QueryValues:  restful.QueryValues{Values: url.Values{"repo": {"github.com/example/two"}, "offset": {""}}},
+		StateManager: sm,
+		LogSink:      logging.SilentLogSet(),
+	}
+	data, status := th.Exchange()
+	assert.Equal(t, 200, status)
+	require.IsType(t, HistoryData{}, data)
+	changes := data.(HistoryData).Changes
+	require.Len(t, changes, 1)
+	assert.Equal(t, "github.com/example/two", changes[0].ManifestID.Source.Repo)
+
+	th.StateManager = sous.NewDummyStateManager()
+	_, status = th.Exchange()
+	assert.Equal(t, 501, status)
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/opentable/sous/lib"
@@ -32,8 +33,9 @@ type (
 		logging.LogSink
 		*http.Request
 		restful.QueryValues
-		User        ClientUser
-		StateWriter sous.StateWriter
+		User           ClientUser
+		StateWriter    sous.StateWriter
+		PromotionFacts sous.PromotionFactFinder
 	}
 
 	// DELETEManifestHandler handles DELETE exchanges for manifests
@@ -59,12 +61,13 @@ func (mr *ManifestResource) Get(_ *restful.RouteMap, _ logging.LogSink, _ http.R
 // Put implements Putable for ManifestResource
 func (mr *ManifestResource) Put(_ *restful.RouteMap, ls logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
 	return &PUTManifestHandler{
-		State:       mr.context.liveState(),
-		LogSink:     ls,
-		Request:     req,
-		QueryValues: mr.ParseQuery(req),
-		User:        mr.GetUser(req),
-		StateWriter: sous.StateWriter(mr.context.StateManager),
+		State:          mr.context.liveState(),
+		LogSink:        ls,
+		Request:        req,
+		QueryValues:    mr.ParseQuery(req),
+		User:           mr.GetUser(req),
+		StateWriter:    sous.StateWriter(mr.context.StateManager),
+		PromotionFacts: mr.context.PromotionFacts,
 	}
 }
 
@@ -116,13 +119,27 @@ func (pmh *PUTManifestHandler) Exchange() (interface{}, int) {
 	dec := json.NewDecoder(pmh.Request.Body)
 	dec.Decode(m)
 
-	flaws := m.Validate()
+	flaws := append(m.Validate(), pmh.State.Defs.ValidateManifestEnv(m)...)
 	if len(flaws) > 0 {
 		messages.ReportLogFieldsMessageToConsole("Exchange contains flaws", logging.ExtraDebug1Level, pmh.LogSink, flaws)
-		return "Invalid manifest", http.StatusBadRequest
+		return "Invalid manifest:" + sous.FlawMessage{Flaws: flaws}.ReturnFlawMsg(), http.StatusBadRequest
+	}
+
+	prior, err := pmh.State.Deployments()
+	if err != nil {
+		return errors.Wrapf(err, "reading deployments"), http.StatusInternalServerError
 	}
 	pmh.State.Manifests.Set(mid, m)
-	if err := pmh.StateWriter.WriteState(pmh.State, sous.User(pmh.User)); err != nil {
+	post, err := pmh.State.Deployments()
+	if err != nil {
+		return "Invalid manifest: " + err.Error(), http.StatusBadRequest
+	}
+	user := sous.User(pmh.User)
+	if err := pmh.State.CheckChanges(prior, post, user, time.Now(), pmh.PromotionFacts); err != nil {
+		return err.Error(), http.StatusForbidden
+	}
+
+	if err := pmh.StateWriter.WriteState(pmh.State, user); err != nil {
 		return errors.Wrapf(err, "state recording collision - retry"), http.StatusConflict
 	}
 	return m, http.StatusOK
@@ -6,10 +6,12 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/logging"
 	"github.com/opentable/sous/util/restful"
+	"github.com/samsalisbury/semv"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -119,6 +121,7 @@ func TestHandlesManifestPut(t *testing.T) {
 	q, err := url.ParseQuery("repo=gh")
 	require.NoError(err)
 	state := sous.NewState()
+	state.Defs.Clusters = sous.Clusters{"ci": &sous.Cluster{}}
 	state.Manifests.Add(&sous.Manifest{
 		Source: sous.SourceLocation{Repo: "gh"},
 		Kind:   sous.ManifestKindService,
@@ -174,3 +177,125 @@ func TestHandlesManifestPut(t *testing.T) {
 	assert.Equal(changed.Owners[1], "judson")
 
 }
+
+func TestHandlesManifestPut_badEnv(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	q, err := url.ParseQuery("repo=gh")
+	require.NoError(err)
+	state := sous.NewState()
+	state.Defs.EnvVars = sous.EnvDefs{{Name: "PORT", Type: sous.VarTypeInt}}
+	writer := &sous.DummyStateManager{State: state}
+
+	manifest := &sous.Manifest{
+		Source: sous.SourceLocation{Repo: "gh"},
+		Kind:   sous.ManifestKindService,
+		Deployments: sous.DeploySpecs{
+			"ci": sous.DeploySpec{
+				DeployConfig: sous.DeployConfig{
+					Resources: sous.Resources{"cpus": "0.1", "memory": "100", "ports": "1"},
+					Env:       sous.Env{"PORT": "eight"},
+				},
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+	json.NewEncoder(buf).Encode(manifest)
+	req, err := http.NewRequest("PUT", "", buf)
+	require.NoError(err)
+
+	th := &PUTManifestHandler{
+		Request:     req,
+		StateWriter: writer,
+		State:       state,
+		QueryValues: restful.QueryValues{Values: q},
+		LogSink:     logging.Log,
+	}
+
+	data, status := th.Exchange()
+	assert.Equal(http.StatusBadRequest, status)
+	assert.Contains(data, `"eight" is not a valid int`)
+
+	_, found := state.Manifests.Get(sous.ManifestID{Source: sous.SourceLocation{Repo: "gh"}})
+	assert.False(found)
+}
+
+// putManifest PUTs a manifest for repo gh, deployed to cluster ci, over
+// state, which already has one deployed at version 1.0.0 with one instance.
+// change changes the deployment PUT.
+func putManifest(t *testing.T, state *sous.State, change func(*sous.DeploySpec)) (interface{}, int, *sous.DummyStateManager) {
+	spec := sous.DeploySpec{
+		Version: semv.MustParse("1.0.0"),
+		DeployConfig: sous.DeployConfig{
+			Resources:    sous.Resources{"cpus": "0.1", "memory": "100", "ports": "1"},
+			NumInstances: 1,
+		},
+	}
+	if state.Defs.Clusters == nil {
+		state.Defs.Clusters = sous.Clusters{"ci": &sous.Cluster{}}
+	}
+	state.Manifests.Add(&sous.Manifest{
+		Source:      sous.SourceLocation{Repo: "gh"},
+		Kind:        sous.ManifestKindService,
+		Deployments: sous.DeploySpecs{"ci": spec.Clone()},
+	})
+	writer := &sous.DummyStateManager{State: sous.NewState()}
+
+	change(&spec)
+	buf := &bytes.Buffer{}
+	json.NewEncoder(buf).Encode(&sous.Manifest{
+		Source:      sous.SourceLocation{Repo: "gh"},
+		Kind:        sous.ManifestKindService,
+		Deployments: sous.DeploySpecs{"ci": spec},
+	})
+	req, err := http.NewRequest("PUT", "", buf)
+	require.NoError(t, err)
+	q, err := url.ParseQuery("repo=gh")
+	require.NoError(t, err)
+
+	th := &PUTManifestHandler{
+		Request:     req,
+		StateWriter: writer,
+		State:       state,
+		QueryValues: restful.QueryValues{Values: q},
+		LogSink:     logging.SilentLogSet(),
+	}
+	data, status := th.Exchange()
+	return data, status, writer
+}
+
+func TestHandlesManifestPut_frozen(t *testing.T) {
+	state := sous.NewState()
+	state.Defs.Freezes = sous.Freezes{{
+		Start:  time.Now().Add(-time.Hour),
+		End:    time.Now().Add(time.Hour),
+		Reason: "release week",
+	}}
+	data, status, writer := putManifest(t, state, func(spec *sous.DeploySpec) {
+		spec.Version = semv.MustParse("2.0.0")
+	})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, data, "release week")
+	assert.Zero(t, writer.WriteCount)
+}
+
+func TestHandlesManifestPut_unapproved(t *testing.T) {
+	state := sous.NewState()
+	state.Defs.Clusters = sous.Clusters{"ci": &sous.Cluster{RequireApproval: true}}
+	data, status, writer := putManifest(t, state, func(spec *sous.DeploySpec) {
+		spec.Version = semv.MustParse("2.0.0")
+	})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, data, "requires approval")
+	assert.Zero(t, writer.WriteCount)
+}
+
+func TestHandlesManifestPut_overQuota(t *testing.T) {
+	state := sous.NewState()
+	state.Defs.Clusters = sous.Clusters{"ci": &sous.Cluster{Quota: sous.Quota{Cpus: 1}}}
+	data, status, writer := putManifest(t, state, func(spec *sous.DeploySpec) { spec.NumInstances = 100 })
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, data, "quota exceeded")
+	assert.Zero(t, writer.WriteCount)
+}
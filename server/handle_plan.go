@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// PlanResource provides the /plan endpoint.
+	PlanResource struct {
+		restful.QueryParser
+		context ComponentLocator
+	}
+
+	// GETPlanHandler handles GET requests to /plan.
+	GETPlanHandler struct {
+		restful.QueryValues
+		GDM           *sous.State
+		ResolveFilter *sous.ResolveFilter
+		Deployer      sous.Deployer
+		Registry      sous.Registry
+		Scaler        *sous.Scaler
+		LogSink       logging.LogSink
+	}
+)
+
+func newPlanResource(ctx ComponentLocator) *PlanResource {
+	return &PlanResource{context: ctx}
+}
+
+// Get implements Getable on PlanResource, which marks it as accepting GET requests.
+func (pr *PlanResource) Get(_ *restful.RouteMap, ls logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	h := &GETPlanHandler{
+		QueryValues:   pr.ParseQuery(req),
+		GDM:           pr.context.liveState(),
+		ResolveFilter: pr.context.ResolveFilter,
+		LogSink:       ls,
+	}
+	if ar := pr.context.AutoResolver; ar != nil {
+		h.Deployer, h.Registry, h.Scaler = ar.Deployer, ar.Registry, ar.Scaler
+	}
+	return h
+}
+
+// Exchange implements Exchanger on GETPlanHandler. It returns the
+// sous.ResolvePlan for the current GDM, without changing any deployments. The
+// repo, offset, flavor and cluster query parameters each narrow the plan.
+func (h *GETPlanHandler) Exchange() (interface{}, int) {
+	if h.Deployer == nil || h.Registry == nil {
+		return errors.New("this server does not resolve deployments"), http.StatusNotImplemented
+	}
+
+	rf, err := resolveFilterFromValues(h.QueryValues, h.ResolveFilter)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+
+	intended, err := h.GDM.Deployments()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+
+	rez := sous.NewResolver(h.Deployer, h.Registry, rf, h.LogSink, nil)
+	rez.Scaler = h.Scaler
+	rez.Freezes = h.GDM.Defs.Freezes
+	plan, err := rez.Plan(intended, h.GDM.Defs.Clusters)
+	if err != nil {
+		logging.ReportError(h.LogSink, errors.Wrapf(err, "planning resolution"))
+		return err, http.StatusInternalServerError
+	}
+	return plan, http.StatusOK
+}
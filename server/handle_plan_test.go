@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/nyarly/spies"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlesPlanGet(t *testing.T) {
+	state := sous.DefaultStateFixture()
+	dpr, c := sous.NewDeployerSpy()
+	c.MatchMethod("RunningDeployments", spies.AnyArgs, sous.NewDeployStates(), nil)
+
+	th := &GETPlanHandler{
+		QueryValues: restful.QueryValues{Values: url.Values{"cluster": {"cluster1"}}},
+		GDM:         state,
+		Deployer:    dpr,
+		Registry:    sous.NewDummyRegistry(),
+		LogSink:     logging.SilentLogSet(),
+	}
+	data, status := th.Exchange()
+	require.Equal(t, 200, status)
+	plan := data.(*sous.ResolvePlan)
+	assert.NotEmpty(t, plan.Added)
+	assert.Empty(t, plan.Removed)
+	assert.Empty(t, plan.Modified)
+	for _, p := range plan.Added {
+		assert.Equal(t, "cluster1", p.DeploymentID.Cluster)
+	}
+	assert.Len(t, c.CallsTo("Rectify"), 0)
+
+	th.ResolveFilter = &sous.ResolveFilter{Cluster: sous.NewResolveFieldMatcher("cluster2")}
+	_, status = th.Exchange()
+	assert.Equal(t, 400, status, "a cluster outside the server's filter")
+
+	th.Deployer = nil
+	_, status = th.Exchange()
+	assert.Equal(t, 501, status)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// PromotionResource provides the /promotion endpoint, which promotes
+	// (PUT) the version deployed to one cluster to another.
+	PromotionResource struct {
+		restful.QueryParser
+		userExtractor
+		context ComponentLocator
+	}
+
+	// PUTPromotionHandler handles PUT requests to /promotion. The repo,
+	// offset and flavor query parameters identify the manifest, from and to
+	// the clusters, and env and resources, comma separated, the environment
+	// variables and resources copied along with the version.
+	PUTPromotionHandler struct {
+		restful.QueryValues
+		User     sous.User
+		LogSink  logging.LogSink
+		deployer *PUTSingleDeploymentHandler
+	}
+)
+
+func newPromotionResource(ctx ComponentLocator) *PromotionResource {
+	return &PromotionResource{context: ctx}
+}
+
+// Put implements Putable on PromotionResource.
+func (pr *PromotionResource) Put(rm *restful.RouteMap, ls logging.LogSink, rw http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	return &PUTPromotionHandler{
+		QueryValues: pr.ParseQuery(req),
+		User:        sous.User(pr.GetUser(req)),
+		LogSink:     ls,
+		deployer:    newSingleDeploymentResource(pr.context).Put(rm, ls, rw, req, nil).(*PUTSingleDeploymentHandler),
+	}
+}
+
+// Exchange implements Exchanger on PUTPromotionHandler. The promotion is made
+// the same way as a PUT to /single-deployment, so the rules in
+// Defs.Promotions, freezes and approvals apply to it as to any other change of
+// version.
+func (h *PUTPromotionHandler) Exchange() (interface{}, int) {
+	p, err := promotionFromValues(h.QueryValues)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+	if p.From == p.To {
+		return errors.Errorf("cannot promote from %s to itself", p.From), http.StatusBadRequest
+	}
+
+	gdm := h.deployer.GDM
+	if gdm == nil {
+		return "Error reading state.", http.StatusInternalServerError
+	}
+	m, ok := gdm.Manifests.Get(p.ManifestID)
+	if !ok {
+		return fmt.Sprintf("No manifest with ID %q.", p.ManifestID), http.StatusNotFound
+	}
+	from, ok := m.Deployments[p.From]
+	if !ok {
+		return fmt.Sprintf("Manifest %q has no deployment for cluster %q.", p.ManifestID, p.From), http.StatusNotFound
+	}
+	to, ok := m.Deployments[p.To]
+	if !ok {
+		return fmt.Sprintf("Manifest %q has no deployment for cluster %q.", p.ManifestID, p.To), http.StatusNotFound
+	}
+
+	spec := p.Apply(from, to)
+	h.deployer.Body.Deployment = &spec
+	return h.deployer.update(p.ToID(), false, h.User)
+}
+
+func promotionFromValues(qv restful.QueryValues) (sous.Promotion, error) {
+	mid, err := manifestIDFromValues(qv)
+	if err != nil {
+		return sous.Promotion{}, err
+	}
+	p := sous.Promotion{ManifestID: mid}
+	if p.From, err = qv.Single("from"); err != nil {
+		return p, err
+	}
+	if p.To, err = qv.Single("to"); err != nil {
+		return p, err
+	}
+	env, err := qv.Single("env", "")
+	if err != nil {
+		return p, err
+	}
+	resources, err := qv.Single("resources", "")
+	if err != nil {
+		return p, err
+	}
+	p.Env, p.Resources = sous.SplitPromotedNames(env), sous.SplitPromotedNames(resources)
+	return p, nil
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/nyarly/spies"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromotionResource(t *testing.T) {
+	mid := sous.ManifestID{
+		Source: sous.SourceLocation{Repo: "github.com/user1/repo1", Dir: "dir1"},
+		Flavor: "flavor1",
+	}
+	query := url.Values{
+		"repo":   {mid.Source.Repo},
+		"offset": {mid.Source.Dir},
+		"flavor": {mid.Flavor},
+		"from":   {"cluster0"},
+		"to":     {"cluster1"},
+		"env":    {"FEATURE"},
+	}
+	deployedAt := time.Now().Add(-48 * time.Hour)
+
+	setup := func(t *testing.T) (*sous.DummyStateManager, *spies.Spy, func(url.Values, sous.DeployStatus) (interface{}, int)) {
+		state := sous.DefaultStateFixture()
+		m, ok := state.Manifests.Get(mid)
+		require.True(t, ok)
+		from := m.Deployments["cluster0"]
+		from.Version = semv.MustParse("2.0.0")
+		from.Env = sous.Env{"FEATURE": "on"}
+		m.Deployments["cluster0"] = from
+
+		sm := &sous.DummyStateManager{State: state}
+		hsm := historyStateManager{DummyStateManager: sm, changes: []sous.ManifestChange{{
+			ManifestID: mid,
+			Time:       deployedAt,
+			Prior:      &sous.Manifest{Source: mid.Source, Flavor: mid.Flavor, Deployments: sous.DeploySpecs{"cluster0": {Version: semv.MustParse("1.0.0")}}},
+			Post:       m.Clone(),
+		}}}
+		qs, qsc := sous.NewQueueSetSpy()
+		qsc.MatchMethod("Push", spies.AnyArgs, &sous.QueuedR11n{ID: "actionid1"}, true)
+		cl := ComponentLocator{StateManager: hsm, QueueSet: qs, LogSink: logging.SilentLogSet()}
+
+		exchange := func(q url.Values, status sous.DeployStatus) (interface{}, int) {
+			req := httptest.NewRequest("PUT", "http://sous.example.com/promotion?"+q.Encode(), nil)
+			req.Header.Set("Sous-User-Name", "Someone")
+			h := newPromotionResource(cl).Put(routemap(cl), logging.SilentLogSet(), httptest.NewRecorder(), req, nil).(*PUTPromotionHandler)
+			ds, err := h.deployer.GDM.Deployments()
+			require.NoError(t, err)
+			d, ok := ds.Get(sous.DeploymentID{ManifestID: mid, Cluster: "cluster0"})
+			require.True(t, ok)
+			deployer, dc := sous.NewDeployerSpy()
+			dc.MatchMethod("RunningDeployments", spies.AnyArgs, sous.NewDeployStates(&sous.DeployState{Deployment: *d, Status: status}), nil)
+			h.deployer.PromotionFacts = &sous.RecordedPromotionFacts{History: hsm, Deployer: deployer, Registry: sous.NewDummyRegistry()}
+			return h.Exchange()
+		}
+		return sm, qsc, exchange
+	}
+
+	promoted := func(sm *sous.DummyStateManager) sous.DeploySpec {
+		m, _ := sm.State.Manifests.Get(mid)
+		return m.Deployments["cluster1"]
+	}
+
+	t.Run("no_rules", func(t *testing.T) {
+		sm, qs, exchange := setup(t)
+		body, status := exchange(query, sous.DeployStatusPending)
+		require.Equal(t, 201, status, "%v", body)
+		assert.Equal(t, "2.0.0", promoted(sm).Version.String())
+		assert.Equal(t, "on", promoted(sm).Env["FEATURE"])
+		assert.Equal(t, "0.1", promoted(sm).Resources["cpus"])
+		assert.Len(t, qs.CallsTo("Push"), 1)
+	})
+
+	t.Run("rules_kept", func(t *testing.T) {
+		sm, _, exchange := setup(t)
+		sm.State.Defs.Promotions = sous.Promotions{{To: []string{"cluster1"}, From: "cluster0", MinTime: 24 * time.Hour, RequireActive: true}}
+		body, status := exchange(query, sous.DeployStatusActive)
+		require.Equal(t, 201, status, "%v", body)
+		assert.Equal(t, "2.0.0", promoted(sm).Version.String())
+	})
+
+	t.Run("rules_broken", func(t *testing.T) {
+		sm, qs, exchange := setup(t)
+		sm.State.Defs.Promotions = sous.Promotions{{To: []string{"cluster1"}, From: "cluster0", MinTime: 72 * time.Hour, RequireActive: true}}
+		body, status := exchange(query, sous.DeployStatusFailed)
+		require.Equal(t, 403, status)
+		assert.Contains(t, body, "less than the 72h0m0s required")
+		assert.Contains(t, body, "not active")
+		assert.Zero(t, sm.WriteCount)
+		assert.Empty(t, qs.CallsTo("Push"))
+
+		wrongSource := url.Values{}
+		for k, v := range query {
+			wrongSource[k] = v
+		}
+		wrongSource.Set("from", "cluster2")
+		body, status = exchange(wrongSource, sous.DeployStatusActive)
+		require.Equal(t, 403, status)
+		assert.Contains(t, body, "must be promoted to cluster1 from cluster0")
+	})
+
+	t.Run("over_quota", func(t *testing.T) {
+		sm, qs, exchange := setup(t)
+		m, _ := sm.State.Manifests.Get(mid)
+		from := m.Deployments["cluster0"]
+		from.Resources = sous.Resources{"cpus": "50", "memory": "100", "ports": "1"}
+		m.Deployments["cluster0"] = from
+		sm.State.Defs.Clusters["cluster1"].Quota = sous.Quota{Cpus: 10}
+
+		withResources := url.Values{"resources": {"cpus"}}
+		for k, v := range query {
+			withResources[k] = v
+		}
+		body, status := exchange(withResources, sous.DeployStatusActive)
+		require.Equal(t, 403, status)
+		assert.Contains(t, body, "quota exceeded")
+		assert.Zero(t, sm.WriteCount)
+		assert.Empty(t, qs.CallsTo("Push"))
+	})
+
+	t.Run("bad_request", func(t *testing.T) {
+		_, _, exchange := setup(t)
+		same := url.Values{"repo": {mid.Source.Repo}, "from": {"cluster0"}, "to": {"cluster0"}}
+		_, status := exchange(same, sous.DeployStatusActive)
+		assert.Equal(t, 400, status)
+		_, status = exchange(url.Values{"repo": {mid.Source.Repo}}, sous.DeployStatusActive)
+		assert.Equal(t, 400, status)
+		missing := url.Values{"repo": {"github.com/nobody/nothing"}, "from": {"cluster0"}, "to": {"cluster1"}}
+		_, status = exchange(missing, sous.DeployStatusActive)
+		assert.Equal(t, 404, status)
+	})
+}
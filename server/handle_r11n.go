@@ -93,6 +93,7 @@ func (h *GETR11nHandler) Exchange() (interface{}, int) {
 	return dto.R11nResponse{
 		QueuePosition: qr.Pos,
 		Resolution:    rez,
+		Rollout:       qr.Rectification.RolloutProgress(),
 	}, http.StatusOK
 }
 
@@ -4,12 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	sous "github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/logging"
 	"github.com/opentable/sous/util/logging/messages"
 	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+	"github.com/samsalisbury/semv"
 )
 
 // https://github.com/opentable/sous/blob/0a96ed483cd86abc9604993120e8dd211cf7adc6/server/handle_single_deployment.go
@@ -24,9 +27,11 @@ type (
 	// specs. See Exchange method for more details.
 	PUTSingleDeploymentHandler struct {
 		SingleDeploymentHandler
-		QueueSet    sous.QueueSet
-		routeMap    *restful.RouteMap
-		StateWriter sous.StateWriter
+		QueueSet       sous.QueueSet
+		routeMap       *restful.RouteMap
+		StateWriter    sous.StateWriter
+		PendingChanges *sous.PendingChanges
+		PromotionFacts sous.PromotionFactFinder
 	}
 
 	// GETSingleDeploymentHandler retrieves manifests containing single deployment
@@ -81,6 +86,8 @@ func (sdr *SingleDeploymentResource) Put(rm *restful.RouteMap, ls logging.LogSin
 		QueueSet:                sdr.context.QueueSet,
 		routeMap:                rm,
 		StateWriter:             sdr.context.StateManager,
+		PendingChanges:          sdr.context.PendingChanges,
+		PromotionFacts:          sdr.context.PromotionFacts,
 	}
 }
 
@@ -132,6 +139,10 @@ func (sdh *SingleDeploymentHandler) ok(code int, links map[string]string) (Singl
 // a Manifest containing a deployment matching DeploymentID that differs
 // from the current actual deployment set. It first writes the new
 // deployment spec to the GDM.
+//
+// If the deployment's cluster requires approval for version changes, a
+// change of version is instead held as a PendingChange until another user
+// approves it.
 func (psd *PUTSingleDeploymentHandler) Exchange() (interface{}, int) {
 	did, err := psd.depID()
 	if err != nil {
@@ -153,6 +164,12 @@ func (psd *PUTSingleDeploymentHandler) Exchange() (interface{}, int) {
 
 	messages.ReportLogFieldsMessageToConsole("Exchange PutSingleDeplymentHandler", logging.ExtraDebug1Level, psd.log, did, psd.Body)
 
+	return psd.update(did, force, sous.User(psd.GetUser(psd.req)))
+}
+
+// update makes psd.Body.Deployment the deployment did, as user, unless it is
+// no different from the current one and force is false.
+func (psd *PUTSingleDeploymentHandler) update(did sous.DeploymentID, force bool, user sous.User) (interface{}, int) {
 	m, ok := psd.GDM.Manifests.Get(did.ManifestID)
 	if !ok {
 		return psd.err(404, "No manifest with ID %q.", did.ManifestID)
@@ -172,19 +189,68 @@ func (psd *PUTSingleDeploymentHandler) Exchange() (interface{}, int) {
 		return psd.ok(200, nil)
 	}
 
-	m.Deployments[did.Cluster] = *psd.Body.Deployment
+	if err := psd.GDM.Defs.Freezes.Check(did, user, time.Now()); err != nil {
+		return psd.err(403, "Cannot deploy: %s.", err)
+	}
 
-	user := sous.User(psd.GetUser(psd.req))
+	if !psd.Body.Deployment.Version.Equals(original.Version) && psd.GDM.RequiresApproval(did) {
+		return psd.pend(did, original, user)
+	}
 
-	if err := psd.StateWriter.WriteState(psd.GDM, user); err != nil {
-		return psd.err(500, "Failed to write state: %s.", err)
+	return psd.deploy(did, original, user)
+}
+
+// pend records the requested deployment spec as a PendingChange, rather than
+// writing it to the GDM.
+func (psd *PUTSingleDeploymentHandler) pend(did sous.DeploymentID, original sous.DeploySpec, user sous.User) (interface{}, int) {
+	if psd.PendingChanges == nil {
+		return psd.err(501, "Cluster %q requires approval, but this server cannot hold changes for approval.", did.Cluster)
 	}
+	pc, err := psd.PendingChanges.Add(sous.PendingChange{
+		DeploymentID: did,
+		Prior:        original,
+		Post:         *psd.Body.Deployment,
+		User:         user,
+		Time:         time.Now(),
+	})
+	if err != nil {
+		return psd.err(500, "Failed to record change for approval: %s.", err)
+	}
+
+	approvalURI, err := psd.routeMap.FullURIFor(psd.req.Host, "approval", nil, restful.KV{"id", pc.ID})
+	if err != nil {
+		return psd.err(500, "Determining approval URL: %s", err)
+	}
+	return psd.ok(202, map[string]string{"pendingApproval": approvalURI})
+}
+
+// deploy writes psd.Body.Deployment to the GDM as the deployment did,
+// replacing original, and queues its rectification. A change of version
+// which requires approval is refused unless did is among approved.
+func (psd *PUTSingleDeploymentHandler) deploy(did sous.DeploymentID, original sous.DeploySpec, user sous.User, approved ...sous.DeploymentID) (interface{}, int) {
+	m, ok := psd.GDM.Manifests.Get(did.ManifestID)
+	if !ok {
+		return psd.err(404, "No manifest with ID %q.", did.ManifestID)
+	}
+	current, err := psd.GDM.Deployments()
+	if err != nil {
+		return psd.err(500, "Failed to read deployments from GDM: %s", err)
+	}
+	m.Deployments[did.Cluster] = *psd.Body.Deployment
 
 	// Round-trip the updated GDM back to deployments to check validity.
 	deployments, err := psd.GDM.Deployments()
 	if err != nil {
 		return psd.err(500, "Failed to round-trip new deployment spec to GDM: %s", err)
 	}
+	if err := psd.GDM.CheckChanges(current, deployments, user, time.Now(), psd.PromotionFacts, approved...); err != nil {
+		return psd.err(403, "Cannot deploy: %s.", err)
+	}
+
+	if err := psd.StateWriter.WriteState(psd.GDM, user); err != nil {
+		return psd.err(500, "Failed to write state: %s.", err)
+	}
+
 	newDeployment, ok := deployments.Get(did)
 	if !ok {
 		return psd.err(500, "Failed to round-trip new deployment spec to GDM.")
@@ -199,6 +265,13 @@ func (psd *PUTSingleDeploymentHandler) Exchange() (interface{}, int) {
 	}}, psd.log.Child("r11n"))
 
 	r.Pair.SetID(did)
+	r.User = user
+
+	// The rectification has no Prior, so give it the version being replaced
+	// in case it needs to be rolled back.
+	prior := newDeployment.Clone()
+	prior.SourceID.Version = original.Version
+	r.RollBackTo(&sous.Deployable{Deployment: prior})
 
 	postID := ""
 	version := ""
@@ -226,9 +299,58 @@ func (psd *PUTSingleDeploymentHandler) Exchange() (interface{}, int) {
 	if err != nil {
 		return psd.err(500, "Determining queue item URL: %s", err)
 	}
-	if err == nil {
+	if psd.responseWriter != nil {
 		psd.responseWriter.Header().Add("Location", queueURI)
 	}
 
 	return psd.ok(201, map[string]string{"queuedDeployAction": queueURI})
 }
+
+// A SingleDeploymentDeployer changes the version of single deployments on
+// behalf of the server itself, the same way PUT requests to
+// /single-deployment do, so that freezes and approvals apply just the same.
+type SingleDeploymentDeployer struct {
+	context ComponentLocator
+}
+
+// NewSingleDeploymentDeployer returns a SingleDeploymentDeployer which
+// writes deployments with sm and queues their rectification in qs. Version
+// changes which require approval are held in pc.
+func NewSingleDeploymentDeployer(sm sous.StateManager, qs sous.QueueSet, pc *sous.PendingChanges, ls logging.LogSink) *SingleDeploymentDeployer {
+	return &SingleDeploymentDeployer{context: ComponentLocator{
+		LogSink:        ls,
+		StateManager:   sm,
+		QueueSet:       qs,
+		PendingChanges: pc,
+	}}
+}
+
+// DeployVersion implements sous.VersionDeployer on SingleDeploymentDeployer.
+// A change held for approval is not an error.
+func (d *SingleDeploymentDeployer) DeployVersion(did sous.DeploymentID, v semv.Version, user sous.User) error {
+	req, err := http.NewRequest("PUT", "/single-deployment", nil)
+	if err != nil {
+		return err
+	}
+	sdr := newSingleDeploymentResource(d.context)
+	psd := sdr.Put(routemap(d.context), d.context.LogSink, nil, req, nil).(*PUTSingleDeploymentHandler)
+	if psd.GDM == nil {
+		return errors.New("could not read state")
+	}
+	m, ok := psd.GDM.Manifests.Get(did.ManifestID)
+	if !ok {
+		return errors.Errorf("no manifest with ID %q", did.ManifestID)
+	}
+	spec, ok := m.Deployments[did.Cluster]
+	if !ok {
+		return errors.Errorf("manifest %q has no deployment for cluster %q", did.ManifestID, did.Cluster)
+	}
+	spec.Version = v
+	psd.Body.Deployment = &spec
+
+	body, status := psd.update(did, false, user)
+	if status >= 300 {
+		return errors.Errorf("%d: %v", status, body)
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nyarly/spies"
 	sous "github.com/opentable/sous/lib"
@@ -346,4 +347,175 @@ func TestPUTSingleDeploymentHandler_Exchange(t *testing.T) {
 			"sous.example.com/deploy-queue-item?action=actionid1&cluster=cluster1&flavor=flavor1&offset=dir1&repo=github.com%2Fuser1%2Frepo1")
 	})
 
+	freeze := func(scenario *psdhExScenario, overriders ...string) {
+		scenario.gdm.Defs.Freezes = sous.Freezes{{
+			Clusters:   []string{"cluster*"},
+			Start:      time.Now().Add(-time.Hour),
+			End:        time.Now().Add(time.Hour),
+			Reason:     "holidays",
+			Overriders: overriders,
+		}}
+	}
+
+	t.Run("frozen", func(t *testing.T) {
+		body, query := makeBodyAndQuery(t, false)
+		body.Deployment.Version = semv.MustParse("2.0.0")
+		scenario := setup(body, query)
+		freeze(scenario)
+		scenario.exercise()
+
+		scenario.assertStatus(t, 403)
+		scenario.assertStringBody(t, "holidays")
+		scenario.assertNoR11nQueued(t)
+		if scenario.stateManager.WriteCount != 0 {
+			t.Errorf("Expected no write to a frozen cluster; written %d times.", scenario.stateManager.WriteCount)
+		}
+	})
+
+	t.Run("frozen_emergency", func(t *testing.T) {
+		body, query := makeBodyAndQuery(t, false)
+		body.Deployment.Version = semv.MustParse("2.0.0")
+		scenario := setup(body, query)
+		freeze(scenario, "testuser@example")
+		scenario.handler.req.Header.Set("Sous-Emergency", "true")
+		scenario.queueSet.MatchMethod("Push", spies.AnyArgs, &sous.QueuedR11n{ID: "actionid1"}, true)
+		scenario.exercise()
+
+		scenario.assertStatus(t, 201)
+		scenario.assertDeploymentWritten(t)
+		scenario.assertR11nQueued(t)
+	})
+
+	t.Run("frozen_emergency_not_overrider", func(t *testing.T) {
+		body, query := makeBodyAndQuery(t, false)
+		body.Deployment.Version = semv.MustParse("2.0.0")
+		scenario := setup(body, query)
+		freeze(scenario, "someone@example")
+		scenario.handler.req.Header.Set("Sous-Emergency", "true")
+		scenario.exercise()
+
+		scenario.assertStatus(t, 403)
+		scenario.assertStringBody(t, "may not override this freeze")
+	})
+
+	t.Run("requires_approval", func(t *testing.T) {
+		body, query := makeBodyAndQuery(t, false)
+		body.Deployment.Version = semv.MustParse("2.0.0")
+		scenario := setup(body, query)
+		scenario.gdm.Defs.Clusters["cluster1"].RequireApproval = true
+		pc, _ := sous.NewPendingChanges("")
+		scenario.handler.PendingChanges = pc
+		scenario.exercise()
+
+		scenario.assertStatus(t, 202)
+		scenario.assertNoR11nQueued(t)
+		if scenario.stateManager.WriteCount != 0 {
+			t.Errorf("Expected no write before approval; written %d times.", scenario.stateManager.WriteCount)
+		}
+		pending := pc.List(&sous.ResolveFilter{})
+		if len(pending) != 1 {
+			t.Fatalf("Expected 1 pending change, got %d", len(pending))
+		}
+		if pending[0].User.Email != "testuser@example" || pending[0].Post.Version.String() != "2.0.0" {
+			t.Errorf("Unexpected pending change: %#v", pending[0])
+		}
+		scenario.assertHeader(t, "Location", "sous.example.com/approval?id="+pending[0].ID)
+	})
+
+	t.Run("over_quota", func(t *testing.T) {
+		body, query := makeBodyAndQuery(t, false)
+		body.Deployment.NumInstances = 1000
+		scenario := setup(body, query)
+		scenario.gdm.Defs.Clusters["cluster1"].Quota = sous.Quota{Cpus: 10}
+		scenario.exercise()
+
+		scenario.assertStatus(t, 403)
+		scenario.assertStringBody(t, "quota exceeded")
+		scenario.assertNoR11nQueued(t)
+		if scenario.stateManager.WriteCount != 0 {
+			t.Errorf("Expected no write over quota; written %d times.", scenario.stateManager.WriteCount)
+		}
+	})
+
+	t.Run("promotion_rules", func(t *testing.T) {
+		body, query := makeBodyAndQuery(t, false)
+		body.Deployment.Version = semv.MustParse("2.0.0")
+		scenario := setup(body, query)
+		scenario.gdm.Defs.Promotions = sous.Promotions{{To: []string{"cluster1"}, From: "cluster0"}}
+		scenario.exercise()
+
+		scenario.assertStatus(t, 403)
+		scenario.assertStringBody(t, "must be promoted to cluster1 from cluster0")
+		scenario.assertNoR11nQueued(t)
+		if scenario.stateManager.WriteCount != 0 {
+			t.Errorf("Expected no write breaking the promotion rules; written %d times.", scenario.stateManager.WriteCount)
+		}
+	})
+
+	t.Run("requires_approval_same_version", func(t *testing.T) {
+		body, query := makeBodyAndQuery(t, false)
+		body.Deployment.NumInstances++
+		scenario := setup(body, query)
+		scenario.gdm.Defs.Clusters["cluster1"].RequireApproval = true
+		scenario.queueSet.MatchMethod("Push", spies.AnyArgs, &sous.QueuedR11n{ID: "actionid1"}, true)
+		scenario.exercise()
+
+		scenario.assertStatus(t, 201)
+		scenario.assertDeploymentWritten(t)
+	})
+}
+
+func TestSingleDeploymentDeployer(t *testing.T) {
+	did := sous.DeploymentID{
+		ManifestID: sous.ManifestID{
+			Source: sous.SourceLocation{Repo: "github.com/user1/repo1", Dir: "dir1"},
+			Flavor: "flavor1",
+		},
+		Cluster: "cluster1",
+	}
+	user := sous.User{Name: "Someone"}
+	setup := func() (*SingleDeploymentDeployer, *sous.DummyStateManager, *spies.Spy, *sous.PendingChanges) {
+		qs, qsCtrl := sous.NewQueueSetSpy()
+		qsCtrl.MatchMethod("Push", spies.AnyArgs, &sous.QueuedR11n{ID: "actionid1"}, true)
+		sm := &sous.DummyStateManager{State: sous.DefaultStateFixture()}
+		pc, _ := sous.NewPendingChanges("")
+		return NewSingleDeploymentDeployer(sm, qs, pc, logging.SilentLogSet()), sm, qsCtrl, pc
+	}
+
+	t.Run("deploys", func(t *testing.T) {
+		d, sm, qs, _ := setup()
+		if err := d.DeployVersion(did, semv.MustParse("2.0.0"), user); err != nil {
+			t.Fatal(err)
+		}
+		m, _ := sm.State.Manifests.Get(did.ManifestID)
+		if got := m.Deployments[did.Cluster].Version.String(); got != "2.0.0" {
+			t.Errorf("got version %q written; want 2.0.0", got)
+		}
+		if len(qs.CallsTo("Push")) != 1 {
+			t.Errorf("Expected that a rectification would be queued.")
+		}
+	})
+
+	t.Run("requires_approval", func(t *testing.T) {
+		d, sm, _, pc := setup()
+		sm.State.Defs.Clusters["cluster1"].RequireApproval = true
+		if err := d.DeployVersion(did, semv.MustParse("2.0.0"), user); err != nil {
+			t.Fatal(err)
+		}
+		if sm.WriteCount != 0 {
+			t.Errorf("Expected no write before approval; written %d times.", sm.WriteCount)
+		}
+		if n := len(pc.List(&sous.ResolveFilter{})); n != 1 {
+			t.Errorf("Expected 1 pending change, got %d", n)
+		}
+	})
+
+	t.Run("unknown_deployment", func(t *testing.T) {
+		d, _, _, _ := setup()
+		missing := did
+		missing.Cluster = "nowhere"
+		if err := d.DeployVersion(missing, semv.MustParse("2.0.0"), user); err == nil {
+			t.Errorf("Expected an error deploying to an unknown cluster.")
+		}
+	})
 }
@@ -9,6 +9,7 @@ import (
 	sous "github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/logging"
 	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
 )
 
 type (
@@ -82,9 +83,23 @@ func (psd *PUTStateDeployments) Exchange() (interface{}, int) {
 	deps := sous.NewDeployments(data.Deployments...)
 
 	err = psd.cluster.WriteCluster(psd.clusterName, deps, sous.User(psd.User))
+	if forbidden(err) {
+		return err, http.StatusForbidden
+	}
 	if err != nil {
 		return err, http.StatusInternalServerError
 	}
 
 	return nil, http.StatusAccepted
 }
+
+// forbidden returns true if err is the refusal of a change by the policies
+// checked by State.CheckChanges.
+func forbidden(err error) bool {
+	switch errors.Cause(err).(type) {
+	default:
+		return false
+	case *sous.FreezeError, *sous.QuotaError, *sous.PromotionError, *sous.UnapprovedError:
+		return true
+	}
+}
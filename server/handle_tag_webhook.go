@@ -0,0 +1,176 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// TagWebhookResource provides the /tag-webhook endpoint, which GitHub and
+	// GitLab POST tag events to, so that newly tagged versions are deployed
+	// by the server's TagWatcher.
+	TagWebhookResource struct {
+		context ComponentLocator
+	}
+
+	// POSTTagWebhookHandler handles POST requests to /tag-webhook.
+	POSTTagWebhookHandler struct {
+		TagWatcher *sous.TagWatcher
+		// Secret is the secret GitHub signs events with, or the token GitLab
+		// sends with them. Events are refused while it is empty.
+		Secret string
+		req    *http.Request
+	}
+
+	// tagEvent is the part of a GitHub push or create event, or a GitLab tag
+	// push event, that says which tag was pushed and by whom.
+	tagEvent struct {
+		Ref     string `json:"ref"`
+		RefType string `json:"ref_type"`
+		Deleted bool   `json:"deleted"`
+		After   string `json:"after"`
+
+		Repository struct {
+			CloneURL string `json:"clone_url"`
+			HTMLURL  string `json:"html_url"`
+		} `json:"repository"`
+		Pusher struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"pusher"`
+		Sender struct {
+			Login string `json:"login"`
+		} `json:"sender"`
+
+		Project struct {
+			GitHTTPURL string `json:"git_http_url"`
+			WebURL     string `json:"web_url"`
+		} `json:"project"`
+		UserName  string `json:"user_name"`
+		UserEmail string `json:"user_email"`
+	}
+)
+
+// gitLabDeletedRef is the "after" revision of a GitLab event deleting a ref.
+const gitLabDeletedRef = "0000000000000000000000000000000000000000"
+
+func newTagWebhookResource(ctx ComponentLocator) *TagWebhookResource {
+	return &TagWebhookResource{context: ctx}
+}
+
+// Post implements Postable on TagWebhookResource.
+func (tr *TagWebhookResource) Post(_ *restful.RouteMap, _ logging.LogSink, _ http.ResponseWriter, req *http.Request, _ httprouter.Params) restful.Exchanger {
+	h := &POSTTagWebhookHandler{
+		TagWatcher: tr.context.TagWatcher,
+		req:        req,
+	}
+	if tr.context.Config != nil {
+		h.Secret = tr.context.Config.TagWatch.WebhookSecret
+	}
+	return h
+}
+
+// Exchange implements Exchanger on POSTTagWebhookHandler. Events other than
+// the creation of a tag are accepted and ignored.
+func (h *POSTTagWebhookHandler) Exchange() (interface{}, int) {
+	if h.TagWatcher == nil {
+		return errors.New("this server does not deploy tagged versions"), http.StatusNotImplemented
+	}
+	if h.Secret == "" {
+		return errors.New("this server has no tag webhook secret configured"), http.StatusNotImplemented
+	}
+	body, err := ioutil.ReadAll(h.req.Body)
+	if err != nil {
+		return errors.Wrapf(err, "reading event"), http.StatusBadRequest
+	}
+	authentic, signed := h.authentic(body)
+	if !authentic {
+		return errors.New("event not signed with the webhook secret"), http.StatusForbidden
+	}
+
+	var e tagEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return errors.Wrapf(err, "parsing event"), http.StatusBadRequest
+	}
+	repoURL, tag, user, ok := e.tag(h.req.Header)
+	if !ok {
+		return "ignored", http.StatusOK
+	}
+	// Only a signed event vouches for who pushed the tag: a GitLab token
+	// does not cover the body it is sent with.
+	if !signed || user.Name == "" {
+		user = sous.TagWatchUser
+	}
+	if err := h.TagWatcher.Tagged(repoURL, []string{tag}, user); err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return "accepted", http.StatusOK
+}
+
+// authentic returns true if the event with body is signed, as GitHub does,
+// or carries a token, as GitLab does, matching h.Secret. signed is true only
+// if body itself was signed.
+func (h *POSTTagWebhookHandler) authentic(body []byte) (authentic, signed bool) {
+	if token := h.req.Header.Get("X-Gitlab-Token"); token != "" {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(h.Secret)) == 1, false
+	}
+	sig := strings.TrimPrefix(h.req.Header.Get("X-Hub-Signature-256"), "sha256=")
+	got, err := hex.DecodeString(sig)
+	if err != nil || len(got) == 0 {
+		return false, false
+	}
+	mac := hmac.New(sha256.New, []byte(h.Secret))
+	mac.Write(body)
+	ok := hmac.Equal(got, mac.Sum(nil))
+	return ok, ok
+}
+
+// tag returns the repository, tag and user of e, and true, if e is the
+// creation of a tag.
+func (e tagEvent) tag(header http.Header) (repoURL, tag string, user sous.User, ok bool) {
+	if header.Get("X-Gitlab-Event") != "" {
+		if e.After == gitLabDeletedRef || !strings.HasPrefix(e.Ref, "refs/tags/") {
+			return "", "", user, false
+		}
+		repoURL = e.Project.GitHTTPURL
+		if repoURL == "" {
+			repoURL = e.Project.WebURL
+		}
+		user = sous.User{Name: e.UserName, Email: e.UserEmail}
+		return repoURL, strings.TrimPrefix(e.Ref, "refs/tags/"), user, true
+	}
+
+	switch header.Get("X-GitHub-Event") {
+	default:
+		return "", "", user, false
+	case "push":
+		if e.Deleted || !strings.HasPrefix(e.Ref, "refs/tags/") {
+			return "", "", user, false
+		}
+		tag = strings.TrimPrefix(e.Ref, "refs/tags/")
+		user = sous.User{Name: e.Pusher.Name, Email: e.Pusher.Email}
+	case "create":
+		if e.RefType != "tag" {
+			return "", "", user, false
+		}
+		tag = e.Ref
+		user = sous.User{Name: e.Sender.Login}
+	}
+	repoURL = e.Repository.CloneURL
+	if repoURL == "" {
+		repoURL = e.Repository.HTMLURL
+	}
+	return repoURL, tag, user, true
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nyarly/spies"
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/ext/git"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/logging"
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tagWebhookTestDeployer struct {
+	versions []string
+	users    []sous.User
+}
+
+func (d *tagWebhookTestDeployer) DeployVersion(did sous.DeploymentID, v semv.Version, user sous.User) error {
+	d.versions = append(d.versions, v.String())
+	d.users = append(d.users, user)
+	return nil
+}
+
+func TestTagWebhookResource(t *testing.T) {
+	const secret = "sekrit"
+	setup := func() (ComponentLocator, *tagWebhookTestDeployer) {
+		sm := &sous.DummyStateManager{State: sous.DefaultStateFixture()}
+		reg, regc := sous.NewRegistrySpy()
+		regc.MatchMethod("GetArtifact", spies.AnyArgs, &sous.BuildArtifact{}, nil)
+		d := &tagWebhookTestDeployer{}
+		mid := sous.ManifestID{
+			Source: sous.SourceLocation{Repo: "github.com/user1/repo1", Dir: "dir1"},
+			Flavor: "flavor1",
+		}
+		w := sous.NewTagWatcher([]sous.ManifestID{mid}, []string{"cluster1"}, reg, sm, d, logging.SilentLogSet())
+		w.CanonicalRepo = git.CanonicalRepoURL
+		cfg := &config.Config{}
+		cfg.TagWatch.WebhookSecret = secret
+		return ComponentLocator{Config: cfg, TagWatcher: w}, d
+	}
+	post := func(cl ComponentLocator, headers map[string]string, body string) (interface{}, int) {
+		req := httptest.NewRequest("POST", "http://sous.example.com/tag-webhook", bytes.NewBufferString(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		ex := newTagWebhookResource(cl).Post(routemap(cl), logging.SilentLogSet(), httptest.NewRecorder(), req, nil)
+		return ex.Exchange()
+	}
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	githubPush := `{"ref": "refs/tags/v9.0.0", "deleted": false,
+		"repository": {"clone_url": "https://github.com/user1/repo1.git"},
+		"pusher": {"name": "someone", "email": "someone@example.com"}}`
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, status := post(ComponentLocator{}, nil, githubPush)
+		assert.Equal(t, 501, status)
+	})
+
+	t.Run("no_secret", func(t *testing.T) {
+		cl, d := setup()
+		cl.Config.TagWatch.WebhookSecret = ""
+		_, status := post(cl, map[string]string{"X-GitHub-Event": "push"}, githubPush)
+		assert.Equal(t, 501, status)
+		_, status = post(cl, map[string]string{"X-Gitlab-Event": "Tag Push Hook", "X-Gitlab-Token": ""}, githubPush)
+		assert.Equal(t, 501, status)
+		assert.Empty(t, d.versions)
+	})
+
+	t.Run("github_push", func(t *testing.T) {
+		cl, d := setup()
+		_, status := post(cl, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign(githubPush)}, githubPush)
+		require.Equal(t, 200, status)
+		assert.Equal(t, []string{"9.0.0"}, d.versions)
+		assert.Equal(t, sous.User{Name: "someone", Email: "someone@example.com"}, d.users[0])
+	})
+
+	t.Run("github_bad_signature", func(t *testing.T) {
+		cl, d := setup()
+		_, status := post(cl, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign("other")}, githubPush)
+		assert.Equal(t, 403, status)
+		_, status = post(cl, map[string]string{"X-GitHub-Event": "push"}, githubPush)
+		assert.Equal(t, 403, status)
+		assert.Empty(t, d.versions)
+	})
+
+	t.Run("github_create", func(t *testing.T) {
+		cl, d := setup()
+		body := `{"ref": "9.1.0", "ref_type": "tag",
+			"repository": {"html_url": "https://github.com/user1/repo1"},
+			"sender": {"login": "someone"}}`
+		_, status := post(cl, map[string]string{"X-GitHub-Event": "create", "X-Hub-Signature-256": sign(body)}, body)
+		require.Equal(t, 200, status)
+		assert.Equal(t, []string{"9.1.0"}, d.versions)
+	})
+
+	t.Run("github_ignored", func(t *testing.T) {
+		cl, d := setup()
+		for event, body := range map[string]string{
+			"push":   `{"ref": "refs/heads/master", "repository": {"clone_url": "https://github.com/user1/repo1.git"}}`,
+			"create": `{"ref": "feature", "ref_type": "branch", "repository": {"clone_url": "https://github.com/user1/repo1.git"}}`,
+			"ping":   `{"zen": "Keep it logically awesome."}`,
+		} {
+			_, status := post(cl, map[string]string{"X-GitHub-Event": event, "X-Hub-Signature-256": sign(body)}, body)
+			assert.Equal(t, 200, status, event)
+		}
+		deleted := `{"ref": "refs/tags/v9.0.0", "deleted": true, "repository": {"clone_url": "https://github.com/user1/repo1.git"}}`
+		_, status := post(cl, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign(deleted)}, deleted)
+		assert.Equal(t, 200, status)
+		assert.Empty(t, d.versions)
+	})
+
+	gitlabTagPush := `{"object_kind": "tag_push", "ref": "refs/tags/v9.2.0",
+		"after": "82b3d5ae55f7080f1e6022629cdb57bfae7cccc7",
+		"user_name": "Someone", "user_email": "someone@example.com",
+		"project": {"git_http_url": "https://github.com/user1/repo1.git"}}`
+
+	t.Run("gitlab_tag_push", func(t *testing.T) {
+		cl, d := setup()
+		_, status := post(cl, map[string]string{"X-Gitlab-Event": "Tag Push Hook", "X-Gitlab-Token": secret}, gitlabTagPush)
+		require.Equal(t, 200, status)
+		assert.Equal(t, []string{"9.2.0"}, d.versions)
+		// GitLab's token does not vouch for the user named in the event.
+		assert.Equal(t, sous.TagWatchUser, d.users[0])
+	})
+
+	t.Run("gitlab_bad_token", func(t *testing.T) {
+		cl, d := setup()
+		_, status := post(cl, map[string]string{"X-Gitlab-Event": "Tag Push Hook", "X-Gitlab-Token": "wrong"}, gitlabTagPush)
+		assert.Equal(t, 403, status)
+		assert.Empty(t, d.versions)
+	})
+
+	t.Run("gitlab_deleted", func(t *testing.T) {
+		cl, d := setup()
+		body := `{"ref": "refs/tags/v9.2.0", "after": "0000000000000000000000000000000000000000",
+			"project": {"git_http_url": "https://github.com/user1/repo1.git"}}`
+		_, status := post(cl, map[string]string{"X-Gitlab-Event": "Tag Push Hook", "X-Gitlab-Token": secret}, body)
+		assert.Equal(t, 200, status)
+		assert.Empty(t, d.versions)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	sous "github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/firsterr"
 	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
 )
 
 func manifestIDFromValues(qv restful.QueryValues) (sous.ManifestID, error) {
@@ -56,3 +57,33 @@ func deploymentIDFromValues(qv restful.QueryValues) (sous.DeploymentID, error) {
 		Cluster:    cluster,
 	}, nil
 }
+
+// resolveFilterFromValues returns a ResolveFilter matching the repo, offset,
+// flavor and cluster given in qv. Fields which are not given are copied from
+// base, or match all values if base is nil. Values given can only narrow base:
+// it is an error to ask for a value base does not match.
+func resolveFilterFromValues(qv restful.QueryValues, base *sous.ResolveFilter) (*sous.ResolveFilter, error) {
+	rf := &sous.ResolveFilter{}
+	if base != nil {
+		*rf = *base
+	}
+	for field, m := range map[string]*sous.ResolveFieldMatcher{
+		"repo":    &rf.Repo,
+		"offset":  &rf.Offset,
+		"flavor":  &rf.Flavor,
+		"cluster": &rf.Cluster,
+	} {
+		if _, given := qv.Values[field]; !given {
+			continue
+		}
+		v, err := qv.Single(field)
+		if err != nil {
+			return nil, err
+		}
+		if !m.All() && *m.Match != v {
+			return nil, errors.Errorf("%s %q is outside this server's %s %q", field, v, field, *m.Match)
+		}
+		*m = sous.NewResolveFieldMatcher(v)
+	}
+	return rf, nil
+}
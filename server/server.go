@@ -31,8 +31,14 @@ type (
 		sous.DeploymentManager // xxx temporary?
 		ResolveFilter          *sous.ResolveFilter
 		*sous.AutoResolver
-		Version  semv.Version
-		QueueSet sous.QueueSet
+		Version        semv.Version
+		QueueSet       sous.QueueSet
+		PendingChanges *sous.PendingChanges
+		RemoteBuilder  *sous.RemoteBuilder
+		TagWatcher     *sous.TagWatcher
+		// PromotionFacts finds out what the promotion rules in the Defs need
+		// to know to check changes to the GDM.
+		PromotionFacts sous.PromotionFactFinder
 	}
 )
 
@@ -52,6 +58,9 @@ func (userExtractor) GetUser(req *http.Request) ClientUser {
 	clu := ClientUser{
 		Name:  req.Header.Get("Sous-User-Name"),
 		Email: req.Header.Get("Sous-User-Email"),
+		// Sous clients send their trace ID with every request.
+		TraceID:   sous.TraceID(req.Header.Get("OT-RequestId")),
+		Emergency: req.Header.Get("Sous-Emergency") == "true",
 	}
 
 	log := logging.Log
@@ -94,6 +103,7 @@ func routemap(context ComponentLocator) *restful.RouteMap {
 		re("defs", "/defs", newStateDefResource(context))
 		re("manifest", "/manifest", newManifestResource(context))
 		re("artifact", "/artifact", newArtifactResource(context))
+		re("artifact-provenance", "/artifact/provenance", newArtifactProvenanceResource(context))
 		re("status", "/status", newStatusResource(context))
 		re("servers", "/servers", newServerListResource(context))
 		re("health", "/health", newHealthResource(context))
@@ -102,6 +112,14 @@ func routemap(context ComponentLocator) *restful.RouteMap {
 		re("deploy-queue", "/deploy-queue", newDeployQueueResource(context))
 		re("deploy-queue-item", "/deploy-queue-item", newR11nResource(context))
 		re("single-deployment", "/single-deployment", newSingleDeploymentResource(context))
+		re("history", "/history", newHistoryResource(context))
+		re("plan", "/plan", newPlanResource(context))
+		re("capacity", "/capacity", newCapacityResource(context))
+		re("approvals", "/approvals", newApprovalsResource(context))
+		re("approval", "/approval", newApprovalResource(context))
+		re("build", "/build", newBuildResource(context))
+		re("tag-webhook", "/tag-webhook", newTagWebhookResource(context))
+		re("promotion", "/promotion", newPromotionResource(context))
 	})
 }
 
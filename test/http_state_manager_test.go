@@ -79,7 +79,7 @@ func TestWriteState(t *testing.T) {
 		func() graph.StateWriter { return graph.StateWriter{StateWriter: &sm} },
 		func() *graph.StateManager { return &graph.StateManager{StateManager: &sm} },
 		func(sm *graph.StateManager) graph.ClusterManager {
-			return graph.ClusterManager{ClusterManager: sous.MakeClusterManager(sm)}
+			return graph.ClusterManager{ClusterManager: sous.MakeClusterManager(sm, nil)}
 		},
 
 		func() *graph.ServerStateManager { return &graph.ServerStateManager{StateManager: &sm} },
@@ -0,0 +1,122 @@
+package docker_registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/distribution/registry/client"
+	"github.com/pkg/errors"
+)
+
+// ErrNoAttachment is returned by GetAttachment if there is no attachment of
+// the name given.
+var ErrNoAttachment = errors.New("no such attachment")
+
+// attachmentConfig is the config blob of an attachment manifest, which has
+// nothing to configure.
+var attachmentConfig = []byte("{}")
+
+// AttachmentName returns the tagged name under which a document of kind
+// (e.g. "provenance") is attached to the image with the canonical name
+// imageName, e.g. "host/repo:sha256-abc.provenance" for
+// "host/repo@sha256:abc".
+func AttachmentName(imageName, kind string) (string, error) {
+	at := strings.LastIndex(imageName, "@")
+	if at < 0 {
+		return "", errors.Errorf("cannot attach to %q: image name has no digest", imageName)
+	}
+	tag := strings.Replace(imageName[at+1:], ":", "-", 1) + "." + kind
+	return imageName[:at] + ":" + tag, nil
+}
+
+// PushAttachment pushes content, of type mediaType, as the single layer of an
+// OCI manifest tagged name, which is usually given by AttachmentName.
+func (c *liveClient) PushAttachment(name, mediaType string, content []byte) error {
+	regHost, ref, err := splitHost(name)
+	if err != nil {
+		return err
+	}
+	tagged, ok := ref.(reference.Tagged)
+	if !ok {
+		return errors.Errorf("cannot push %q: attachment name has no tag", name)
+	}
+	rep, err := c.registryForHostname(regHost)
+	if err != nil {
+		return err
+	}
+
+	config := Descriptor{MediaType: OCIConfigMediaType, Digest: digest.FromBytes(attachmentConfig), Size: int64(len(attachmentConfig))}
+	layer := Descriptor{MediaType: mediaType, Digest: digest.FromBytes(content), Size: int64(len(content))}
+	for _, b := range []struct {
+		d    Descriptor
+		body []byte
+	}{{config, attachmentConfig}, {layer, content}} {
+		body := b.body
+		open := func() (io.ReadCloser, error) { return ioutil.NopCloser(bytes.NewReader(body)), nil }
+		if err := rep.pushBlob(ref, b.d, open); err != nil {
+			return errors.Wrapf(err, "pushing %s", name)
+		}
+	}
+
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     OCIManifestMediaType,
+		"config":        config,
+		"layers":        []Descriptor{layer},
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(rep.putManifest(ref, tagged.Tag(), OCIManifestMediaType, manifest), "pushing %s", name)
+}
+
+// GetAttachment returns the content of the attachment tagged name, as pushed
+// by PushAttachment.
+func (c *liveClient) GetAttachment(name string) ([]byte, error) {
+	regHost, ref, err := splitHost(name)
+	if err != nil {
+		return nil, err
+	}
+	rep, err := c.registryForHostname(regHost)
+	if err != nil {
+		return nil, err
+	}
+	mani, _, _, err := rep.getManifestWithEtag(c.ctx, ref, "")
+	if isNotFound(err) {
+		return nil, ErrNoAttachment
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, ok := mani.(*schema2.DeserializedManifest)
+	if !ok || len(m.Layers) != 1 {
+		return nil, errors.Errorf("%s is not an attachment", name)
+	}
+	return rep.getBlob(c.ctx, ref, m.Layers[0].Digest)
+}
+
+// isNotFound returns true if err reports that a manifest does not exist.
+func isNotFound(err error) bool {
+	switch err := err.(type) {
+	case *client.UnexpectedHTTPResponseError:
+		return err.StatusCode == http.StatusNotFound
+	case errcode.Errors:
+		for _, e := range err {
+			if isNotFound(e) {
+				return true
+			}
+		}
+	case errcode.Error:
+		return err.Code == v2.ErrorCodeManifestUnknown
+	}
+	return false
+}
@@ -69,6 +69,14 @@ type (
 		LabelsForImageName(string) (map[string]string, error)
 		GetImageMetadata(imageName, etag string) (Metadata, error)
 		AllTags(repoName string) ([]string, error)
+		// PushImageLayout pushes the image in the OCI image layout in a
+		// directory under each of the (tagged) image names given.
+		PushImageLayout(dir string, imageNames ...string) error
+		// PushAttachment pushes a document, e.g. a build's provenance, as
+		// an OCI artifact tagged with the given name.
+		PushAttachment(name, mediaType string, content []byte) error
+		// GetAttachment returns a document pushed by PushAttachment.
+		GetAttachment(name string) ([]byte, error)
 		Cancel()
 		BecomeFoolishlyTrusting()
 	}
@@ -82,6 +90,9 @@ type (
 		CanonicalName string
 		AllNames      []string
 		OnBuild       []string
+		// Platforms are the platforms (e.g. "linux/amd64") the image was
+		// built for, if known. A manifest list may cover several.
+		Platforms []string
 	}
 )
 
@@ -282,6 +293,7 @@ func (c *liveClient) registryForHostname(regHost string) (*registry, error) {
 
 type stubConfig struct {
 	Config stubImage `json:"config"`
+	Platform
 }
 
 type stubImage struct {
@@ -333,7 +345,35 @@ func (c *liveClient) metadataForImage(regHost string, ref reference.Named, etag
 	md.CanonicalName = ref.Name() + "@" + dg.String()
 	md.AllNames[1] = md.CanonicalName
 
+	err = c.describeManifest(rep, ref, mani, &md)
+	return
+}
+
+// describeManifest fills in md from mani, the manifest of the image ref.
+func (c *liveClient) describeManifest(rep *registry, ref reference.Named, mani distribution.Manifest, md *Metadata) (err error) {
 	switch mani := mani.(type) {
+	case *ManifestList:
+		pm, ok := mani.image()
+		if !ok {
+			return fmt.Errorf("manifest list for %s has no images", ref)
+		}
+		// An image's labels are the same for each platform, so those of the
+		// first will do.
+		var pref reference.Canonical
+		pref, err = digestRef(ref, pm.Digest.String())
+		if err != nil {
+			return
+		}
+		var pmani distribution.Manifest
+		pmani, _, _, err = rep.getManifestWithEtag(c.ctx, pref, "")
+		if err != nil {
+			return
+		}
+		if err = c.describeManifest(rep, pref, pmani, md); err != nil {
+			return
+		}
+		md.Platforms = mani.Platforms()
+
 	case *schema1.SignedManifest:
 		history := mani.History
 
@@ -369,24 +409,27 @@ func (c *liveClient) metadataForImage(regHost string, ref reference.Named, etag
 			return
 		}
 
-		var c stubConfig
-		err = json.Unmarshal(cj, &c)
+		var sc stubConfig
+		err = json.Unmarshal(cj, &sc)
 
 		if err != nil {
 			return
 		}
 
-		md.Labels = c.Config.Labels
+		md.Labels = sc.Config.Labels
 		md.Env = map[string]string{}
-		for _, line := range c.Config.Env {
+		for _, line := range sc.Config.Env {
 			pair := strings.SplitN(line, "=", 2)
 			k := pair[0]
 			v := pair[1]
 			md.Env[k] = v
 		}
 
-		md.OnBuild = make([]string, len(c.Config.OnBuild))
-		copy(md.OnBuild, c.Config.OnBuild)
+		md.OnBuild = make([]string, len(sc.Config.OnBuild))
+		copy(md.OnBuild, sc.Config.OnBuild)
+		if sc.Platform.Known() {
+			md.Platforms = []string{sc.Platform.String()}
+		}
 
 	default:
 		// We shouldn't receive this, because we shouldn't include the Accept
@@ -557,7 +600,7 @@ func (r *registry) manifestFromResponse(resp *http.Response) (distribution.Manif
 		case *schema1.SignedManifest:
 			//log.Print(string(v.Canonical))
 			d = digest.FromBytes(v.Canonical)
-		case *schema2.DeserializedManifest:
+		case *schema2.DeserializedManifest, *ManifestList:
 			_, pl, err := m.Payload()
 			if err != nil {
 				return nil, "", err
@@ -68,6 +68,25 @@ func (drc *DummyRegistryClient) AllTags(rn string) (tags []string, err error) {
 	return res.Get(0).([]string), res.Error(1)
 }
 
+// PushImageLayout fulfills part of Client
+func (drc *DummyRegistryClient) PushImageLayout(dir string, names ...string) error {
+	res := drc.Called(dir, names)
+	return res.Error(0)
+}
+
+// PushAttachment fulfills part of Client
+func (drc *DummyRegistryClient) PushAttachment(name, mediaType string, content []byte) error {
+	res := drc.Called(name, mediaType, content)
+	return res.Error(0)
+}
+
+// GetAttachment fulfills part of Client
+func (drc *DummyRegistryClient) GetAttachment(name string) ([]byte, error) {
+	res := drc.Called(name)
+	content, _ := res.Get(0).([]byte)
+	return content, res.Error(1)
+}
+
 // LabelsForImageName fulfills part of Client
 func (drc *DummyRegistryClient) LabelsForImageName(in string) (labels map[string]string, err error) {
 	res := drc.Called(in)
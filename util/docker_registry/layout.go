@@ -0,0 +1,213 @@
+package docker_registry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/distribution/digest"
+	"github.com/pkg/errors"
+)
+
+const (
+	// OCIManifestMediaType is the media type of an OCI image manifest.
+	OCIManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	// OCIConfigMediaType is the media type of an OCI image config.
+	OCIConfigMediaType = "application/vnd.oci.image.config.v1+json"
+)
+
+type (
+	// An ImageLayout is a single image in an OCI image layout directory, as
+	// written by e.g. `buildah push <image> oci:<dir>`. Its config, and so its
+	// labels, can be changed in place before it is pushed to a registry with
+	// Client.PushImageLayout.
+	ImageLayout struct {
+		Dir string
+
+		index    map[string]json.RawMessage
+		manifest map[string]json.RawMessage
+		config   map[string]json.RawMessage
+	}
+
+	// A Descriptor refers to a blob in an ImageLayout.
+	Descriptor struct {
+		MediaType   string            `json:"mediaType"`
+		Digest      digest.Digest     `json:"digest"`
+		Size        int64             `json:"size"`
+		Annotations map[string]string `json:"annotations,omitempty"`
+	}
+)
+
+// OpenImageLayout reads the image layout in dir, which must contain exactly
+// one image manifest.
+func OpenImageLayout(dir string) (*ImageLayout, error) {
+	l := &ImageLayout{Dir: dir}
+	if err := readJSON(filepath.Join(dir, "index.json"), &l.index); err != nil {
+		return nil, errors.Wrapf(err, "reading image layout index")
+	}
+	ms, err := l.manifests()
+	if err != nil {
+		return nil, err
+	}
+	if len(ms) != 1 {
+		return nil, errors.Errorf("image layout %s has %d manifests, want 1", dir, len(ms))
+	}
+	if err := l.readBlob(ms[0].Digest, &l.manifest); err != nil {
+		return nil, errors.Wrapf(err, "reading image manifest")
+	}
+	cd, err := l.ConfigDescriptor()
+	if err != nil {
+		return nil, err
+	}
+	if err := l.readBlob(cd.Digest, &l.config); err != nil {
+		return nil, errors.Wrapf(err, "reading image config")
+	}
+	return l, nil
+}
+
+// ManifestDescriptor returns the descriptor of the image's manifest.
+func (l *ImageLayout) ManifestDescriptor() (Descriptor, error) {
+	ms, err := l.manifests()
+	if err != nil {
+		return Descriptor{}, err
+	}
+	if len(ms) == 0 {
+		return Descriptor{}, errors.Errorf("image layout %s has no manifest", l.Dir)
+	}
+	return ms[0], nil
+}
+
+// ConfigDescriptor returns the descriptor of the image's config.
+func (l *ImageLayout) ConfigDescriptor() (Descriptor, error) {
+	d := Descriptor{}
+	err := json.Unmarshal(l.manifest["config"], &d)
+	return d, errors.Wrapf(err, "parsing image config descriptor")
+}
+
+// LayerDescriptors returns the descriptors of the image's layers, base layer
+// first.
+func (l *ImageLayout) LayerDescriptors() ([]Descriptor, error) {
+	ds := []Descriptor{}
+	if raw, ok := l.manifest["layers"]; ok {
+		if err := json.Unmarshal(raw, &ds); err != nil {
+			return nil, errors.Wrapf(err, "parsing image layer descriptors")
+		}
+	}
+	return ds, nil
+}
+
+// Labels returns the labels in the image's config.
+func (l *ImageLayout) Labels() (map[string]string, error) {
+	cc, err := l.containerConfig()
+	if err != nil {
+		return nil, err
+	}
+	labels := map[string]string{}
+	if raw, ok := cc["Labels"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &labels); err != nil {
+			return nil, errors.Wrapf(err, "parsing image labels")
+		}
+	}
+	return labels, nil
+}
+
+// AddLabels sets labels in the image's config, keeping any others, and
+// rewrites the config, manifest and index of the layout to match.
+func (l *ImageLayout) AddLabels(add map[string]string) error {
+	labels, err := l.Labels()
+	if err != nil {
+		return err
+	}
+	for k, v := range add {
+		labels[k] = v
+	}
+	cc, err := l.containerConfig()
+	if err != nil {
+		return err
+	}
+	if cc["Labels"], err = json.Marshal(labels); err != nil {
+		return err
+	}
+	if l.config["config"], err = json.Marshal(cc); err != nil {
+		return err
+	}
+
+	cd, err := l.ConfigDescriptor()
+	if err != nil {
+		return err
+	}
+	if cd, err = l.writeBlob(cd.MediaType, l.config); err != nil {
+		return errors.Wrapf(err, "writing image config")
+	}
+	if l.manifest["config"], err = json.Marshal(cd); err != nil {
+		return err
+	}
+
+	md, err := l.ManifestDescriptor()
+	if err != nil {
+		return err
+	}
+	nmd, err := l.writeBlob(md.MediaType, l.manifest)
+	if err != nil {
+		return errors.Wrapf(err, "writing image manifest")
+	}
+	nmd.Annotations = md.Annotations
+	if l.index["manifests"], err = json.Marshal([]Descriptor{nmd}); err != nil {
+		return err
+	}
+	b, err := json.Marshal(l.index)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(ioutil.WriteFile(filepath.Join(l.Dir, "index.json"), b, 0644),
+		"writing image layout index")
+}
+
+// BlobPath returns the path of the blob with digest d in the layout.
+func (l *ImageLayout) BlobPath(d digest.Digest) string {
+	return filepath.Join(l.Dir, "blobs", string(d.Algorithm()), d.Hex())
+}
+
+func (l *ImageLayout) manifests() ([]Descriptor, error) {
+	ms := []Descriptor{}
+	err := json.Unmarshal(l.index["manifests"], &ms)
+	return ms, errors.Wrapf(err, "parsing image layout index")
+}
+
+func (l *ImageLayout) containerConfig() (map[string]json.RawMessage, error) {
+	cc := map[string]json.RawMessage{}
+	if raw, ok := l.config["config"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &cc); err != nil {
+			return nil, errors.Wrapf(err, "parsing image config")
+		}
+	}
+	return cc, nil
+}
+
+func (l *ImageLayout) readBlob(d digest.Digest, v interface{}) error {
+	return readJSON(l.BlobPath(d), v)
+}
+
+// writeBlob stores v as a blob and returns its descriptor. The blob it
+// replaces is left in place: it may be shared with another image.
+func (l *ImageLayout) writeBlob(mediaType string, v interface{}) (Descriptor, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	d := Descriptor{MediaType: mediaType, Digest: digest.FromBytes(b), Size: int64(len(b))}
+	path := l.BlobPath(d.Digest)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Descriptor{}, err
+	}
+	return d, ioutil.WriteFile(path, b, 0644)
+}
+
+func readJSON(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,184 @@
+package docker_registry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/distribution/digest"
+	"github.com/opentable/sous/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestLayout writes an image layout with one layer and the given labels
+// to a new temporary directory.
+func writeTestLayout(t *testing.T, labels map[string]string) string {
+	dir, err := ioutil.TempDir("", "sous-layout-test")
+	require.NoError(t, err)
+	l := &ImageLayout{Dir: dir}
+
+	write := func(mediaType string, b []byte) Descriptor {
+		d := Descriptor{MediaType: mediaType, Digest: digest.FromBytes(b), Size: int64(len(b))}
+		require.NoError(t, os.MkdirAll(filepath.Dir(l.BlobPath(d.Digest)), 0755))
+		require.NoError(t, ioutil.WriteFile(l.BlobPath(d.Digest), b, 0644))
+		return d
+	}
+	marshal := func(v interface{}) []byte {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return b
+	}
+
+	layer := write("application/vnd.oci.image.layer.v1.tar", []byte("layer"))
+	config := write(OCIConfigMediaType, marshal(map[string]interface{}{
+		"architecture": "amd64",
+		"config":       map[string]interface{}{"Labels": labels, "Env": []string{"A=1"}},
+	}))
+	manifest := write(OCIManifestMediaType, marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"config":        config,
+		"layers":        []Descriptor{layer},
+	}))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "index.json"), marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"manifests":     []Descriptor{manifest},
+	}), 0644))
+	return dir
+}
+
+func TestImageLayout_AddLabels(t *testing.T) {
+	dir := writeTestLayout(t, map[string]string{"keep": "this", "change": "old"})
+	defer os.RemoveAll(dir)
+
+	l, err := OpenImageLayout(dir)
+	require.NoError(t, err)
+	before, err := l.ManifestDescriptor()
+	require.NoError(t, err)
+	require.NoError(t, l.AddLabels(map[string]string{"change": "new", "add": "too"}))
+
+	l, err = OpenImageLayout(dir)
+	require.NoError(t, err)
+	labels, err := l.Labels()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"keep": "this", "change": "new", "add": "too"}, labels)
+
+	after, err := l.ManifestDescriptor()
+	require.NoError(t, err)
+	assert.NotEqual(t, before.Digest, after.Digest)
+	assert.Equal(t, OCIManifestMediaType, after.MediaType)
+
+	cc, err := l.containerConfig()
+	require.NoError(t, err)
+	assert.JSONEq(t, `["A=1"]`, string(cc["Env"]))
+}
+
+// testRegistry is a minimal registry which accepts uploads, and serves what
+// was uploaded.
+type testRegistry struct {
+	sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string]string
+}
+
+func (tr *testRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tr.Lock()
+	defer tr.Unlock()
+	switch {
+	case r.Method == "HEAD" && strings.Contains(r.URL.Path, "/blobs/"):
+		d := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if _, ok := tr.blobs[d]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/blobs/uploads/"):
+		w.Header().Set("Location", r.URL.Path+"upload-1")
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == "PUT" && strings.Contains(r.URL.Path, "/blobs/uploads/"):
+		b, _ := ioutil.ReadAll(r.Body)
+		tr.blobs[r.URL.Query().Get("digest")] = b
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "PUT" && strings.Contains(r.URL.Path, "/manifests/"):
+		b, _ := ioutil.ReadAll(r.Body)
+		tr.manifests[r.URL.Path] = r.Header.Get("Content-Type") + " " + string(b)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "GET" && strings.Contains(r.URL.Path, "/manifests/"):
+		m, ok := tr.manifests[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		parts := strings.SplitN(m, " ", 2)
+		w.Header().Set("Content-Type", parts[0])
+		w.Write([]byte(parts[1]))
+	case r.Method == "GET" && strings.Contains(r.URL.Path, "/blobs/"):
+		b, ok := tr.blobs[r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(b)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestClient_PushImageLayout(t *testing.T) {
+	dir := writeTestLayout(t, map[string]string{"label": "value"})
+	defer os.RemoveAll(dir)
+
+	tr := &testRegistry{blobs: map[string][]byte{}, manifests: map[string]string{}}
+	srv := httptest.NewTLSServer(tr)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	c := NewClient(logging.SilentLogSet())
+	c.BecomeFoolishlyTrusting()
+	require.NoError(t, c.PushImageLayout(dir, host+"/sous/app:1.0.0", host+"/sous/app:z1234"))
+
+	assert.Len(t, tr.blobs, 2)
+	l, err := OpenImageLayout(dir)
+	require.NoError(t, err)
+	cd, err := l.ConfigDescriptor()
+	require.NoError(t, err)
+	config, err := ioutil.ReadFile(l.BlobPath(cd.Digest))
+	require.NoError(t, err)
+	assert.Equal(t, config, tr.blobs[string(cd.Digest)])
+
+	assert.Len(t, tr.manifests, 2)
+	assert.Contains(t, tr.manifests["/v2/sous/app/manifests/1.0.0"], OCIManifestMediaType)
+	assert.Contains(t, tr.manifests, "/v2/sous/app/manifests/z1234")
+
+	assert.Error(t, c.PushImageLayout(dir, host+"/sous/app"))
+}
+
+func TestClient_Attachment(t *testing.T) {
+	tr := &testRegistry{blobs: map[string][]byte{}, manifests: map[string]string{}}
+	srv := httptest.NewTLSServer(tr)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	name, err := AttachmentName(host+"/sous/app@sha256:abc123", "provenance")
+	require.NoError(t, err)
+	assert.Equal(t, host+"/sous/app:sha256-abc123.provenance", name)
+	_, err = AttachmentName(host+"/sous/app:1.0.0", "provenance")
+	assert.Error(t, err)
+
+	c := NewClient(logging.SilentLogSet())
+	c.BecomeFoolishlyTrusting()
+	require.NoError(t, c.PushAttachment(name, "application/vnd.in-toto+json", []byte(`{"_type":"x"}`)))
+	assert.Len(t, tr.blobs, 2)
+	assert.Contains(t, tr.manifests["/v2/sous/app/manifests/sha256-abc123.provenance"], "application/vnd.in-toto+json")
+
+	content, err := c.GetAttachment(name)
+	require.NoError(t, err)
+	assert.Equal(t, `{"_type":"x"}`, string(content))
+
+	_, err = c.GetAttachment(host + "/sous/app:sha256-def456.provenance")
+	assert.Equal(t, ErrNoAttachment, err)
+}
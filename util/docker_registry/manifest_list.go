@@ -0,0 +1,157 @@
+package docker_registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest/schema2"
+)
+
+const (
+	// ManifestListMediaType is the media type of a Docker manifest list.
+	ManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	// OCIIndexMediaType is the media type of an OCI image index, the OCI
+	// equivalent of a manifest list.
+	OCIIndexMediaType = "application/vnd.oci.image.index.v1+json"
+)
+
+type (
+	// A ManifestList refers to a manifest of the same image for each of
+	// several platforms. It is either a Docker manifest list or an OCI image
+	// index.
+	ManifestList struct {
+		SchemaVersion int                `json:"schemaVersion"`
+		MediaType     string             `json:"mediaType,omitempty"`
+		Manifests     []PlatformManifest `json:"manifests"`
+
+		canonical []byte
+	}
+
+	// A PlatformManifest is the entry in a ManifestList for one platform.
+	PlatformManifest struct {
+		MediaType string        `json:"mediaType"`
+		Digest    digest.Digest `json:"digest"`
+		Size      int64         `json:"size"`
+		Platform  Platform      `json:"platform"`
+	}
+
+	// A Platform is an operating system and CPU architecture an image runs
+	// on.
+	Platform struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+		Variant      string `json:"variant,omitempty"`
+	}
+)
+
+func init() {
+	list := func(b []byte) (distribution.Manifest, distribution.Descriptor, error) {
+		m := &ManifestList{}
+		if err := m.UnmarshalJSON(b); err != nil {
+			return nil, distribution.Descriptor{}, err
+		}
+		mt := m.MediaType
+		if mt == "" {
+			mt = OCIIndexMediaType
+		}
+		return m, distribution.Descriptor{Digest: digest.FromBytes(b), Size: int64(len(b)), MediaType: mt}, nil
+	}
+	// OCI image manifests have the same shape as Docker's schema 2.
+	oci := func(b []byte) (distribution.Manifest, distribution.Descriptor, error) {
+		m := &schema2.DeserializedManifest{}
+		if err := m.UnmarshalJSON(b); err != nil {
+			return nil, distribution.Descriptor{}, err
+		}
+		return m, distribution.Descriptor{Digest: digest.FromBytes(b), Size: int64(len(b)), MediaType: OCIManifestMediaType}, nil
+	}
+	for mt, u := range map[string]distribution.UnmarshalFunc{
+		ManifestListMediaType: list,
+		OCIIndexMediaType:     list,
+		OCIManifestMediaType:  oci,
+	} {
+		if err := distribution.RegisterManifestSchema(mt, u); err != nil {
+			panic(fmt.Sprintf("Unable to register manifest: %s", err))
+		}
+	}
+}
+
+// UnmarshalJSON populates m from JSON, keeping the JSON as its payload.
+func (m *ManifestList) UnmarshalJSON(b []byte) error {
+	type plain ManifestList
+	if err := json.Unmarshal(b, (*plain)(m)); err != nil {
+		return err
+	}
+	m.canonical = append([]byte{}, b...)
+	return nil
+}
+
+// References implements distribution.Manifest.
+func (m *ManifestList) References() []distribution.Descriptor {
+	ds := make([]distribution.Descriptor, 0, len(m.Manifests))
+	for _, pm := range m.Manifests {
+		ds = append(ds, distribution.Descriptor{MediaType: pm.MediaType, Digest: pm.Digest, Size: pm.Size})
+	}
+	return ds
+}
+
+// Payload implements distribution.Manifest.
+func (m *ManifestList) Payload() (string, []byte, error) {
+	mt := m.MediaType
+	if mt == "" {
+		mt = OCIIndexMediaType
+	}
+	return mt, m.canonical, nil
+}
+
+// Platforms returns the platforms m has images for, omitting the entries for
+// e.g. build attestations, whose platform is unknown.
+func (m *ManifestList) Platforms() []string {
+	ps := []string{}
+	for _, pm := range m.Manifests {
+		if pm.Platform.Known() {
+			ps = append(ps, pm.Platform.String())
+		}
+	}
+	return ps
+}
+
+// image returns the manifest in m of the image for the first platform it
+// lists, or false if it has none.
+func (m *ManifestList) image() (PlatformManifest, bool) {
+	for _, pm := range m.Manifests {
+		if pm.Platform.Known() {
+			return pm, true
+		}
+	}
+	return PlatformManifest{}, false
+}
+
+// ParsePlatform parses a platform in the form "os/architecture[/variant]",
+// e.g. "linux/arm64/v8".
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("platform %q is not of the form os/architecture[/variant]", s)
+	}
+	p := Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// Known returns false for the placeholder platform of list entries which are
+// not images.
+func (p Platform) Known() bool {
+	return p.OS != "" && p.OS != "unknown" && p.Architecture != "" && p.Architecture != "unknown"
+}
+
+func (p Platform) String() string {
+	if p.Variant == "" {
+		return p.OS + "/" + p.Architecture
+	}
+	return p.OS + "/" + p.Architecture + "/" + p.Variant
+}
@@ -0,0 +1,81 @@
+package docker_registry
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/opentable/sous/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetImageMetadata_ManifestList(t *testing.T) {
+	tr := &testRegistry{blobs: map[string][]byte{}, manifests: map[string]string{}}
+	srv := httptest.NewTLSServer(tr)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	put := func(mediaType, path string, v interface{}) digest.Digest {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		d := digest.FromBytes(b)
+		if path == "" {
+			path = "/v2/sous/app/manifests/" + d.String()
+		}
+		tr.manifests[path] = mediaType + " " + string(b)
+		return d
+	}
+	image := func(arch string) PlatformManifest {
+		config, err := json.Marshal(map[string]interface{}{
+			"architecture": arch,
+			"os":           "linux",
+			"config":       map[string]interface{}{"Labels": map[string]string{"label": "value"}},
+		})
+		require.NoError(t, err)
+		cd := digest.FromBytes(config)
+		tr.blobs[cd.String()] = config
+		d := put(schema2.MediaTypeManifest, "", map[string]interface{}{
+			"schemaVersion": 2,
+			"mediaType":     schema2.MediaTypeManifest,
+			"config":        map[string]interface{}{"mediaType": schema2.MediaTypeConfig, "digest": cd, "size": len(config)},
+			"layers":        []interface{}{},
+		})
+		return PlatformManifest{MediaType: schema2.MediaTypeManifest, Digest: d, Platform: Platform{OS: "linux", Architecture: arch}}
+	}
+
+	amd64, arm64 := image("amd64"), image("arm64")
+	attestation := PlatformManifest{MediaType: OCIManifestMediaType, Digest: amd64.Digest, Platform: Platform{OS: "unknown", Architecture: "unknown"}}
+	list := put(ManifestListMediaType, "/v2/sous/app/manifests/1.0.0", ManifestList{
+		SchemaVersion: 2,
+		MediaType:     ManifestListMediaType,
+		Manifests:     []PlatformManifest{attestation, amd64, arm64},
+	})
+
+	c := NewClient(logging.SilentLogSet())
+	c.BecomeFoolishlyTrusting()
+	md, err := c.GetImageMetadata(host+"/sous/app:1.0.0", "")
+	require.NoError(t, err)
+	assert.Equal(t, "sous/app@"+list.String(), md.CanonicalName)
+	assert.Equal(t, "value", md.Labels["label"])
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, md.Platforms)
+
+	md, err = c.GetImageMetadata(host+"/sous/app@"+arm64.Digest.String(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"linux/arm64"}, md.Platforms)
+}
+
+func TestParsePlatform(t *testing.T) {
+	p, err := ParsePlatform("linux/arm64/v8")
+	require.NoError(t, err)
+	assert.Equal(t, Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, p)
+	assert.Equal(t, "linux/arm64/v8", p.String())
+
+	for _, bad := range []string{"", "linux", "linux/", "/amd64", "a/b/c/d"} {
+		_, err := ParsePlatform(bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,160 @@
+package docker_registry
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/distribution/registry/client"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// PushImageLayout pushes the image in the OCI image layout in dir to the
+// registry, once for each of imageNames, which must be tagged.
+func (c *liveClient) PushImageLayout(dir string, imageNames ...string) error {
+	l, err := OpenImageLayout(dir)
+	if err != nil {
+		return err
+	}
+	for _, in := range imageNames {
+		regHost, ref, err := splitHost(in)
+		if err != nil {
+			return err
+		}
+		tagged, ok := ref.(reference.Tagged)
+		if !ok {
+			return errors.Errorf("cannot push %q: image name has no tag", in)
+		}
+		rep, err := c.registryForHostname(regHost)
+		if err != nil {
+			return err
+		}
+		if err := rep.pushLayout(c.ctx, ref, tagged.Tag(), l); err != nil {
+			return errors.Wrapf(err, "pushing %s", in)
+		}
+	}
+	return nil
+}
+
+// pushLayout uploads the blobs of l that the registry lacks, then its
+// manifest, tagged tag.
+func (r *registry) pushLayout(ctx context.Context, ref reference.Named, tag string, l *ImageLayout) error {
+	blobs, err := l.LayerDescriptors()
+	if err != nil {
+		return err
+	}
+	cd, err := l.ConfigDescriptor()
+	if err != nil {
+		return err
+	}
+	for _, d := range append(blobs, cd) {
+		path := l.BlobPath(d.Digest)
+		open := func() (io.ReadCloser, error) { return os.Open(path) }
+		if err := r.pushBlob(ref, d, open); err != nil {
+			return err
+		}
+	}
+
+	md, err := l.ManifestDescriptor()
+	if err != nil {
+		return err
+	}
+	body, err := ioutil.ReadFile(l.BlobPath(md.Digest))
+	if err != nil {
+		return err
+	}
+	return r.putManifest(ref, tag, md.MediaType, body)
+}
+
+// putManifest uploads body, a manifest of type mediaType, tagged tag.
+func (r *registry) putManifest(ref reference.Named, tag, mediaType string, body []byte) error {
+	tr, err := reference.WithTag(ref, tag)
+	if err != nil {
+		return err
+	}
+	u, err := r.ub.BuildManifestURL(tr)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT", u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mediaType)
+	return r.expect("docker-put-manifest", req)
+}
+
+// pushBlob uploads the blob described by d, read from open, in a single
+// request, unless the registry already has it.
+func (r *registry) pushBlob(ref reference.Named, d Descriptor, open func() (io.ReadCloser, error)) error {
+	cref, err := digestRef(ref, string(d.Digest))
+	if err != nil {
+		return err
+	}
+	u, err := r.ub.BuildBlobURL(cref)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Head("docker-blob-exists", u)
+	if err != nil {
+		return err
+	}
+	safeCloseBody(resp)
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	u, err = r.ub.BuildBlobUploadURL(ref)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err = r.client.Do("docker-start-upload", req)
+	if err != nil {
+		return err
+	}
+	defer safeCloseBody(resp)
+	if !client.SuccessStatus(resp.StatusCode) {
+		return client.HandleErrorResponse(resp)
+	}
+	loc, err := resp.Location()
+	if err != nil {
+		return errors.Wrapf(err, "starting upload of %s", d.Digest)
+	}
+	q := loc.Query()
+	q.Set("digest", string(d.Digest))
+	loc.RawQuery = q.Encode()
+
+	f, err := open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	req, err = http.NewRequest("PUT", loc.String(), f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = d.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return errors.Wrapf(r.expect("docker-put-blob", req), "uploading %s", d.Digest)
+}
+
+// expect sends req, returning an error unless the registry reports success.
+func (r *registry) expect(resourceName string, req *http.Request) error {
+	resp, err := r.client.Do(resourceName, req)
+	if err != nil {
+		return err
+	}
+	defer safeCloseBody(resp)
+	if !client.SuccessStatus(resp.StatusCode) {
+		return client.HandleErrorResponse(resp)
+	}
+	return nil
+}
@@ -35,6 +35,9 @@ type (
 )
 
 func roundtrip(in, out interface{}) {
+	if out == nil {
+		return
+	}
 	bs, err := json.Marshal(in)
 	if err != nil {
 		panic(err)
@@ -58,10 +58,11 @@ type (
 	Optionsable interface {
 		Options(*RouteMap, logging.LogSink, http.ResponseWriter, *http.Request, httprouter.Params) Exchanger
 	}
+	// Postable tags ResourceFamilies that respond to POST
+	Postable interface {
+		Post(*RouteMap, logging.LogSink, http.ResponseWriter, *http.Request, httprouter.Params) Exchanger
+	}
 	/*
-		Postable interface {
-			Post() Exchanger
-		}
 		// also consider Headable or Patchable
 		// which maybe should be named "SpecializedHead" or something
 		// Note that Patchable and SpecialPatch should be separate
@@ -120,6 +121,7 @@ func (rm *RouteMap) BuildRouter(ls logging.LogSink) http.Handler {
 		get, canGet := e.Resource.(Getable)
 		put, canPut := e.Resource.(Putable)
 		del, canDel := e.Resource.(Deleteable)
+		post, canPost := e.Resource.(Postable)
 		opt, canOpt := e.Resource.(Optionsable)
 
 		if canGet {
@@ -132,6 +134,9 @@ func (rm *RouteMap) BuildRouter(ls logging.LogSink) http.Handler {
 		if canDel {
 			r.Handle("DELETE", e.Path, mh.DeleteHandling(e.Name, del.Delete))
 		}
+		if canPost {
+			r.Handle("POST", e.Path, mh.PostHandling(e.Name, post.Post))
+		}
 		if canOpt {
 			r.Handle("OPTIONS", e.Path, mh.OptionsHandling(e.Name, opt.Options))
 		} else {
@@ -154,6 +159,9 @@ func defaultOptions(res Resource) ExchangeFactory {
 	if _, can := res.(Deleteable); can {
 		ex.methods = append(ex.methods, "DELETE")
 	}
+	if _, can := res.(Postable); can {
+		ex.methods = append(ex.methods, "POST")
+	}
 
 	return func(*RouteMap, logging.LogSink, http.ResponseWriter, *http.Request, httprouter.Params) Exchanger {
 		return ex
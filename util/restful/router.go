@@ -153,6 +153,14 @@ func (mh *MetaHandler) DeleteHandling(resName string, factory ExchangeFactory) h
 	}
 }
 
+// PostHandling handles POST requests.
+func (mh *MetaHandler) PostHandling(resName string, factory ExchangeFactory) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		lrw, data, status := mh.genericHandling(resName, factory, rw, r, p)
+		mh.renderData(status, lrw, r, data)
+	}
+}
+
 // HeadHandling handles Head requests.
 func (mh *MetaHandler) HeadHandling(resName string, factory ExchangeFactory) httprouter.Handle {
 	return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
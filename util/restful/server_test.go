@@ -61,6 +61,10 @@ func (tr *TestResource) Put(rm *RouteMap, _ logging.LogSink, write http.Response
 	}
 }
 
+func (tr *TestResource) Post(rm *RouteMap, ls logging.LogSink, write http.ResponseWriter, req *http.Request, ps httprouter.Params) Exchanger {
+	return tr.Put(rm, ls, write, req, ps)
+}
+
 func (ge *TestGetExchanger) Exchange() (interface{}, int) {
 	p := ge.Params.ByName("param")
 	if p == "missing" {
@@ -164,9 +168,20 @@ func (t *PutConditionalsSuite) TestOptionsAllowCORS() {
 	t.Regexp("GET", methods)
 	t.Regexp("HEAD", methods)
 	t.Regexp("PUT", methods)
+	t.Regexp("POST", methods)
 	t.Regexp("OPTIONS", methods)
 }
 
+func (t *PutConditionalsSuite) TestPostUnconditional() {
+	req := t.testReq("POST", "/test/one", map[string]string{"Data": "posted"})
+	res, err := t.client.Do(req)
+	t.NoError(err)
+	t.Equal("200 OK", res.Status)
+	data := TestData{}
+	t.NoError(json.NewDecoder(res.Body).Decode(&data))
+	t.Equal("posted", data.Data)
+}
+
 func (t *PutConditionalsSuite) TestGetAllowCORS() {
 	req := t.testReq("GET", "/test/one", nil)
 	req.Header.Add("Origin", "test-client.example.com")